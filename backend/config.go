@@ -4,6 +4,11 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/elevation"
+	"github.com/anish-chanda/cadent/backend/internal/handlers"
+	"github.com/anish-chanda/cadent/backend/internal/valhalla"
 )
 
 // Config holds all application configuration
@@ -32,11 +37,23 @@ type Config struct {
 	CookieDuration int // in hours
 
 	// Storage configuration
-	StorageDsn        string
-	AvatarStoragePath string
+	StorageDsn          string
+	AvatarStoragePath   string
+	FullStreamCacheSize int    // number of parsed activity files cached for lod=full streams, 0 disables
+	StreamDecimation    string // how medium LOD stream points are picked at ingest: uniform or lttb
 
 	// Valhalla configuration
-	ValhallaURL string
+	ValhallaURL                    string
+	ValhallaMaxRetries             int // retries for network errors, 429 and 5xx responses
+	ValhallaRetryBackoffMs         int // wait before the first retry in milliseconds, doubled for each further retry
+	ValhallaBreakerThreshold       int // consecutive failed requests before Valhalla calls are skipped, 0 disables
+	ValhallaBreakerCooldownSeconds int // how long Valhalla calls are skipped before trying again
+	ValhallaHeightChunkPoints      int // longer polylines are split into several height requests, 0 disables
+	ValhallaHeightCacheSize        int // number of height responses cached, 0 disables
+
+	// Elevation configuration
+	ElevationProvider string // where heights for uploaded tracks come from: valhalla or dem
+	ElevationDEMPath  string // directory of SRTM .hgt or GeoTIFF tiles for the dem provider
 }
 
 // LoadConfig loads configuration from environment variables with sensible defaults
@@ -66,11 +83,23 @@ func LoadConfig() Config {
 		CookieDuration: getEnvIntOrDefault("COOKIE_DURATION_HOURS", 24),
 
 		// Storage
-		StorageDsn:        getEnvOrDefault("STORAGE_DSN", "local://./data"),
-		AvatarStoragePath: getEnvOrDefault("AVATAR_STORAGE_PATH", "/data/auth/avatars"),
+		StorageDsn:          getEnvOrDefault("STORAGE_DSN", "local://./data"),
+		AvatarStoragePath:   getEnvOrDefault("AVATAR_STORAGE_PATH", "/data/auth/avatars"),
+		FullStreamCacheSize: getEnvIntOrDefault("FULL_STREAM_CACHE_SIZE", handlers.DefaultFullStreamCacheSize),
+		StreamDecimation:    getEnvOrDefault("STREAM_DECIMATION", string(handlers.DecimationUniform)),
 
 		// Valhalla
-		ValhallaURL: getEnvOrDefault("VALHALLA_URL", "https://valhalla.anishchanda.dev"),
+		ValhallaURL:                    getEnvOrDefault("VALHALLA_URL", "https://valhalla.anishchanda.dev"),
+		ValhallaMaxRetries:             getEnvIntOrDefault("VALHALLA_MAX_RETRIES", valhalla.DefaultMaxRetries),
+		ValhallaRetryBackoffMs:         getEnvIntOrDefault("VALHALLA_RETRY_BACKOFF_MS", int(valhalla.DefaultRetryBackoff/time.Millisecond)),
+		ValhallaBreakerThreshold:       getEnvIntOrDefault("VALHALLA_BREAKER_THRESHOLD", valhalla.DefaultBreakerThreshold),
+		ValhallaBreakerCooldownSeconds: getEnvIntOrDefault("VALHALLA_BREAKER_COOLDOWN_SECONDS", int(valhalla.DefaultBreakerCooldown/time.Second)),
+		ValhallaHeightChunkPoints:      getEnvIntOrDefault("VALHALLA_HEIGHT_CHUNK_POINTS", valhalla.DefaultHeightChunkPoints),
+		ValhallaHeightCacheSize:        getEnvIntOrDefault("VALHALLA_HEIGHT_CACHE_SIZE", valhalla.DefaultHeightCacheSize),
+
+		// Elevation
+		ElevationProvider: getEnvOrDefault("ELEVATION_PROVIDER", elevation.ProviderValhalla),
+		ElevationDEMPath:  getEnvOrDefault("ELEVATION_DEM_PATH", "/data/dem"),
 	}
 }
 
@@ -14,29 +14,70 @@ type Database interface {
 
 	// --- Activities stuff ----
 	CreateActivity(ctx context.Context, activity *models.Activity) error
-	GetActivitiesByUserID(ctx context.Context, userID string) ([]models.Activity, error)
+	GetActivitiesByUserID(ctx context.Context, userID string, query models.ActivityListQuery) ([]models.Activity, error)
 	GetActivitiesByUserIDAndDate(ctx context.Context, userID string, start_date time.Time, end_date time.Time) ([]models.Activity, []models.PlannedActivity, error)
 	CheckIdempotency(ctx context.Context, clientActivityID string) (bool, error)
 	GetActivityByID(ctx context.Context, activityID string) (*models.Activity, error)
+	GetActivitiesByIDs(ctx context.Context, userID string, activityIDs []string) ([]models.Activity, error)
+	FindActivityIDByStartTime(ctx context.Context, userID string, startTime time.Time, tolerance time.Duration) (*string, error)
+	UpdateActivity(ctx context.Context, activityID string, userID string, updates map[string]interface{}) error
+	DeleteActivity(ctx context.Context, activityID string, userID string) error
 	CreatePlannedActivity(ctx context.Context, plan *models.PlannedActivity) (*models.PlannedActivity, error)
 
 	// --- Activity Streams ---
 	GetActivityStreams(ctx context.Context, activityID string, lod models.StreamLOD) ([]models.ActivityStream, error)
 	CreateActivityStreams(ctx context.Context, streams []models.ActivityStream) error
 
+	// --- Activity Splits ---
+	GetActivitySplits(ctx context.Context, activityID string, unit models.SplitUnit) ([]models.ActivitySplit, error)
+	CreateActivitySplits(ctx context.Context, splits []models.ActivitySplit) error
+
+	// --- Activity Laps ---
+	GetActivityLaps(ctx context.Context, activityID string) ([]models.ActivityLap, error)
+	CreateActivityLaps(ctx context.Context, laps []models.ActivityLap) error
+
+	// --- Best Efforts and Personal Records ---
+	SaveActivityBestEfforts(ctx context.Context, activity *models.Activity, efforts []models.BestEffort) ([]string, error)
+	GetPersonalRecords(ctx context.Context, userID string, activityType *models.ActivityType) ([]models.PersonalRecord, error)
+
+	// --- Training Load ---
+	SaveActivityTrainingStress(ctx context.Context, stress *models.ActivityTrainingStress) error
+	GetDailyTrainingStress(ctx context.Context, userID string, from time.Time) ([]models.DailyTrainingStress, error)
+	GetTrainingLoad(ctx context.Context, userID string, from time.Time, to time.Time) ([]models.TrainingLoadDay, error)
+	GetTrainingLoadBefore(ctx context.Context, userID string, day time.Time) (*models.TrainingLoadDay, error)
+	ReplaceTrainingLoad(ctx context.Context, userID string, from time.Time, days []models.TrainingLoadDay) error
+
+	// --- Zones ---
+	ReplaceActivityZoneTimes(ctx context.Context, activityID string, zones []models.ActivityZoneTime) error
+	GetActivityZoneTimes(ctx context.Context, activityIDs []string) ([]models.ActivityZoneTime, error)
+
+	// --- Stats ---
+	GetActivityStats(ctx context.Context, userID string, period models.StatsPeriod, timezone string, from time.Time, to time.Time) ([]models.ActivityStats, error)
+
 	// --- Training Plans ---
 	GetTrainingPlans(ctx context.Context, searchQuery string, activityType *models.ActivityType) ([]models.TrainingPlan, error)
 	GetTrainingPlanByID(ctx context.Context, planID string) (*models.TrainingPlan, error)
 	GetTrainingPlanWorkouts(ctx context.Context, planID string) ([]models.TrainingPlanWorkout, error)
 	CreateUserTrainingPlanWithPlannedActivities(ctx context.Context, userPlan *models.UserTrainingPlan, plannedActivities []models.PlannedActivity) error
+	GetUserTrainingPlanByID(ctx context.Context, userTrainingPlanID string, userID string) (*models.UserTrainingPlan, error)
+	GetPlannedActivitiesByUserTrainingPlan(ctx context.Context, userTrainingPlanID string, userID string) ([]models.PlannedActivity, error)
   
 	// --- Planned Activities ---
 	DeletePlannedActivity(ctx context.Context, activityID string, userID string) error
 	UpdatePlannedActivity(ctx context.Context, activityID string, userID string, updates map[string]interface{}) error
+	GetUnmatchedPlannedActivities(ctx context.Context, userID string, start time.Time, end time.Time) ([]models.PlannedActivity, error)
+	SetPlannedActivityMatch(ctx context.Context, plannedActivityID string, userID string, activityID *string) error
+
+	// --- Routes ---
+	CreateRoute(ctx context.Context, route *models.Route) error
+	GetRoutesByUserID(ctx context.Context, userID string) ([]models.Route, error)
+	GetRouteByID(ctx context.Context, routeID string, userID string) (*models.Route, error)
+	DeleteRoute(ctx context.Context, routeID string, userID string) error
 
 	// --- User management methods ---
 	GetUserByID(ctx context.Context, userID string) (*models.UserRecord, error)
 	UpdateUser(ctx context.Context, userID string, updates map[string]interface{}) error
+	GetUserTrainingSettings(ctx context.Context, userID string) (*models.TrainingSettings, error)
 
 	// establishes a database connection
 	Connect(dsn string) error
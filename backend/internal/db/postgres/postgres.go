@@ -122,9 +122,9 @@ func (s *PostgresDB) CreatePlannedActivity(ctx context.Context, plan *models.Pla
         INSERT INTO planned_activities (
             user_id, title, description, type, start_time, 
             planned_distance_m, planned_duration_s, planned_elevation_gain_m, 
-            target_avg_speed_mps, target_power_watt
+            target_avg_speed_mps, target_power_watt, route_id
         ) 
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
         RETURNING id, created_at, updated_at`
 
 	// Execute query and scan the DB-generated fields back into the model
@@ -139,6 +139,7 @@ func (s *PostgresDB) CreatePlannedActivity(ctx context.Context, plan *models.Pla
 		plan.PlannedElevationGainM,
 		plan.TargetAvgSpeedMps,
 		plan.TargetPowerWatt,
+		plan.RouteID,
 	).Scan(&plan.ID, &plan.CreatedAt, &plan.UpdatedAt)
 
 	if err != nil {
@@ -158,14 +159,15 @@ func (s *PostgresDB) CreateActivity(ctx context.Context, activity *models.Activi
 	query := `
 		INSERT INTO activities (
 			id, user_id, client_activity_id, title, description, type,
-			start_time, end_time, elapsed_time, distance_m, elevation_gain_m,
+			start_time, end_time, elapsed_time, moving_time_s, distance_m, elevation_gain_m,
 			elevation_loss_m, max_height_m, min_height_m,
-			avg_speed_mps, max_speed_mps, avg_hr_bpm, max_hr_bpm, perceived_effort, processing_ver,
-			polyline, bbox_min_lat, bbox_min_lon, bbox_max_lat, bbox_max_lon,
+			avg_speed_mps, max_speed_mps, avg_gap_mps, avg_hr_bpm, max_hr_bpm, perceived_effort, processing_ver,
+			avg_cadence_rpm, max_cadence_rpm, avg_power_watt, max_power_watt,
+			polyline, raw_polyline, bbox_min_lat, bbox_min_lon, bbox_max_lat, bbox_max_lon,
 			start_lat, start_lon, end_lat, end_lon, file_url, created_at, updated_at
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21,
-			$22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32
+			$22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35, $36, $37, $38, $39
 		)
 	`
 
@@ -179,6 +181,7 @@ func (s *PostgresDB) CreateActivity(ctx context.Context, activity *models.Activi
 		activity.StartTime,
 		activity.EndTime,
 		activity.ElapsedTime,
+		activity.MovingTime,
 		activity.DistanceM,
 		activity.ElevationGainM,
 		activity.ElevationLossM,
@@ -186,11 +189,17 @@ func (s *PostgresDB) CreateActivity(ctx context.Context, activity *models.Activi
 		activity.MinHeightM,
 		activity.AvgSpeedMps,
 		activity.MaxSpeedMps,
+		activity.AvgGAPMps,
 		activity.AvgHRBpm,
 		activity.MaxHRBpm,
 		activity.PerceivedEffort,
 		activity.ProcessingVer,
+		activity.AvgCadenceRpm,
+		activity.MaxCadenceRpm,
+		activity.AvgPowerWatt,
+		activity.MaxPowerWatt,
 		activity.Polyline,
+		activity.RawPolyline,
 		activity.BBoxMinLat,
 		activity.BBoxMinLon,
 		activity.BBoxMaxLat,
@@ -213,24 +222,93 @@ func (s *PostgresDB) CreateActivity(ctx context.Context, activity *models.Activi
 	return nil
 }
 
-// GetActivitiesByUserID retrieves all activities for a specific user
-func (s *PostgresDB) GetActivitiesByUserID(ctx context.Context, userID string) ([]models.Activity, error) {
+// GetActivitiesByUserID retrieves a page of a user's activities matching the query's filters and sort order
+func (s *PostgresDB) GetActivitiesByUserID(ctx context.Context, userID string, listQuery models.ActivityListQuery) ([]models.Activity, error) {
 	s.log.Debug(fmt.Sprintf("Fetching activities for user: %s", userID))
 
 	query := `
 		SELECT 
 			id, user_id, client_activity_id, title, description, type,
-			start_time, end_time, elapsed_time, distance_m, elevation_gain_m,
+			start_time, end_time, elapsed_time, moving_time_s, distance_m, elevation_gain_m,
 			elevation_loss_m, max_height_m, min_height_m,
-			avg_speed_mps, max_speed_mps, avg_hr_bpm, max_hr_bpm, perceived_effort, processing_ver,
-			polyline, bbox_min_lat, bbox_min_lon, bbox_max_lat, bbox_max_lon,
+			avg_speed_mps, max_speed_mps, avg_gap_mps, avg_hr_bpm, max_hr_bpm, perceived_effort, processing_ver,
+			avg_cadence_rpm, max_cadence_rpm, avg_power_watt, max_power_watt,
+			polyline, raw_polyline, bbox_min_lat, bbox_min_lon, bbox_max_lat, bbox_max_lon,
 			start_lat, start_lon, end_lat, end_lon, file_url, created_at, updated_at
 		FROM activities 
-		WHERE user_id = $1
-		ORDER BY start_time DESC
-	`
+		WHERE user_id = $1`
+	args := []interface{}{userID}
+	argIdx := 2
+
+	if listQuery.ActivityType != nil {
+		query += fmt.Sprintf(" AND type = $%d", argIdx)
+		args = append(args, *listQuery.ActivityType)
+		argIdx++
+	}
+	if listQuery.StartFrom != nil {
+		query += fmt.Sprintf(" AND start_time >= $%d", argIdx)
+		args = append(args, *listQuery.StartFrom)
+		argIdx++
+	}
+	if listQuery.StartTo != nil {
+		query += fmt.Sprintf(" AND start_time <= $%d", argIdx)
+		args = append(args, *listQuery.StartTo)
+		argIdx++
+	}
+	if listQuery.MinDistanceM != nil {
+		query += fmt.Sprintf(" AND distance_m >= $%d", argIdx)
+		args = append(args, *listQuery.MinDistanceM)
+		argIdx++
+	}
+	if listQuery.MaxDistanceM != nil {
+		query += fmt.Sprintf(" AND distance_m <= $%d", argIdx)
+		args = append(args, *listQuery.MaxDistanceM)
+		argIdx++
+	}
+	if listQuery.TitleSearch != "" {
+		query += fmt.Sprintf(` AND title ILIKE $%d ESCAPE '\'`, argIdx)
+		args = append(args, "%"+escapeLikePattern(listQuery.TitleSearch)+"%")
+		argIdx++
+	}
+
+	sortColumn := "start_time"
+	var cursorValue interface{}
+	if listQuery.After != nil {
+		cursorValue = listQuery.After.StartTime
+	}
+	switch listQuery.SortBy {
+	case models.ActivitySortByDistance:
+		sortColumn = "distance_m"
+		if listQuery.After != nil {
+			cursorValue = listQuery.After.DistanceM
+		}
+	case models.ActivitySortByDuration:
+		sortColumn = "elapsed_time"
+		if listQuery.After != nil {
+			cursorValue = listQuery.After.ElapsedTime
+		}
+	}
+
+	direction, comparison := "DESC", "<"
+	if listQuery.Ascending {
+		direction, comparison = "ASC", ">"
+	}
 
-	rows, err := s.pool.Query(ctx, query, userID)
+	// Keyset pagination: continue strictly after the previous page's last (sort value, id)
+	if listQuery.After != nil {
+		query += fmt.Sprintf(" AND (%s, id) %s ($%d, $%d)", sortColumn, comparison, argIdx, argIdx+1)
+		args = append(args, cursorValue, listQuery.After.ID)
+		argIdx += 2
+	}
+
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s", sortColumn, direction, direction)
+
+	if listQuery.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argIdx)
+		args = append(args, listQuery.Limit)
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
 	if err != nil {
 		s.log.Error(fmt.Sprintf("Database error while fetching activities for user: %s", userID), err)
 		return nil, fmt.Errorf("failed to get activities: %w", err)
@@ -250,6 +328,7 @@ func (s *PostgresDB) GetActivitiesByUserID(ctx context.Context, userID string) (
 			&activity.StartTime,
 			&activity.EndTime,
 			&activity.ElapsedTime,
+			&activity.MovingTime,
 			&activity.DistanceM,
 			&activity.ElevationGainM,
 			&activity.ElevationLossM,
@@ -257,11 +336,17 @@ func (s *PostgresDB) GetActivitiesByUserID(ctx context.Context, userID string) (
 			&activity.MinHeightM,
 			&activity.AvgSpeedMps,
 			&activity.MaxSpeedMps,
+			&activity.AvgGAPMps,
 			&activity.AvgHRBpm,
 			&activity.MaxHRBpm,
 			&activity.PerceivedEffort,
 			&activity.ProcessingVer,
+			&activity.AvgCadenceRpm,
+			&activity.MaxCadenceRpm,
+			&activity.AvgPowerWatt,
+			&activity.MaxPowerWatt,
 			&activity.Polyline,
+			&activity.RawPolyline,
 			&activity.BBoxMinLat,
 			&activity.BBoxMinLon,
 			&activity.BBoxMaxLat,
@@ -290,16 +375,22 @@ func (s *PostgresDB) GetActivitiesByUserID(ctx context.Context, userID string) (
 	return activities, nil
 }
 
+// escapeLikePattern escapes LIKE wildcards so user input only matches literally
+func escapeLikePattern(v string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(v)
+}
+
 func (s *PostgresDB) GetActivitiesByUserIDAndDate(ctx context.Context, userID string, start_date time.Time, end_date time.Time) ([]models.Activity, []models.PlannedActivity, error) {
 	s.log.Debug(fmt.Sprintf("Fetching activities for user: %s", userID))
 
 	query := `
 		SELECT
 			id, user_id, client_activity_id, title, description, type,
-			start_time, end_time, elapsed_time, distance_m, elevation_gain_m,
+			start_time, end_time, elapsed_time, moving_time_s, distance_m, elevation_gain_m,
 			elevation_loss_m, max_height_m, min_height_m,
-			avg_speed_mps, max_speed_mps, avg_hr_bpm, max_hr_bpm, processing_ver,
-			polyline, bbox_min_lat, bbox_min_lon, bbox_max_lat, bbox_max_lon,
+			avg_speed_mps, max_speed_mps, avg_gap_mps, avg_hr_bpm, max_hr_bpm, processing_ver,
+			avg_cadence_rpm, max_cadence_rpm, avg_power_watt, max_power_watt,
+			polyline, raw_polyline, bbox_min_lat, bbox_min_lon, bbox_max_lat, bbox_max_lon,
 			start_lat, start_lon, end_lat, end_lon, file_url, created_at, updated_at
 		FROM activities
 		WHERE user_id = $1 AND created_at >= $2 AND created_at <= $3
@@ -326,6 +417,7 @@ func (s *PostgresDB) GetActivitiesByUserIDAndDate(ctx context.Context, userID st
             &activity.StartTime,
             &activity.EndTime,
             &activity.ElapsedTime,
+            &activity.MovingTime,
             &activity.DistanceM,
             &activity.ElevationGainM,
             &activity.ElevationLossM,
@@ -333,10 +425,16 @@ func (s *PostgresDB) GetActivitiesByUserIDAndDate(ctx context.Context, userID st
             &activity.MinHeightM,
             &activity.AvgSpeedMps,
             &activity.MaxSpeedMps,
+            &activity.AvgGAPMps,
             &activity.AvgHRBpm,
             &activity.MaxHRBpm,
             &activity.ProcessingVer,
+            &activity.AvgCadenceRpm,
+            &activity.MaxCadenceRpm,
+            &activity.AvgPowerWatt,
+            &activity.MaxPowerWatt,
             &activity.Polyline,
+            &activity.RawPolyline,
             &activity.BBoxMinLat,
             &activity.BBoxMinLon,
             &activity.BBoxMaxLat,
@@ -368,7 +466,7 @@ func (s *PostgresDB) GetActivitiesByUserIDAndDate(ctx context.Context, userID st
             id, user_id, title, description, type,
             start_time, planned_distance_m, planned_duration_s,
             planned_elevation_gain_m, target_avg_speed_mps, target_power_watt,
-            matched_activity_id, user_training_plan_id, plan_sequence_index,
+            matched_activity_id, route_id, user_training_plan_id, plan_sequence_index,
             created_at, updated_at
         FROM planned_activities
         WHERE user_id = $1 AND start_time >= $2 AND start_time <= $3
@@ -398,6 +496,7 @@ func (s *PostgresDB) GetActivitiesByUserIDAndDate(ctx context.Context, userID st
             &plannedActivity.TargetAvgSpeedMps,
             &plannedActivity.TargetPowerWatt,
             &plannedActivity.MatchedActivityID,
+            &plannedActivity.RouteID,
             &plannedActivity.UserTrainingPlanID,
             &plannedActivity.PlanSequenceIndex,
             &plannedActivity.CreatedAt,
@@ -484,10 +583,11 @@ func (s *PostgresDB) GetActivityByID(ctx context.Context, activityID string) (*m
 	query := `
 		SELECT 
 			id, user_id, client_activity_id, title, description, type,
-			start_time, end_time, elapsed_time, distance_m, elevation_gain_m,
+			start_time, end_time, elapsed_time, moving_time_s, distance_m, elevation_gain_m,
 			elevation_loss_m, max_height_m, min_height_m,
-			avg_speed_mps, max_speed_mps, avg_hr_bpm, max_hr_bpm, perceived_effort, processing_ver,
-			polyline, bbox_min_lat, bbox_min_lon, bbox_max_lat, bbox_max_lon,
+			avg_speed_mps, max_speed_mps, avg_gap_mps, avg_hr_bpm, max_hr_bpm, perceived_effort, processing_ver,
+			avg_cadence_rpm, max_cadence_rpm, avg_power_watt, max_power_watt,
+			polyline, raw_polyline, bbox_min_lat, bbox_min_lon, bbox_max_lat, bbox_max_lon,
 			start_lat, start_lon, end_lat, end_lon, file_url, created_at, updated_at
 		FROM activities 
 		WHERE id = $1
@@ -504,6 +604,7 @@ func (s *PostgresDB) GetActivityByID(ctx context.Context, activityID string) (*m
 		&activity.StartTime,
 		&activity.EndTime,
 		&activity.ElapsedTime,
+		&activity.MovingTime,
 		&activity.DistanceM,
 		&activity.ElevationGainM,
 		&activity.ElevationLossM,
@@ -511,11 +612,17 @@ func (s *PostgresDB) GetActivityByID(ctx context.Context, activityID string) (*m
 		&activity.MinHeightM,
 		&activity.AvgSpeedMps,
 		&activity.MaxSpeedMps,
+		&activity.AvgGAPMps,
 		&activity.AvgHRBpm,
 		&activity.MaxHRBpm,
 		&activity.PerceivedEffort,
 		&activity.ProcessingVer,
+		&activity.AvgCadenceRpm,
+		&activity.MaxCadenceRpm,
+		&activity.AvgPowerWatt,
+		&activity.MaxPowerWatt,
 		&activity.Polyline,
+		&activity.RawPolyline,
 		&activity.BBoxMinLat,
 		&activity.BBoxMinLon,
 		&activity.BBoxMaxLat,
@@ -542,6 +649,308 @@ func (s *PostgresDB) GetActivityByID(ctx context.Context, activityID string) (*m
 	return &activity, nil
 }
 
+// FindActivityIDByStartTime returns the ID of the user's activity starting within tolerance of startTime, or nil if none.
+// Used to skip files that were already imported.
+func (s *PostgresDB) FindActivityIDByStartTime(ctx context.Context, userID string, startTime time.Time, tolerance time.Duration) (*string, error) {
+	s.log.Debug(fmt.Sprintf("Looking up activity starting at %s for user: %s", startTime.Format(time.RFC3339), userID))
+
+	query := `
+		SELECT id
+		FROM activities
+		WHERE user_id = $1 AND start_time BETWEEN $2 AND $3
+		ORDER BY start_time
+		LIMIT 1
+	`
+
+	var id string
+	err := s.pool.QueryRow(ctx, query, userID, startTime.Add(-tolerance), startTime.Add(tolerance)).Scan(&id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		s.log.Error(fmt.Sprintf("Database error while looking up activity by start time for user: %s", userID), err)
+		return nil, fmt.Errorf("failed to find activity by start time: %w", err)
+	}
+
+	return &id, nil
+}
+
+// GetActivitiesByIDs fetches the user's activities with the given IDs; unknown or foreign IDs are skipped
+func (s *PostgresDB) GetActivitiesByIDs(ctx context.Context, userID string, activityIDs []string) ([]models.Activity, error) {
+	s.log.Debug(fmt.Sprintf("Fetching %d activities by ID for user: %s", len(activityIDs), userID))
+
+	if len(activityIDs) == 0 {
+		return []models.Activity{}, nil
+	}
+
+	query := `
+		SELECT
+			id, user_id, client_activity_id, title, description, type,
+			start_time, end_time, elapsed_time, moving_time_s, distance_m, elevation_gain_m,
+			elevation_loss_m, max_height_m, min_height_m,
+			avg_speed_mps, max_speed_mps, avg_gap_mps, avg_hr_bpm, max_hr_bpm, perceived_effort, processing_ver,
+			avg_cadence_rpm, max_cadence_rpm, avg_power_watt, max_power_watt,
+			polyline, raw_polyline, bbox_min_lat, bbox_min_lon, bbox_max_lat, bbox_max_lon,
+			start_lat, start_lon, end_lat, end_lon, file_url, created_at, updated_at
+		FROM activities
+		WHERE user_id = $1 AND id = ANY($2::uuid[])
+	`
+
+	rows, err := s.pool.Query(ctx, query, userID, activityIDs)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Database error while fetching activities by ID for user: %s", userID), err)
+		return nil, fmt.Errorf("failed to get activities: %w", err)
+	}
+	defer rows.Close()
+
+	activities := []models.Activity{}
+	for rows.Next() {
+		var activity models.Activity
+		err := rows.Scan(
+			&activity.ID,
+			&activity.UserID,
+			&activity.ClientActivityID,
+			&activity.Title,
+			&activity.Description,
+			&activity.ActivityType,
+			&activity.StartTime,
+			&activity.EndTime,
+			&activity.ElapsedTime,
+			&activity.MovingTime,
+			&activity.DistanceM,
+			&activity.ElevationGainM,
+			&activity.ElevationLossM,
+			&activity.MaxHeightM,
+			&activity.MinHeightM,
+			&activity.AvgSpeedMps,
+			&activity.MaxSpeedMps,
+			&activity.AvgGAPMps,
+			&activity.AvgHRBpm,
+			&activity.MaxHRBpm,
+			&activity.PerceivedEffort,
+			&activity.ProcessingVer,
+			&activity.AvgCadenceRpm,
+			&activity.MaxCadenceRpm,
+			&activity.AvgPowerWatt,
+			&activity.MaxPowerWatt,
+			&activity.Polyline,
+			&activity.RawPolyline,
+			&activity.BBoxMinLat,
+			&activity.BBoxMinLon,
+			&activity.BBoxMaxLat,
+			&activity.BBoxMaxLon,
+			&activity.StartLat,
+			&activity.StartLon,
+			&activity.EndLat,
+			&activity.EndLon,
+			&activity.FileURL,
+			&activity.CreatedAt,
+			&activity.UpdatedAt,
+		)
+		if err != nil {
+			s.log.Error(fmt.Sprintf("Error scanning activity row for user: %s", userID), err)
+			return nil, fmt.Errorf("failed to scan activity: %w", err)
+		}
+		activities = append(activities, activity)
+	}
+
+	if err = rows.Err(); err != nil {
+		s.log.Error(fmt.Sprintf("Row iteration error for user: %s", userID), err)
+		return nil, fmt.Errorf("failed to iterate activities: %w", err)
+	}
+
+	return activities, nil
+}
+
+// UpdateActivity updates user-editable fields of an activity by ID, scoped to the owning user, and its
+// grade-adjusted pace when the activity is rescored. Best efforts, training stress, zone times and grade-adjusted
+// pace depend on the activity type, so changing the type to a different one drops the activity's efforts, the
+// records they held, its stress score, its zone times and its grade-adjusted pace; the records are refilled from
+// the remaining activities and the caller rescores the activity. Setting the type it already has keeps them.
+func (s *PostgresDB) UpdateActivity(ctx context.Context, activityID string, userID string, updates map[string]interface{}) error {
+	s.log.Debug(fmt.Sprintf("Updating activity ID: %s for user: %s with %d fields", activityID, userID, len(updates)))
+
+	if len(updates) == 0 {
+		return fmt.Errorf("no updates provided")
+	}
+
+	for field := range updates {
+		switch field {
+		case "title", "description", "type", "perceived_effort", "avg_gap_mps":
+		default:
+			return fmt.Errorf("invalid field for update: %s", field)
+		}
+	}
+
+	beginner, ok := s.pool.(interface {
+		Begin(context.Context) (pgx.Tx, error)
+	})
+	if !ok {
+		return fmt.Errorf("database pool does not support transactions")
+	}
+
+	tx, err := beginner.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	// Lock the row so the type compared here is the one being replaced
+	typeChanged := false
+	if newType, ok := updates["type"]; ok {
+		var currentType string
+		err := tx.QueryRow(ctx, `SELECT type FROM activities WHERE id = $1 AND user_id = $2 FOR UPDATE`, activityID, userID).Scan(&currentType)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				s.log.Debug(fmt.Sprintf("Activity not found with ID: %s for user: %s", activityID, userID))
+				return fmt.Errorf("activity not found")
+			}
+			s.log.Error(fmt.Sprintf("Database error while reading type of activity: %s", activityID), err)
+			return fmt.Errorf("failed to read activity type: %w", err)
+		}
+		typeChanged = currentType != fmt.Sprint(newType)
+	}
+
+	// Build dynamic query
+	setClauses := make([]string, 0, len(updates)+2)
+	args := make([]interface{}, 0, len(updates)+3)
+	argIndex := 1
+
+	for field, value := range updates {
+		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", field, argIndex))
+		args = append(args, value)
+		argIndex++
+	}
+
+	if _, ok := updates["avg_gap_mps"]; typeChanged && !ok {
+		setClauses = append(setClauses, "avg_gap_mps = NULL")
+	}
+
+	// Always update updated_at
+	setClauses = append(setClauses, fmt.Sprintf("updated_at = $%d", argIndex))
+	args = append(args, time.Now())
+	argIndex++
+
+	// Add WHERE clause params
+	args = append(args, activityID, userID)
+
+	query := fmt.Sprintf(`
+		UPDATE activities
+		SET %s
+		WHERE id = $%d AND user_id = $%d
+	`, strings.Join(setClauses, ", "), argIndex, argIndex+1)
+
+	cmdTag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Database error while updating activity ID: %s", activityID), err)
+		return fmt.Errorf("failed to update activity: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		s.log.Debug(fmt.Sprintf("Activity not found with ID: %s for user: %s", activityID, userID))
+		return fmt.Errorf("activity not found")
+	}
+
+	if typeChanged {
+		if _, err := tx.Exec(ctx, `DELETE FROM activity_best_efforts WHERE activity_id = $1`, activityID); err != nil {
+			s.log.Error(fmt.Sprintf("Database error while clearing best efforts of activity: %s", activityID), err)
+			return fmt.Errorf("failed to clear best efforts: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM personal_records WHERE activity_id = $1`, activityID); err != nil {
+			s.log.Error(fmt.Sprintf("Database error while clearing personal records of activity: %s", activityID), err)
+			return fmt.Errorf("failed to clear personal records: %w", err)
+		}
+		if _, err := tx.Exec(ctx, refillPersonalRecordsQuery, userID, time.Now()); err != nil {
+			s.log.Error(fmt.Sprintf("Database error while refilling personal records for user: %s", userID), err)
+			return fmt.Errorf("failed to refill personal records: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM activity_training_stress WHERE activity_id = $1`, activityID); err != nil {
+			s.log.Error(fmt.Sprintf("Database error while clearing training stress of activity: %s", activityID), err)
+			return fmt.Errorf("failed to clear training stress: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM activity_zone_times WHERE activity_id = $1`, activityID); err != nil {
+			s.log.Error(fmt.Sprintf("Database error while clearing zone times of activity: %s", activityID), err)
+			return fmt.Errorf("failed to clear zone times: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+
+	s.log.Debug(fmt.Sprintf("Successfully updated activity: %s", activityID))
+	return nil
+}
+
+// DeleteActivity deletes an activity by ID, scoped to the owning user.
+// Streams are removed by the foreign key cascade; planned activities matched to it are unlinked
+// and personal records it held are refilled from the remaining activities.
+func (s *PostgresDB) DeleteActivity(ctx context.Context, activityID string, userID string) error {
+	s.log.Debug(fmt.Sprintf("Deleting activity ID: %s for user: %s", activityID, userID))
+
+	beginner, ok := s.pool.(interface {
+		Begin(context.Context) (pgx.Tx, error)
+	})
+	if !ok {
+		return fmt.Errorf("database pool does not support transactions")
+	}
+
+	tx, err := beginner.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	// The foreign key would null the match on its own, but clearing it here also bumps updated_at
+	unlinkQuery := `
+		UPDATE planned_activities
+		SET matched_activity_id = NULL, updated_at = $1
+		WHERE user_id = $2 AND matched_activity_id = $3
+	`
+	if _, err := tx.Exec(ctx, unlinkQuery, time.Now(), userID, activityID); err != nil {
+		s.log.Error(fmt.Sprintf("Database error while unlinking planned activities from activity: %s", activityID), err)
+		return fmt.Errorf("failed to unlink planned activities: %w", err)
+	}
+
+	cmdTag, err := tx.Exec(ctx, `DELETE FROM activities WHERE id = $1 AND user_id = $2`, activityID, userID)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Database error while deleting activity ID: %s", activityID), err)
+		return fmt.Errorf("failed to delete activity: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		s.log.Debug(fmt.Sprintf("Activity not found with ID: %s for user: %s", activityID, userID))
+		return fmt.Errorf("activity not found")
+	}
+
+	// Records set by the deleted activity fall back to the next best remaining effort
+	if _, err := tx.Exec(ctx, refillPersonalRecordsQuery, userID, time.Now()); err != nil {
+		s.log.Error(fmt.Sprintf("Database error while refilling personal records for user: %s", userID), err)
+		return fmt.Errorf("failed to refill personal records: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+
+	s.log.Debug(fmt.Sprintf("Successfully deleted activity: %s", activityID))
+	return nil
+}
+
 func (s *PostgresDB) UpdateUser(ctx context.Context, userID string, updates map[string]interface{}) error {
 	s.log.Debug(fmt.Sprintf("Updating user ID: %s with %d fields", userID, len(updates)))
 
@@ -556,7 +965,9 @@ func (s *PostgresDB) UpdateUser(ctx context.Context, userID string, updates map[
 
 	for field, value := range updates {
 		switch field {
-		case "name", "email":
+		case "name", "email",
+			"max_hr_bpm", "resting_hr_bpm", "threshold_hr_bpm", "threshold_pace_s_per_km", "ftp_watt",
+			"weight_kg", "birth_year", "sex", "hr_zones_bpm", "pace_zones_s_per_km", "power_zones_watt":
 			setClauses = append(setClauses, fmt.Sprintf("%s = $%d", field, argIndex))
 			args = append(args, value)
 			argIndex++
@@ -594,6 +1005,43 @@ func (s *PostgresDB) UpdateUser(ctx context.Context, userID string, updates map[
 	return nil
 }
 
+// GetUserTrainingSettings retrieves a user's thresholds, body metrics and zone boundaries.
+// Returns nil when the user doesn't exist.
+func (s *PostgresDB) GetUserTrainingSettings(ctx context.Context, userID string) (*models.TrainingSettings, error) {
+	s.log.Debug(fmt.Sprintf("Fetching training settings for user: %s", userID))
+
+	query := `
+		SELECT max_hr_bpm, resting_hr_bpm, threshold_hr_bpm, threshold_pace_s_per_km, ftp_watt,
+		       weight_kg, birth_year, sex, hr_zones_bpm, pace_zones_s_per_km, power_zones_watt
+		FROM users WHERE id = $1
+	`
+
+	var settings models.TrainingSettings
+	err := s.pool.QueryRow(ctx, query, userID).Scan(
+		&settings.MaxHRBpm,
+		&settings.RestingHRBpm,
+		&settings.ThresholdHRBpm,
+		&settings.ThresholdPaceSPerKm,
+		&settings.FTPWatt,
+		&settings.WeightKg,
+		&settings.BirthYear,
+		&settings.Sex,
+		&settings.HRZonesBpm,
+		&settings.PaceZonesSPerKm,
+		&settings.PowerZonesWatt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			s.log.Debug(fmt.Sprintf("User not found with ID: %s", userID))
+			return nil, nil
+		}
+		s.log.Error(fmt.Sprintf("Database error while fetching training settings for user: %s", userID), err)
+		return nil, fmt.Errorf("failed to get training settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
 // GetActivityStreams retrieves activity streams for a given activity and LOD
 func (s *PostgresDB) GetActivityStreams(ctx context.Context, activityID string, lod models.StreamLOD) ([]models.ActivityStream, error) {
 	s.log.Debug(fmt.Sprintf("Fetching activity streams for activity: %s, LOD: %s", activityID, lod))
@@ -602,6 +1050,8 @@ func (s *PostgresDB) GetActivityStreams(ctx context.Context, activityID string,
 		SELECT 
 			activity_id, lod, index_by, num_points, original_num_points,
 			time_s_bytes, distance_m_bytes, speed_mps_bytes, elevation_m_bytes,
+			heart_rate_bpm_bytes, cadence_rpm_bytes, power_watt_bytes, temperature_c_bytes,
+			lat_bytes, lon_bytes, grade_pct_bytes,
 			codec, created_at, updated_at
 		FROM activity_streams 
 		WHERE activity_id = $1 AND lod = $2
@@ -630,6 +1080,13 @@ func (s *PostgresDB) GetActivityStreams(ctx context.Context, activityID string,
 			&stream.DistanceMBytes,
 			&stream.SpeedMpsBytes,
 			&stream.ElevationMBytes,
+			&stream.HeartRateBpmBytes,
+			&stream.CadenceRpmBytes,
+			&stream.PowerWattBytes,
+			&stream.TemperatureCBytes,
+			&stream.LatBytes,
+			&stream.LonBytes,
+			&stream.GradePctBytes,
 			&codecJSON,
 			&stream.CreatedAt,
 			&stream.UpdatedAt,
@@ -674,9 +1131,11 @@ func (s *PostgresDB) CreateActivityStreams(ctx context.Context, streams []models
 		INSERT INTO activity_streams (
 			activity_id, lod, index_by, num_points, original_num_points,
 			time_s_bytes, distance_m_bytes, speed_mps_bytes, elevation_m_bytes,
+			heart_rate_bpm_bytes, cadence_rpm_bytes, power_watt_bytes, temperature_c_bytes,
+			lat_bytes, lon_bytes, grade_pct_bytes,
 			codec, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
 		)
 	`
 
@@ -697,6 +1156,13 @@ func (s *PostgresDB) CreateActivityStreams(ctx context.Context, streams []models
 			stream.DistanceMBytes,
 			stream.SpeedMpsBytes,
 			stream.ElevationMBytes,
+			stream.HeartRateBpmBytes,
+			stream.CadenceRpmBytes,
+			stream.PowerWattBytes,
+			stream.TemperatureCBytes,
+			stream.LatBytes,
+			stream.LonBytes,
+			stream.GradePctBytes,
 			codecJSON,
 			stream.CreatedAt,
 			stream.UpdatedAt,
@@ -712,52 +1178,775 @@ func (s *PostgresDB) CreateActivityStreams(ctx context.Context, streams []models
 	return nil
 }
 
-// --- Planned Activities ---
+// --- Activity Splits ---
 
-// DeletePlannedActivity deletes a planned activity by ID, scoped to the owning user
-func (s *PostgresDB) DeletePlannedActivity(ctx context.Context, activityID string, userID string) error {
-	s.log.Debug(fmt.Sprintf("Deleting planned activity ID: %s for user: %s", activityID, userID))
+// GetActivitySplits retrieves an activity's splits for one unit, in order
+func (s *PostgresDB) GetActivitySplits(ctx context.Context, activityID string, unit models.SplitUnit) ([]models.ActivitySplit, error) {
+	s.log.Debug(fmt.Sprintf("Fetching %s splits for activity: %s", unit, activityID))
 
-	query := `DELETE FROM planned_activities WHERE id = $1 AND user_id = $2`
+	query := `
+		SELECT
+			activity_id, unit, split_index, distance_m, elapsed_s, moving_s,
+			elevation_gain_m, elevation_loss_m, avg_hr_bpm, created_at
+		FROM activity_splits
+		WHERE activity_id = $1 AND unit = $2
+		ORDER BY split_index
+	`
 
-	cmdTag, err := s.pool.Exec(ctx, query, activityID, userID)
+	rows, err := s.pool.Query(ctx, query, activityID, unit)
 	if err != nil {
-		s.log.Error(fmt.Sprintf("Database error while deleting planned activity ID: %s", activityID), err)
-		return fmt.Errorf("failed to delete planned activity: %w", err)
+		s.log.Error(fmt.Sprintf("Database error while fetching splits for activity: %s", activityID), err)
+		return nil, fmt.Errorf("failed to get activity splits: %w", err)
 	}
+	defer rows.Close()
 
-	if cmdTag.RowsAffected() == 0 {
-		s.log.Debug(fmt.Sprintf("Planned activity not found with ID: %s for user: %s", activityID, userID))
-		return fmt.Errorf("planned activity not found")
+	splits := []models.ActivitySplit{}
+	for rows.Next() {
+		var split models.ActivitySplit
+		err := rows.Scan(
+			&split.ActivityID,
+			&split.Unit,
+			&split.SplitIndex,
+			&split.DistanceM,
+			&split.ElapsedS,
+			&split.MovingS,
+			&split.ElevationGainM,
+			&split.ElevationLossM,
+			&split.AvgHRBpm,
+			&split.CreatedAt,
+		)
+		if err != nil {
+			s.log.Error(fmt.Sprintf("Error scanning split row for activity: %s", activityID), err)
+			return nil, fmt.Errorf("failed to scan activity split: %w", err)
+		}
+		splits = append(splits, split)
 	}
 
-	s.log.Debug(fmt.Sprintf("Successfully deleted planned activity: %s", activityID))
-	return nil
-}
+	if err = rows.Err(); err != nil {
+		s.log.Error(fmt.Sprintf("Row iteration error for splits of activity: %s", activityID), err)
+		return nil, fmt.Errorf("failed to iterate activity splits: %w", err)
+	}
 
-// UpdatePlannedActivity updates a planned activity by ID, scoped to the owning user
-func (s *PostgresDB) UpdatePlannedActivity(ctx context.Context, activityID string, userID string, updates map[string]interface{}) error {
-	s.log.Debug(fmt.Sprintf("Updating planned activity ID: %s for user: %s with %d fields", activityID, userID, len(updates)))
+	return splits, nil
+}
 
-	if len(updates) == 0 {
-		return fmt.Errorf("no updates provided")
+// CreateActivitySplits stores an activity's splits
+func (s *PostgresDB) CreateActivitySplits(ctx context.Context, splits []models.ActivitySplit) error {
+	if len(splits) == 0 {
+		return nil
 	}
 
-	// Build dynamic query
-	setClauses := make([]string, 0, len(updates))
-	args := make([]interface{}, 0, len(updates)+2)
-	argIndex := 1
+	activityID := splits[0].ActivityID // All splits should be for same activity
+	s.log.Debug(fmt.Sprintf("Creating %d splits for activity: %s", len(splits), activityID))
 
-	for field, value := range updates {
-		switch field {
-		case "title", "description", "type", "start_time",
-			"planned_distance_m", "planned_duration_s", "planned_elevation_gain_m",
-			"target_avg_speed_mps", "target_power_watt":
-			setClauses = append(setClauses, fmt.Sprintf("%s = $%d", field, argIndex))
-			args = append(args, value)
-			argIndex++
-		default:
-			return fmt.Errorf("invalid field for update: %s", field)
+	query := `
+		INSERT INTO activity_splits (
+			activity_id, unit, split_index, distance_m, elapsed_s, moving_s,
+			elevation_gain_m, elevation_loss_m, avg_hr_bpm, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		)
+	`
+
+	for _, split := range splits {
+		_, err := s.pool.Exec(ctx, query,
+			split.ActivityID,
+			split.Unit,
+			split.SplitIndex,
+			split.DistanceM,
+			split.ElapsedS,
+			split.MovingS,
+			split.ElevationGainM,
+			split.ElevationLossM,
+			split.AvgHRBpm,
+			split.CreatedAt,
+		)
+		if err != nil {
+			s.log.Error(fmt.Sprintf("Database error while creating split for activity: %s", activityID), err)
+			return fmt.Errorf("failed to create activity split: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// --- Activity Laps ---
+
+// GetActivityLaps retrieves an activity's laps, in order
+func (s *PostgresDB) GetActivityLaps(ctx context.Context, activityID string) ([]models.ActivityLap, error) {
+	s.log.Debug(fmt.Sprintf("Fetching laps for activity: %s", activityID))
+
+	query := `
+		SELECT
+			activity_id, lap_index, start_index, start_time, elapsed_s, distance_m,
+			avg_speed_mps, avg_hr_bpm, max_hr_bpm, created_at
+		FROM activity_laps
+		WHERE activity_id = $1
+		ORDER BY lap_index
+	`
+
+	rows, err := s.pool.Query(ctx, query, activityID)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Database error while fetching laps for activity: %s", activityID), err)
+		return nil, fmt.Errorf("failed to get activity laps: %w", err)
+	}
+	defer rows.Close()
+
+	laps := []models.ActivityLap{}
+	for rows.Next() {
+		var lap models.ActivityLap
+		err := rows.Scan(
+			&lap.ActivityID,
+			&lap.LapIndex,
+			&lap.StartIndex,
+			&lap.StartTime,
+			&lap.ElapsedS,
+			&lap.DistanceM,
+			&lap.AvgSpeedMps,
+			&lap.AvgHRBpm,
+			&lap.MaxHRBpm,
+			&lap.CreatedAt,
+		)
+		if err != nil {
+			s.log.Error(fmt.Sprintf("Error scanning lap row for activity: %s", activityID), err)
+			return nil, fmt.Errorf("failed to scan activity lap: %w", err)
+		}
+		laps = append(laps, lap)
+	}
+
+	if err = rows.Err(); err != nil {
+		s.log.Error(fmt.Sprintf("Row iteration error for laps of activity: %s", activityID), err)
+		return nil, fmt.Errorf("failed to iterate activity laps: %w", err)
+	}
+
+	return laps, nil
+}
+
+// CreateActivityLaps stores an activity's laps
+func (s *PostgresDB) CreateActivityLaps(ctx context.Context, laps []models.ActivityLap) error {
+	if len(laps) == 0 {
+		return nil
+	}
+
+	activityID := laps[0].ActivityID // All laps should be for same activity
+	s.log.Debug(fmt.Sprintf("Creating %d laps for activity: %s", len(laps), activityID))
+
+	query := `
+		INSERT INTO activity_laps (
+			activity_id, lap_index, start_index, start_time, elapsed_s, distance_m,
+			avg_speed_mps, avg_hr_bpm, max_hr_bpm, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		)
+	`
+
+	for _, lap := range laps {
+		_, err := s.pool.Exec(ctx, query,
+			lap.ActivityID,
+			lap.LapIndex,
+			lap.StartIndex,
+			lap.StartTime,
+			lap.ElapsedS,
+			lap.DistanceM,
+			lap.AvgSpeedMps,
+			lap.AvgHRBpm,
+			lap.MaxHRBpm,
+			lap.CreatedAt,
+		)
+		if err != nil {
+			s.log.Error(fmt.Sprintf("Database error while creating lap for activity: %s", activityID), err)
+			return fmt.Errorf("failed to create activity lap: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// --- Best Efforts and Personal Records ---
+
+// personalRecordUpsert replaces a stored personal record only when the new effort beats it:
+// a faster time over a distance or a longer distance in a duration. Ties keep the older record.
+const personalRecordUpsert = `
+	ON CONFLICT (user_id, activity_type, effort) DO UPDATE SET
+		activity_id = EXCLUDED.activity_id,
+		distance_m = EXCLUDED.distance_m,
+		elapsed_s = EXCLUDED.elapsed_s,
+		achieved_at = EXCLUDED.achieved_at,
+		updated_at = EXCLUDED.updated_at
+	WHERE (personal_records.kind = 'distance' AND EXCLUDED.elapsed_s < personal_records.elapsed_s)
+		OR (personal_records.kind = 'duration' AND EXCLUDED.distance_m > personal_records.distance_m)
+`
+
+// refillPersonalRecordsQuery rebuilds a user's personal records from the best efforts of their remaining activities.
+// Records whose activity was deleted are already gone through the foreign key cascade.
+const refillPersonalRecordsQuery = `
+	INSERT INTO personal_records (
+		user_id, activity_type, effort, kind, activity_id, distance_m, elapsed_s, achieved_at, updated_at
+	)
+	SELECT DISTINCT ON (a.type, e.effort)
+		a.user_id, a.type, e.effort, e.kind, e.activity_id, e.distance_m, e.elapsed_s, a.start_time, $2
+	FROM activity_best_efforts e
+	JOIN activities a ON a.id = e.activity_id
+	WHERE a.user_id = $1
+	ORDER BY a.type, e.effort,
+		CASE e.kind WHEN 'distance' THEN e.elapsed_s ELSE -e.distance_m END,
+		a.start_time
+` + personalRecordUpsert
+
+// SaveActivityBestEfforts stores an activity's best efforts and rolls them up into the owner's personal records.
+// It returns the efforts that set a new personal record.
+func (s *PostgresDB) SaveActivityBestEfforts(ctx context.Context, activity *models.Activity, efforts []models.BestEffort) ([]string, error) {
+	if len(efforts) == 0 {
+		return nil, nil
+	}
+
+	s.log.Debug(fmt.Sprintf("Saving %d best efforts for activity: %s", len(efforts), activity.ID))
+
+	beginner, ok := s.pool.(interface {
+		Begin(context.Context) (pgx.Tx, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("database pool does not support transactions")
+	}
+
+	tx, err := beginner.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	effortQuery := `
+		INSERT INTO activity_best_efforts (
+			activity_id, effort, kind, distance_m, elapsed_s, start_index, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		)
+	`
+	recordQuery := `
+		INSERT INTO personal_records (
+			user_id, activity_type, effort, kind, activity_id, distance_m, elapsed_s, achieved_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		)
+	` + personalRecordUpsert + `
+		RETURNING effort
+	`
+
+	now := time.Now()
+	var newRecords []string
+	for _, effort := range efforts {
+		_, err := tx.Exec(ctx, effortQuery,
+			effort.ActivityID,
+			effort.Effort,
+			effort.Kind,
+			effort.DistanceM,
+			effort.ElapsedS,
+			effort.StartIndex,
+			effort.CreatedAt,
+		)
+		if err != nil {
+			s.log.Error(fmt.Sprintf("Database error while creating best effort for activity: %s", activity.ID), err)
+			return nil, fmt.Errorf("failed to create best effort: %w", err)
+		}
+
+		// No row comes back when the stored record is at least as good
+		var record string
+		err = tx.QueryRow(ctx, recordQuery,
+			activity.UserID,
+			activity.ActivityType,
+			effort.Effort,
+			effort.Kind,
+			effort.ActivityID,
+			effort.DistanceM,
+			effort.ElapsedS,
+			activity.StartTime,
+			now,
+		).Scan(&record)
+		if err == pgx.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			s.log.Error(fmt.Sprintf("Database error while updating personal record for user: %s", activity.UserID), err)
+			return nil, fmt.Errorf("failed to update personal record: %w", err)
+		}
+		newRecords = append(newRecords, record)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+
+	return newRecords, nil
+}
+
+// GetPersonalRecords retrieves a user's personal records, optionally of one activity type.
+// Records are ordered by activity type, then distance efforts by distance and duration efforts by duration.
+func (s *PostgresDB) GetPersonalRecords(ctx context.Context, userID string, activityType *models.ActivityType) ([]models.PersonalRecord, error) {
+	s.log.Debug(fmt.Sprintf("Fetching personal records for user: %s", userID))
+
+	query := `
+		SELECT
+			user_id, activity_type, effort, kind, activity_id, distance_m, elapsed_s, achieved_at, updated_at
+		FROM personal_records
+		WHERE user_id = $1
+	`
+	args := []interface{}{userID}
+	if activityType != nil {
+		query += " AND activity_type = $2"
+		args = append(args, *activityType)
+	}
+	query += " ORDER BY activity_type, kind, CASE kind WHEN 'distance' THEN distance_m ELSE elapsed_s END"
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Database error while fetching personal records for user: %s", userID), err)
+		return nil, fmt.Errorf("failed to get personal records: %w", err)
+	}
+	defer rows.Close()
+
+	records := []models.PersonalRecord{}
+	for rows.Next() {
+		var record models.PersonalRecord
+		err := rows.Scan(
+			&record.UserID,
+			&record.ActivityType,
+			&record.Effort,
+			&record.Kind,
+			&record.ActivityID,
+			&record.DistanceM,
+			&record.ElapsedS,
+			&record.AchievedAt,
+			&record.UpdatedAt,
+		)
+		if err != nil {
+			s.log.Error(fmt.Sprintf("Error scanning personal record row for user: %s", userID), err)
+			return nil, fmt.Errorf("failed to scan personal record: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	if err = rows.Err(); err != nil {
+		s.log.Error(fmt.Sprintf("Row iteration error for personal records of user: %s", userID), err)
+		return nil, fmt.Errorf("failed to iterate personal records: %w", err)
+	}
+
+	return records, nil
+}
+
+// --- Training Load ---
+
+// SaveActivityTrainingStress stores an activity's training stress score, replacing an earlier one
+func (s *PostgresDB) SaveActivityTrainingStress(ctx context.Context, stress *models.ActivityTrainingStress) error {
+	s.log.Debug(fmt.Sprintf("Saving training stress for activity: %s", stress.ActivityID))
+
+	query := `
+		INSERT INTO activity_training_stress (
+			activity_id, user_id, day, tss, intensity_factor, method, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		)
+		ON CONFLICT (activity_id) DO UPDATE SET
+			day = EXCLUDED.day,
+			tss = EXCLUDED.tss,
+			intensity_factor = EXCLUDED.intensity_factor,
+			method = EXCLUDED.method,
+			created_at = EXCLUDED.created_at
+	`
+
+	_, err := s.pool.Exec(ctx, query,
+		stress.ActivityID,
+		stress.UserID,
+		stress.Day,
+		stress.TSS,
+		stress.IntensityFactor,
+		stress.Method,
+		stress.CreatedAt,
+	)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Database error while saving training stress for activity: %s", stress.ActivityID), err)
+		return fmt.Errorf("failed to save activity training stress: %w", err)
+	}
+
+	return nil
+}
+
+// GetDailyTrainingStress sums a user's training stress per day for the days on or after from, in order.
+// Days without activities are left out.
+func (s *PostgresDB) GetDailyTrainingStress(ctx context.Context, userID string, from time.Time) ([]models.DailyTrainingStress, error) {
+	s.log.Debug(fmt.Sprintf("Fetching daily training stress for user: %s from %s", userID, from.Format("2006-01-02")))
+
+	query := `
+		SELECT day, SUM(tss)
+		FROM activity_training_stress
+		WHERE user_id = $1 AND day >= $2
+		GROUP BY day
+		ORDER BY day
+	`
+
+	rows, err := s.pool.Query(ctx, query, userID, from)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Database error while fetching daily training stress for user: %s", userID), err)
+		return nil, fmt.Errorf("failed to get daily training stress: %w", err)
+	}
+	defer rows.Close()
+
+	days := []models.DailyTrainingStress{}
+	for rows.Next() {
+		var day models.DailyTrainingStress
+		if err := rows.Scan(&day.Day, &day.TSS); err != nil {
+			s.log.Error(fmt.Sprintf("Error scanning daily training stress row for user: %s", userID), err)
+			return nil, fmt.Errorf("failed to scan daily training stress: %w", err)
+		}
+		days = append(days, day)
+	}
+
+	if err = rows.Err(); err != nil {
+		s.log.Error(fmt.Sprintf("Row iteration error for daily training stress of user: %s", userID), err)
+		return nil, fmt.Errorf("failed to iterate daily training stress: %w", err)
+	}
+
+	return days, nil
+}
+
+// GetTrainingLoad retrieves the stored days of a user's training load series between from and to, inclusive
+func (s *PostgresDB) GetTrainingLoad(ctx context.Context, userID string, from time.Time, to time.Time) ([]models.TrainingLoadDay, error) {
+	s.log.Debug(fmt.Sprintf("Fetching training load for user: %s from %s to %s", userID, from.Format("2006-01-02"), to.Format("2006-01-02")))
+
+	query := `
+		SELECT user_id, day, tss, ctl, atl, tsb
+		FROM training_load
+		WHERE user_id = $1 AND day >= $2 AND day <= $3
+		ORDER BY day
+	`
+
+	rows, err := s.pool.Query(ctx, query, userID, from, to)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Database error while fetching training load for user: %s", userID), err)
+		return nil, fmt.Errorf("failed to get training load: %w", err)
+	}
+	defer rows.Close()
+
+	days := []models.TrainingLoadDay{}
+	for rows.Next() {
+		var day models.TrainingLoadDay
+		if err := rows.Scan(&day.UserID, &day.Day, &day.TSS, &day.CTL, &day.ATL, &day.TSB); err != nil {
+			s.log.Error(fmt.Sprintf("Error scanning training load row for user: %s", userID), err)
+			return nil, fmt.Errorf("failed to scan training load: %w", err)
+		}
+		days = append(days, day)
+	}
+
+	if err = rows.Err(); err != nil {
+		s.log.Error(fmt.Sprintf("Row iteration error for training load of user: %s", userID), err)
+		return nil, fmt.Errorf("failed to iterate training load: %w", err)
+	}
+
+	return days, nil
+}
+
+// GetTrainingLoadBefore retrieves the last stored day of a user's training load series before day.
+// Returns nil when the series starts on or after day.
+func (s *PostgresDB) GetTrainingLoadBefore(ctx context.Context, userID string, day time.Time) (*models.TrainingLoadDay, error) {
+	query := `
+		SELECT user_id, day, tss, ctl, atl, tsb
+		FROM training_load
+		WHERE user_id = $1 AND day < $2
+		ORDER BY day DESC
+		LIMIT 1
+	`
+
+	var load models.TrainingLoadDay
+	err := s.pool.QueryRow(ctx, query, userID, day).Scan(&load.UserID, &load.Day, &load.TSS, &load.CTL, &load.ATL, &load.TSB)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		s.log.Error(fmt.Sprintf("Database error while fetching training load before %s for user: %s", day.Format("2006-01-02"), userID), err)
+		return nil, fmt.Errorf("failed to get training load: %w", err)
+	}
+
+	return &load, nil
+}
+
+// ReplaceTrainingLoad replaces the days of a user's training load series from the given day on
+func (s *PostgresDB) ReplaceTrainingLoad(ctx context.Context, userID string, from time.Time, days []models.TrainingLoadDay) error {
+	s.log.Debug(fmt.Sprintf("Replacing training load for user: %s from %s with %d days", userID, from.Format("2006-01-02"), len(days)))
+
+	beginner, ok := s.pool.(interface {
+		Begin(context.Context) (pgx.Tx, error)
+	})
+	if !ok {
+		return fmt.Errorf("database pool does not support transactions")
+	}
+
+	tx, err := beginner.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM training_load WHERE user_id = $1 AND day >= $2`, userID, from); err != nil {
+		s.log.Error(fmt.Sprintf("Database error while clearing training load for user: %s", userID), err)
+		return fmt.Errorf("failed to clear training load: %w", err)
+	}
+
+	if len(days) > 0 {
+		// A series covers years of days, so it goes in as arrays rather than a statement per row
+		dates := make([]time.Time, len(days))
+		tss := make([]float64, len(days))
+		ctl := make([]float64, len(days))
+		atl := make([]float64, len(days))
+		tsb := make([]float64, len(days))
+		for i, day := range days {
+			dates[i], tss[i], ctl[i], atl[i], tsb[i] = day.Day, day.TSS, day.CTL, day.ATL, day.TSB
+		}
+
+		query := `
+			INSERT INTO training_load (user_id, day, tss, ctl, atl, tsb)
+			SELECT $1, day, tss, ctl, atl, tsb
+			FROM unnest($2::date[], $3::float8[], $4::float8[], $5::float8[], $6::float8[]) AS t(day, tss, ctl, atl, tsb)
+		`
+		if _, err := tx.Exec(ctx, query, userID, dates, tss, ctl, atl, tsb); err != nil {
+			s.log.Error(fmt.Sprintf("Database error while inserting training load for user: %s", userID), err)
+			return fmt.Errorf("failed to insert training load: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+
+	return nil
+}
+
+// --- Zones ---
+
+// ReplaceActivityZoneTimes replaces the time-in-zone results of an activity, so rescoring an activity
+// against changed zones leaves no zones of the old definition behind
+func (s *PostgresDB) ReplaceActivityZoneTimes(ctx context.Context, activityID string, zones []models.ActivityZoneTime) error {
+	s.log.Debug(fmt.Sprintf("Replacing %d zone times for activity: %s", len(zones), activityID))
+
+	beginner, ok := s.pool.(interface {
+		Begin(context.Context) (pgx.Tx, error)
+	})
+	if !ok {
+		return fmt.Errorf("database pool does not support transactions")
+	}
+
+	tx, err := beginner.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM activity_zone_times WHERE activity_id = $1`, activityID); err != nil {
+		s.log.Error(fmt.Sprintf("Database error while clearing zone times for activity: %s", activityID), err)
+		return fmt.Errorf("failed to clear zone times: %w", err)
+	}
+
+	query := `
+		INSERT INTO activity_zone_times (
+			activity_id, kind, zone, min_value, max_value, seconds, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		)
+	`
+	for _, zone := range zones {
+		_, err := tx.Exec(ctx, query,
+			activityID,
+			zone.Kind,
+			zone.Zone,
+			zone.MinValue,
+			zone.MaxValue,
+			zone.Seconds,
+			zone.CreatedAt,
+		)
+		if err != nil {
+			s.log.Error(fmt.Sprintf("Database error while creating zone time for activity: %s", activityID), err)
+			return fmt.Errorf("failed to create zone time: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+
+	return nil
+}
+
+// GetActivityZoneTimes retrieves the time-in-zone results of the given activities
+func (s *PostgresDB) GetActivityZoneTimes(ctx context.Context, activityIDs []string) ([]models.ActivityZoneTime, error) {
+	s.log.Debug(fmt.Sprintf("Fetching zone times for %d activities", len(activityIDs)))
+
+	if len(activityIDs) == 0 {
+		return []models.ActivityZoneTime{}, nil
+	}
+
+	query := `
+		SELECT activity_id, kind, zone, min_value, max_value, seconds, created_at
+		FROM activity_zone_times
+		WHERE activity_id = ANY($1::uuid[])
+		ORDER BY activity_id, kind, zone
+	`
+
+	rows, err := s.pool.Query(ctx, query, activityIDs)
+	if err != nil {
+		s.log.Error("Database error while fetching zone times", err)
+		return nil, fmt.Errorf("failed to get zone times: %w", err)
+	}
+	defer rows.Close()
+
+	zones := []models.ActivityZoneTime{}
+	for rows.Next() {
+		var zone models.ActivityZoneTime
+		err := rows.Scan(
+			&zone.ActivityID,
+			&zone.Kind,
+			&zone.Zone,
+			&zone.MinValue,
+			&zone.MaxValue,
+			&zone.Seconds,
+			&zone.CreatedAt,
+		)
+		if err != nil {
+			s.log.Error("Error scanning zone time row", err)
+			return nil, fmt.Errorf("failed to scan zone time: %w", err)
+		}
+		zones = append(zones, zone)
+	}
+
+	if err = rows.Err(); err != nil {
+		s.log.Error("Row iteration error for zone times", err)
+		return nil, fmt.Errorf("failed to iterate zone times: %w", err)
+	}
+
+	return zones, nil
+}
+
+// --- Stats ---
+
+// GetActivityStats sums a user's activities started in [from, to) per period and activity type. Periods follow
+// the calendar of the named timezone, so an activity counts toward the week, month or year of its local start.
+func (s *PostgresDB) GetActivityStats(ctx context.Context, userID string, period models.StatsPeriod, timezone string, from time.Time, to time.Time) ([]models.ActivityStats, error) {
+	s.log.Debug(fmt.Sprintf("Fetching %s activity stats for user: %s from %s to %s in %s", period, userID, from.Format(time.RFC3339), to.Format(time.RFC3339), timezone))
+
+	query := `
+		SELECT
+			date_trunc($2::text, a.start_time AT TIME ZONE $3)::date AS period_start,
+			a.type,
+			COUNT(*),
+			COALESCE(SUM(a.distance_m), 0)::double precision,
+			COALESCE(SUM(COALESCE(a.moving_time_s, a.elapsed_time)), 0)::double precision,
+			COALESCE(SUM(a.elevation_gain_m), 0)::double precision,
+			COALESCE(SUM(ts.tss), 0)::double precision
+		FROM activities a
+		LEFT JOIN activity_training_stress ts ON ts.activity_id = a.id
+		WHERE a.user_id = $1 AND a.start_time >= $4 AND a.start_time < $5
+		GROUP BY period_start, a.type
+		ORDER BY period_start, a.type
+	`
+
+	rows, err := s.pool.Query(ctx, query, userID, string(period), timezone, from, to)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Database error while fetching activity stats for user: %s", userID), err)
+		return nil, fmt.Errorf("failed to get activity stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []models.ActivityStats{}
+	for rows.Next() {
+		var stat models.ActivityStats
+		err := rows.Scan(
+			&stat.PeriodStart,
+			&stat.ActivityType,
+			&stat.Count,
+			&stat.DistanceM,
+			&stat.MovingTimeS,
+			&stat.ElevationGainM,
+			&stat.TSS,
+		)
+		if err != nil {
+			s.log.Error(fmt.Sprintf("Error scanning activity stats row for user: %s", userID), err)
+			return nil, fmt.Errorf("failed to scan activity stats: %w", err)
+		}
+		stats = append(stats, stat)
+	}
+
+	if err = rows.Err(); err != nil {
+		s.log.Error(fmt.Sprintf("Row iteration error for activity stats of user: %s", userID), err)
+		return nil, fmt.Errorf("failed to iterate activity stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// --- Planned Activities ---
+
+// DeletePlannedActivity deletes a planned activity by ID, scoped to the owning user
+func (s *PostgresDB) DeletePlannedActivity(ctx context.Context, activityID string, userID string) error {
+	s.log.Debug(fmt.Sprintf("Deleting planned activity ID: %s for user: %s", activityID, userID))
+
+	query := `DELETE FROM planned_activities WHERE id = $1 AND user_id = $2`
+
+	cmdTag, err := s.pool.Exec(ctx, query, activityID, userID)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Database error while deleting planned activity ID: %s", activityID), err)
+		return fmt.Errorf("failed to delete planned activity: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		s.log.Debug(fmt.Sprintf("Planned activity not found with ID: %s for user: %s", activityID, userID))
+		return fmt.Errorf("planned activity not found")
+	}
+
+	s.log.Debug(fmt.Sprintf("Successfully deleted planned activity: %s", activityID))
+	return nil
+}
+
+// UpdatePlannedActivity updates a planned activity by ID, scoped to the owning user
+func (s *PostgresDB) UpdatePlannedActivity(ctx context.Context, activityID string, userID string, updates map[string]interface{}) error {
+	s.log.Debug(fmt.Sprintf("Updating planned activity ID: %s for user: %s with %d fields", activityID, userID, len(updates)))
+
+	if len(updates) == 0 {
+		return fmt.Errorf("no updates provided")
+	}
+
+	// Build dynamic query
+	setClauses := make([]string, 0, len(updates))
+	args := make([]interface{}, 0, len(updates)+2)
+	argIndex := 1
+
+	for field, value := range updates {
+		switch field {
+		case "title", "description", "type", "start_time",
+			"planned_distance_m", "planned_duration_s", "planned_elevation_gain_m",
+			"target_avg_speed_mps", "target_power_watt", "route_id":
+			setClauses = append(setClauses, fmt.Sprintf("%s = $%d", field, argIndex))
+			args = append(args, value)
+			argIndex++
+		default:
+			return fmt.Errorf("invalid field for update: %s", field)
 		}
 	}
 
@@ -790,6 +1979,132 @@ func (s *PostgresDB) UpdatePlannedActivity(ctx context.Context, activityID strin
 	return nil
 }
 
+// GetUnmatchedPlannedActivities returns the user's planned activities in [start, end) that have no matched activity yet
+func (s *PostgresDB) GetUnmatchedPlannedActivities(ctx context.Context, userID string, start time.Time, end time.Time) ([]models.PlannedActivity, error) {
+	s.log.Debug(fmt.Sprintf("Fetching unmatched planned activities for user: %s", userID))
+
+	query := `
+		SELECT
+			id, user_id, title, description, type,
+			start_time, planned_distance_m, planned_duration_s,
+			planned_elevation_gain_m, target_avg_speed_mps, target_power_watt,
+			matched_activity_id, route_id, user_training_plan_id, plan_sequence_index,
+			created_at, updated_at
+		FROM planned_activities
+		WHERE user_id = $1 AND start_time >= $2 AND start_time < $3
+			AND matched_activity_id IS NULL
+		ORDER BY start_time ASC
+	`
+
+	rows, err := s.pool.Query(ctx, query, userID, start, end)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Database error while fetching unmatched planned activities for user: %s", userID), err)
+		return nil, fmt.Errorf("failed to get unmatched planned activities: %w", err)
+	}
+	defer rows.Close()
+
+	var plannedActivities []models.PlannedActivity
+	for rows.Next() {
+		var plannedActivity models.PlannedActivity
+		err := rows.Scan(
+			&plannedActivity.ID,
+			&plannedActivity.UserID,
+			&plannedActivity.Title,
+			&plannedActivity.Description,
+			&plannedActivity.Type,
+			&plannedActivity.StartTime,
+			&plannedActivity.PlannedDistanceM,
+			&plannedActivity.PlannedDurationS,
+			&plannedActivity.PlannedElevationGainM,
+			&plannedActivity.TargetAvgSpeedMps,
+			&plannedActivity.TargetPowerWatt,
+			&plannedActivity.MatchedActivityID,
+			&plannedActivity.RouteID,
+			&plannedActivity.UserTrainingPlanID,
+			&plannedActivity.PlanSequenceIndex,
+			&plannedActivity.CreatedAt,
+			&plannedActivity.UpdatedAt,
+		)
+		if err != nil {
+			s.log.Error(fmt.Sprintf("Error scanning planned activity row for user: %s", userID), err)
+			return nil, fmt.Errorf("failed to scan planned activity: %w", err)
+		}
+		plannedActivities = append(plannedActivities, plannedActivity)
+	}
+
+	if err = rows.Err(); err != nil {
+		s.log.Error(fmt.Sprintf("Row iteration error for user: %s", userID), err)
+		return nil, fmt.Errorf("failed to iterate planned activities: %w", err)
+	}
+
+	s.log.Debug(fmt.Sprintf("Found %d unmatched planned activities for user: %s", len(plannedActivities), userID))
+	return plannedActivities, nil
+}
+
+// SetPlannedActivityMatch links a planned activity to a completed activity, or unlinks it when activityID is nil.
+// Linking an activity that already satisfies another of the user's planned activities moves the match.
+func (s *PostgresDB) SetPlannedActivityMatch(ctx context.Context, plannedActivityID string, userID string, activityID *string) error {
+	s.log.Debug(fmt.Sprintf("Setting match for planned activity ID: %s for user: %s", plannedActivityID, userID))
+
+	beginner, ok := s.pool.(interface {
+		Begin(context.Context) (pgx.Tx, error)
+	})
+	if !ok {
+		return fmt.Errorf("database pool does not support transactions")
+	}
+
+	tx, err := beginner.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	now := time.Now()
+
+	if activityID != nil {
+		// Release the activity from any other planned activity so the unique match index holds
+		releaseQuery := `
+			UPDATE planned_activities
+			SET matched_activity_id = NULL, updated_at = $1
+			WHERE user_id = $2 AND matched_activity_id = $3 AND id <> $4
+		`
+		if _, err := tx.Exec(ctx, releaseQuery, now, userID, *activityID, plannedActivityID); err != nil {
+			s.log.Error(fmt.Sprintf("Database error while releasing activity match: %s", *activityID), err)
+			return fmt.Errorf("failed to release existing match: %w", err)
+		}
+	}
+
+	updateQuery := `
+		UPDATE planned_activities
+		SET matched_activity_id = $1, updated_at = $2
+		WHERE id = $3 AND user_id = $4
+	`
+	cmdTag, err := tx.Exec(ctx, updateQuery, activityID, now, plannedActivityID, userID)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Database error while matching planned activity ID: %s", plannedActivityID), err)
+		return fmt.Errorf("failed to set planned activity match: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		s.log.Debug(fmt.Sprintf("Planned activity not found with ID: %s for user: %s", plannedActivityID, userID))
+		return fmt.Errorf("planned activity not found")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+
+	s.log.Debug(fmt.Sprintf("Successfully set match for planned activity: %s", plannedActivityID))
+	return nil
+}
+
 // --- Other stuff ---
 
 func (s *PostgresDB) Connect(dsn string) error {
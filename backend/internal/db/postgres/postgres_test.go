@@ -407,6 +407,21 @@ func TestUpdateUser_Unit(t *testing.T) {
 			},
 			expectedError: false,
 		},
+		{
+			name:   "update training settings",
+			userID: "user-123",
+			updates: map[string]interface{}{
+				"max_hr_bpm":   int16(192),
+				"hr_zones_bpm": []int16{120, 140, 160, 175},
+				"weight_kg":    nil,
+			},
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectExec(`UPDATE users`).
+					WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			expectedError: false,
+		},
 		{
 			name:    "empty updates",
 			userID:  "user-123",
@@ -476,6 +491,66 @@ func TestUpdateUser_Unit(t *testing.T) {
 }
 
 // TestCreateActivity_Unit tests CreateActivity with mocked database
+func TestGetUserTrainingSettings_Unit(t *testing.T) {
+	columns := []string{
+		"max_hr_bpm", "resting_hr_bpm", "threshold_hr_bpm", "threshold_pace_s_per_km", "ftp_watt",
+		"weight_kg", "birth_year", "sex", "hr_zones_bpm", "pace_zones_s_per_km", "power_zones_watt",
+	}
+
+	t.Run("settings found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		maxHR, pace := int16(190), 270.0
+		sex := models.SexFemale
+		rows := pgxmock.NewRows(columns).AddRow(
+			&maxHR, nil, nil, &pace, nil, nil, nil, &sex, []int16{130, 150, 165, 178}, nil, nil,
+		)
+		mock.ExpectQuery(`SELECT max_hr_bpm, resting_hr_bpm`).
+			WithArgs("user-123").
+			WillReturnRows(rows)
+
+		settings, err := db.GetUserTrainingSettings(context.Background(), "user-123")
+		assert.NoError(t, err)
+		if assert.NotNil(t, settings) {
+			assert.Equal(t, int16(190), *settings.MaxHRBpm)
+			assert.Nil(t, settings.RestingHRBpm)
+			assert.Equal(t, 270.0, *settings.ThresholdPaceSPerKm)
+			assert.Equal(t, models.SexFemale, *settings.Sex)
+			assert.Equal(t, []int16{130, 150, 165, 178}, settings.HRZonesBpm)
+			assert.Nil(t, settings.PowerZonesWatt)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectQuery(`SELECT max_hr_bpm, resting_hr_bpm`).
+			WithArgs("nonexistent").
+			WillReturnError(pgx.ErrNoRows)
+
+		settings, err := db.GetUserTrainingSettings(context.Background(), "nonexistent")
+		assert.NoError(t, err)
+		assert.Nil(t, settings)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectQuery(`SELECT max_hr_bpm, resting_hr_bpm`).
+			WithArgs("user-123").
+			WillReturnError(fmt.Errorf("connection timeout"))
+
+		_, err := db.GetUserTrainingSettings(context.Background(), "user-123")
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestCreateActivity_Unit(t *testing.T) {
 	activityID := uuid.New()
 	clientActivityID := uuid.New()
@@ -523,7 +598,8 @@ func TestCreateActivity_Unit(t *testing.T) {
 						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
 						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
 						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
-						pgxmock.AnyArg(), pgxmock.AnyArg()).
+						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
 					WillReturnResult(pgxmock.NewResult("INSERT", 1))
 			},
 			expectedError: false,
@@ -551,7 +627,8 @@ func TestCreateActivity_Unit(t *testing.T) {
 						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
 						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
 						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
-						pgxmock.AnyArg(), pgxmock.AnyArg()).
+						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
 					WillReturnError(fmt.Errorf("foreign key constraint violation"))
 			},
 			expectedError: true,
@@ -599,17 +676,19 @@ func TestGetActivityByID_Unit(t *testing.T) {
 			setupMock: func(mock pgxmock.PgxConnIface) {
 				rows := pgxmock.NewRows([]string{
 					"id", "user_id", "client_activity_id", "title", "description", "type",
-					"start_time", "end_time", "elapsed_time", "distance_m", "elevation_gain_m",
+					"start_time", "end_time", "elapsed_time", "moving_time_s", "distance_m", "elevation_gain_m",
 					"elevation_loss_m", "max_height_m", "min_height_m",
-					"avg_speed_mps", "max_speed_mps", "avg_hr_bpm", "max_hr_bpm", "perceived_effort", "processing_ver",
-					"polyline", "bbox_min_lat", "bbox_min_lon", "bbox_max_lat", "bbox_max_lon",
+					"avg_speed_mps", "max_speed_mps", "avg_gap_mps", "avg_hr_bpm", "max_hr_bpm", "perceived_effort", "processing_ver",
+					"avg_cadence_rpm", "max_cadence_rpm", "avg_power_watt", "max_power_watt",
+					"polyline", "raw_polyline", "bbox_min_lat", "bbox_min_lon", "bbox_max_lat", "bbox_max_lon",
 					"start_lat", "start_lon", "end_lat", "end_lon", "file_url", "created_at", "updated_at",
 				}).AddRow(
 					activityID, "user-123", clientActivityID, "Test Activity", nil, models.ActivityTypeRun,
-					time.Now(), endTime, 1800, distance, nil,
+					time.Now(), endTime, 1800, nil, distance, nil,
 					nil, nil, nil,
-					nil, nil, nil, nil, int16(5), 1,
-					nil, nil, nil, nil, nil,
+					nil, nil, nil, nil, nil, int16(5), 1,
+					nil, nil, nil, nil,
+					nil, nil, nil, nil, nil, nil,
 					nil, nil, nil, nil, nil, time.Now(), time.Now(),
 				)
 
@@ -695,26 +774,29 @@ func TestGetActivitiesByUserID_Unit(t *testing.T) {
 				effort := int16(5)
 				rows := pgxmock.NewRows([]string{
 					"id", "user_id", "client_activity_id", "title", "description", "type",
-					"start_time", "end_time", "elapsed_time", "distance_m", "elevation_gain_m",
+					"start_time", "end_time", "elapsed_time", "moving_time_s", "distance_m", "elevation_gain_m",
 					"elevation_loss_m", "max_height_m", "min_height_m",
-					"avg_speed_mps", "max_speed_mps", "avg_hr_bpm", "max_hr_bpm", "perceived_effort", "processing_ver",
-					"polyline", "bbox_min_lat", "bbox_min_lon", "bbox_max_lat", "bbox_max_lon",
+					"avg_speed_mps", "max_speed_mps", "avg_gap_mps", "avg_hr_bpm", "max_hr_bpm", "perceived_effort", "processing_ver",
+					"avg_cadence_rpm", "max_cadence_rpm", "avg_power_watt", "max_power_watt",
+					"polyline", "raw_polyline", "bbox_min_lat", "bbox_min_lon", "bbox_max_lat", "bbox_max_lon",
 					"start_lat", "start_lon", "end_lat", "end_lon", "file_url", "created_at", "updated_at",
 				}).
 					AddRow(
 						activityID1, userID, clientActivityID1, "Activity 1", nil, models.ActivityTypeRun,
-						time.Now(), endTime, 1800, distance, nil,
+						time.Now(), endTime, 1800, nil, distance, nil,
 						nil, nil, nil,
-						nil, nil, nil, nil, &effort, 1,
-						nil, nil, nil, nil, nil,
+						nil, nil, nil, nil, nil, &effort, 1,
+						nil, nil, nil, nil,
+						nil, nil, nil, nil, nil, nil,
 						nil, nil, nil, nil, nil, time.Now(), time.Now(),
 					).
 					AddRow(
 						activityID2, userID, clientActivityID2, "Activity 2", nil, models.ActivityTypeRun,
-						time.Now(), endTime, 1800, distance, nil,
+						time.Now(), endTime, 1800, nil, distance, nil,
 						nil, nil, nil,
-						nil, nil, nil, nil, &effort, 1,
-						nil, nil, nil, nil, nil,
+						nil, nil, nil, nil, nil, &effort, 1,
+						nil, nil, nil, nil,
+						nil, nil, nil, nil, nil, nil,
 						nil, nil, nil, nil, nil, time.Now(), time.Now(),
 					)
 
@@ -731,10 +813,11 @@ func TestGetActivitiesByUserID_Unit(t *testing.T) {
 			setupMock: func(mock pgxmock.PgxConnIface) {
 				rows := pgxmock.NewRows([]string{
 					"id", "user_id", "client_activity_id", "title", "description", "type",
-					"start_time", "end_time", "elapsed_time", "distance_m", "elevation_gain_m",
+					"start_time", "end_time", "elapsed_time", "moving_time_s", "distance_m", "elevation_gain_m",
 					"elevation_loss_m", "max_height_m", "min_height_m",
-					"avg_speed_mps", "max_speed_mps", "avg_hr_bpm", "max_hr_bpm", "perceived_effort", "processing_ver",
-					"polyline", "bbox_min_lat", "bbox_min_lon", "bbox_max_lat", "bbox_max_lon",
+					"avg_speed_mps", "max_speed_mps", "avg_gap_mps", "avg_hr_bpm", "max_hr_bpm", "perceived_effort", "processing_ver",
+					"avg_cadence_rpm", "max_cadence_rpm", "avg_power_watt", "max_power_watt",
+					"polyline", "raw_polyline", "bbox_min_lat", "bbox_min_lon", "bbox_max_lat", "bbox_max_lon",
 					"start_lat", "start_lon", "end_lat", "end_lon", "file_url", "created_at", "updated_at",
 				})
 
@@ -765,7 +848,7 @@ func TestGetActivitiesByUserID_Unit(t *testing.T) {
 
 			tt.setupMock(mock)
 
-			activities, err := db.GetActivitiesByUserID(context.Background(), tt.userID)
+			activities, err := db.GetActivitiesByUserID(context.Background(), tt.userID, models.ActivityListQuery{})
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -779,6 +862,37 @@ func TestGetActivitiesByUserID_Unit(t *testing.T) {
 	}
 }
 
+// TestGetActivitiesByUserID_ListQuery_Unit tests filter, sort and cursor query building
+func TestGetActivitiesByUserID_ListQuery_Unit(t *testing.T) {
+	runType := models.ActivityTypeRun
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	minDistance := 5000.0
+	cursorID := uuid.New()
+
+	db, mock := setupMockDB(t)
+	defer mock.Close(context.Background())
+
+	rows := pgxmock.NewRows([]string{"id"})
+	mock.ExpectQuery(`WHERE user_id = \$1 AND type = \$2 AND start_time >= \$3 AND distance_m >= \$4 AND title ILIKE \$5 ESCAPE '\\' AND \(distance_m, id\) > \(\$6, \$7\) ORDER BY distance_m ASC, id ASC LIMIT \$8`).
+		WithArgs("user-123", runType, from, minDistance, `%100\%%`, 8000.0, cursorID, 21).
+		WillReturnRows(rows)
+
+	activities, err := db.GetActivitiesByUserID(context.Background(), "user-123", models.ActivityListQuery{
+		ActivityType: &runType,
+		StartFrom:    &from,
+		MinDistanceM: &minDistance,
+		TitleSearch:  "100%",
+		SortBy:       models.ActivitySortByDistance,
+		Ascending:    true,
+		Limit:        21,
+		After:        &models.ActivityCursor{DistanceM: 8000, ElapsedTime: 1800, ID: cursorID},
+	})
+
+	assert.NoError(t, err)
+	assert.Empty(t, activities)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 // TestCheckIdempotency_Unit tests CheckIdempotency with mocked database
 func TestCheckIdempotency_Unit(t *testing.T) {
 	tests := []struct {
@@ -865,10 +979,14 @@ func TestGetActivityStreams_Unit(t *testing.T) {
 				rows := pgxmock.NewRows([]string{
 					"activity_id", "lod", "index_by", "num_points", "original_num_points",
 					"time_s_bytes", "distance_m_bytes", "speed_mps_bytes", "elevation_m_bytes",
+					"heart_rate_bpm_bytes", "cadence_rpm_bytes", "power_watt_bytes", "temperature_c_bytes",
+					"lat_bytes", "lon_bytes", "grade_pct_bytes",
 					"codec", "created_at", "updated_at",
 				}).AddRow(
 					activityID, models.StreamLODMedium, models.StreamIndexByDistance, 100, 1000,
 					[]byte{1, 2, 3}, []byte{10, 20, 30}, []byte{5, 6, 7}, []byte{100, 101, 102},
+					nil, nil, nil, nil,
+					[]byte{7, 8}, []byte{9, 10}, nil,
 					codecJSON, time.Now(), time.Now(),
 				)
 
@@ -887,6 +1005,8 @@ func TestGetActivityStreams_Unit(t *testing.T) {
 				rows := pgxmock.NewRows([]string{
 					"activity_id", "lod", "index_by", "num_points", "original_num_points",
 					"time_s_bytes", "distance_m_bytes", "speed_mps_bytes", "elevation_m_bytes",
+					"heart_rate_bpm_bytes", "cadence_rpm_bytes", "power_watt_bytes", "temperature_c_bytes",
+					"lat_bytes", "lon_bytes", "grade_pct_bytes",
 					"codec", "created_at", "updated_at",
 				})
 
@@ -918,10 +1038,14 @@ func TestGetActivityStreams_Unit(t *testing.T) {
 				rows := pgxmock.NewRows([]string{
 					"activity_id", "lod", "index_by", "num_points", "original_num_points",
 					"time_s_bytes", "distance_m_bytes", "speed_mps_bytes", "elevation_m_bytes",
+					"heart_rate_bpm_bytes", "cadence_rpm_bytes", "power_watt_bytes", "temperature_c_bytes",
+					"lat_bytes", "lon_bytes", "grade_pct_bytes",
 					"codec", "created_at", "updated_at",
 				}).AddRow(
 					activityID, models.StreamLODMedium, models.StreamIndexByDistance, 100, 1000,
 					[]byte{1, 2, 3}, []byte{10, 20, 30}, []byte{5, 6, 7}, []byte{100, 101, 102},
+					nil, nil, nil, nil,
+					nil, nil, nil,
 					invalidJSON, time.Now(), time.Now(),
 				)
 
@@ -951,6 +1075,8 @@ func TestGetActivityStreams_Unit(t *testing.T) {
 
 				if tt.expectedCount > 0 {
 					assert.NotNil(t, streams[0].Codec)
+					assert.Equal(t, []byte{7, 8}, streams[0].LatBytes)
+					assert.Equal(t, []byte{9, 10}, streams[0].LonBytes)
 				}
 			}
 
@@ -994,7 +1120,8 @@ func TestCreateActivityStreams_Unit(t *testing.T) {
 				mock.ExpectExec(`INSERT INTO activity_streams`).
 					WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
 						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
-						pgxmock.AnyArg(), pgxmock.AnyArg()).
+						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
 					WillReturnResult(pgxmock.NewResult("INSERT", 1))
 			},
 			expectedError: false,
@@ -1031,7 +1158,8 @@ func TestCreateActivityStreams_Unit(t *testing.T) {
 				mock.ExpectExec(`INSERT INTO activity_streams`).
 					WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
 						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
-						pgxmock.AnyArg(), pgxmock.AnyArg()).
+						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+						pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
 					WillReturnError(fmt.Errorf("constraint violation"))
 			},
 			expectedError: true,
@@ -1097,3 +1225,995 @@ func TestCreateActivityStreams_MarshalError_Unit(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+// TestSetPlannedActivityMatch_Unit tests linking and unlinking planned activities
+func TestSetPlannedActivityMatch_Unit(t *testing.T) {
+	plannedID := uuid.New().String()
+	activityID := uuid.New().String()
+
+	tests := []struct {
+		name          string
+		activityID    *string
+		setupMock     func(mock pgxmock.PgxConnIface)
+		expectedError string
+	}{
+		{
+			name:       "link releases previous match",
+			activityID: &activityID,
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE planned_activities\s+SET matched_activity_id = NULL`).
+					WithArgs(pgxmock.AnyArg(), "user-123", activityID, plannedID).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec(`UPDATE planned_activities\s+SET matched_activity_id = \$1`).
+					WithArgs(&activityID, pgxmock.AnyArg(), plannedID, "user-123").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:       "unlink",
+			activityID: nil,
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE planned_activities\s+SET matched_activity_id = \$1`).
+					WithArgs((*string)(nil), pgxmock.AnyArg(), plannedID, "user-123").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:       "planned activity not found",
+			activityID: nil,
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE planned_activities\s+SET matched_activity_id = \$1`).
+					WithArgs((*string)(nil), pgxmock.AnyArg(), plannedID, "user-123").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+				mock.ExpectRollback()
+			},
+			expectedError: "planned activity not found",
+		},
+		{
+			name:       "database error",
+			activityID: &activityID,
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE planned_activities\s+SET matched_activity_id = NULL`).
+					WithArgs(pgxmock.AnyArg(), "user-123", activityID, plannedID).
+					WillReturnError(fmt.Errorf("connection lost"))
+				mock.ExpectRollback()
+			},
+			expectedError: "failed to release existing match",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupMockDB(t)
+			defer mock.Close(context.Background())
+
+			tt.setupMock(mock)
+
+			err := db.SetPlannedActivityMatch(context.Background(), plannedID, "user-123", tt.activityID)
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// TestFindActivityIDByStartTime_Unit tests duplicate lookup by start time
+func TestFindActivityIDByStartTime_Unit(t *testing.T) {
+	startTime := time.Date(2024, 5, 4, 7, 30, 0, 0, time.UTC)
+	activityID := uuid.New().String()
+
+	tests := []struct {
+		name          string
+		setupMock     func(mock pgxmock.PgxConnIface)
+		expectedID    *string
+		expectedError string
+	}{
+		{
+			name: "activity found",
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectQuery(`SELECT id\s+FROM activities`).
+					WithArgs("user-123", startTime.Add(-2*time.Second), startTime.Add(2*time.Second)).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(activityID))
+			},
+			expectedID: &activityID,
+		},
+		{
+			name: "no activity",
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectQuery(`SELECT id\s+FROM activities`).
+					WithArgs("user-123", startTime.Add(-2*time.Second), startTime.Add(2*time.Second)).
+					WillReturnError(pgx.ErrNoRows)
+			},
+		},
+		{
+			name: "database error",
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectQuery(`SELECT id\s+FROM activities`).
+					WithArgs("user-123", startTime.Add(-2*time.Second), startTime.Add(2*time.Second)).
+					WillReturnError(fmt.Errorf("connection lost"))
+			},
+			expectedError: "failed to find activity by start time",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupMockDB(t)
+			defer mock.Close(context.Background())
+
+			tt.setupMock(mock)
+
+			id, err := db.FindActivityIDByStartTime(context.Background(), "user-123", startTime, 2*time.Second)
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedID, id)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// TestUpdateActivity_Unit tests UpdateActivity with mocked database
+func TestUpdateActivity_Unit(t *testing.T) {
+	activityID := uuid.New().String()
+
+	tests := []struct {
+		name          string
+		updates       map[string]interface{}
+		setupMock     func(mock pgxmock.PgxConnIface)
+		expectedError string
+	}{
+		{
+			name:    "successful update",
+			updates: map[string]interface{}{"title": "Evening Run"},
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE activities\s+SET title = \$1, updated_at = \$2\s+WHERE id = \$3 AND user_id = \$4`).
+					WithArgs("Evening Run", pgxmock.AnyArg(), activityID, "user-123").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "type change drops type-dependent metrics and refills personal records",
+			updates: map[string]interface{}{"type": "road_biking"},
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT type FROM activities WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+					WithArgs(activityID, "user-123").
+					WillReturnRows(pgxmock.NewRows([]string{"type"}).AddRow("running"))
+				mock.ExpectExec(`UPDATE activities\s+SET type = \$1, avg_gap_mps = NULL, updated_at = \$2`).
+					WithArgs("road_biking", pgxmock.AnyArg(), activityID, "user-123").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec(`DELETE FROM activity_best_efforts WHERE activity_id = \$1`).
+					WithArgs(activityID).
+					WillReturnResult(pgxmock.NewResult("DELETE", 6))
+				mock.ExpectExec(`DELETE FROM personal_records WHERE activity_id = \$1`).
+					WithArgs(activityID).
+					WillReturnResult(pgxmock.NewResult("DELETE", 2))
+				mock.ExpectExec(`INSERT INTO personal_records`).
+					WithArgs("user-123", pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("INSERT", 2))
+				mock.ExpectExec(`DELETE FROM activity_training_stress WHERE activity_id = \$1`).
+					WithArgs(activityID).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectExec(`DELETE FROM activity_zone_times WHERE activity_id = \$1`).
+					WithArgs(activityID).
+					WillReturnResult(pgxmock.NewResult("DELETE", 10))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "type change failure rolls back the update",
+			updates: map[string]interface{}{"type": "road_biking"},
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT type FROM activities WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+					WithArgs(activityID, "user-123").
+					WillReturnRows(pgxmock.NewRows([]string{"type"}).AddRow("running"))
+				mock.ExpectExec(`UPDATE activities\s+SET type = \$1`).
+					WithArgs("road_biking", pgxmock.AnyArg(), activityID, "user-123").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec(`DELETE FROM activity_best_efforts`).
+					WithArgs(activityID).
+					WillReturnResult(pgxmock.NewResult("DELETE", 6))
+				mock.ExpectExec(`DELETE FROM personal_records`).
+					WithArgs(activityID).
+					WillReturnResult(pgxmock.NewResult("DELETE", 2))
+				mock.ExpectExec(`INSERT INTO personal_records`).
+					WithArgs("user-123", pgxmock.AnyArg()).
+					WillReturnError(fmt.Errorf("connection lost"))
+				mock.ExpectRollback()
+			},
+			expectedError: "failed to refill personal records",
+		},
+		{
+			name:    "same type keeps type-dependent metrics",
+			updates: map[string]interface{}{"type": "running"},
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT type FROM activities WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+					WithArgs(activityID, "user-123").
+					WillReturnRows(pgxmock.NewRows([]string{"type"}).AddRow("running"))
+				mock.ExpectExec(`UPDATE activities\s+SET type = \$1, updated_at = \$2\s+WHERE`).
+					WithArgs("running", pgxmock.AnyArg(), activityID, "user-123").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "type change of unknown activity",
+			updates: map[string]interface{}{"type": "road_biking"},
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT type FROM activities`).
+					WithArgs(activityID, "user-123").
+					WillReturnError(pgx.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			expectedError: "activity not found",
+		},
+		{
+			name:    "rescored grade-adjusted pace",
+			updates: map[string]interface{}{"avg_gap_mps": 3.2},
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE activities\s+SET avg_gap_mps = \$1, updated_at = \$2`).
+					WithArgs(3.2, pgxmock.AnyArg(), activityID, "user-123").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:          "no updates",
+			updates:       map[string]interface{}{},
+			setupMock:     func(mock pgxmock.PgxConnIface) {},
+			expectedError: "no updates provided",
+		},
+		{
+			name:          "invalid field",
+			updates:       map[string]interface{}{"distance_m": 100.0},
+			setupMock:     func(mock pgxmock.PgxConnIface) {},
+			expectedError: "invalid field for update",
+		},
+		{
+			name:    "activity not found",
+			updates: map[string]interface{}{"perceived_effort": int16(4)},
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE activities`).
+					WithArgs(int16(4), pgxmock.AnyArg(), activityID, "user-123").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+				mock.ExpectRollback()
+			},
+			expectedError: "activity not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupMockDB(t)
+			defer mock.Close(context.Background())
+
+			tt.setupMock(mock)
+
+			err := db.UpdateActivity(context.Background(), activityID, "user-123", tt.updates)
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// TestDeleteActivity_Unit tests DeleteActivity with mocked database
+func TestDeleteActivity_Unit(t *testing.T) {
+	activityID := uuid.New().String()
+
+	tests := []struct {
+		name          string
+		setupMock     func(mock pgxmock.PgxConnIface)
+		expectedError string
+	}{
+		{
+			name: "deletes and unlinks planned activities",
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE planned_activities\s+SET matched_activity_id = NULL`).
+					WithArgs(pgxmock.AnyArg(), "user-123", activityID).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec(`DELETE FROM activities`).
+					WithArgs(activityID, "user-123").
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectExec(`INSERT INTO personal_records`).
+					WithArgs("user-123", pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "activity not found",
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE planned_activities`).
+					WithArgs(pgxmock.AnyArg(), "user-123", activityID).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+				mock.ExpectExec(`DELETE FROM activities`).
+					WithArgs(activityID, "user-123").
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				mock.ExpectRollback()
+			},
+			expectedError: "activity not found",
+		},
+		{
+			name: "database error",
+			setupMock: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE planned_activities`).
+					WithArgs(pgxmock.AnyArg(), "user-123", activityID).
+					WillReturnError(fmt.Errorf("connection lost"))
+				mock.ExpectRollback()
+			},
+			expectedError: "failed to unlink planned activities",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupMockDB(t)
+			defer mock.Close(context.Background())
+
+			tt.setupMock(mock)
+
+			err := db.DeleteActivity(context.Background(), activityID, "user-123")
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// TestActivitySplits_Unit tests CreateActivitySplits and GetActivitySplits with mocked database
+func TestActivitySplits_Unit(t *testing.T) {
+	activityID := uuid.New()
+	hr := int16(150)
+	now := time.Now()
+	splits := []models.ActivitySplit{
+		{ActivityID: activityID, Unit: models.SplitUnitKm, SplitIndex: 1, DistanceM: 1000, ElapsedS: 300, MovingS: 290, ElevationGainM: 5, AvgHRBpm: &hr, CreatedAt: now},
+		{ActivityID: activityID, Unit: models.SplitUnitKm, SplitIndex: 2, DistanceM: 420, ElapsedS: 130, MovingS: 130, ElevationLossM: 3, CreatedAt: now},
+	}
+
+	t.Run("create", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		for _, split := range splits {
+			mock.ExpectExec(`INSERT INTO activity_splits`).
+				WithArgs(activityID, models.SplitUnitKm, split.SplitIndex, split.DistanceM, split.ElapsedS, split.MovingS,
+					split.ElevationGainM, split.ElevationLossM, split.AvgHRBpm, now).
+				WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		}
+
+		require.NoError(t, db.CreateActivitySplits(context.Background(), splits))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create with no splits", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		require.NoError(t, db.CreateActivitySplits(context.Background(), nil))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		rows := pgxmock.NewRows([]string{
+			"activity_id", "unit", "split_index", "distance_m", "elapsed_s", "moving_s",
+			"elevation_gain_m", "elevation_loss_m", "avg_hr_bpm", "created_at",
+		})
+		for _, split := range splits {
+			rows.AddRow(split.ActivityID, split.Unit, split.SplitIndex, split.DistanceM, split.ElapsedS, split.MovingS,
+				split.ElevationGainM, split.ElevationLossM, split.AvgHRBpm, split.CreatedAt)
+		}
+		mock.ExpectQuery(`SELECT\s+activity_id, unit, split_index`).
+			WithArgs(activityID.String(), models.SplitUnitKm).
+			WillReturnRows(rows)
+
+		result, err := db.GetActivitySplits(context.Background(), activityID.String(), models.SplitUnitKm)
+		require.NoError(t, err)
+		assert.Equal(t, splits, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get database error", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectQuery(`SELECT\s+activity_id, unit, split_index`).
+			WithArgs(activityID.String(), models.SplitUnitMile).
+			WillReturnError(fmt.Errorf("connection lost"))
+
+		_, err := db.GetActivitySplits(context.Background(), activityID.String(), models.SplitUnitMile)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to get activity splits")
+	})
+}
+
+// TestActivityLaps_Unit tests CreateActivityLaps and GetActivityLaps with mocked database
+func TestActivityLaps_Unit(t *testing.T) {
+	activityID := uuid.New()
+	speed := 3.2
+	hr, maxHR := int16(155), int16(171)
+	start := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+	now := time.Now()
+	laps := []models.ActivityLap{
+		{ActivityID: activityID, LapIndex: 1, StartIndex: 0, StartTime: start, ElapsedS: 312.5, DistanceM: 1000, AvgSpeedMps: &speed, AvgHRBpm: &hr, MaxHRBpm: &maxHR, CreatedAt: now},
+		{ActivityID: activityID, LapIndex: 2, StartIndex: 313, StartTime: start.Add(313 * time.Second), ElapsedS: 90, DistanceM: 150, CreatedAt: now},
+	}
+
+	t.Run("create", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		for _, lap := range laps {
+			mock.ExpectExec(`INSERT INTO activity_laps`).
+				WithArgs(activityID, lap.LapIndex, lap.StartIndex, lap.StartTime, lap.ElapsedS, lap.DistanceM,
+					lap.AvgSpeedMps, lap.AvgHRBpm, lap.MaxHRBpm, now).
+				WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		}
+
+		require.NoError(t, db.CreateActivityLaps(context.Background(), laps))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create database error", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectExec(`INSERT INTO activity_laps`).
+			WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+				pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+			WillReturnError(fmt.Errorf("foreign key constraint violation"))
+
+		err := db.CreateActivityLaps(context.Background(), laps)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to create activity lap")
+	})
+
+	t.Run("get", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		rows := pgxmock.NewRows([]string{
+			"activity_id", "lap_index", "start_index", "start_time", "elapsed_s", "distance_m",
+			"avg_speed_mps", "avg_hr_bpm", "max_hr_bpm", "created_at",
+		})
+		for _, lap := range laps {
+			rows.AddRow(lap.ActivityID, lap.LapIndex, lap.StartIndex, lap.StartTime, lap.ElapsedS, lap.DistanceM,
+				lap.AvgSpeedMps, lap.AvgHRBpm, lap.MaxHRBpm, lap.CreatedAt)
+		}
+		mock.ExpectQuery(`SELECT\s+activity_id, lap_index`).
+			WithArgs(activityID.String()).
+			WillReturnRows(rows)
+
+		result, err := db.GetActivityLaps(context.Background(), activityID.String())
+		require.NoError(t, err)
+		assert.Equal(t, laps, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// TestBestEfforts_Unit tests SaveActivityBestEfforts and GetPersonalRecords with mocked database
+func TestBestEfforts_Unit(t *testing.T) {
+	activity := &models.Activity{
+		ID:           uuid.New(),
+		UserID:       "user-123",
+		ActivityType: models.ActivityTypeRun,
+		StartTime:    time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC),
+	}
+	now := time.Now()
+	efforts := []models.BestEffort{
+		{ActivityID: activity.ID, Effort: "1k", Kind: models.BestEffortKindDistance, DistanceM: 1000, ElapsedS: 240, StartIndex: 12, CreatedAt: now},
+		{ActivityID: activity.ID, Effort: "5k", Kind: models.BestEffortKindDistance, DistanceM: 5000, ElapsedS: 1290, StartIndex: 0, CreatedAt: now},
+	}
+
+	expectEffort := func(mock pgxmock.PgxConnIface, effort models.BestEffort) {
+		mock.ExpectExec(`INSERT INTO activity_best_efforts`).
+			WithArgs(activity.ID, effort.Effort, effort.Kind, effort.DistanceM, effort.ElapsedS, effort.StartIndex, now).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	}
+	expectRecord := func(mock pgxmock.PgxConnIface, effort models.BestEffort) *pgxmock.ExpectedQuery {
+		return mock.ExpectQuery(`INSERT INTO personal_records[\s\S]+ON CONFLICT[\s\S]+RETURNING effort`).
+			WithArgs("user-123", models.ActivityTypeRun, effort.Effort, effort.Kind, activity.ID,
+				effort.DistanceM, effort.ElapsedS, activity.StartTime, pgxmock.AnyArg())
+	}
+
+	t.Run("save returns new records", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectBegin()
+		expectEffort(mock, efforts[0])
+		// The stored 1k record is faster, so nothing comes back
+		expectRecord(mock, efforts[0]).WillReturnError(pgx.ErrNoRows)
+		expectEffort(mock, efforts[1])
+		expectRecord(mock, efforts[1]).WillReturnRows(pgxmock.NewRows([]string{"effort"}).AddRow("5k"))
+		mock.ExpectCommit()
+
+		newRecords, err := db.SaveActivityBestEfforts(context.Background(), activity, efforts)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"5k"}, newRecords)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("save with no efforts", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		newRecords, err := db.SaveActivityBestEfforts(context.Background(), activity, nil)
+		require.NoError(t, err)
+		assert.Nil(t, newRecords)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("save database error rolls back", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectBegin()
+		expectEffort(mock, efforts[0])
+		expectRecord(mock, efforts[0]).WillReturnError(fmt.Errorf("connection lost"))
+		mock.ExpectRollback()
+
+		_, err := db.SaveActivityBestEfforts(context.Background(), activity, efforts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to update personal record")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		records := []models.PersonalRecord{
+			{UserID: "user-123", ActivityType: models.ActivityTypeRun, Effort: "5k", Kind: models.BestEffortKindDistance,
+				ActivityID: activity.ID, DistanceM: 5000, ElapsedS: 1290, AchievedAt: activity.StartTime, UpdatedAt: now},
+		}
+		rows := pgxmock.NewRows([]string{
+			"user_id", "activity_type", "effort", "kind", "activity_id", "distance_m", "elapsed_s", "achieved_at", "updated_at",
+		})
+		for _, record := range records {
+			rows.AddRow(record.UserID, record.ActivityType, record.Effort, record.Kind, record.ActivityID,
+				record.DistanceM, record.ElapsedS, record.AchievedAt, record.UpdatedAt)
+		}
+		activityType := models.ActivityTypeRun
+		mock.ExpectQuery(`SELECT\s+user_id, activity_type, effort[\s\S]+AND activity_type = \$2`).
+			WithArgs("user-123", models.ActivityTypeRun).
+			WillReturnRows(rows)
+
+		result, err := db.GetPersonalRecords(context.Background(), "user-123", &activityType)
+		require.NoError(t, err)
+		assert.Equal(t, records, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get database error", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectQuery(`SELECT\s+user_id, activity_type, effort`).
+			WithArgs("user-123").
+			WillReturnError(fmt.Errorf("connection lost"))
+
+		_, err := db.GetPersonalRecords(context.Background(), "user-123", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to get personal records")
+	})
+}
+
+// TestTrainingLoad_Unit tests the training stress and training load queries with mocked database
+func TestTrainingLoad_Unit(t *testing.T) {
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	t.Run("save training stress", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		stress := &models.ActivityTrainingStress{
+			ActivityID: uuid.New(), UserID: "user-123", Day: day, TSS: 72.5, IntensityFactor: 0.85,
+			Method: models.TrainingStressMethodHR, CreatedAt: now,
+		}
+		mock.ExpectExec(`INSERT INTO activity_training_stress[\s\S]+ON CONFLICT \(activity_id\) DO UPDATE`).
+			WithArgs(stress.ActivityID, "user-123", day, 72.5, 0.85, models.TrainingStressMethodHR, now).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+		require.NoError(t, db.SaveActivityTrainingStress(context.Background(), stress))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get daily training stress", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectQuery(`SELECT day, SUM\(tss\)\s+FROM activity_training_stress`).
+			WithArgs("user-123", day).
+			WillReturnRows(pgxmock.NewRows([]string{"day", "sum"}).
+				AddRow(day, 120.0).
+				AddRow(day.AddDate(0, 0, 2), 45.0))
+
+		result, err := db.GetDailyTrainingStress(context.Background(), "user-123", day)
+		require.NoError(t, err)
+		assert.Equal(t, []models.DailyTrainingStress{{Day: day, TSS: 120}, {Day: day.AddDate(0, 0, 2), TSS: 45}}, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	columns := []string{"user_id", "day", "tss", "ctl", "atl", "tsb"}
+	load := models.TrainingLoadDay{UserID: "user-123", Day: day, TSS: 100, CTL: 2.38, ATL: 14.29, TSB: 0}
+
+	t.Run("get training load", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectQuery(`SELECT user_id, day, tss, ctl, atl, tsb\s+FROM training_load\s+WHERE user_id = \$1 AND day >= \$2 AND day <= \$3`).
+			WithArgs("user-123", day, day.AddDate(0, 0, 6)).
+			WillReturnRows(pgxmock.NewRows(columns).AddRow(load.UserID, load.Day, load.TSS, load.CTL, load.ATL, load.TSB))
+
+		result, err := db.GetTrainingLoad(context.Background(), "user-123", day, day.AddDate(0, 0, 6))
+		require.NoError(t, err)
+		assert.Equal(t, []models.TrainingLoadDay{load}, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get training load before", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectQuery(`FROM training_load\s+WHERE user_id = \$1 AND day < \$2\s+ORDER BY day DESC\s+LIMIT 1`).
+			WithArgs("user-123", day.AddDate(0, 0, 3)).
+			WillReturnRows(pgxmock.NewRows(columns).AddRow(load.UserID, load.Day, load.TSS, load.CTL, load.ATL, load.TSB))
+		mock.ExpectQuery(`FROM training_load\s+WHERE user_id = \$1 AND day < \$2`).
+			WithArgs("user-123", day).
+			WillReturnError(pgx.ErrNoRows)
+
+		result, err := db.GetTrainingLoadBefore(context.Background(), "user-123", day.AddDate(0, 0, 3))
+		require.NoError(t, err)
+		assert.Equal(t, &load, result)
+
+		result, err = db.GetTrainingLoadBefore(context.Background(), "user-123", day)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("replace training load", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		next := models.TrainingLoadDay{UserID: "user-123", Day: day.AddDate(0, 0, 1), CTL: 2.32, ATL: 12.24, TSB: -11.91}
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM training_load WHERE user_id = \$1 AND day >= \$2`).
+			WithArgs("user-123", day).
+			WillReturnResult(pgxmock.NewResult("DELETE", 3))
+		mock.ExpectExec(`INSERT INTO training_load[\s\S]+unnest`).
+			WithArgs("user-123",
+				[]time.Time{load.Day, next.Day},
+				[]float64{load.TSS, next.TSS},
+				[]float64{load.CTL, next.CTL},
+				[]float64{load.ATL, next.ATL},
+				[]float64{load.TSB, next.TSB}).
+			WillReturnResult(pgxmock.NewResult("INSERT", 2))
+		mock.ExpectCommit()
+
+		require.NoError(t, db.ReplaceTrainingLoad(context.Background(), "user-123", day, []models.TrainingLoadDay{load, next}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("replace with no days only clears", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM training_load`).
+			WithArgs("user-123", day).
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, db.ReplaceTrainingLoad(context.Background(), "user-123", day, nil))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestActivityZoneTimes_Unit(t *testing.T) {
+	activityID := uuid.New()
+	low, high := 140.0, 160.0
+	now := time.Now()
+	zones := []models.ActivityZoneTime{
+		{ActivityID: activityID, Kind: models.ZoneKindHR, Zone: 1, MaxValue: &low, Seconds: 600, CreatedAt: now},
+		{ActivityID: activityID, Kind: models.ZoneKindHR, Zone: 2, MinValue: &low, MaxValue: &high, Seconds: 1500, CreatedAt: now},
+		{ActivityID: activityID, Kind: models.ZoneKindHR, Zone: 3, MinValue: &high, Seconds: 0, CreatedAt: now},
+	}
+
+	t.Run("replace", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM activity_zone_times`).
+			WithArgs(activityID.String()).
+			WillReturnResult(pgxmock.NewResult("DELETE", 5))
+		for _, zone := range zones {
+			mock.ExpectExec(`INSERT INTO activity_zone_times`).
+				WithArgs(activityID.String(), zone.Kind, zone.Zone, zone.MinValue, zone.MaxValue, zone.Seconds, now).
+				WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		}
+		mock.ExpectCommit()
+
+		require.NoError(t, db.ReplaceActivityZoneTimes(context.Background(), activityID.String(), zones))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("replace rolls back on error", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM activity_zone_times`).
+			WithArgs(activityID.String()).
+			WillReturnResult(pgxmock.NewResult("DELETE", 0))
+		mock.ExpectExec(`INSERT INTO activity_zone_times`).
+			WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+			WillReturnError(fmt.Errorf("foreign key constraint violation"))
+		mock.ExpectRollback()
+
+		err := db.ReplaceActivityZoneTimes(context.Background(), activityID.String(), zones)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to create zone time")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		rows := pgxmock.NewRows([]string{"activity_id", "kind", "zone", "min_value", "max_value", "seconds", "created_at"})
+		for _, zone := range zones {
+			rows.AddRow(zone.ActivityID, zone.Kind, zone.Zone, zone.MinValue, zone.MaxValue, zone.Seconds, zone.CreatedAt)
+		}
+		mock.ExpectQuery(`SELECT activity_id, kind, zone`).
+			WithArgs([]string{activityID.String()}).
+			WillReturnRows(rows)
+
+		result, err := db.GetActivityZoneTimes(context.Background(), []string{activityID.String()})
+		require.NoError(t, err)
+		assert.Equal(t, zones, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get without activities", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		result, err := db.GetActivityZoneTimes(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Empty(t, result)
+		assert.NotNil(t, result)
+	})
+}
+
+func TestGetActivityStats_Unit(t *testing.T) {
+	from := time.Date(2024, 5, 31, 22, 0, 0, 0, time.UTC) // June 1st in Europe/Berlin
+	to := time.Date(2024, 7, 31, 22, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		june := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+		rows := pgxmock.NewRows([]string{"period_start", "type", "count", "distance_m", "moving_time_s", "elevation_gain_m", "tss"}).
+			AddRow(june, models.ActivityTypeRun, 12, 120500.0, 39000.0, 850.0, 610.5).
+			AddRow(june, models.ActivityTypeRoadBike, 2, 90000.0, 10800.0, 1200.0, 180.0)
+		mock.ExpectQuery(`SELECT\s+date_trunc\(\$2::text, a.start_time AT TIME ZONE \$3\)::date AS period_start`).
+			WithArgs("user-123", "month", "Europe/Berlin", from, to).
+			WillReturnRows(rows)
+
+		stats, err := db.GetActivityStats(context.Background(), "user-123", models.StatsPeriodMonth, "Europe/Berlin", from, to)
+		require.NoError(t, err)
+		require.Len(t, stats, 2)
+		assert.Equal(t, models.ActivityStats{
+			PeriodStart:    june,
+			ActivityType:   models.ActivityTypeRun,
+			Count:          12,
+			DistanceM:      120500,
+			MovingTimeS:    39000,
+			ElevationGainM: 850,
+			TSS:            610.5,
+		}, stats[0])
+		assert.Equal(t, models.ActivityTypeRoadBike, stats[1].ActivityType)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no activities", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectQuery(`SELECT\s+date_trunc`).
+			WithArgs("user-123", "week", "UTC", from, to).
+			WillReturnRows(pgxmock.NewRows([]string{"period_start", "type", "count", "distance_m", "moving_time_s", "elevation_gain_m", "tss"}))
+
+		stats, err := db.GetActivityStats(context.Background(), "user-123", models.StatsPeriodWeek, "UTC", from, to)
+		require.NoError(t, err)
+		assert.NotNil(t, stats)
+		assert.Empty(t, stats)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectQuery(`SELECT\s+date_trunc`).
+			WithArgs("user-123", "year", "UTC", from, to).
+			WillReturnError(fmt.Errorf("invalid time zone"))
+
+		_, err := db.GetActivityStats(context.Background(), "user-123", models.StatsPeriodYear, "UTC", from, to)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to get activity stats")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// TestRoutes_Unit tests CreateRoute, GetRoutesByUserID, GetRouteByID and DeleteRoute with mocked database
+func TestRoutes_Unit(t *testing.T) {
+	routeID := uuid.New()
+	gain, loss := 42.5, 40.0
+	now := time.Now()
+	route := models.Route{
+		ID:                     routeID,
+		UserID:                 "user-123",
+		Name:                   "Park loop",
+		Type:                   models.ActivityTypeRun,
+		Waypoints:              []models.RouteWaypoint{{Lat: 37.7694, Lon: -122.4862}, {Lat: 37.7710, Lon: -122.4545}},
+		Polyline:               "encoded",
+		DistanceM:              5230.5,
+		ElevationGainM:         &gain,
+		ElevationLossM:         &loss,
+		ProfileDistanceMBytes:  []byte{1, 2},
+		ProfileElevationMBytes: []byte{3, 4},
+		CreatedAt:              now,
+		UpdatedAt:              now,
+	}
+	columns := []string{
+		"id", "user_id", "name", "description", "type", "waypoints", "polyline",
+		"distance_m", "elevation_gain_m", "elevation_loss_m",
+		"profile_distance_m_bytes", "profile_elevation_m_bytes", "created_at", "updated_at",
+	}
+	routeRow := func(rows *pgxmock.Rows) *pgxmock.Rows {
+		return rows.AddRow(route.ID, route.UserID, route.Name, route.Description, route.Type,
+			[]byte(`[{"lat":37.7694,"lon":-122.4862},{"lat":37.771,"lon":-122.4545}]`), route.Polyline,
+			route.DistanceM, route.ElevationGainM, route.ElevationLossM,
+			route.ProfileDistanceMBytes, route.ProfileElevationMBytes, route.CreatedAt, route.UpdatedAt)
+	}
+
+	t.Run("create", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectQuery(`INSERT INTO routes`).
+			WithArgs("user-123", "Park loop", (*string)(nil), models.ActivityTypeRun,
+				[]byte(`[{"lat":37.7694,"lon":-122.4862},{"lat":37.771,"lon":-122.4545}]`), "encoded",
+				5230.5, &gain, &loss, []byte{1, 2}, []byte{3, 4}).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(routeID, now, now))
+
+		created := route
+		created.ID = uuid.Nil
+		require.NoError(t, db.CreateRoute(context.Background(), &created))
+		assert.Equal(t, routeID, created.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectQuery(`SELECT\s+id, user_id, name`).
+			WithArgs("user-123").
+			WillReturnRows(routeRow(pgxmock.NewRows(columns)))
+
+		routes, err := db.GetRoutesByUserID(context.Background(), "user-123")
+		require.NoError(t, err)
+		require.Len(t, routes, 1)
+		assert.Equal(t, route, routes[0])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list with no routes", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectQuery(`SELECT\s+id, user_id, name`).
+			WithArgs("user-123").
+			WillReturnRows(pgxmock.NewRows(columns))
+
+		routes, err := db.GetRoutesByUserID(context.Background(), "user-123")
+		require.NoError(t, err)
+		assert.NotNil(t, routes)
+		assert.Empty(t, routes)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectQuery(`SELECT\s+id, user_id, name.*WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(routeID.String(), "user-123").
+			WillReturnRows(routeRow(pgxmock.NewRows(columns)))
+
+		result, err := db.GetRouteByID(context.Background(), routeID.String(), "user-123")
+		require.NoError(t, err)
+		assert.Equal(t, &route, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get not found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectQuery(`SELECT\s+id, user_id, name`).
+			WithArgs(routeID.String(), "user-456").
+			WillReturnError(pgx.ErrNoRows)
+
+		result, err := db.GetRouteByID(context.Background(), routeID.String(), "user-456")
+		require.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		defer mock.Close(context.Background())
+
+		mock.ExpectExec(`DELETE FROM routes WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(routeID.String(), "user-123").
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		mock.ExpectExec(`DELETE FROM routes`).
+			WithArgs(routeID.String(), "user-123").
+			WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+		require.NoError(t, db.DeleteRoute(context.Background(), routeID.String(), "user-123"))
+		err := db.DeleteRoute(context.Background(), routeID.String(), "user-123")
+		require.Error(t, err)
+		assert.Equal(t, "route not found", err.Error())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const routeColumns = `
+	id, user_id, name, description, type, waypoints, polyline,
+	distance_m, elevation_gain_m, elevation_loss_m,
+	profile_distance_m_bytes, profile_elevation_m_bytes,
+	created_at, updated_at`
+
+// CreateRoute saves a new route, filling in its ID and timestamps
+func (s *PostgresDB) CreateRoute(ctx context.Context, route *models.Route) error {
+	s.log.Debug(fmt.Sprintf("Creating route %q for user: %s", route.Name, route.UserID))
+
+	waypointsJSON, err := json.Marshal(route.Waypoints)
+	if err != nil {
+		return fmt.Errorf("failed to marshal route waypoints: %w", err)
+	}
+
+	query := `
+		INSERT INTO routes (
+			user_id, name, description, type, waypoints, polyline,
+			distance_m, elevation_gain_m, elevation_loss_m,
+			profile_distance_m_bytes, profile_elevation_m_bytes
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at, updated_at
+	`
+
+	err = s.pool.QueryRow(ctx, query,
+		route.UserID,
+		route.Name,
+		route.Description,
+		route.Type,
+		waypointsJSON,
+		route.Polyline,
+		route.DistanceM,
+		route.ElevationGainM,
+		route.ElevationLossM,
+		route.ProfileDistanceMBytes,
+		route.ProfileElevationMBytes,
+	).Scan(&route.ID, &route.CreatedAt, &route.UpdatedAt)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Database error while creating route for user: %s", route.UserID), err)
+		return fmt.Errorf("failed to create route: %w", err)
+	}
+
+	s.log.Debug(fmt.Sprintf("Successfully created route: %s", route.ID))
+	return nil
+}
+
+// GetRoutesByUserID returns the user's routes, newest first
+func (s *PostgresDB) GetRoutesByUserID(ctx context.Context, userID string) ([]models.Route, error) {
+	s.log.Debug(fmt.Sprintf("Fetching routes for user: %s", userID))
+
+	query := `SELECT` + routeColumns + `
+		FROM routes
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := s.pool.Query(ctx, query, userID)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Database error while fetching routes for user: %s", userID), err)
+		return nil, fmt.Errorf("failed to get routes: %w", err)
+	}
+	defer rows.Close()
+
+	routes := []models.Route{}
+	for rows.Next() {
+		route, err := scanRoute(rows)
+		if err != nil {
+			s.log.Error(fmt.Sprintf("Error scanning route row for user: %s", userID), err)
+			return nil, err
+		}
+		routes = append(routes, *route)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.log.Error(fmt.Sprintf("Row iteration error for routes of user: %s", userID), err)
+		return nil, fmt.Errorf("failed to iterate routes: %w", err)
+	}
+
+	s.log.Debug(fmt.Sprintf("Found %d routes for user: %s", len(routes), userID))
+	return routes, nil
+}
+
+// GetRouteByID returns one of the user's routes, or nil if the user has no route with that ID
+func (s *PostgresDB) GetRouteByID(ctx context.Context, routeID string, userID string) (*models.Route, error) {
+	s.log.Debug(fmt.Sprintf("Fetching route ID: %s for user: %s", routeID, userID))
+
+	query := `SELECT` + routeColumns + `
+		FROM routes
+		WHERE id = $1 AND user_id = $2
+	`
+
+	route, err := scanRoute(s.pool.QueryRow(ctx, query, routeID, userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			s.log.Debug(fmt.Sprintf("Route not found: %s", routeID))
+			return nil, nil
+		}
+		s.log.Error(fmt.Sprintf("Database error while fetching route: %s", routeID), err)
+		return nil, fmt.Errorf("failed to get route: %w", err)
+	}
+
+	return route, nil
+}
+
+// DeleteRoute deletes one of the user's routes. Planned activities that referenced it keep their plan without a route.
+func (s *PostgresDB) DeleteRoute(ctx context.Context, routeID string, userID string) error {
+	s.log.Debug(fmt.Sprintf("Deleting route ID: %s for user: %s", routeID, userID))
+
+	cmdTag, err := s.pool.Exec(ctx, `DELETE FROM routes WHERE id = $1 AND user_id = $2`, routeID, userID)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Database error while deleting route ID: %s", routeID), err)
+		return fmt.Errorf("failed to delete route: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		s.log.Debug(fmt.Sprintf("Route not found with ID: %s for user: %s", routeID, userID))
+		return fmt.Errorf("route not found")
+	}
+
+	s.log.Debug(fmt.Sprintf("Successfully deleted route: %s", routeID))
+	return nil
+}
+
+// scanRoute scans a row of routeColumns
+func scanRoute(row pgx.Row) (*models.Route, error) {
+	var route models.Route
+	var waypointsJSON []byte
+
+	err := row.Scan(
+		&route.ID,
+		&route.UserID,
+		&route.Name,
+		&route.Description,
+		&route.Type,
+		&waypointsJSON,
+		&route.Polyline,
+		&route.DistanceM,
+		&route.ElevationGainM,
+		&route.ElevationLossM,
+		&route.ProfileDistanceMBytes,
+		&route.ProfileElevationMBytes,
+		&route.CreatedAt,
+		&route.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan route: %w", err)
+	}
+
+	if err := json.Unmarshal(waypointsJSON, &route.Waypoints); err != nil {
+		return nil, fmt.Errorf("failed to parse route waypoints: %w", err)
+	}
+	return &route, nil
+}
@@ -223,3 +223,64 @@ func (s *PostgresDB) CreateUserTrainingPlanWithPlannedActivities(ctx context.Con
 
 	return nil
 }
+
+// GetUserTrainingPlanByID returns a user's training plan enrollment, or nil if it does not exist for that user
+func (s *PostgresDB) GetUserTrainingPlanByID(ctx context.Context, userTrainingPlanID string, userID string) (*models.UserTrainingPlan, error) {
+	query := `
+		SELECT id, user_id, training_plan_id, title, description, start_date,
+			   selected_workouts_per_week, created_at, updated_at
+		FROM user_training_plans
+		WHERE id = $1 AND user_id = $2
+	`
+
+	var plan models.UserTrainingPlan
+	err := s.pool.QueryRow(ctx, query, userTrainingPlanID, userID).Scan(
+		&plan.ID, &plan.UserID, &plan.TrainingPlanID, &plan.Title, &plan.Description, &plan.StartDate,
+		&plan.SelectedWorkoutsPerWeek, &plan.CreatedAt, &plan.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user training plan by id: %w", err)
+	}
+
+	return &plan, nil
+}
+
+// GetPlannedActivitiesByUserTrainingPlan returns the planned activities created for an enrollment, in plan order
+func (s *PostgresDB) GetPlannedActivitiesByUserTrainingPlan(ctx context.Context, userTrainingPlanID string, userID string) ([]models.PlannedActivity, error) {
+	query := `
+		SELECT id, user_id, title, description, type, start_time, planned_distance_m, planned_duration_s,
+			   planned_elevation_gain_m, target_avg_speed_mps, target_power_watt,
+			   matched_activity_id, route_id, user_training_plan_id, plan_sequence_index, created_at, updated_at
+		FROM planned_activities
+		WHERE user_training_plan_id = $1 AND user_id = $2
+		ORDER BY plan_sequence_index ASC
+	`
+
+	rows, err := s.pool.Query(ctx, query, userTrainingPlanID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query planned activities: %w", err)
+	}
+	defer rows.Close()
+
+	plannedActivities := []models.PlannedActivity{}
+	for rows.Next() {
+		var p models.PlannedActivity
+		if err := rows.Scan(
+			&p.ID, &p.UserID, &p.Title, &p.Description, &p.Type, &p.StartTime, &p.PlannedDistanceM, &p.PlannedDurationS,
+			&p.PlannedElevationGainM, &p.TargetAvgSpeedMps, &p.TargetPowerWatt,
+			&p.MatchedActivityID, &p.RouteID, &p.UserTrainingPlanID, &p.PlanSequenceIndex, &p.CreatedAt, &p.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan planned activity: %w", err)
+		}
+		plannedActivities = append(plannedActivities, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iteration error: %w", err)
+	}
+
+	return plannedActivities, nil
+}
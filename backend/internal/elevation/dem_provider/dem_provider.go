@@ -0,0 +1,165 @@
+package dem_provider
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"io/fs"
+	"math"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/anish-chanda/cadent/backend/internal/geo"
+	"github.com/anish-chanda/cadent/backend/internal/logger"
+	"github.com/anish-chanda/cadent/backend/internal/valhalla"
+)
+
+// DefaultTileCacheSize is the number of tiles kept in memory. A 1 arc second SRTM tile takes about 50MB.
+const DefaultTileCacheSize = 8
+
+// tile is an elevation model file found in the DEM directory
+type tile struct {
+	path   string
+	bounds bounds
+	read   func(path string) (*raster, error)
+}
+
+// Provider looks up heights in SRTM .hgt and GeoTIFF tiles stored in a local directory, interpolating
+// bilinearly between samples. Tiles are indexed by their extent on startup and loaded on first use.
+type Provider struct {
+	tiles []tile
+	log   logger.ServiceLogger
+
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // loaded rasters, front = most recently used
+	loaded   map[string]*list.Element
+}
+
+type loadedTile struct {
+	path   string
+	raster *raster
+}
+
+// NewProvider indexes the SRTM (.hgt) and GeoTIFF (.tif, .tiff) tiles under dir. GeoTIFFs must hold a single
+// band of heights in meters in WGS84 geographic coordinates.
+func NewProvider(dir string, log logger.ServiceLogger) (*Provider, error) {
+	p := &Provider{
+		log:      log,
+		capacity: DefaultTileCacheSize,
+		order:    list.New(),
+		loaded:   make(map[string]*list.Element),
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		var t tile
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".hgt":
+			t.bounds, err = hgtBounds(path)
+			t.read = readHGT
+		case ".tif", ".tiff":
+			t.bounds, err = geoTIFFBounds(path)
+			t.read = readGeoTIFF
+		default:
+			return nil
+		}
+		if err != nil {
+			log.Warn(fmt.Sprintf("Skipping elevation tile %s: %v", path, err))
+			return nil
+		}
+		t.path = path
+		p.tiles = append(p.tiles, t)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan DEM directory %s: %w", dir, err)
+	}
+	if len(p.tiles) == 0 {
+		return nil, fmt.Errorf("no .hgt or GeoTIFF tiles found in DEM directory %s", dir)
+	}
+
+	log.Info(fmt.Sprintf("Indexed %d elevation tiles in %s", len(p.tiles), dir))
+	return p, nil
+}
+
+// GetHeight returns the interpolated height of every point of the request's polyline, rounded to
+// req.HeightPrecision decimals. Points outside all tiles or surrounded by voids get a nil height.
+func (p *Provider) GetHeight(ctx context.Context, req valhalla.HeightRequest) (*valhalla.HeightResponse, error) {
+	if req.EncodedPolyline == "" {
+		return nil, fmt.Errorf("encoded_polyline cannot be empty")
+	}
+
+	points, err := geo.Decode6(req.EncodedPolyline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode polyline: %w", err)
+	}
+
+	scale := math.Pow(10, float64(req.HeightPrecision))
+	heights := make([]*float64, len(points))
+	for i, pt := range points {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		h, ok, err := p.heightAt(pt.Lat, pt.Lon)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			h = math.Round(h*scale) / scale
+			heights[i] = &h
+		}
+	}
+
+	return &valhalla.HeightResponse{EncodedPolyline: req.EncodedPolyline, Height: heights}, nil
+}
+
+// heightAt returns the height at a point from the first tile covering it that has data there
+func (p *Provider) heightAt(lat, lon float64) (float64, bool, error) {
+	for _, t := range p.tiles {
+		if !t.bounds.contains(lat, lon) {
+			continue
+		}
+		r, err := p.raster(t)
+		if err != nil {
+			return 0, false, err
+		}
+		if h, ok := r.heightAt(lat, lon); ok {
+			return h, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// raster returns the samples of a tile, reading it from disk unless it is among the recently used ones
+func (p *Provider) raster(t tile) (*raster, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if elem, ok := p.loaded[t.path]; ok {
+		p.order.MoveToFront(elem)
+		return elem.Value.(*loadedTile).raster, nil
+	}
+
+	p.log.Debug(fmt.Sprintf("Loading elevation tile %s", t.path))
+	r, err := t.read(t.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load elevation tile %s: %w", t.path, err)
+	}
+
+	p.loaded[t.path] = p.order.PushFront(&loadedTile{path: t.path, raster: r})
+	for p.order.Len() > p.capacity {
+		oldest := p.order.Back()
+		p.order.Remove(oldest)
+		delete(p.loaded, oldest.Value.(*loadedTile).path)
+	}
+	return r, nil
+}
@@ -0,0 +1,413 @@
+package dem_provider
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anish-chanda/cadent/backend/internal/geo"
+	"github.com/anish-chanda/cadent/backend/internal/logger"
+	"github.com/anish-chanda/cadent/backend/internal/valhalla"
+)
+
+// plane is a linear elevation field, which bilinear interpolation reproduces exactly
+func plane(lat, lon float64) float64 {
+	return 400*lon + 200*lat
+}
+
+// writeHGT writes a size x size SRTM tile with its south-west corner at lat, lon sampled from plane
+func writeHGT(t *testing.T, dir, name string, lat, lon float64, size int, voids map[[2]int]bool) string {
+	t.Helper()
+	spacing := 1 / float64(size-1)
+	data := make([]byte, 2*size*size)
+	for row := 0; row < size; row++ {
+		for col := 0; col < size; col++ {
+			v := int16(plane(lat+1-float64(row)*spacing, lon+float64(col)*spacing))
+			if voids[[2]int{row, col}] {
+				v = hgtVoid
+			}
+			binary.BigEndian.PutUint16(data[2*(row*size+col):], uint16(v))
+		}
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write tile: %v", err)
+	}
+	return path
+}
+
+type tiffOptions struct {
+	order        binary.ByteOrder
+	sampleFormat int // 2 for int16, 3 for float32
+	deflate      bool
+	predictor    bool
+	tileSize     int // 0 for strips of two rows
+	pixelIsPoint bool
+	noData       string
+}
+
+// writeGeoTIFF writes a width x height GeoTIFF sampled from plane whose first pixel has its corner, or its
+// center with pixelIsPoint, at west, north
+func writeGeoTIFF(t *testing.T, dir, name string, west, north, scale float64, width, height int, opts tiffOptions) string {
+	t.Helper()
+	order := opts.order
+	if order == nil {
+		order = binary.LittleEndian
+	}
+	bytesPerSample := 2
+	if opts.sampleFormat == 3 {
+		bytesPerSample = 4
+	}
+	centerWest, centerNorth := west+scale/2, north-scale/2
+	if opts.pixelIsPoint {
+		centerWest, centerNorth = west, north
+	}
+
+	chunkW, chunkH := width, 2
+	if opts.tileSize > 0 {
+		chunkW, chunkH = opts.tileSize, opts.tileSize
+	}
+	across, down := (width+chunkW-1)/chunkW, (height+chunkH-1)/chunkH
+
+	var body bytes.Buffer
+	body.Write(make([]byte, 8))
+	var offsets, counts []uint32
+	for i := 0; i < across*down; i++ {
+		row0, col0 := (i/across)*chunkH, (i%across)*chunkW
+		rows := chunkH
+		if opts.tileSize == 0 {
+			rows = min(chunkH, height-row0)
+		}
+
+		var chunk bytes.Buffer
+		for r := 0; r < rows; r++ {
+			var previous uint32
+			for c := 0; c < chunkW; c++ {
+				row, col := row0+r, col0+c
+				var v float64
+				if row < height && col < width {
+					v = plane(centerNorth-float64(row)*scale, centerWest+float64(col)*scale)
+					if opts.noData != "" && row == 1 && col == 1 {
+						v = -9999
+					}
+				}
+				var raw uint32
+				if opts.sampleFormat == 3 {
+					raw = math.Float32bits(float32(v))
+				} else {
+					raw = uint32(uint16(int16(v)))
+				}
+				encoded := raw
+				if opts.predictor && c > 0 {
+					encoded = raw - previous
+				}
+				previous = raw
+				if bytesPerSample == 2 {
+					_ = binary.Write(&chunk, order, uint16(encoded))
+				} else {
+					_ = binary.Write(&chunk, order, encoded)
+				}
+			}
+		}
+
+		data := chunk.Bytes()
+		if opts.deflate {
+			var compressed bytes.Buffer
+			zw := zlib.NewWriter(&compressed)
+			_, _ = zw.Write(data)
+			_ = zw.Close()
+			data = compressed.Bytes()
+		}
+		offsets = append(offsets, uint32(body.Len()))
+		counts = append(counts, uint32(len(data)))
+		body.Write(data)
+	}
+
+	type entry struct {
+		tag, fieldType uint16
+		data           []byte
+		count          int
+	}
+	var entries []entry
+	add := func(tag, fieldType uint16, values ...any) {
+		var buf bytes.Buffer
+		for _, v := range values {
+			_ = binary.Write(&buf, order, v)
+		}
+		entries = append(entries, entry{tag, fieldType, buf.Bytes(), len(values)})
+	}
+	longs := func(values []uint32) []any {
+		out := make([]any, len(values))
+		for i, v := range values {
+			out[i] = v
+		}
+		return out
+	}
+
+	compression, predictor, rasterType := uint16(1), uint16(1), uint16(rasterPixelIsArea)
+	if opts.deflate {
+		compression = 8
+	}
+	if opts.predictor {
+		predictor = 2
+	}
+	if opts.pixelIsPoint {
+		rasterType = rasterPixelIsPoint
+	}
+
+	add(tagImageWidth, 4, uint32(width))
+	add(tagImageLength, 4, uint32(height))
+	add(tagBitsPerSample, 3, uint16(8*bytesPerSample))
+	add(tagCompression, 3, compression)
+	add(tagSamplesPerPixel, 3, uint16(1))
+	add(tagPredictor, 3, predictor)
+	add(tagSampleFormat, 3, uint16(opts.sampleFormat))
+	if opts.tileSize > 0 {
+		add(tagTileWidth, 3, uint16(chunkW))
+		add(tagTileLength, 3, uint16(chunkH))
+		add(tagTileOffsets, 4, longs(offsets)...)
+		add(tagTileByteCounts, 4, longs(counts)...)
+	} else {
+		add(tagRowsPerStrip, 3, uint16(chunkH))
+		add(tagStripOffsets, 4, longs(offsets)...)
+		add(tagStripByteCounts, 4, longs(counts)...)
+	}
+	add(tagModelPixelScale, 12, scale, scale, 0.0)
+	add(tagModelTiepoint, 12, 0.0, 0.0, 0.0, west, north, 0.0)
+	add(tagGeoKeyDirectory, 3, uint16(1), uint16(1), uint16(0), uint16(1), uint16(geoKeyRasterType), uint16(0), uint16(1), rasterType)
+	if opts.noData != "" {
+		entries = append(entries, entry{tagGDALNoData, 2, append([]byte(opts.noData), 0), len(opts.noData) + 1})
+	}
+
+	ifdOffset := body.Len()
+	extraOffset := ifdOffset + 2 + 12*len(entries) + 4
+	var ifd, extra bytes.Buffer
+	_ = binary.Write(&ifd, order, uint16(len(entries)))
+	for _, e := range entries {
+		_ = binary.Write(&ifd, order, e.tag)
+		_ = binary.Write(&ifd, order, e.fieldType)
+		_ = binary.Write(&ifd, order, uint32(e.count))
+		if len(e.data) <= 4 {
+			ifd.Write(append(e.data, make([]byte, 4-len(e.data))...))
+		} else {
+			_ = binary.Write(&ifd, order, uint32(extraOffset+extra.Len()))
+			extra.Write(e.data)
+		}
+	}
+	ifd.Write(make([]byte, 4))
+
+	data := body.Bytes()
+	if order == binary.LittleEndian {
+		copy(data, "II")
+	} else {
+		copy(data, "MM")
+	}
+	order.PutUint16(data[2:], 42)
+	order.PutUint32(data[4:], uint32(ifdOffset))
+	data = append(append(data, ifd.Bytes()...), extra.Bytes()...)
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write GeoTIFF: %v", err)
+	}
+	return path
+}
+
+func testLogger() logger.ServiceLogger {
+	return *logger.New(logger.Config{Level: "error", Environment: "test"})
+}
+
+func TestReadHGT(t *testing.T) {
+	dir := t.TempDir()
+	path := writeHGT(t, dir, "N47E008.hgt", 47, 8, 5, map[[2]int]bool{{0, 0}: true})
+
+	b, err := hgtBounds(path)
+	if err != nil {
+		t.Fatalf("hgtBounds() error = %v", err)
+	}
+	if b != (bounds{minLat: 47, maxLat: 48, minLon: 8, maxLon: 9}) {
+		t.Errorf("hgtBounds() = %+v, want one degree from N47E008", b)
+	}
+
+	r, err := readHGT(path)
+	if err != nil {
+		t.Fatalf("readHGT() error = %v", err)
+	}
+	if r.width != 5 || r.height != 5 {
+		t.Fatalf("readHGT() size = %dx%d, want 5x5", r.width, r.height)
+	}
+
+	for _, pt := range []geo.Point{{Lat: 47, Lon: 8}, {Lat: 47.3, Lon: 8.6}, {Lat: 47.5, Lon: 8.5}, {Lat: 48, Lon: 9}} {
+		h, ok := r.heightAt(pt.Lat, pt.Lon)
+		if !ok || math.Abs(h-plane(pt.Lat, pt.Lon)) > 1e-6 {
+			t.Errorf("heightAt(%v) = %f, %v, want %f", pt, h, ok, plane(pt.Lat, pt.Lon))
+		}
+	}
+
+	if _, ok := r.heightAt(48, 8); ok {
+		t.Error("Expected no height at a void sample")
+	}
+	if h, ok := r.heightAt(47.9, 8.1); !ok || math.IsNaN(h) {
+		t.Errorf("Expected the void to be interpolated around, got %f, %v", h, ok)
+	}
+	if _, ok := r.heightAt(46.5, 8.5); ok {
+		t.Error("Expected no height outside the tile")
+	}
+
+	for _, name := range []string{"S12W077.hgt", "n47e008.HGT"} {
+		if _, _, err := parseHGTName(name); err != nil {
+			t.Errorf("parseHGTName(%q) error = %v", name, err)
+		}
+	}
+	if lat, lon, _ := parseHGTName("S12W077.hgt"); lat != -12 || lon != -77 {
+		t.Errorf("parseHGTName(S12W077.hgt) = %d, %d, want -12, -77", lat, lon)
+	}
+	if _, _, err := parseHGTName("dem.hgt"); err == nil {
+		t.Error("Expected an error for a file not named after its corner")
+	}
+}
+
+func TestReadGeoTIFF(t *testing.T) {
+	tests := []struct {
+		name string
+		opts tiffOptions
+	}{
+		{"int16 strips", tiffOptions{sampleFormat: 2}},
+		{"int16 big-endian", tiffOptions{sampleFormat: 2, order: binary.BigEndian}},
+		{"int16 deflate with predictor", tiffOptions{sampleFormat: 2, deflate: true, predictor: true}},
+		{"int16 tiles", tiffOptions{sampleFormat: 2, tileSize: 2, deflate: true, predictor: true}},
+		{"float32 tiles", tiffOptions{sampleFormat: 3, tileSize: 4, deflate: true}},
+		{"pixel is point", tiffOptions{sampleFormat: 3, pixelIsPoint: true}},
+		{"nodata", tiffOptions{sampleFormat: 2, noData: "-9999"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeGeoTIFF(t, t.TempDir(), "dem.tif", 10, 46, 0.5, 5, 4, tt.opts)
+
+			b, err := geoTIFFBounds(path)
+			if err != nil {
+				t.Fatalf("geoTIFFBounds() error = %v", err)
+			}
+			expected := bounds{minLat: 44, maxLat: 46, minLon: 10, maxLon: 12.5}
+			if tt.opts.pixelIsPoint {
+				expected = bounds{minLat: 44.25, maxLat: 46.25, minLon: 9.75, maxLon: 12.25}
+			}
+			if b != expected {
+				t.Errorf("geoTIFFBounds() = %+v, want %+v", b, expected)
+			}
+
+			r, err := readGeoTIFF(path)
+			if err != nil {
+				t.Fatalf("readGeoTIFF() error = %v", err)
+			}
+			for _, pt := range []geo.Point{{Lat: 45.7, Lon: 10.3}, {Lat: 45.1, Lon: 11.3}, {Lat: 44.6, Lon: 11.9}} {
+				if tt.opts.noData != "" && pt.Lat != 44.6 {
+					continue // next to the void
+				}
+				h, ok := r.heightAt(pt.Lat, pt.Lon)
+				if !ok || math.Abs(h-plane(pt.Lat, pt.Lon)) > 1e-3 {
+					t.Errorf("heightAt(%v) = %f, %v, want %f", pt, h, ok, plane(pt.Lat, pt.Lon))
+				}
+			}
+
+			if tt.opts.noData != "" {
+				if _, ok := r.heightAt(45.25, 10.75); ok {
+					t.Error("Expected no height at a nodata sample")
+				}
+			}
+		})
+	}
+
+	t.Run("not a TIFF", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dem.tif")
+		_ = os.WriteFile(path, []byte("not a tiff"), 0644)
+		if _, err := readGeoTIFF(path); err == nil {
+			t.Error("Expected an error for a file that is not a TIFF")
+		}
+	})
+}
+
+func TestProvider_GetHeight(t *testing.T) {
+	dir := t.TempDir()
+	writeHGT(t, dir, "N47E008.hgt", 47, 8, 5, nil)
+	if err := os.Mkdir(filepath.Join(dir, "alps"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeGeoTIFF(t, filepath.Join(dir, "alps"), "dem.tiff", 10, 46, 0.5, 5, 4, tiffOptions{sampleFormat: 3, deflate: true})
+	_ = os.WriteFile(filepath.Join(dir, "README.md"), []byte("tiles"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "broken.hgt"), []byte{0, 1}, 0644)
+
+	provider, err := NewProvider(dir, testLogger())
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	if len(provider.tiles) != 2 {
+		t.Fatalf("Expected 2 indexed tiles, got %d", len(provider.tiles))
+	}
+
+	points := []geo.Point{
+		{Lat: 47.123456, Lon: 8.654321},
+		{Lat: 45.5, Lon: 11.25},
+		{Lat: 0, Lon: 0},
+	}
+	resp, err := provider.GetHeight(context.Background(), valhalla.HeightRequest{
+		EncodedPolyline: geo.Encode6(points),
+		HeightPrecision: 2,
+	})
+	if err != nil {
+		t.Fatalf("GetHeight() error = %v", err)
+	}
+	if len(resp.Height) != len(points) {
+		t.Fatalf("Expected %d heights, got %d", len(points), len(resp.Height))
+	}
+
+	for i, pt := range points[:2] {
+		expected := math.Round(plane(pt.Lat, pt.Lon)*100) / 100
+		if resp.Height[i] == nil || math.Abs(*resp.Height[i]-expected) > 0.011 {
+			t.Errorf("Height[%d] = %v, want %.2f", i, resp.Height[i], expected)
+		}
+		if h := *resp.Height[i]; math.Abs(h*100-math.Round(h*100)) > 1e-6 {
+			t.Errorf("Height[%d] = %f, want 2 decimals", i, h)
+		}
+	}
+	if resp.Height[2] != nil {
+		t.Errorf("Expected no height outside the tiles, got %f", *resp.Height[2])
+	}
+
+	if _, err := provider.GetHeight(context.Background(), valhalla.HeightRequest{}); err == nil {
+		t.Error("Expected an error for an empty polyline")
+	}
+	if _, err := NewProvider(t.TempDir(), testLogger()); err == nil {
+		t.Error("Expected an error for a directory without tiles")
+	}
+}
+
+func TestProvider_TileCache(t *testing.T) {
+	dir := t.TempDir()
+	for lon := 0; lon < 3; lon++ {
+		writeHGT(t, dir, "N00E00"+string(rune('0'+lon))+".hgt", 0, float64(lon), 3, nil)
+	}
+	provider, err := NewProvider(dir, testLogger())
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	provider.capacity = 2
+
+	for _, lon := range []float64{0.5, 1.5, 2.5, 0.5} {
+		if _, ok, err := provider.heightAt(0.5, lon); err != nil || !ok {
+			t.Fatalf("heightAt(0.5, %f) = %v, %v", lon, ok, err)
+		}
+	}
+	if len(provider.loaded) != 2 || provider.order.Len() != 2 {
+		t.Errorf("Expected 2 loaded tiles, got %d", len(provider.loaded))
+	}
+	if _, ok := provider.loaded[filepath.Join(dir, "N00E001.hgt")]; ok {
+		t.Error("Expected the least recently used tile to be evicted")
+	}
+}
@@ -0,0 +1,363 @@
+package dem_provider
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// TIFF tags read from elevation GeoTIFFs
+const (
+	tagImageWidth      = 256
+	tagImageLength     = 257
+	tagBitsPerSample   = 258
+	tagCompression     = 259
+	tagStripOffsets    = 273
+	tagSamplesPerPixel = 277
+	tagRowsPerStrip    = 278
+	tagStripByteCounts = 279
+	tagPredictor       = 317
+	tagTileWidth       = 322
+	tagTileLength      = 323
+	tagTileOffsets     = 324
+	tagTileByteCounts  = 325
+	tagSampleFormat    = 339
+	tagModelPixelScale = 33550
+	tagModelTiepoint   = 33922
+	tagGeoKeyDirectory = 34735
+	tagGDALNoData      = 42113
+
+	geoKeyRasterType   = 1025 // GTRasterTypeGeoKey
+	rasterPixelIsArea  = 1
+	rasterPixelIsPoint = 2
+
+	// maxGeoTIFFSamples guards against allocating for corrupt or unreasonably large images
+	maxGeoTIFFSamples = 1 << 28
+)
+
+// TIFF field types and their sizes in bytes
+var tiffTypeSizes = map[uint16]int{
+	1:  1, // BYTE
+	2:  1, // ASCII
+	3:  2, // SHORT
+	4:  4, // LONG
+	11: 4, // FLOAT
+	12: 8, // DOUBLE
+}
+
+// geoTIFF is the layout of a single-band elevation GeoTIFF. Strips are read as tiles as wide as the image.
+type geoTIFF struct {
+	order         binary.ByteOrder
+	width, height int
+	bitsPerSample int
+	sampleFormat  int // 1 unsigned integer, 2 signed integer, 3 floating point
+	compression   int // 1 none, 8 or 32946 deflate
+	predictor     int // 1 none, 2 horizontal differencing
+	tileWidth     int
+	tileHeight    int
+	offsets       []uint64
+	byteCounts    []uint64
+	noData        *float64
+
+	west, north float64 // center of the first pixel
+	dLon, dLat  float64
+}
+
+// readTIFFField reads the values of an IFD entry as unsigned integers, floats or a string
+func readTIFFField(r io.ReaderAt, order binary.ByteOrder, entry []byte) (ints []uint64, floats []float64, text string, err error) {
+	fieldType := order.Uint16(entry[2:])
+	count := int(order.Uint32(entry[4:]))
+	size, ok := tiffTypeSizes[fieldType]
+	if !ok || count < 0 || count > 1<<24 {
+		return nil, nil, "", nil // unsupported types are skipped
+	}
+
+	data := entry[8:12]
+	if count*size > 4 {
+		data = make([]byte, count*size)
+		if _, err := r.ReadAt(data, int64(order.Uint32(entry[8:]))); err != nil {
+			return nil, nil, "", fmt.Errorf("failed to read TIFF field: %w", err)
+		}
+	}
+
+	for i := 0; i < count; i++ {
+		switch fieldType {
+		case 1:
+			ints = append(ints, uint64(data[i]))
+		case 3:
+			ints = append(ints, uint64(order.Uint16(data[2*i:])))
+		case 4:
+			ints = append(ints, uint64(order.Uint32(data[4*i:])))
+		case 11:
+			floats = append(floats, float64(math.Float32frombits(order.Uint32(data[4*i:]))))
+		case 12:
+			floats = append(floats, math.Float64frombits(order.Uint64(data[8*i:])))
+		}
+	}
+	if fieldType == 2 {
+		text = strings.TrimRight(string(data[:count]), "\x00")
+	}
+	return ints, floats, text, nil
+}
+
+// readGeoTIFFHeader reads the layout and georeferencing of the first image of a GeoTIFF.
+// Only classic TIFFs with one band in geographic (WGS84 degree) coordinates are supported.
+func readGeoTIFFHeader(r io.ReaderAt) (*geoTIFF, error) {
+	header := make([]byte, 8)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read TIFF header: %w", err)
+	}
+
+	var order binary.ByteOrder
+	switch string(header[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("not a TIFF file")
+	}
+	if magic := order.Uint16(header[2:]); magic != 42 {
+		return nil, fmt.Errorf("unsupported TIFF version %d (BigTIFF is not supported)", magic)
+	}
+
+	ifdOffset := int64(order.Uint32(header[4:]))
+	countBytes := make([]byte, 2)
+	if _, err := r.ReadAt(countBytes, ifdOffset); err != nil {
+		return nil, fmt.Errorf("failed to read TIFF directory: %w", err)
+	}
+	entries := make([]byte, 12*int(order.Uint16(countBytes)))
+	if _, err := r.ReadAt(entries, ifdOffset+2); err != nil {
+		return nil, fmt.Errorf("failed to read TIFF directory: %w", err)
+	}
+
+	t := &geoTIFF{order: order, bitsPerSample: 1, sampleFormat: 1, compression: 1, predictor: 1}
+	samplesPerPixel := 1
+	rowsPerStrip := 0
+	var pixelScale, tiepoint []float64
+	rasterType := uint64(rasterPixelIsArea)
+
+	for i := 0; i < len(entries); i += 12 {
+		entry := entries[i : i+12]
+		ints, floats, text, err := readTIFFField(r, order, entry)
+		if err != nil {
+			return nil, err
+		}
+		first := 0
+		if len(ints) > 0 {
+			first = int(ints[0])
+		}
+
+		switch order.Uint16(entry) {
+		case tagImageWidth:
+			t.width = first
+		case tagImageLength:
+			t.height = first
+		case tagBitsPerSample:
+			t.bitsPerSample = first
+		case tagCompression:
+			t.compression = first
+		case tagSamplesPerPixel:
+			samplesPerPixel = first
+		case tagRowsPerStrip:
+			rowsPerStrip = first
+		case tagPredictor:
+			t.predictor = first
+		case tagTileWidth:
+			t.tileWidth = first
+		case tagTileLength:
+			t.tileHeight = first
+		case tagStripOffsets, tagTileOffsets:
+			t.offsets = ints
+		case tagStripByteCounts, tagTileByteCounts:
+			t.byteCounts = ints
+		case tagSampleFormat:
+			t.sampleFormat = first
+		case tagModelPixelScale:
+			pixelScale = floats
+		case tagModelTiepoint:
+			tiepoint = floats
+		case tagGeoKeyDirectory:
+			// Header of four shorts, then keys of four shorts: ID, location, count, value
+			for k := 4; k+3 < len(ints); k += 4 {
+				if ints[k] == geoKeyRasterType && ints[k+1] == 0 {
+					rasterType = ints[k+3]
+				}
+			}
+		case tagGDALNoData:
+			if v, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+				t.noData = &v
+			}
+		}
+	}
+
+	if t.width <= 0 || t.height <= 0 || t.width*t.height > maxGeoTIFFSamples {
+		return nil, fmt.Errorf("unsupported GeoTIFF size %dx%d", t.width, t.height)
+	}
+	if samplesPerPixel != 1 {
+		return nil, fmt.Errorf("unsupported GeoTIFF with %d bands, elevation tiles must have one", samplesPerPixel)
+	}
+	switch {
+	case t.bitsPerSample == 16 && t.sampleFormat != 3,
+		t.bitsPerSample == 32:
+	default:
+		return nil, fmt.Errorf("unsupported GeoTIFF sample type: %d bits, format %d", t.bitsPerSample, t.sampleFormat)
+	}
+	if t.compression != 1 && t.compression != 8 && t.compression != 32946 {
+		return nil, fmt.Errorf("unsupported GeoTIFF compression %d (only none and deflate are supported)", t.compression)
+	}
+	if t.predictor != 1 && (t.predictor != 2 || t.sampleFormat == 3) {
+		return nil, fmt.Errorf("unsupported GeoTIFF predictor %d", t.predictor)
+	}
+
+	// Strips are tiles spanning the full width
+	if t.tileWidth == 0 || t.tileHeight == 0 {
+		t.tileWidth = t.width
+		t.tileHeight = rowsPerStrip
+		if t.tileHeight <= 0 || t.tileHeight > t.height {
+			t.tileHeight = t.height
+		}
+	}
+	tilesAcross := (t.width + t.tileWidth - 1) / t.tileWidth
+	tilesDown := (t.height + t.tileHeight - 1) / t.tileHeight
+	if len(t.offsets) != tilesAcross*tilesDown || len(t.byteCounts) != len(t.offsets) {
+		return nil, fmt.Errorf("GeoTIFF has %d strips or tiles, expected %d", len(t.offsets), tilesAcross*tilesDown)
+	}
+
+	if len(pixelScale) < 2 || len(tiepoint) < 6 || pixelScale[0] <= 0 || pixelScale[1] <= 0 {
+		return nil, fmt.Errorf("GeoTIFF is not georeferenced with a pixel scale and tiepoint")
+	}
+	t.dLon, t.dLat = pixelScale[0], pixelScale[1]
+	t.west = tiepoint[3] - tiepoint[0]*t.dLon
+	t.north = tiepoint[4] + tiepoint[1]*t.dLat
+	if rasterType != rasterPixelIsPoint {
+		// The tiepoint is the corner of the first pixel rather than its center
+		t.west += t.dLon / 2
+		t.north -= t.dLat / 2
+	}
+	return t, nil
+}
+
+// bounds returns the area covered by the GeoTIFF
+func (t *geoTIFF) bounds() bounds {
+	return (&raster{west: t.west, north: t.north, dLon: t.dLon, dLat: t.dLat, width: t.width, height: t.height}).bounds()
+}
+
+// readChunk reads and decompresses one strip or tile
+func (t *geoTIFF) readChunk(r io.ReaderAt, i int) ([]byte, error) {
+	data := make([]byte, t.byteCounts[i])
+	if _, err := r.ReadAt(data, int64(t.offsets[i])); err != nil {
+		return nil, fmt.Errorf("failed to read GeoTIFF data: %w", err)
+	}
+	if t.compression == 1 {
+		return data, nil
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress GeoTIFF data: %w", err)
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// decodeChunk converts a decompressed strip or tile into samples, undoing horizontal differencing
+func (t *geoTIFF) decodeChunk(data []byte) []float64 {
+	bytesPerSample := t.bitsPerSample / 8
+	samples := make([]float64, len(data)/bytesPerSample)
+	var previous uint32
+	for i := range samples {
+		var raw uint32
+		if bytesPerSample == 2 {
+			raw = uint32(t.order.Uint16(data[2*i:]))
+		} else {
+			raw = t.order.Uint32(data[4*i:])
+		}
+		if t.predictor == 2 && i%t.tileWidth != 0 {
+			raw += previous
+		}
+		previous = raw
+
+		switch {
+		case t.sampleFormat == 3:
+			samples[i] = float64(math.Float32frombits(raw))
+		case t.sampleFormat == 2 && bytesPerSample == 2:
+			samples[i] = float64(int16(raw))
+		case t.sampleFormat == 2:
+			samples[i] = float64(int32(raw))
+		case bytesPerSample == 2:
+			samples[i] = float64(uint16(raw))
+		default:
+			samples[i] = float64(raw)
+		}
+	}
+	return samples
+}
+
+// geoTIFFBounds returns the area covered by a GeoTIFF tile, reading only its header
+func geoTIFFBounds(path string) (bounds, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return bounds{}, fmt.Errorf("failed to open GeoTIFF: %w", err)
+	}
+	defer f.Close()
+
+	t, err := readGeoTIFFHeader(f)
+	if err != nil {
+		return bounds{}, err
+	}
+	return t.bounds(), nil
+}
+
+// readGeoTIFF reads the elevation samples of a single-band GeoTIFF in geographic coordinates.
+// Uncompressed and deflate-compressed images with strips or tiles are supported.
+func readGeoTIFF(path string) (*raster, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoTIFF: %w", err)
+	}
+	defer f.Close()
+
+	t, err := readGeoTIFFHeader(f)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]float32, t.width*t.height)
+	tilesAcross := (t.width + t.tileWidth - 1) / t.tileWidth
+	for i := range t.offsets {
+		data, err := t.readChunk(f, i)
+		if err != nil {
+			return nil, err
+		}
+		samples := t.decodeChunk(data)
+
+		row0, col0 := (i/tilesAcross)*t.tileHeight, (i%tilesAcross)*t.tileWidth
+		for j, v := range samples {
+			row, col := row0+j/t.tileWidth, col0+j%t.tileWidth
+			if row >= t.height || col >= t.width {
+				continue // padding of edge tiles
+			}
+			if math.IsNaN(v) || (t.noData != nil && v == *t.noData) {
+				values[row*t.width+col] = float32(math.NaN())
+			} else {
+				values[row*t.width+col] = float32(v)
+			}
+		}
+	}
+
+	return &raster{
+		west:   t.west,
+		north:  t.north,
+		dLon:   t.dLon,
+		dLat:   t.dLat,
+		width:  t.width,
+		height: t.height,
+		values: values,
+	}, nil
+}
@@ -0,0 +1,85 @@
+package dem_provider
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hgtVoid marks samples without data in SRTM tiles
+const hgtVoid = -32768
+
+// hgtName matches SRTM tile names such as N47E008.hgt, named after the tile's south-west corner
+var hgtName = regexp.MustCompile(`^([NS])(\d{2})([EW])(\d{3})\.HGT$`)
+
+// parseHGTName returns the latitude and longitude of the south-west corner of an SRTM tile from its file name
+func parseHGTName(path string) (lat, lon int, err error) {
+	m := hgtName.FindStringSubmatch(strings.ToUpper(filepath.Base(path)))
+	if m == nil {
+		return 0, 0, fmt.Errorf("not an SRTM tile name: %s", filepath.Base(path))
+	}
+	lat, _ = strconv.Atoi(m[2])
+	lon, _ = strconv.Atoi(m[4])
+	if m[1] == "S" {
+		lat = -lat
+	}
+	if m[3] == "W" {
+		lon = -lon
+	}
+	return lat, lon, nil
+}
+
+// hgtBounds returns the area covered by an SRTM tile without reading it. Samples lie on the whole-degree
+// grid lines, so a tile covers exactly one degree by one degree.
+func hgtBounds(path string) (bounds, error) {
+	lat, lon, err := parseHGTName(path)
+	if err != nil {
+		return bounds{}, err
+	}
+	return bounds{minLat: float64(lat), maxLat: float64(lat + 1), minLon: float64(lon), maxLon: float64(lon + 1)}, nil
+}
+
+// readHGT reads an SRTM tile: a square grid of big-endian int16 heights in meters, 3601 samples a side for
+// 1 arc second tiles and 1201 for 3 arc second tiles, from the north-west corner row by row
+func readHGT(path string) (*raster, error) {
+	lat, lon, err := parseHGTName(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SRTM tile: %w", err)
+	}
+
+	size := int(math.Sqrt(float64(len(data) / 2)))
+	if size < 2 || size*size*2 != len(data) {
+		return nil, fmt.Errorf("SRTM tile %s has %d bytes, not a square grid of int16 samples", filepath.Base(path), len(data))
+	}
+
+	values := make([]float32, size*size)
+	for i := range values {
+		v := int16(binary.BigEndian.Uint16(data[2*i:]))
+		if v == hgtVoid {
+			values[i] = float32(math.NaN())
+		} else {
+			values[i] = float32(v)
+		}
+	}
+
+	spacing := 1 / float64(size-1)
+	return &raster{
+		west:   float64(lon),
+		north:  float64(lat + 1),
+		dLon:   spacing,
+		dLat:   spacing,
+		width:  size,
+		height: size,
+		values: values,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package dem_provider
+
+import (
+	"math"
+)
+
+// raster is a grid of elevation samples in geographic coordinates. Rows run from north to south.
+type raster struct {
+	west, north float64 // longitude and latitude of the center of the first sample
+	dLon, dLat  float64 // spacing between samples in degrees, both positive
+	width       int
+	height      int
+	values      []float32 // row-major, NaN for voids
+}
+
+// bounds is the area covered by a raster, up to half a sample beyond the outer sample centers
+type bounds struct {
+	minLat, maxLat, minLon, maxLon float64
+}
+
+func (b bounds) contains(lat, lon float64) bool {
+	return lat >= b.minLat && lat <= b.maxLat && lon >= b.minLon && lon <= b.maxLon
+}
+
+// bounds returns the area covered by the raster
+func (r *raster) bounds() bounds {
+	return bounds{
+		minLat: r.north - float64(r.height-1)*r.dLat - r.dLat/2,
+		maxLat: r.north + r.dLat/2,
+		minLon: r.west - r.dLon/2,
+		maxLon: r.west + float64(r.width-1)*r.dLon + r.dLon/2,
+	}
+}
+
+// heightAt bilinearly interpolates the elevation at a point from the four surrounding samples.
+// Voids are left out and the remaining weights renormalized; reports false when all four are voids
+// or the point is outside the raster.
+func (r *raster) heightAt(lat, lon float64) (float64, bool) {
+	if !r.bounds().contains(lat, lon) {
+		return 0, false
+	}
+
+	// Fractional sample position, clamped so points in the outer half sample use the edge samples
+	x := math.Max(0, math.Min(float64(r.width-1), (lon-r.west)/r.dLon))
+	y := math.Max(0, math.Min(float64(r.height-1), (r.north-lat)/r.dLat))
+
+	x0, y0 := int(x), int(y)
+	x1, y1 := min(x0+1, r.width-1), min(y0+1, r.height-1)
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	corners := [4]struct {
+		col, row int
+		weight   float64
+	}{
+		{x0, y0, (1 - fx) * (1 - fy)},
+		{x1, y0, fx * (1 - fy)},
+		{x0, y1, (1 - fx) * fy},
+		{x1, y1, fx * fy},
+	}
+
+	var sum, weights float64
+	for _, c := range corners {
+		v := r.values[c.row*r.width+c.col]
+		if math.IsNaN(float64(v)) || c.weight == 0 {
+			continue
+		}
+		sum += float64(v) * c.weight
+		weights += c.weight
+	}
+	if weights == 0 {
+		return 0, false
+	}
+	return sum / weights, true
+}
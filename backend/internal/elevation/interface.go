@@ -0,0 +1,21 @@
+package elevation
+
+import (
+	"context"
+
+	"github.com/anish-chanda/cadent/backend/internal/valhalla"
+)
+
+// Provider looks up terrain heights for the points of a track. valhalla.Client is the hosted implementation,
+// dem_provider.Provider reads elevation model tiles from a local directory.
+type Provider interface {
+	// GetHeight returns one height per point of the request's encoded polyline (precision 6), in order.
+	// Heights are nil for points the provider has no data for.
+	GetHeight(ctx context.Context, req valhalla.HeightRequest) (*valhalla.HeightResponse, error)
+}
+
+// Supported provider types
+const (
+	ProviderValhalla = "valhalla" // Valhalla /height API
+	ProviderDEM      = "dem"      // local SRTM .hgt or GeoTIFF tiles
+)
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
@@ -10,11 +11,13 @@ import (
 	"time"
 
 	"github.com/anish-chanda/cadent/backend/internal/compression"
+	"github.com/anish-chanda/cadent/backend/internal/elevation"
 	"github.com/anish-chanda/cadent/backend/internal/geo"
 	"github.com/anish-chanda/cadent/backend/internal/logger"
 	"github.com/anish-chanda/cadent/backend/internal/models"
 	"github.com/anish-chanda/cadent/backend/internal/store"
 	"github.com/anish-chanda/cadent/backend/internal/valhalla"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-pkgz/auth/v2/token"
 	"github.com/google/uuid"
 	"github.com/muktihari/fit/encoder"
@@ -35,6 +38,13 @@ type CreateActivityRequest struct {
 	Description      *string   `json:"description"`
 	PerceivedEffort  *int16    `json:"perceived_effort"`
 	Samples          []Sample  `json:"samples"`
+	Timezone         string    `json:"timezone"` // IANA name used to find same-day planned activities, defaults to UTC
+
+	// Timer pauses from the recording device; when omitted, stops are detected from sample speed
+	Pauses []TimerPause `json:"pauses,omitempty"`
+
+	// Snap map-matches the track to the road and path network; the raw track is kept with the activity
+	Snap bool `json:"snap,omitempty"`
 }
 
 type Sample struct {
@@ -42,6 +52,11 @@ type Sample struct {
 	Lat float64  `json:"lat"`           // latitude
 	Lon float64  `json:"lon"`           // longitude
 	Ele *float64 `json:"ele,omitempty"` // elevation in meters (optional)
+
+	HR          *int     `json:"hr,omitempty"`          // heart rate in bpm (optional)
+	Cadence     *int     `json:"cadence,omitempty"`     // cadence in rpm or steps per minute (optional)
+	Power       *int     `json:"power,omitempty"`       // power in watts (optional)
+	Temperature *float64 `json:"temperature,omitempty"` // temperature in degrees celsius (optional)
 }
 
 // FullResolutionStream holds full-resolution stream data for all points
@@ -50,17 +65,39 @@ type FullResolutionStream struct {
 	DistanceM  []float64 // cumulative distance in meters
 	ElevationM []float64 // elevation in meters
 	SpeedMps   []float64 // speed in meters per second
+	GradePct   []float64 // smoothed grade in percent, see gradeStream
+
+	// Coordinates in degrees, nil for streams built without positions
+	Lat []float64
+	Lon []float64
+
+	// Sensor streams are nil when no sample carried the sensor
+	HeartRateBpm []float64 // heart rate in beats per minute
+	CadenceRpm   []float64 // cadence in rpm or steps per minute
+	PowerWatt    []float64 // power in watts
+	TemperatureC []float64 // temperature in degrees celsius
 }
 
 type ActivityStats struct {
-	ElapsedSeconds float64      `json:"elapsed_seconds"`
-	AvgSpeedMs     float64      `json:"avg_speed_ms"`     // meters per second (SI unit)
-	ElevationGainM float64      `json:"elevation_gain_m"` // elevation gain in meters
-	ElevationLossM float64      `json:"elevation_loss_m"` // elevation loss in meters
-	MaxHeightM     float64      `json:"max_height_m"`     // maximum height in meters
-	MinHeightM     float64      `json:"min_height_m"`     // minimum height in meters
-	DistanceM      float64      `json:"distance_m"`       // distance in meters
-	Derived        DerivedStats `json:"derived"`
+	ElapsedSeconds     float64      `json:"elapsed_seconds"`
+	AvgSpeedMs         float64      `json:"avg_speed_ms"`                      // meters per second (SI unit)
+	MovingSeconds      *float64     `json:"moving_seconds,omitempty"`          // omitted for activities without moving time
+	MovingAvgSpeed     *float64     `json:"moving_avg_speed_ms,omitempty"`     // meters per second over moving time
+	GradeAdjustedSpeed *float64     `json:"grade_adjusted_speed_ms,omitempty"` // flat-equivalent moving speed, runs only
+	ElevationGainM     float64      `json:"elevation_gain_m"`                  // elevation gain in meters
+	ElevationLossM     float64      `json:"elevation_loss_m"`                  // elevation loss in meters
+	MaxHeightM         float64      `json:"max_height_m"`                      // maximum height in meters
+	MinHeightM         float64      `json:"min_height_m"`                      // minimum height in meters
+	DistanceM          float64      `json:"distance_m"`                        // distance in meters
+	Derived            DerivedStats `json:"derived"`
+
+	// Sensor summaries, omitted when the activity has no data for the sensor
+	AvgHRBpm      *int16 `json:"avg_hr_bpm,omitempty"`
+	MaxHRBpm      *int16 `json:"max_hr_bpm,omitempty"`
+	AvgCadenceRpm *int16 `json:"avg_cadence_rpm,omitempty"`
+	MaxCadenceRpm *int16 `json:"max_cadence_rpm,omitempty"`
+	AvgPowerWatt  *int16 `json:"avg_power_watt,omitempty"`
+	MaxPowerWatt  *int16 `json:"max_power_watt,omitempty"`
 }
 
 type DerivedStats struct {
@@ -70,6 +107,16 @@ type DerivedStats struct {
 	PaceSPerMile  *float64 `json:"pace_s_per_mile,omitempty"`
 	DistanceKm    float64  `json:"distance_km"`
 	DistanceMiles float64  `json:"distance_miles"`
+
+	// Speed and pace over moving time, set alongside the elapsed versions above
+	MovingSpeedKmh     *float64 `json:"moving_speed_kmh,omitempty"`
+	MovingSpeedMph     *float64 `json:"moving_speed_mph,omitempty"`
+	MovingPaceSPerKm   *float64 `json:"moving_pace_s_per_km,omitempty"`
+	MovingPaceSPerMile *float64 `json:"moving_pace_s_per_mile,omitempty"`
+
+	// Grade-adjusted pace, set for runs with a grade-adjusted speed
+	GradeAdjustedPaceSPerKm   *float64 `json:"grade_adjusted_pace_s_per_km,omitempty"`
+	GradeAdjustedPaceSPerMile *float64 `json:"grade_adjusted_pace_s_per_mile,omitempty"`
 }
 
 type BoundingBox struct {
@@ -97,9 +144,15 @@ type ActivityResult struct {
 	Start           Coordinate    `json:"start"`
 	End             Coordinate    `json:"end"`
 	Polyline        string        `json:"polyline"`
+	RawPolyline     *string       `json:"raw_polyline,omitempty"` // original GPS track of snapped activities
 	ProcessingVer   int           `json:"processing_ver"`
 	CreatedAt       time.Time     `json:"created_at"`
 	UpdatedAt       time.Time     `json:"updated_at"`
+
+	MatchedPlannedActivityID *string  `json:"matched_planned_activity_id,omitempty"`
+	NewRecords               []string `json:"new_records,omitempty"` // best efforts that set a personal record
+
+	Zones []ActivityZonesResult `json:"zones,omitempty"` // time in zone, only in the activity detail
 }
 
 type PlannedActivityResult struct {
@@ -115,19 +168,27 @@ type PlannedActivityResult struct {
 	TargetPowerWatt       *int     `json:"target_power_watt"`
 	IsDryRun             bool      `json:"is_dry_run,omitempty"`
 	MatchedActivityID    *string   `json:"matched_activity_id,omitempty"`
+	RouteID              *string   `json:"route_id,omitempty"`
 	UserTrainingPlanID   *string   `json:"user_training_plan_id,omitempty"`
 	PlanSequenceIndex    *int      `json:"plan_sequence_index,omitempty"`
 	CreatedAt            time.Time `json:"created_at"`
 	UpdatedAt            time.Time `json:"updated_at"`
+
+	Compliance *WorkoutCompliance `json:"compliance,omitempty"`
 }
 
 type GetCalendarResponse struct {
 	Activities        []ActivityResult        `json:"activities"`
 	PlannedActivities []PlannedActivityResult `json:"planned_activities"`
+
+	WeeklyCompliance []WeeklyCompliance       `json:"weekly_compliance,omitempty"`
+	PlanCompliance   []TrainingPlanCompliance `json:"plan_compliance,omitempty"`
+	WeeklyZones      []WeeklyZoneDistribution `json:"weekly_zones,omitempty"`
 }
 
 type GetActivitiesResponse struct {
 	Activities []ActivityResult `json:"activities"`
+	NextCursor *string          `json:"next_cursor"` // nil on the last page
 }
 
 func (h *Handler) HandleCreateActivity() http.HandlerFunc {
@@ -159,6 +220,18 @@ func (h *Handler) HandleCreateActivity() http.HandlerFunc {
 			http.Error(w, "perceived_effort must be between 1 and 10", http.StatusBadRequest)
 			return
 		}
+		loc, err := parseTimezone(req.Timezone)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		for i, pause := range req.Pauses {
+			if pause.EndT < pause.StartT {
+				http.Error(w, fmt.Sprintf("Pause %d: end_t must not be before start_t", i+1), http.StatusBadRequest)
+				return
+			}
+		}
 
 		// Validate sample data completeness
 		for i, sample := range req.Samples {
@@ -202,15 +275,27 @@ func (h *Handler) HandleCreateActivity() http.HandlerFunc {
 		// Process GPS data to create polyline and calculate metrics
 		polyline, totalDistance, bounds := processGPSData(req.Samples)
 
-		// Get elevation data from Valhalla
-		elevationData, elevationHeights := getElevationDataAndHeights(ctx, h.valhallaClient, polyline, h.log)
+		// Get elevation data from the elevation provider
+		elevationData, elevationHeights := getElevationDataAndHeights(ctx, h.elevationProvider(), polyline, h.log)
+
+		// Snap the track to the map if requested; the stored FIT file keeps the raw GPS positions
+		rawSamples := req.Samples
+		var rawPolyline *string
+		if req.Snap {
+			req.Samples, rawPolyline = h.snapTrack(ctx, models.ActivityType(req.ActivityType), req.Samples)
+			if rawPolyline != nil {
+				polyline, totalDistance, bounds = processGPSData(req.Samples)
+			}
+		}
 
 		// Calculate time-based metrics
 		elapsedSeconds := calculateElapsedSeconds(req.Samples)
+		movingSeconds := calculateMovingSeconds(req.Samples, req.Pauses)
 		avgSpeedMs := calculateAverageSpeed(totalDistance, elapsedSeconds)
 
 		// Create activity model
-		activity := buildActivityModel(req, userID, polyline, totalDistance, bounds, elevationData, elapsedSeconds, avgSpeedMs)
+		activity := buildActivityModel(req, userID, polyline, totalDistance, bounds, elevationData, elapsedSeconds, movingSeconds, avgSpeedMs)
+		activity.RawPolyline = rawPolyline
 
 		// Process full-resolution streams
 		fullStream := processFullResolutionStreams(req.Samples, elevationData, elevationHeights)
@@ -221,13 +306,10 @@ func (h *Handler) HandleCreateActivity() http.HandlerFunc {
 			http.Error(w, "Internal stream processing error", http.StatusInternalServerError)
 			return
 		}
+		activity.AvgGAPMps = gradeAdjustedSpeed(activity.ActivityType, fullStream, movingSeconds)
 
-		// Create medium LOD streams using distance decimation
-		mediumTargetPoints := MediumLODTargetPoints
-		keepIndices := decimateByDistance(fullStream, mediumTargetPoints)
-
-		// Create compressed medium LOD streams
-		activityStreams, err := createCompressedStreams(activity.ID, keepIndices, fullStream)
+		// Create compressed medium LOD streams, decimated by distance and by time
+		activityStreams, err := createMediumLODStreams(activity.ID, fullStream, h.streamDecimation())
 		if err != nil {
 			h.log.Error("Failed to create compressed streams", err)
 			http.Error(w, "Failed to process stream data", http.StatusInternalServerError)
@@ -235,7 +317,7 @@ func (h *Handler) HandleCreateActivity() http.HandlerFunc {
 		}
 
 		// Create and store FIT file
-		if err := createAndStoreFITFile(ctx, activity, req.Samples, h.objectStore, h.log); err != nil {
+		if err := createAndStoreFITFile(ctx, activity, rawSamples, h.objectStore, h.log); err != nil {
 			h.log.Error("Failed to create FIT file", err)
 			http.Error(w, "Failed to create FIT file", http.StatusInternalServerError)
 			return
@@ -259,10 +341,22 @@ func (h *Handler) HandleCreateActivity() http.HandlerFunc {
 			}
 		}
 
+		// Splits are computed from the same full-resolution stream
+		h.saveActivitySplits(ctx, activity.ID, fullStream, req.Samples, req.Pauses)
+		newRecords := h.saveBestEfforts(ctx, activity, fullStream)
+		settings := h.userTrainingSettings(ctx, userID)
+		h.saveTrainingStress(ctx, activity, fullStream, req.Samples, req.Pauses, trainingThresholdsFor(settings, time.Now()), loc, true)
+		h.saveZoneTimes(ctx, activity, fullStream, req.Samples, req.Pauses, settings)
+
 		h.log.Debug(fmt.Sprintf("Created activity: %s for user: %s", activity.ID.String(), userID))
 
+		// Link to the best planned activity on the same local day
+		matchedPlanID := h.autoMatchPlannedActivity(ctx, activity, loc)
+
 		// Build and return response
 		result := createActivityResult(activity)
+		result.MatchedPlannedActivityID = matchedPlanID
+		result.NewRecords = newRecords
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
 		_ = json.NewEncoder(w).Encode(result)
@@ -368,18 +462,27 @@ func processGPSData(samples []Sample) (string, float64, Bounds) {
 	return polyline, totalDistance, bounds
 }
 
-// Helper function to get elevation data and heights from Valhalla, returns calculated
+// Helper function to get elevation data and heights from the elevation provider, returns calculated
 // elevation change and array of elevation heights
-func getElevationDataAndHeights(ctx context.Context, valhallaClient *valhalla.Client, polyline string, log *logger.ServiceLogger) (*valhalla.ElevationChange, []float64) {
+func getElevationDataAndHeights(ctx context.Context, provider elevation.Provider, polyline string, log *logger.ServiceLogger) (*valhalla.ElevationChange, []float64) {
+	if provider == nil {
+		log.Warn("No elevation provider configured, skipping elevation lookup")
+		return nil, nil
+	}
+
 	heightReq := valhalla.HeightRequest{
 		Range:           false, // range off
 		EncodedPolyline: polyline,
 		HeightPrecision: 2, // Request 2 decimal places precision
 	}
 
-	heightResp, err := valhallaClient.GetHeight(ctx, heightReq)
+	heightResp, err := provider.GetHeight(ctx, heightReq)
+	if errors.Is(err, valhalla.ErrCircuitOpen) {
+		log.Warn("Valhalla is unavailable, skipping elevation lookup")
+		return nil, nil
+	}
 	if err != nil {
-		log.Error("Failed to get height data from Valhalla", err)
+		log.Error("Failed to get height data from elevation provider", err)
 		return nil, nil
 	}
 
@@ -513,6 +616,22 @@ func validateStreamAlignment(stream *FullResolutionStream, expectedLength int) e
 		return fmt.Errorf("speedMps array length mismatch: expected %d, got %d", expectedLength, len(stream.SpeedMps))
 	}
 
+	// Coordinates and sensor streams are optional but must line up with the other streams when present
+	sensors := map[string][]float64{
+		"lat":          stream.Lat,
+		"lon":          stream.Lon,
+		"gradePct":     stream.GradePct,
+		"heartRateBpm": stream.HeartRateBpm,
+		"cadenceRpm":   stream.CadenceRpm,
+		"powerWatt":    stream.PowerWatt,
+		"temperatureC": stream.TemperatureC,
+	}
+	for name, values := range sensors {
+		if values != nil && len(values) != expectedLength {
+			return fmt.Errorf("%s array length mismatch: expected %d, got %d", name, expectedLength, len(values))
+		}
+	}
+
 	// Validate that arrays are properly aligned (first distance should be 0, first time should be 0)
 	if expectedLength > 0 {
 		if stream.DistanceM[0] != 0 {
@@ -526,8 +645,26 @@ func validateStreamAlignment(stream *FullResolutionStream, expectedLength int) e
 	return nil
 }
 
+// createMediumLODStreams creates the compressed medium LOD streams of every index mode, one decimated along
+// distance and one along time. The decimation strategy is recorded in each stream's codec.
+func createMediumLODStreams(activityID uuid.UUID, fullStream *FullResolutionStream, strategy DecimationStrategy) ([]models.ActivityStream, error) {
+	var streams []models.ActivityStream
+	for _, indexBy := range []models.StreamIndexBy{models.StreamIndexByDistance, models.StreamIndexByTime} {
+		keepIndices := decimate(fullStream, indexBy, strategy, MediumLODTargetPoints)
+		indexed, err := createCompressedStreams(activityID, indexBy, keepIndices, fullStream)
+		if err != nil {
+			return nil, err
+		}
+		for i := range indexed {
+			indexed[i].Codec["decimation"] = string(strategy)
+		}
+		streams = append(streams, indexed...)
+	}
+	return streams, nil
+}
+
 // createCompressedStreams creates compressed activity streams for medium LOD
-func createCompressedStreams(activityID uuid.UUID, keepIndices []int, fullStream *FullResolutionStream) ([]models.ActivityStream, error) {
+func createCompressedStreams(activityID uuid.UUID, indexBy models.StreamIndexBy, keepIndices []int, fullStream *FullResolutionStream) ([]models.ActivityStream, error) {
 	if len(keepIndices) == 0 {
 		return nil, fmt.Errorf("no indices to keep for medium LOD")
 	}
@@ -552,18 +689,36 @@ func createCompressedStreams(activityID uuid.UUID, keepIndices []int, fullStream
 	distanceBytes, distanceCodec := compressDIBS(decimatedDistance, 2)
 	elevationBytes, elevationCodec := compressDIBS(decimatedElevation, 2)
 	speedBytes, speedCodec := compressDIBS(decimatedSpeed, 3)
+	gradeBytes := compressSensorStream(fullStream.GradePct, keepIndices, 1)
+
+	// Sensor streams are only stored when the source recorded them
+	heartRateBytes := compressSensorStream(fullStream.HeartRateBpm, keepIndices, 0)
+	cadenceBytes := compressSensorStream(fullStream.CadenceRpm, keepIndices, 0)
+	powerBytes := compressSensorStream(fullStream.PowerWatt, keepIndices, 0)
+	temperatureBytes := compressSensorStream(fullStream.TemperatureC, keepIndices, 1)
+
+	// Coordinates at 6 decimal places (~0.1 m), aligned with the streams above for map-chart synchronization
+	latBytes := compressSensorStream(fullStream.Lat, keepIndices, 6)
+	lonBytes := compressSensorStream(fullStream.Lon, keepIndices, 6)
 
 	// Create activity stream record
 	stream := models.ActivityStream{
 		ActivityID:        activityID,
 		LOD:               models.StreamLODMedium,
-		IndexBy:           models.StreamIndexByDistance,
+		IndexBy:           indexBy,
 		NumPoints:         len(keepIndices),
 		OriginalNumPoints: len(fullStream.TimeS),
 		TimeSBytes:        timeBytes,
 		DistanceMBytes:    distanceBytes,
 		SpeedMpsBytes:     speedBytes,
 		ElevationMBytes:   elevationBytes,
+		HeartRateBpmBytes: heartRateBytes,
+		CadenceRpmBytes:   cadenceBytes,
+		PowerWattBytes:    powerBytes,
+		TemperatureCBytes: temperatureBytes,
+		LatBytes:          latBytes,
+		LonBytes:          lonBytes,
+		GradePctBytes:     gradeBytes,
 		Codec:             timeCodec, // Using time codec as representative
 		CreatedAt:         now,
 		UpdatedAt:         now,
@@ -590,15 +745,15 @@ func calculateElapsedSeconds(samples []Sample) float64 {
 // Helper function to calculate average speed
 func calculateAverageSpeed(distanceMeters, elapsedSeconds float64) float64 {
 	if elapsedSeconds > 0 {
-		// TODO: this should use moving time in the future
 		return distanceMeters / elapsedSeconds // meters per second (SI unit)
 	}
 	return 0
 }
 
 // Helper function to build the Activity model
-func buildActivityModel(req CreateActivityRequest, userID string, polyline string, totalDistance float64, bounds Bounds, elevationData *valhalla.ElevationChange, elapsedSeconds, avgSpeedMs float64) *models.Activity {
+func buildActivityModel(req CreateActivityRequest, userID string, polyline string, totalDistance float64, bounds Bounds, elevationData *valhalla.ElevationChange, elapsedSeconds, movingSeconds, avgSpeedMs float64) *models.Activity {
 	now := time.Now()
+	movingTime := int(movingSeconds)
 	startTime := time.Unix(req.Samples[0].T/1000, 0)
 	endTime := time.Unix(req.Samples[len(req.Samples)-1].T/1000, 0)
 
@@ -613,6 +768,7 @@ func buildActivityModel(req CreateActivityRequest, userID string, polyline strin
 		StartTime:        startTime,
 		EndTime:          &endTime,
 		ElapsedTime:      int(elapsedSeconds),
+		MovingTime:       &movingTime,
 		DistanceM:        totalDistance,
 		AvgSpeedMps:      &avgSpeedMs,
 		ProcessingVer:    1,
@@ -641,6 +797,11 @@ func buildActivityModel(req CreateActivityRequest, userID string, polyline strin
 		activity.MinHeightM = &elevationData.MinHeight
 	}
 
+	// Zero heart rate and cadence are sensor dropouts or stops; zero power is coasting and counts
+	activity.AvgHRBpm, activity.MaxHRBpm = summarizeSensor(req.Samples, func(s Sample) *int { return s.HR }, false)
+	activity.AvgCadenceRpm, activity.MaxCadenceRpm = summarizeSensor(req.Samples, func(s Sample) *int { return s.Cadence }, false)
+	activity.AvgPowerWatt, activity.MaxPowerWatt = summarizeSensor(req.Samples, func(s Sample) *int { return s.Power }, true)
+
 	return activity
 }
 
@@ -666,6 +827,23 @@ func createActivityResult(activity *models.Activity) ActivityResult {
 	// Calculate average speed from stored data
 	avgSpeedMs := floatOrDefault(activity.AvgSpeedMps, 0.0)
 
+	derived := calculateDerivedStats(string(activity.ActivityType), avgSpeedMs, activity.DistanceM)
+
+	// Moving time is only known for activities processed since it was introduced
+	var movingSeconds, movingAvgSpeed *float64
+	if activity.MovingTime != nil {
+		moving := float64(*activity.MovingTime)
+		movingSeconds = &moving
+		speed := calculateAverageSpeed(activity.DistanceM, moving)
+		movingAvgSpeed = &speed
+		addMovingDerivedStats(&derived, string(activity.ActivityType), speed)
+	}
+	if activity.AvgGAPMps != nil {
+		gap := calculateDerivedStats(string(activity.ActivityType), *activity.AvgGAPMps, 0)
+		derived.GradeAdjustedPaceSPerKm = gap.PaceSPerKm
+		derived.GradeAdjustedPaceSPerMile = gap.PaceSPerMile
+	}
+
 	return ActivityResult{
 		ID:              activity.ID.String(),
 		Title:           activity.Title,
@@ -676,14 +854,23 @@ func createActivityResult(activity *models.Activity) ActivityResult {
 		EndTime:         activity.EndTime,
 		ProcessingVer:   activity.ProcessingVer,
 		Stats: ActivityStats{
-			ElapsedSeconds: elapsedSeconds,
-			AvgSpeedMs:     avgSpeedMs,
-			ElevationGainM: floatOrDefault(activity.ElevationGainM, 0),
-			ElevationLossM: floatOrDefault(activity.ElevationLossM, 0),
-			MaxHeightM:     floatOrDefault(activity.MaxHeightM, 0),
-			MinHeightM:     floatOrDefault(activity.MinHeightM, 0),
-			DistanceM:      activity.DistanceM,
-			Derived:        calculateDerivedStats(string(activity.ActivityType), avgSpeedMs, activity.DistanceM),
+			ElapsedSeconds:     elapsedSeconds,
+			AvgSpeedMs:         avgSpeedMs,
+			MovingSeconds:      movingSeconds,
+			MovingAvgSpeed:     movingAvgSpeed,
+			GradeAdjustedSpeed: activity.AvgGAPMps,
+			ElevationGainM:     floatOrDefault(activity.ElevationGainM, 0),
+			ElevationLossM:     floatOrDefault(activity.ElevationLossM, 0),
+			MaxHeightM:         floatOrDefault(activity.MaxHeightM, 0),
+			MinHeightM:         floatOrDefault(activity.MinHeightM, 0),
+			DistanceM:          activity.DistanceM,
+			Derived:            derived,
+			AvgHRBpm:           activity.AvgHRBpm,
+			MaxHRBpm:           activity.MaxHRBpm,
+			AvgCadenceRpm:      activity.AvgCadenceRpm,
+			MaxCadenceRpm:      activity.MaxCadenceRpm,
+			AvgPowerWatt:       activity.AvgPowerWatt,
+			MaxPowerWatt:       activity.MaxPowerWatt,
 		},
 		BBox: BoundingBox{
 			MinLat: floatOrDefault(activity.BBoxMinLat, 0),
@@ -699,9 +886,10 @@ func createActivityResult(activity *models.Activity) ActivityResult {
 			Lat: floatOrDefault(activity.EndLat, 0),
 			Lon: floatOrDefault(activity.EndLon, 0),
 		},
-		Polyline:  stringOrDefault(activity.Polyline, ""),
-		CreatedAt: activity.CreatedAt,
-		UpdatedAt: activity.UpdatedAt,
+		Polyline:    stringOrDefault(activity.Polyline, ""),
+		RawPolyline: activity.RawPolyline,
+		CreatedAt:   activity.CreatedAt,
+		UpdatedAt:   activity.UpdatedAt,
 	}
 }
 
@@ -713,6 +901,11 @@ func createPlannedActivityResult(PlannedActivity *models.PlannedActivity) Planne
 		s := PlannedActivity.MatchedActivityID.String()
 		matchedActivityId = &s
 	}
+	var routeId *string
+	if PlannedActivity.RouteID != nil {
+		s := PlannedActivity.RouteID.String()
+		routeId = &s
+	}
 	var userTrainingPlanId *string
 	if PlannedActivity.UserTrainingPlanID != nil {
 		s := PlannedActivity.UserTrainingPlanID.String()
@@ -731,6 +924,7 @@ func createPlannedActivityResult(PlannedActivity *models.PlannedActivity) Planne
 		TargetAvgSpeedMps:     PlannedActivity.TargetAvgSpeedMps,
 		TargetPowerWatt:       PlannedActivity.TargetPowerWatt,
 		MatchedActivityID:    matchedActivityId,
+		RouteID:              routeId,
 		UserTrainingPlanID:   userTrainingPlanId,
 		PlanSequenceIndex:    PlannedActivity.PlanSequenceIndex,
 		CreatedAt:            PlannedActivity.CreatedAt,
@@ -749,14 +943,31 @@ func (h *Handler) HandleGetActivities() http.HandlerFunc {
 			return
 		}
 
+		listQuery, err := parseActivityListQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Fetch one extra activity to find out whether there is a next page
+		pageSize := listQuery.Limit
+		listQuery.Limit = pageSize + 1
+
 		// Get user's activities from database
-		activities, err := h.database.GetActivitiesByUserID(ctx, userID)
+		activities, err := h.database.GetActivitiesByUserID(ctx, userID, listQuery)
 		if err != nil {
 			h.log.Error("Failed to get activities from database", err)
 			http.Error(w, "Failed to retrieve activities", http.StatusInternalServerError)
 			return
 		}
 
+		var nextCursor *string
+		if len(activities) > pageSize {
+			activities = activities[:pageSize]
+			cursor := encodeActivityCursor(activities[pageSize-1], listQuery)
+			nextCursor = &cursor
+		}
+
 		// Transform activities to the response format using the unified helper function
 		// Initialize as empty slice to ensure we always return [] instead of null
 		results := make([]ActivityResult, 0, len(activities))
@@ -767,6 +978,7 @@ func (h *Handler) HandleGetActivities() http.HandlerFunc {
 
 		response := GetActivitiesResponse{
 			Activities: results,
+			NextCursor: nextCursor,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -774,6 +986,271 @@ func (h *Handler) HandleGetActivities() http.HandlerFunc {
 	}
 }
 
+// HandleGetActivity serves a single activity with its time in each heart rate, pace and power zone
+func (h *Handler) HandleGetActivity() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.Background()
+
+		activityID := chi.URLParam(r, "id")
+		if _, err := uuid.Parse(activityID); err != nil {
+			http.Error(w, "Invalid activity ID format", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		activity, err := h.database.GetActivityByID(ctx, activityID)
+		if err != nil {
+			h.log.Error("Failed to get activity from database", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if activity == nil || activity.UserID != userID {
+			http.Error(w, "Activity not found", http.StatusNotFound) // Return 404 instead of 403
+			return
+		}
+
+		zones, err := h.database.GetActivityZoneTimes(ctx, []string{activityID})
+		if err != nil {
+			h.log.Error("Failed to get zone times from database", err)
+			http.Error(w, "Failed to retrieve activity", http.StatusInternalServerError)
+			return
+		}
+
+		result := createActivityResult(activity)
+		result.Zones = buildActivityZones(zones)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(result)
+	}
+}
+
+// HandleUpdateActivity updates the user-editable fields of an activity: title, description, type and perceived_effort.
+// Changing the type to a different one rescores the activity's best efforts, training stress, zone times and
+// grade-adjusted pace for the new type; the optional timezone query parameter sets the local day of its training stress, like the timezone of
+// an upload.
+func (h *Handler) HandleUpdateActivity() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		activityID := chi.URLParam(r, "id")
+		if _, err := uuid.Parse(activityID); err != nil {
+			http.Error(w, "Invalid activity ID format", http.StatusBadRequest)
+			return
+		}
+
+		loc, err := parseTimezone(r.URL.Query().Get("timezone"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		// Decode into raw map to distinguish absent fields from explicit null
+		var rawFields map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&rawFields); err != nil {
+			h.log.Error("Failed to decode activity update request", err)
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		updates := make(map[string]interface{})
+
+		if raw, ok := rawFields["title"]; ok {
+			var title string
+			if string(raw) == "null" || json.Unmarshal(raw, &title) != nil {
+				http.Error(w, "title must be a string", http.StatusBadRequest)
+				return
+			}
+			title = strings.TrimSpace(title)
+			if title == "" {
+				http.Error(w, "title cannot be empty", http.StatusBadRequest)
+				return
+			}
+			updates["title"] = title
+		}
+
+		if raw, ok := rawFields["description"]; ok {
+			if string(raw) == "null" {
+				updates["description"] = nil
+			} else {
+				var desc string
+				if err := json.Unmarshal(raw, &desc); err != nil {
+					http.Error(w, "description must be a string", http.StatusBadRequest)
+					return
+				}
+				updates["description"] = desc
+			}
+		}
+
+		if raw, ok := rawFields["type"]; ok {
+			var activityType string
+			if string(raw) == "null" || json.Unmarshal(raw, &activityType) != nil {
+				http.Error(w, "type must be a string", http.StatusBadRequest)
+				return
+			}
+			if activityType != string(models.ActivityTypeRun) && activityType != string(models.ActivityTypeRoadBike) {
+				http.Error(w, fmt.Sprintf("Invalid activity_type: %s. Supported types: running, road_biking", activityType), http.StatusBadRequest)
+				return
+			}
+			updates["type"] = activityType
+		}
+
+		if raw, ok := rawFields["perceived_effort"]; ok {
+			if string(raw) == "null" {
+				updates["perceived_effort"] = nil
+			} else {
+				var effort int16
+				if err := json.Unmarshal(raw, &effort); err != nil || effort < 1 || effort > 10 {
+					http.Error(w, "perceived_effort must be between 1 and 10", http.StatusBadRequest)
+					return
+				}
+				updates["perceived_effort"] = effort
+			}
+		}
+
+		if len(updates) == 0 {
+			http.Error(w, "No updatable fields provided", http.StatusBadRequest)
+			return
+		}
+
+		// Clients may send the whole activity back, so only a different type is a retype
+		current, err := h.database.GetActivityByID(ctx, activityID)
+		if err != nil {
+			h.log.Error("Failed to get activity from database", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if current == nil || current.UserID != userID {
+			http.Error(w, "Activity not found", http.StatusNotFound)
+			return
+		}
+		newType, ok := updates["type"]
+		retyped := ok && newType != string(current.ActivityType)
+
+		if err := h.database.UpdateActivity(ctx, activityID, userID, updates); err != nil {
+			if err.Error() == "activity not found" {
+				http.Error(w, "Activity not found", http.StatusNotFound)
+				return
+			}
+			h.log.Error("Failed to update activity", err)
+			http.Error(w, "Failed to update activity", http.StatusInternalServerError)
+			return
+		}
+
+		activity, err := h.database.GetActivityByID(ctx, activityID)
+		if err != nil || activity == nil {
+			h.log.Error("Failed to reload updated activity", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if retyped {
+			h.rescoreRetypedActivity(ctx, activity, loc)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(createActivityResult(activity))
+	}
+}
+
+// rescoreRetypedActivity recomputes the best efforts, training stress, zone times and grade-adjusted pace of an
+// activity whose type was changed from its stored original file, since the update dropped the ones scored for the
+// old type, then rebuilds the training load. Activities without a stored file are left without them.
+func (h *Handler) rescoreRetypedActivity(ctx context.Context, activity *models.Activity, loc *time.Location) {
+	samples, metadata, fullStream, err := h.parseStoredActivityFile(ctx, activity)
+	if err == nil {
+		settings := h.userTrainingSettings(ctx, activity.UserID)
+		h.saveBestEfforts(ctx, activity, fullStream)
+		h.saveDerivedMetrics(ctx, activity, fullStream, samples, metadata.TimerPauses, settings, trainingThresholdsFor(settings, time.Now()), loc)
+
+		if gap := gradeAdjustedSpeed(activity.ActivityType, fullStream, calculateMovingSeconds(samples, metadata.TimerPauses)); gap != nil {
+			if err := h.database.UpdateActivity(ctx, activity.ID.String(), activity.UserID, map[string]interface{}{"avg_gap_mps": gap}); err != nil {
+				h.log.Error("Failed to save grade-adjusted pace", err)
+			} else {
+				activity.AvgGAPMps = gap
+			}
+		}
+	}
+
+	// The old score is gone even when there is no new one; start a day early since it was stored on its local day
+	if err := h.updateTrainingLoad(ctx, activity.UserID, trainingDay(activity.StartTime, time.UTC).AddDate(0, 0, -1)); err != nil {
+		h.log.Error("Failed to update training load", err)
+	}
+}
+
+// HandleDeleteActivity deletes an activity with its streams and stored original file.
+// Planned activities it satisfied become unmatched.
+func (h *Handler) HandleDeleteActivity() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		activityID := chi.URLParam(r, "id")
+		if _, err := uuid.Parse(activityID); err != nil {
+			http.Error(w, "Invalid activity ID format", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		// Load the activity first to know which stored file to remove
+		activity, err := h.database.GetActivityByID(ctx, activityID)
+		if err != nil {
+			h.log.Error("Failed to get activity from database", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if activity == nil || activity.UserID != userID {
+			http.Error(w, "Activity not found", http.StatusNotFound) // 404 instead of 403 for other users' activities
+			return
+		}
+
+		if err := h.database.DeleteActivity(ctx, activityID, userID); err != nil {
+			if err.Error() == "activity not found" {
+				http.Error(w, "Activity not found", http.StatusNotFound)
+				return
+			}
+			h.log.Error("Failed to delete activity", err)
+			http.Error(w, "Failed to delete activity", http.StatusInternalServerError)
+			return
+		}
+
+		// The activity's stress is gone with it; start a day early since it was stored on its local day
+		if err := h.updateTrainingLoad(ctx, userID, trainingDay(activity.StartTime, time.UTC).AddDate(0, 0, -1)); err != nil {
+			h.log.Error("Failed to update training load", err)
+		}
+
+		// The activity is gone at this point, so a leftover file is only logged
+		if activity.FileURL != nil && *activity.FileURL != "" {
+			fileKey := *activity.FileURL
+			if h.fullStreams != nil {
+				h.fullStreams.Invalidate(fileKey)
+			}
+			if h.objectStore != nil {
+				if err := h.objectStore.DeleteObject(ctx, fileKey); err != nil {
+					h.log.Error(fmt.Sprintf("Failed to delete stored file %s for activity %s", fileKey, activityID), err)
+				}
+			}
+		}
+
+		h.log.Info(fmt.Sprintf("Deleted activity %s for user %s", activityID, userID))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
 func (h *Handler) HandleGetActivityCalendar() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := context.Background()
@@ -810,6 +1287,13 @@ func (h *Handler) HandleGetActivityCalendar() http.HandlerFunc {
 			return
 		}
 
+		// Weekly rollups, weekly zone totals and missed workouts follow the local calendar of the user's timezone
+		loc, err := parseTimezone(r.URL.Query().Get("timezone"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		// Interpret endDate as inclusive through the end of the selected day.
 		endDate = endDate.Add(24*time.Hour - time.Nanosecond)
 
@@ -829,15 +1313,33 @@ func (h *Handler) HandleGetActivityCalendar() http.HandlerFunc {
 			resultActivities = append(resultActivities, result)
 		}
 
-		resultPlannedActivities := make([]PlannedActivityResult, 0, len(plannedActivities))
-		for _, plannedActivity := range plannedActivities {
-			plannedResult := createPlannedActivityResult(&plannedActivity)
-			resultPlannedActivities = append(resultPlannedActivities, plannedResult)
+		// Matched activities can fall outside the requested range, so fetch any that weren't returned
+		matched, err := h.loadMatchedActivities(ctx, userID, plannedActivities, activities)
+		if err != nil {
+			h.log.Error("Failed to get matched activities from database", err)
+			http.Error(w, "Failed to retrieve activities", http.StatusInternalServerError)
+			return
+		}
+
+		resultPlannedActivities, weeklyCompliance, planCompliance := buildComplianceRollups(plannedActivities, matched, time.Now(), loc)
+
+		activityIDs := make([]string, 0, len(activities))
+		for _, activity := range activities {
+			activityIDs = append(activityIDs, activity.ID.String())
+		}
+		zones, err := h.database.GetActivityZoneTimes(ctx, activityIDs)
+		if err != nil {
+			h.log.Error("Failed to get zone times from database", err)
+			http.Error(w, "Failed to retrieve activities", http.StatusInternalServerError)
+			return
 		}
 
 		response := GetCalendarResponse{
 			Activities:        resultActivities,
 			PlannedActivities: resultPlannedActivities,
+			WeeklyCompliance:  weeklyCompliance,
+			PlanCompliance:    planCompliance,
+			WeeklyZones:       buildWeeklyZoneDistributions(activities, zones, loc),
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -870,6 +1372,19 @@ func createFITFile(ctx context.Context, activity *models.Activity, samples []Sam
 				SetPositionLat(int32(sample.Lat * 11930465)). // Convert to semicircles
 				SetPositionLong(int32(sample.Lon * 11930465)) // Convert to semicircles
 
+			if sample.HR != nil {
+				record.SetHeartRate(uint8(*sample.HR))
+			}
+			if sample.Cadence != nil {
+				record.SetCadence(uint8(*sample.Cadence))
+			}
+			if sample.Power != nil {
+				record.SetPower(uint16(*sample.Power))
+			}
+			if sample.Temperature != nil {
+				record.SetTemperature(int8(math.Round(*sample.Temperature)))
+			}
+
 			// Add distance and speed if we can calculate it
 			if i > 0 {
 				prevSample := samples[i-1]
@@ -902,6 +1417,9 @@ func createFITFile(ctx context.Context, activity *models.Activity, samples []Sam
 		if activity.AvgSpeedMps != nil && *activity.AvgSpeedMps > 0 {
 			session.SetAvgSpeed(uint16(*activity.AvgSpeedMps * 1000)) // Convert to mm/s
 		}
+		if activity.MovingTime != nil {
+			session.SetTotalTimerTime(uint32(*activity.MovingTime * 1000)) // milliseconds
+		}
 
 		fitActivity.Sessions = append(fitActivity.Sessions, session)
 
@@ -988,6 +1506,15 @@ func calculateDerivedStats(activityType string, speedMs float64, distanceM float
 	return derived
 }
 
+// addMovingDerivedStats fills the moving speed or pace of derived stats from the moving average speed
+func addMovingDerivedStats(derived *DerivedStats, activityType string, movingSpeedMs float64) {
+	moving := calculateDerivedStats(activityType, movingSpeedMs, 0)
+	derived.MovingSpeedKmh = moving.SpeedKmh
+	derived.MovingSpeedMph = moving.SpeedMph
+	derived.MovingPaceSPerKm = moving.PaceSPerKm
+	derived.MovingPaceSPerMile = moving.PaceSPerMile
+}
+
 // processFullResolutionStreams converts samples and elevation data into full-resolution stream arrays
 // Elevation priority: 1) sample.Ele if present, 2) elevationHeights from Valhalla, 3) default to 0
 func processFullResolutionStreams(samples []Sample, elevationData *valhalla.ElevationChange, elevationHeights []float64) *FullResolutionStream {
@@ -1001,6 +1528,8 @@ func processFullResolutionStreams(samples []Sample, elevationData *valhalla.Elev
 		DistanceM:  make([]float64, n),
 		ElevationM: make([]float64, n),
 		SpeedMps:   make([]float64, n),
+		Lat:        make([]float64, n),
+		Lon:        make([]float64, n),
 	}
 
 	// Start time for calculating seconds since start
@@ -1011,6 +1540,7 @@ func processFullResolutionStreams(samples []Sample, elevationData *valhalla.Elev
 	for i, sample := range samples {
 		// Time in seconds since start
 		stream.TimeS[i] = float64(sample.T-startTimeMs) / 1000.0
+		stream.Lat[i], stream.Lon[i] = sample.Lat, sample.Lon
 
 		// Cumulative distance
 		if i == 0 {
@@ -1055,44 +1585,65 @@ func processFullResolutionStreams(samples []Sample, elevationData *valhalla.Elev
 		}
 	}
 
+	// Grade is measured over a window of distance, so it needs the whole elevation profile
+	stream.GradePct = gradeStream(stream.DistanceM, stream.ElevationM)
+
+	// Third pass: sensor streams, gaps between readings are interpolated
+	stream.HeartRateBpm = sensorStream(samples, func(s Sample) *float64 { return intReading(s.HR) })
+	stream.CadenceRpm = sensorStream(samples, func(s Sample) *float64 { return intReading(s.Cadence) })
+	stream.PowerWatt = sensorStream(samples, func(s Sample) *float64 { return intReading(s.Power) })
+	stream.TemperatureC = sensorStream(samples, func(s Sample) *float64 { return s.Temperature })
+
 	return stream
 }
 
 // decimateByDistance performs Strava-style distance-uniform decimation to create medium LOD
 // Targets around MediumLODTargetPoints points by selecting points evenly spaced in distance
 func decimateByDistance(fullStream *FullResolutionStream, targetPoints int) []int {
-	if len(fullStream.DistanceM) <= targetPoints {
+	return decimateEvenly(fullStream.DistanceM, targetPoints)
+}
+
+// decimateByTime performs time-uniform decimation to create the time-indexed medium LOD.
+// Unlike distance decimation, which collapses a stop into a single point, stops keep their length on a time axis.
+func decimateByTime(fullStream *FullResolutionStream, targetPoints int) []int {
+	return decimateEvenly(fullStream.TimeS, targetPoints)
+}
+
+// decimateEvenly selects the indices of points closest to targetPoints evenly spaced values along a
+// non-decreasing axis, always keeping the first and last point
+func decimateEvenly(axis []float64, targetPoints int) []int {
+	if len(axis) <= targetPoints {
 		// If we already have fewer than target points, keep all
-		indices := make([]int, len(fullStream.DistanceM))
+		indices := make([]int, len(axis))
 		for i := range indices {
 			indices[i] = i
 		}
 		return indices
 	}
 
-	totalDistance := fullStream.DistanceM[len(fullStream.DistanceM)-1]
-	if totalDistance <= 0 {
-		// If no distance, just return first and last points
-		return []int{0, len(fullStream.DistanceM) - 1}
+	total := axis[len(axis)-1]
+	if total <= 0 {
+		// If the axis doesn't advance, just return first and last points
+		return []int{0, len(axis) - 1}
 	}
 
-	// Calculate step size in meters
-	stepSize := totalDistance / float64(targetPoints-1) // -1 because we include both endpoints
+	// Calculate step size in axis units
+	stepSize := total / float64(targetPoints-1) // -1 because we include both endpoints
 
 	keepIndices := make([]int, 0, targetPoints)
 	keepIndices = append(keepIndices, 0) // Always keep first point
 
 	currentIndex := 0
 	for step := 1; step < targetPoints-1; step++ {
-		targetDistance := float64(step) * stepSize
+		target := float64(step) * stepSize
 
-		// Find the closest point to target distance
+		// Find the closest point to target value
 		// Walk forward from current index to ensure indices always move forward
 		bestIndex := currentIndex
-		bestDiff := math.Abs(fullStream.DistanceM[currentIndex] - targetDistance)
+		bestDiff := math.Abs(axis[currentIndex] - target)
 
-		for i := currentIndex; i < len(fullStream.DistanceM); i++ {
-			diff := math.Abs(fullStream.DistanceM[i] - targetDistance)
+		for i := currentIndex; i < len(axis); i++ {
+			diff := math.Abs(axis[i] - target)
 			if diff < bestDiff {
 				bestDiff = diff
 				bestIndex = i
@@ -1110,7 +1661,7 @@ func decimateByDistance(fullStream *FullResolutionStream, targetPoints int) []in
 	}
 
 	// Always keep last point
-	lastIndex := len(fullStream.DistanceM) - 1
+	lastIndex := len(axis) - 1
 	if len(keepIndices) == 0 || keepIndices[len(keepIndices)-1] != lastIndex {
 		keepIndices = append(keepIndices, lastIndex)
 	}
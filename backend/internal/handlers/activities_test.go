@@ -2,13 +2,20 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"math"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/anish-chanda/cadent/backend/internal/logger"
 	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/anish-chanda/cadent/backend/internal/store"
+	"github.com/anish-chanda/cadent/backend/internal/store/local_store"
 	"github.com/anish-chanda/cadent/backend/internal/valhalla"
 	"github.com/google/uuid"
 )
@@ -55,7 +62,7 @@ func (m *activitiesMockDB) GetActivityByID(ctx context.Context, activityID strin
 	return m.activities[activityID], nil
 }
 
-func (m *activitiesMockDB) GetActivitiesByUserID(ctx context.Context, userID string) ([]models.Activity, error) {
+func (m *activitiesMockDB) GetActivitiesByUserID(ctx context.Context, userID string, query models.ActivityListQuery) ([]models.Activity, error) {
 	if m.activityError != nil {
 		return nil, m.activityError
 	}
@@ -477,6 +484,19 @@ func TestValidateStreamAlignment(t *testing.T) {
 			expectError:    true,
 			errorSubstring: "timeS array length mismatch",
 		},
+		{
+			name: "mismatched heart rate array length",
+			stream: &FullResolutionStream{
+				TimeS:        []float64{0, 1, 2},
+				DistanceM:    []float64{0, 10, 20},
+				ElevationM:   []float64{100, 110, 120},
+				SpeedMps:     []float64{0, 10, 10},
+				HeartRateBpm: []float64{120, 125},
+			},
+			expectedLength: 3,
+			expectError:    true,
+			errorSubstring: "heartRateBpm array length mismatch",
+		},
 		{
 			name: "non-zero first distance",
 			stream: &FullResolutionStream{
@@ -594,6 +614,58 @@ func TestDecimateByDistance(t *testing.T) {
 	}
 }
 
+func TestDecimateByTime(t *testing.T) {
+	// 10 s samples with a stop from 20 s to 80 s
+	stream := &FullResolutionStream{
+		TimeS:     []float64{0, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100},
+		DistanceM: []float64{0, 50, 100, 100, 100, 100, 100, 100, 100, 150, 200},
+	}
+
+	indices := decimateByTime(stream, 6)
+	expected := []int{0, 2, 4, 6, 8, 10}
+	if len(indices) != len(expected) {
+		t.Fatalf("decimateByTime() = %v, want %v", indices, expected)
+	}
+	for i := range expected {
+		if indices[i] != expected[i] {
+			t.Fatalf("decimateByTime() = %v, want %v", indices, expected)
+		}
+	}
+
+	// Distance decimation keeps a single point of the stop
+	stopped := 0
+	for _, idx := range decimateByDistance(stream, 6) {
+		if idx >= 2 && idx <= 8 {
+			stopped++
+		}
+	}
+	if stopped != 1 {
+		t.Errorf("Expected distance decimation to collapse the stop, got %d points in it", stopped)
+	}
+}
+
+func TestCreateMediumLODStreams(t *testing.T) {
+	samples := []Sample{
+		{T: 1000, Lat: 40.0, Lon: -74.0},
+		{T: 2000, Lat: 40.001, Lon: -74.001},
+		{T: 3000, Lat: 40.002, Lon: -74.002},
+	}
+	fullStream := processFullResolutionStreams(samples, nil, nil)
+
+	streams, err := createMediumLODStreams(uuid.New(), fullStream, DecimationUniform)
+	if err != nil {
+		t.Fatalf("createMediumLODStreams() error = %v", err)
+	}
+	if len(streams) != 2 || streams[0].IndexBy != models.StreamIndexByDistance || streams[1].IndexBy != models.StreamIndexByTime {
+		t.Fatalf("Expected distance and time indexed streams, got %+v", streams)
+	}
+	for _, stream := range streams {
+		if stream.LOD != models.StreamLODMedium || stream.NumPoints != 3 || stream.OriginalNumPoints != 3 {
+			t.Errorf("Unexpected %s stream: %+v", stream.IndexBy, stream)
+		}
+	}
+}
+
 func TestCreateActivityResult(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -944,6 +1016,58 @@ func TestInterpolateElevationNulls(t *testing.T) {
 	}
 }
 
+// fakeElevationProvider returns fixed heights and records the polyline it was asked about
+type fakeElevationProvider struct {
+	heights  []*float64
+	err      error
+	polyline string
+}
+
+func (f *fakeElevationProvider) GetHeight(ctx context.Context, req valhalla.HeightRequest) (*valhalla.HeightResponse, error) {
+	f.polyline = req.EncodedPolyline
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &valhalla.HeightResponse{EncodedPolyline: req.EncodedPolyline, Height: f.heights}, nil
+}
+
+func TestGetElevationDataAndHeights(t *testing.T) {
+	testLogger := logger.New(logger.Config{Level: "error", Environment: "test"})
+
+	provider := &fakeElevationProvider{heights: []*float64{floatPtr(100), nil, floatPtr(110), floatPtr(104)}}
+	elevationData, heights := getElevationDataAndHeights(context.Background(), provider, "polyline", testLogger)
+	if provider.polyline != "polyline" {
+		t.Errorf("Provider asked about %q, want the activity polyline", provider.polyline)
+	}
+	if elevationData == nil || elevationData.GainMeters != 10 || elevationData.LossMeters != 6 {
+		t.Errorf("elevationData = %+v, want 10m gain and 6m loss", elevationData)
+	}
+	if len(heights) != 4 || heights[1] != 105 {
+		t.Errorf("heights = %v, want the null interpolated", heights)
+	}
+
+	elevationData, heights = getElevationDataAndHeights(context.Background(), &fakeElevationProvider{err: errors.New("no tiles")}, "polyline", testLogger)
+	if elevationData != nil || heights != nil {
+		t.Error("Expected no elevation data when the provider fails")
+	}
+	if elevationData, _ := getElevationDataAndHeights(context.Background(), nil, "polyline", testLogger); elevationData != nil {
+		t.Error("Expected no elevation data without a provider")
+	}
+
+	h := NewHandler(NewMockDatabase(), nil, nil, testLogger)
+	if h.elevationProvider() != nil {
+		t.Error("Expected no elevation provider without a Valhalla client")
+	}
+	h.SetElevationProvider(provider)
+	if h.elevationProvider() != provider {
+		t.Error("Expected the configured elevation provider")
+	}
+	h = NewHandler(NewMockDatabase(), valhalla.NewClient("http://localhost"), nil, testLogger)
+	if _, ok := h.elevationProvider().(*valhalla.Client); !ok {
+		t.Error("Expected the Valhalla client to be the default elevation provider")
+	}
+}
+
 // Helper function for creating float pointers in tests
 func floatPtr(f float64) *float64 {
 	return &f
@@ -983,9 +1107,10 @@ func TestBuildActivityModel(t *testing.T) {
 		MinHeight:  100.0,
 	}
 	elapsedSeconds := 1.0
+	movingSeconds := 1.0
 	avgSpeedMs := 150.5
 
-	activity := buildActivityModel(req, userID, polyline, totalDistance, bounds, &elevationData, elapsedSeconds, avgSpeedMs)
+	activity := buildActivityModel(req, userID, polyline, totalDistance, bounds, &elevationData, elapsedSeconds, movingSeconds, avgSpeedMs)
 
 	if activity.UserID != userID {
 		t.Errorf("UserID = %s, want %s", activity.UserID, userID)
@@ -1005,6 +1130,9 @@ func TestBuildActivityModel(t *testing.T) {
 	if activity.ElapsedTime != int(elapsedSeconds) {
 		t.Errorf("ElapsedTime = %d, want %d", activity.ElapsedTime, int(elapsedSeconds))
 	}
+	if activity.MovingTime == nil || *activity.MovingTime != int(movingSeconds) {
+		t.Errorf("MovingTime = %v, want %d", activity.MovingTime, int(movingSeconds))
+	}
 	if activity.AvgSpeedMps == nil || *activity.AvgSpeedMps != avgSpeedMs {
 		t.Errorf("AvgSpeedMps = %v, want %f", activity.AvgSpeedMps, avgSpeedMs)
 	}
@@ -1012,3 +1140,306 @@ func TestBuildActivityModel(t *testing.T) {
 		t.Errorf("PerceivedEffort = %d, want %d", activity.PerceivedEffort, req.PerceivedEffort)
 	}
 }
+
+func setupActivityEditHandler(t *testing.T) (*Handler, *MockDatabase, store.ObjectStore, *models.Activity) {
+	t.Helper()
+
+	h, mockDB := newMatchingTestHandler()
+	objectStore := local_store.NewLocalStore(*h.log)
+	if err := objectStore.Connect("local://" + t.TempDir()); err != nil {
+		t.Fatalf("Failed to connect local store: %v", err)
+	}
+	h.objectStore = objectStore
+	h.EnableFullStreamCache(4)
+
+	activity := createTestActivity(uuid.New().String(), "user-123")
+	activity.ActivityType = models.ActivityTypeRun
+	fileKey := "activities/user-123/" + activity.ID.String() + ".gpx"
+	if err := objectStore.PutObject(context.Background(), fileKey, strings.NewReader(testFullLODGPX), int64(len(testFullLODGPX))); err != nil {
+		t.Fatalf("Failed to store test file: %v", err)
+	}
+	activity.FileURL = &fileKey
+	mockDB.activities[activity.ID.String()] = activity
+
+	return h, mockDB, objectStore, activity
+}
+
+func TestHandleUpdateActivity(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		email          string
+		expectedStatus int
+	}{
+		{"updates fields", `{"title":" Tempo ","description":null,"type":"road_biking","perceived_effort":8}`, "user@example.com", http.StatusOK},
+		{"empty title", `{"title":"  "}`, "user@example.com", http.StatusBadRequest},
+		{"invalid type", `{"type":"swimming"}`, "user@example.com", http.StatusBadRequest},
+		{"effort out of range", `{"perceived_effort":11}`, "user@example.com", http.StatusBadRequest},
+		{"no fields", `{"distance_m":10}`, "user@example.com", http.StatusBadRequest},
+		{"other user", `{"title":"Mine now"}`, "other@example.com", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockDB, _, activity := setupActivityEditHandler(t)
+			mockDB.usersByEmail["other@example.com"] = &models.UserRecord{ID: "user-456", Email: "other@example.com"}
+
+			req := httptest.NewRequest(http.MethodPatch, "/activities/"+activity.ID.String(), strings.NewReader(tt.body))
+			req = withTestUser(req, tt.email, map[string]string{"id": activity.ID.String()})
+			rr := httptest.NewRecorder()
+			h.HandleUpdateActivity()(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, want %d: %s", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var result ActivityResult
+			if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if result.Title != "Tempo" || result.Type != string(models.ActivityTypeRoadBike) || result.Description != "" {
+				t.Errorf("result = %+v", result)
+			}
+			if result.PerceivedEffort == nil || *result.PerceivedEffort != 8 {
+				t.Errorf("PerceivedEffort = %v, want 8", result.PerceivedEffort)
+			}
+		})
+	}
+}
+
+// steadyGPX runs north at 5 m/s for 10 minutes, 100 m between samples
+func steadyGPX() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk>
+    <type>running</type>
+    <trkseg>
+`)
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	for i := 0; i <= 30; i++ {
+		fmt.Fprintf(&b, "      <trkpt lat=\"%.6f\" lon=\"-122.419400\"><ele>10</ele><time>%s</time></trkpt>\n",
+			37.7749+0.0008993*float64(i), start.Add(time.Duration(i)*20*time.Second).Format(time.RFC3339))
+	}
+	b.WriteString("    </trkseg>\n  </trk>\n</gpx>")
+	return b.String()
+}
+
+func TestHandleUpdateActivity_TypeChange(t *testing.T) {
+	h, mockDB, objectStore, activity := setupActivityEditHandler(t)
+	ctx := context.Background()
+	content := steadyGPX()
+	if err := objectStore.PutObject(ctx, *activity.FileURL, strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatalf("Failed to store test file: %v", err)
+	}
+
+	// Score the run as at upload
+	_, _, fullStream, err := h.parseStoredActivityFile(ctx, activity)
+	if err != nil {
+		t.Fatalf("parseStoredActivityFile() error = %v", err)
+	}
+	if newRecords := h.saveBestEfforts(ctx, activity, fullStream); len(newRecords) != 3 {
+		t.Fatalf("Expected 400m, 1k and 1mi run records, got %v", newRecords)
+	}
+
+	// Another ride holds the bike records
+	ride := createTestActivity(uuid.New().String(), "user-123")
+	ride.ActivityType = models.ActivityTypeRoadBike
+	mockDB.activities[ride.ID.String()] = ride
+	if _, err := mockDB.SaveActivityBestEfforts(ctx, ride, []models.BestEffort{
+		{ActivityID: ride.ID, Effort: "5min", Kind: models.BestEffortKindDuration, DistanceM: 1000, ElapsedS: 300},
+		{ActivityID: ride.ID, Effort: "10k", Kind: models.BestEffortKindDistance, DistanceM: 10000, ElapsedS: 1500},
+	}); err != nil {
+		t.Fatalf("SaveActivityBestEfforts() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, "/activities/"+activity.ID.String(), strings.NewReader(`{"type":"road_biking"}`))
+	req = withTestUser(req, "user@example.com", map[string]string{"id": activity.ID.String()})
+	rr := httptest.NewRecorder()
+	h.HandleUpdateActivity()(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+
+	runType, rideType := models.ActivityTypeRun, models.ActivityTypeRoadBike
+	if records, _ := mockDB.GetPersonalRecords(ctx, "user-123", &runType); len(records) != 0 {
+		t.Errorf("Expected the run records to go with the run, got %+v", records)
+	}
+
+	records, _ := mockDB.GetPersonalRecords(ctx, "user-123", &rideType)
+	byEffort := make(map[string]models.PersonalRecord)
+	for _, record := range records {
+		byEffort[record.Effort] = record
+	}
+	if len(byEffort) != 2 {
+		t.Fatalf("Expected only the 5min and 10k bike records, got %+v", records)
+	}
+	if record := byEffort["5min"]; record.ActivityID != activity.ID || math.Abs(record.DistanceM-3000*300/600.0) > 1 {
+		t.Errorf("Expected the retyped ride to take the 5min record with about 1500 m, got %+v", record)
+	}
+	if record := byEffort["10k"]; record.ActivityID != ride.ID {
+		t.Errorf("Expected the 10k record to stay with the other ride, got %+v", record)
+	}
+	for _, effort := range mockDB.bestEfforts {
+		if effort.ActivityID == activity.ID && effort.Effort != "5min" {
+			t.Errorf("Expected only bike efforts for the retyped activity, got %s", effort.Effort)
+		}
+	}
+}
+
+func TestHandleUpdateActivity_TypeChangeRescoresMetrics(t *testing.T) {
+	h, mockDB, objectStore, activity := setupActivityEditHandler(t)
+	ctx := context.Background()
+	content := steadyGPX()
+	if err := objectStore.PutObject(ctx, *activity.FileURL, strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatalf("Failed to store test file: %v", err)
+	}
+	activity.StartTime = time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	activity.AvgGAPMps = floatPtr(4.5) // differs from the rescored 5 m/s
+
+	retype := func(activityType string, query string) ActivityResult {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPatch, "/activities/"+activity.ID.String()+query, strings.NewReader(`{"type":"`+activityType+`"}`))
+		req = withTestUser(req, "user@example.com", map[string]string{"id": activity.ID.String()})
+		rr := httptest.NewRecorder()
+		h.HandleUpdateActivity()(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d: %s", rr.Code, http.StatusOK, rr.Body.String())
+		}
+		var result ActivityResult
+		if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return result
+	}
+	paceZones := func() int {
+		count := 0
+		for _, zone := range mockDB.zoneTimes {
+			if zone.ActivityID == activity.ID && zone.Kind == models.ZoneKindPace {
+				count++
+			}
+		}
+		return count
+	}
+	loadTSS := func() float64 {
+		var total float64
+		for _, day := range mockDB.trainingLoad {
+			total += day.TSS
+		}
+		return total
+	}
+
+	// The run is scored from pace as at upload
+	samples, metadata, fullStream, err := h.parseStoredActivityFile(ctx, activity)
+	if err != nil {
+		t.Fatalf("parseStoredActivityFile() error = %v", err)
+	}
+	h.saveTrainingStress(ctx, activity, fullStream, samples, metadata.TimerPauses, defaultTrainingThresholds, time.UTC, true)
+	h.saveZoneTimes(ctx, activity, fullStream, samples, metadata.TimerPauses, nil)
+	if len(mockDB.trainingStress) != 1 || loadTSS() == 0 || paceZones() == 0 {
+		t.Fatalf("Expected the run to be scored, got %+v and %d pace zones", mockDB.trainingStress, paceZones())
+	}
+
+	// Sending back the type it already has keeps everything scored for it
+	zones, stress := paceZones(), mockDB.trainingStress[0]
+	retype("running", "")
+	if activity.AvgGAPMps == nil || *activity.AvgGAPMps != 4.5 {
+		t.Errorf("Expected the grade-adjusted pace to be kept, got %v", activity.AvgGAPMps)
+	}
+	if len(mockDB.trainingStress) != 1 || mockDB.trainingStress[0] != stress || paceZones() != zones {
+		t.Errorf("Expected the score and zones to be kept, got %+v and %d pace zones", mockDB.trainingStress, paceZones())
+	}
+
+	// Rides are only scored from heart rate, which the file doesn't have
+	result := retype("road_biking", "")
+	if activity.AvgGAPMps != nil || result.Stats.GradeAdjustedSpeed != nil {
+		t.Errorf("Expected the ride to lose its grade-adjusted pace, got %v", activity.AvgGAPMps)
+	}
+	if len(mockDB.trainingStress) != 0 {
+		t.Errorf("Expected the ride to lose its pace-based score, got %+v", mockDB.trainingStress)
+	}
+	if tss := loadTSS(); tss != 0 {
+		t.Errorf("Expected the training load to drop the score, got %.1f", tss)
+	}
+	if n := paceZones(); n != 0 {
+		t.Errorf("Expected the ride to lose its pace zones, got %d", n)
+	}
+
+	result = retype("running", "?timezone=Pacific/Honolulu")
+	// The file is flat, so the grade-adjusted pace is the moving speed
+	if activity.AvgGAPMps == nil || math.Abs(*activity.AvgGAPMps-5) > 0.05 {
+		t.Errorf("Expected the run to get a grade-adjusted pace of about 5 m/s, got %v", activity.AvgGAPMps)
+	}
+	if result.Stats.GradeAdjustedSpeed == nil {
+		t.Error("Expected the grade-adjusted pace in the response")
+	}
+	if len(mockDB.trainingStress) != 1 || mockDB.trainingStress[0].Method != models.TrainingStressMethodPace {
+		t.Fatalf("Expected the run to be scored again, got %+v", mockDB.trainingStress)
+	}
+	if day := mockDB.trainingStress[0].Day; !day.Equal(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Day = %s, want the local day in Honolulu", day)
+	}
+	if loadTSS() == 0 {
+		t.Error("Expected the training load to include the new score")
+	}
+	if paceZones() == 0 {
+		t.Error("Expected the run to get pace zones again")
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, "/activities/"+activity.ID.String()+"?timezone=Mars/Olympus", strings.NewReader(`{"type":"running"}`))
+	req = withTestUser(req, "user@example.com", map[string]string{"id": activity.ID.String()})
+	rr := httptest.NewRecorder()
+	h.HandleUpdateActivity()(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("status = %d for an invalid timezone, want %d", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleDeleteActivity(t *testing.T) {
+	h, mockDB, objectStore, activity := setupActivityEditHandler(t)
+	activityID := activity.ID.String()
+	mockDB.activityStreams[activityID+string(models.StreamLODMedium)] = createTestActivityStreams(activityID, models.StreamLODMedium)
+	mockDB.planned = []models.PlannedActivity{
+		{ID: uuid.New(), UserID: "user-123", Type: models.PlannedActivityTypeRunning, MatchedActivityID: &activity.ID},
+	}
+
+	// Warm the full resolution cache so we can check it is dropped
+	if _, err := h.loadFullResolutionStream(context.Background(), activity); err != nil {
+		t.Fatalf("loadFullResolutionStream() error = %v", err)
+	}
+
+	// Another user can't delete it
+	mockDB.usersByEmail["other@example.com"] = &models.UserRecord{ID: "user-456", Email: "other@example.com"}
+	req := withTestUser(httptest.NewRequest(http.MethodDelete, "/activities/"+activityID, nil), "other@example.com", map[string]string{"id": activityID})
+	rr := httptest.NewRecorder()
+	h.HandleDeleteActivity()(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+
+	req = withTestUser(httptest.NewRequest(http.MethodDelete, "/activities/"+activityID, nil), "user@example.com", map[string]string{"id": activityID})
+	rr = httptest.NewRecorder()
+	h.HandleDeleteActivity()(rr, req)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d: %s", rr.Code, http.StatusNoContent, rr.Body.String())
+	}
+
+	if _, exists := mockDB.activities[activityID]; exists {
+		t.Error("Expected activity to be deleted")
+	}
+	if len(mockDB.activityStreams) != 0 {
+		t.Error("Expected activity streams to be deleted")
+	}
+	if mockDB.planned[0].MatchedActivityID != nil {
+		t.Error("Expected planned activity match to be cleared")
+	}
+	if _, err := objectStore.GetObject(context.Background(), *activity.FileURL); err == nil {
+		t.Error("Expected stored file to be deleted")
+	}
+	if h.fullStreams.Len() != 0 {
+		t.Error("Expected cached full resolution stream to be invalidated")
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// Activity list paging limits
+const (
+	DefaultActivityPageSize = 50
+	MaxActivityPageSize     = 200
+)
+
+// activityCursorToken is the JSON payload behind the opaque next_cursor string.
+// The sort it was issued for is kept so a cursor can't be replayed against a different ordering.
+type activityCursorToken struct {
+	SortBy      models.ActivitySortField `json:"sort"`
+	Ascending   bool                     `json:"asc,omitempty"`
+	StartTime   time.Time                `json:"start_time"`
+	DistanceM   float64                  `json:"distance_m"`
+	ElapsedTime int                      `json:"elapsed_time"`
+	ID          uuid.UUID                `json:"id"`
+}
+
+// encodeActivityCursor returns the cursor that continues the list after activity
+func encodeActivityCursor(activity models.Activity, query models.ActivityListQuery) string {
+	token := activityCursorToken{
+		SortBy:      query.SortBy,
+		Ascending:   query.Ascending,
+		StartTime:   activity.StartTime,
+		DistanceM:   activity.DistanceM,
+		ElapsedTime: activity.ElapsedTime,
+		ID:          activity.ID,
+	}
+	payload, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// decodeActivityCursor parses a cursor issued by encodeActivityCursor for the same sort order
+func decodeActivityCursor(cursor string, query models.ActivityListQuery) (*models.ActivityCursor, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var token activityCursorToken
+	if err := json.Unmarshal(payload, &token); err != nil || token.ID == uuid.Nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if token.SortBy != query.SortBy || token.Ascending != query.Ascending {
+		return nil, fmt.Errorf("cursor does not match the requested sort order")
+	}
+
+	return &models.ActivityCursor{
+		StartTime:   token.StartTime,
+		DistanceM:   token.DistanceM,
+		ElapsedTime: token.ElapsedTime,
+		ID:          token.ID,
+	}, nil
+}
+
+// parseActivityListQuery parses the activity list query parameters:
+// type, startDate/endDate (YYYY-MM-DD, inclusive), minDistanceM/maxDistanceM, q (title search),
+// sort (date, distance, duration), order (asc, desc), limit and cursor.
+func parseActivityListQuery(r *http.Request) (models.ActivityListQuery, error) {
+	params := r.URL.Query()
+	query := models.ActivityListQuery{
+		SortBy: models.ActivitySortByDate,
+		Limit:  DefaultActivityPageSize,
+	}
+
+	if v := params.Get("type"); v != "" {
+		activityType := models.ActivityType(v)
+		if activityType != models.ActivityTypeRun && activityType != models.ActivityTypeRoadBike {
+			return query, fmt.Errorf("invalid type: %s (must be running or road_biking)", v)
+		}
+		query.ActivityType = &activityType
+	}
+
+	if v := params.Get("startDate"); v != "" {
+		startDate, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return query, fmt.Errorf("invalid start date format (use YYYY-MM-DD)")
+		}
+		query.StartFrom = &startDate
+	}
+	if v := params.Get("endDate"); v != "" {
+		endDate, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return query, fmt.Errorf("invalid end date format (use YYYY-MM-DD)")
+		}
+		// Interpret endDate as inclusive through the end of the selected day
+		endDate = endDate.Add(24*time.Hour - time.Nanosecond)
+		query.StartTo = &endDate
+	}
+	if query.StartFrom != nil && query.StartTo != nil && query.StartTo.Before(*query.StartFrom) {
+		return query, fmt.Errorf("invalid date range: endDate must be greater than or equal to startDate")
+	}
+
+	var err error
+	if query.MinDistanceM, err = parseDistanceParam(params.Get("minDistanceM"), "minDistanceM"); err != nil {
+		return query, err
+	}
+	if query.MaxDistanceM, err = parseDistanceParam(params.Get("maxDistanceM"), "maxDistanceM"); err != nil {
+		return query, err
+	}
+	if query.MinDistanceM != nil && query.MaxDistanceM != nil && *query.MaxDistanceM < *query.MinDistanceM {
+		return query, fmt.Errorf("invalid distance range: maxDistanceM must be greater than or equal to minDistanceM")
+	}
+
+	query.TitleSearch = strings.TrimSpace(params.Get("q"))
+
+	switch v := params.Get("sort"); v {
+	case "", string(models.ActivitySortByDate):
+	case string(models.ActivitySortByDistance), string(models.ActivitySortByDuration):
+		query.SortBy = models.ActivitySortField(v)
+	default:
+		return query, fmt.Errorf("invalid sort: %s (must be date, distance or duration)", v)
+	}
+
+	switch v := params.Get("order"); v {
+	case "", "desc":
+	case "asc":
+		query.Ascending = true
+	default:
+		return query, fmt.Errorf("invalid order: %s (must be asc or desc)", v)
+	}
+
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > MaxActivityPageSize {
+			return query, fmt.Errorf("invalid limit: must be between 1 and %d", MaxActivityPageSize)
+		}
+		query.Limit = limit
+	}
+
+	if v := params.Get("cursor"); v != "" {
+		after, err := decodeActivityCursor(v, query)
+		if err != nil {
+			return query, err
+		}
+		query.After = after
+	}
+
+	return query, nil
+}
+
+// parseDistanceParam parses an optional non-negative distance in meters
+func parseDistanceParam(v string, name string) (*float64, error) {
+	if v == "" {
+		return nil, nil
+	}
+	distance, err := strconv.ParseFloat(v, 64)
+	if err != nil || distance < 0 {
+		return nil, fmt.Errorf("invalid %s: must be a non-negative number of meters", name)
+	}
+	return &distance, nil
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestParseActivityListQuery(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/activities", nil)
+		query, err := parseActivityListQuery(req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if query.SortBy != models.ActivitySortByDate || query.Ascending {
+			t.Errorf("Expected newest-first date sort, got %s asc=%v", query.SortBy, query.Ascending)
+		}
+		if query.Limit != DefaultActivityPageSize {
+			t.Errorf("Expected limit %d, got %d", DefaultActivityPageSize, query.Limit)
+		}
+		if query.ActivityType != nil || query.StartFrom != nil || query.StartTo != nil || query.After != nil {
+			t.Errorf("Expected no filters, got %+v", query)
+		}
+	})
+
+	t.Run("all parameters", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/activities?type=running&startDate=2024-03-01&endDate=2024-03-31&minDistanceM=5000&maxDistanceM=21100&q=%20tempo%20&sort=distance&order=asc&limit=10", nil)
+		query, err := parseActivityListQuery(req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if query.ActivityType == nil || *query.ActivityType != models.ActivityTypeRun {
+			t.Errorf("Expected running type filter, got %v", query.ActivityType)
+		}
+		if !query.StartFrom.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected start date: %v", query.StartFrom)
+		}
+		if !query.StartTo.Equal(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)) {
+			t.Errorf("Expected end date to include the whole day, got %v", query.StartTo)
+		}
+		if *query.MinDistanceM != 5000 || *query.MaxDistanceM != 21100 {
+			t.Errorf("Unexpected distance range: %v-%v", *query.MinDistanceM, *query.MaxDistanceM)
+		}
+		if query.TitleSearch != "tempo" {
+			t.Errorf("Expected trimmed title search, got %q", query.TitleSearch)
+		}
+		if query.SortBy != models.ActivitySortByDistance || !query.Ascending || query.Limit != 10 {
+			t.Errorf("Unexpected sort/limit: %s asc=%v limit=%d", query.SortBy, query.Ascending, query.Limit)
+		}
+	})
+
+	invalid := []string{
+		"type=swimming",
+		"startDate=03-01-2024",
+		"endDate=2024-13-01",
+		"startDate=2024-03-02&endDate=2024-03-01",
+		"minDistanceM=-1",
+		"maxDistanceM=far",
+		"minDistanceM=10&maxDistanceM=5",
+		"sort=pace",
+		"order=up",
+		"limit=0",
+		"limit=201",
+		"cursor=not-a-cursor",
+	}
+	for _, params := range invalid {
+		t.Run("invalid "+params, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/activities?"+params, nil)
+			if _, err := parseActivityListQuery(req); err == nil {
+				t.Errorf("Expected error for %s", params)
+			}
+		})
+	}
+}
+
+func TestActivityCursorRoundTrip(t *testing.T) {
+	activity := models.Activity{
+		ID:          uuid.New(),
+		StartTime:   time.Date(2024, 3, 15, 7, 30, 0, 0, time.UTC),
+		DistanceM:   10000,
+		ElapsedTime: 3000,
+	}
+	query := models.ActivityListQuery{SortBy: models.ActivitySortByDuration, Ascending: true}
+
+	cursor := encodeActivityCursor(activity, query)
+	after, err := decodeActivityCursor(cursor, query)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if after.ID != activity.ID || !after.StartTime.Equal(activity.StartTime) ||
+		after.DistanceM != activity.DistanceM || after.ElapsedTime != activity.ElapsedTime {
+		t.Errorf("Cursor round trip mismatch: %+v", after)
+	}
+
+	if _, err := decodeActivityCursor(cursor, models.ActivityListQuery{SortBy: models.ActivitySortByDuration}); err == nil {
+		t.Error("Expected error when replaying a cursor against a different order")
+	}
+	if _, err := decodeActivityCursor(cursor, models.ActivityListQuery{SortBy: models.ActivitySortByDate, Ascending: true}); err == nil {
+		t.Error("Expected error when replaying a cursor against a different sort field")
+	}
+}
+
+func TestHandleGetActivitiesPagination(t *testing.T) {
+	h, mockDB := newMatchingTestHandler()
+	start := time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		activity := createTestActivity(uuid.New().String(), "user-123")
+		activity.StartTime = start.AddDate(0, 0, i)
+		mockDB.activities[activity.ID.String()] = activity
+	}
+
+	var seen []time.Time
+	cursor := ""
+	for page := 0; page < 3; page++ {
+		target := "/activities?limit=2"
+		if cursor != "" {
+			target += "&cursor=" + cursor
+		}
+		req := withTestUser(httptest.NewRequest(http.MethodGet, target, nil), "user@example.com", nil)
+		w := httptest.NewRecorder()
+		h.HandleGetActivities()(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Page %d: expected status 200, got %d: %s", page, w.Code, w.Body.String())
+		}
+		var response GetActivitiesResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		for _, activity := range response.Activities {
+			seen = append(seen, activity.StartTime)
+		}
+
+		if page < 2 {
+			if response.NextCursor == nil {
+				t.Fatalf("Page %d: expected next_cursor", page)
+			}
+			cursor = *response.NextCursor
+		} else if response.NextCursor != nil {
+			t.Errorf("Expected no next_cursor on the last page, got %s", *response.NextCursor)
+		}
+	}
+
+	if len(seen) != 5 {
+		t.Fatalf("Expected 5 activities across pages, got %d", len(seen))
+	}
+	for i := 1; i < len(seen); i++ {
+		if !seen[i].Before(seen[i-1]) {
+			t.Errorf("Expected newest-first order, got %v after %v", seen[i], seen[i-1])
+		}
+	}
+}
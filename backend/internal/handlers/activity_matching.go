@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// neutralMatchScore is used for planned activities without distance or duration targets,
+// so a bare "run today" plan still matches but loses to a plan whose targets fit
+const neutralMatchScore = 0.5
+
+// LinkPlannedActivityRequest is the body for manually linking a planned activity to a completed one
+type LinkPlannedActivityRequest struct {
+	ActivityID string `json:"activityId"`
+}
+
+// parseTimezone resolves an IANA timezone name, defaulting to UTC when empty
+func parseTimezone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %s", name)
+	}
+	return loc, nil
+}
+
+// localDayBounds returns the [start, end) instants of the calendar day containing t in loc
+func localDayBounds(t time.Time, loc *time.Location) (time.Time, time.Time) {
+	local := t.In(loc)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// isCompatiblePlannedType reports whether a completed activity can satisfy a planned activity type.
+// Cross training accepts any activity; rest, strength and mobility days never match a GPS activity.
+func isCompatiblePlannedType(activityType models.ActivityType, plannedType models.PlannedActivityType) bool {
+	switch plannedType {
+	case models.PlannedActivityTypeRunning:
+		return activityType == models.ActivityTypeRun
+	case models.PlannedActivityTypeRoadBiking:
+		return activityType == models.ActivityTypeRoadBike
+	case models.PlannedActivityTypeCrossTraining:
+		return true
+	}
+	return false
+}
+
+// targetCloseness scores how close actual is to target: 1 for an exact hit, falling linearly to 0 at 100% off
+func targetCloseness(actual, target float64) float64 {
+	return math.Max(0, 1-math.Abs(actual-target)/target)
+}
+
+// scorePlannedActivityMatch scores a candidate plan in [0, 1] by averaging distance and duration closeness
+func scorePlannedActivityMatch(activity *models.Activity, plan models.PlannedActivity) float64 {
+	var total float64
+	var count int
+
+	if plan.PlannedDistanceM != nil && *plan.PlannedDistanceM > 0 {
+		total += targetCloseness(activity.DistanceM, *plan.PlannedDistanceM)
+		count++
+	}
+	if plan.PlannedDurationS != nil && *plan.PlannedDurationS > 0 {
+		total += targetCloseness(float64(activity.ElapsedTime), float64(*plan.PlannedDurationS))
+		count++
+	}
+
+	if count == 0 {
+		return neutralMatchScore
+	}
+	return total / float64(count)
+}
+
+// selectBestPlannedMatch picks the compatible candidate with the highest score.
+// Ties go to the plan scheduled closest to the activity's start time.
+func selectBestPlannedMatch(activity *models.Activity, candidates []models.PlannedActivity) *models.PlannedActivity {
+	var best *models.PlannedActivity
+	bestScore := -1.0
+	var bestGap time.Duration
+
+	for i := range candidates {
+		plan := &candidates[i]
+		if plan.MatchedActivityID != nil || !isCompatiblePlannedType(activity.ActivityType, plan.Type) {
+			continue
+		}
+
+		score := scorePlannedActivityMatch(activity, *plan)
+		gap := plan.StartTime.Sub(activity.StartTime).Abs()
+		if score > bestScore || (score == bestScore && gap < bestGap) {
+			best = plan
+			bestScore = score
+			bestGap = gap
+		}
+	}
+
+	return best
+}
+
+// autoMatchPlannedActivity links a newly created activity to the best planned activity on the same local day.
+// Matching is best effort: failures are logged and never fail the activity creation.
+// Returns the matched planned activity ID, or nil when nothing was linked.
+func (h *Handler) autoMatchPlannedActivity(ctx context.Context, activity *models.Activity, loc *time.Location) *string {
+	dayStart, dayEnd := localDayBounds(activity.StartTime, loc)
+
+	candidates, err := h.database.GetUnmatchedPlannedActivities(ctx, activity.UserID, dayStart, dayEnd)
+	if err != nil {
+		h.log.Error("Failed to load planned activities for matching", err)
+		return nil
+	}
+
+	best := selectBestPlannedMatch(activity, candidates)
+	if best == nil {
+		h.log.Debug(fmt.Sprintf("No planned activity to match for activity %s", activity.ID.String()))
+		return nil
+	}
+
+	activityID := activity.ID.String()
+	if err := h.database.SetPlannedActivityMatch(ctx, best.ID.String(), activity.UserID, &activityID); err != nil {
+		h.log.Error("Failed to link activity to planned activity", err)
+		return nil
+	}
+
+	plannedID := best.ID.String()
+	h.log.Info(fmt.Sprintf("Matched activity %s to planned activity %s", activityID, plannedID))
+	return &plannedID
+}
+
+// HandleLinkPlannedActivity manually links a planned activity to a completed activity,
+// replacing any existing match on either side
+func (h *Handler) HandleLinkPlannedActivity() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		plannedID := chi.URLParam(r, "id")
+		if _, err := uuid.Parse(plannedID); err != nil {
+			sendError(w, http.StatusBadRequest, "Invalid planned activity ID")
+			return
+		}
+
+		var req LinkPlannedActivityRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.log.Error("Failed to decode link request", err)
+			sendError(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+
+		activityID := strings.TrimSpace(req.ActivityID)
+		if _, err := uuid.Parse(activityID); err != nil {
+			sendError(w, http.StatusBadRequest, "Valid activityId is required")
+			return
+		}
+
+		activity, err := h.database.GetActivityByID(ctx, activityID)
+		if err != nil {
+			h.log.Error("Failed to get activity from database", err)
+			sendError(w, http.StatusInternalServerError, "Failed to link planned activity")
+			return
+		}
+		if activity == nil || activity.UserID != userID {
+			sendError(w, http.StatusNotFound, "Activity not found")
+			return
+		}
+
+		if err := h.database.SetPlannedActivityMatch(ctx, plannedID, userID, &activityID); err != nil {
+			if err.Error() == "planned activity not found" {
+				sendError(w, http.StatusNotFound, "Planned activity not found")
+				return
+			}
+			h.log.Error("Failed to link planned activity", err)
+			sendError(w, http.StatusInternalServerError, "Failed to link planned activity")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{
+			"id":                plannedID,
+			"matchedActivityId": activityID,
+		})
+	}
+}
+
+// HandleUnlinkPlannedActivity clears the matched activity of a planned activity
+func (h *Handler) HandleUnlinkPlannedActivity() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		plannedID := chi.URLParam(r, "id")
+		if _, err := uuid.Parse(plannedID); err != nil {
+			sendError(w, http.StatusBadRequest, "Invalid planned activity ID")
+			return
+		}
+
+		if err := h.database.SetPlannedActivityMatch(ctx, plannedID, userID, nil); err != nil {
+			if err.Error() == "planned activity not found" {
+				sendError(w, http.StatusNotFound, "Planned activity not found")
+				return
+			}
+			h.log.Error("Failed to unlink planned activity", err)
+			sendError(w, http.StatusInternalServerError, "Failed to unlink planned activity")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/logger"
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-pkgz/auth/v2/token"
+	"github.com/google/uuid"
+)
+
+func intPtr(v int) *int { return &v }
+
+func makePlan(planType models.PlannedActivityType, start time.Time, distanceM *float64, durationS *int) models.PlannedActivity {
+	return models.PlannedActivity{
+		ID:               uuid.New(),
+		UserID:           "user-123",
+		Title:            "Planned",
+		Type:             planType,
+		StartTime:        start,
+		PlannedDistanceM: distanceM,
+		PlannedDurationS: durationS,
+	}
+}
+
+// newMatchingTestHandler builds a handler with a known user for request-level tests
+func newMatchingTestHandler() (*Handler, *MockDatabase) {
+	mockDB := NewMockDatabase()
+	mockDB.usersByEmail["user@example.com"] = &models.UserRecord{ID: "user-123", Email: "user@example.com"}
+	testLogger := logger.New(logger.Config{Level: "error", Environment: "test"})
+	return NewHandler(mockDB, nil, nil, testLogger), mockDB
+}
+
+// withTestUser attaches an authenticated user and chi URL params to the request
+func withTestUser(r *http.Request, email string, params map[string]string) *http.Request {
+	r = token.SetUserInfo(r, token.User{Name: email})
+	rctx := chi.NewRouteContext()
+	for k, v := range params {
+		rctx.URLParams.Add(k, v)
+	}
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestIsCompatiblePlannedType(t *testing.T) {
+	tests := []struct {
+		activity models.ActivityType
+		planned  models.PlannedActivityType
+		expected bool
+	}{
+		{models.ActivityTypeRun, models.PlannedActivityTypeRunning, true},
+		{models.ActivityTypeRun, models.PlannedActivityTypeRoadBiking, false},
+		{models.ActivityTypeRoadBike, models.PlannedActivityTypeRoadBiking, true},
+		{models.ActivityTypeRoadBike, models.PlannedActivityTypeCrossTraining, true},
+		{models.ActivityTypeRun, models.PlannedActivityTypeResting, false},
+		{models.ActivityTypeRun, models.PlannedActivityTypeStrengthTraining, false},
+	}
+
+	for _, tt := range tests {
+		if got := isCompatiblePlannedType(tt.activity, tt.planned); got != tt.expected {
+			t.Errorf("isCompatiblePlannedType(%s, %s) = %v, want %v", tt.activity, tt.planned, got, tt.expected)
+		}
+	}
+}
+
+func TestScorePlannedActivityMatch(t *testing.T) {
+	activity := &models.Activity{DistanceM: 10000, ElapsedTime: 3000}
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		plan     models.PlannedActivity
+		expected float64
+	}{
+		{"exact distance and duration", makePlan(models.PlannedActivityTypeRunning, start, floatPtr(10000), intPtr(3000)), 1},
+		{"distance half off", makePlan(models.PlannedActivityTypeRunning, start, floatPtr(20000), nil), 0.5},
+		{"distance exact duration double", makePlan(models.PlannedActivityTypeRunning, start, floatPtr(10000), intPtr(1500)), 0.5},
+		{"no targets", makePlan(models.PlannedActivityTypeRunning, start, nil, nil), neutralMatchScore},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scorePlannedActivityMatch(activity, tt.plan); got != tt.expected {
+				t.Errorf("scorePlannedActivityMatch() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSelectBestPlannedMatch(t *testing.T) {
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	activity := &models.Activity{ActivityType: models.ActivityTypeRun, StartTime: start, DistanceM: 10000, ElapsedTime: 3000}
+
+	easy := makePlan(models.PlannedActivityTypeRunning, start.Add(-2*time.Hour), floatPtr(5000), nil)
+	long := makePlan(models.PlannedActivityTypeRunning, start.Add(4*time.Hour), floatPtr(11000), nil)
+	ride := makePlan(models.PlannedActivityTypeRoadBiking, start, floatPtr(10000), nil)
+
+	best := selectBestPlannedMatch(activity, []models.PlannedActivity{easy, ride, long})
+	if best == nil || best.ID != long.ID {
+		t.Fatalf("Expected closest-distance run plan to win, got %v", best)
+	}
+
+	if best := selectBestPlannedMatch(activity, []models.PlannedActivity{ride}); best != nil {
+		t.Errorf("Expected no match for incompatible type, got %v", best.ID)
+	}
+
+	// Equal scores fall back to the plan closest in time
+	morning := makePlan(models.PlannedActivityTypeRunning, start.Add(30*time.Minute), nil, nil)
+	evening := makePlan(models.PlannedActivityTypeRunning, start.Add(10*time.Hour), nil, nil)
+	best = selectBestPlannedMatch(activity, []models.PlannedActivity{evening, morning})
+	if best == nil || best.ID != morning.ID {
+		t.Errorf("Expected time-closest plan on tie, got %v", best)
+	}
+}
+
+func TestLocalDayBounds(t *testing.T) {
+	loc, err := parseTimezone("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("parseTimezone() error = %v", err)
+	}
+
+	// 03:00 UTC on Mar 2 is still Mar 1 in Los Angeles
+	start, end := localDayBounds(time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC), loc)
+	if got, want := start.UTC(), time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("day start = %v, want %v", got, want)
+	}
+	if got := end.Sub(start); got != 24*time.Hour {
+		t.Errorf("day length = %v, want 24h", got)
+	}
+
+	if _, err := parseTimezone("Not/AZone"); err == nil {
+		t.Error("Expected error for invalid timezone")
+	}
+	if loc, _ := parseTimezone(""); loc != time.UTC {
+		t.Error("Expected UTC for empty timezone")
+	}
+}
+
+func TestAutoMatchPlannedActivity(t *testing.T) {
+	h, mockDB := newMatchingTestHandler()
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	sameDay := makePlan(models.PlannedActivityTypeRunning, start.Add(2*time.Hour), floatPtr(10000), nil)
+	nextDay := makePlan(models.PlannedActivityTypeRunning, start.Add(24*time.Hour), floatPtr(10000), nil)
+	mockDB.planned = []models.PlannedActivity{nextDay, sameDay}
+
+	activity := &models.Activity{ID: uuid.New(), UserID: "user-123", ActivityType: models.ActivityTypeRun, StartTime: start, DistanceM: 9500}
+
+	matched := h.autoMatchPlannedActivity(context.Background(), activity, time.UTC)
+	if matched == nil || *matched != sameDay.ID.String() {
+		t.Fatalf("autoMatchPlannedActivity() = %v, want %s", matched, sameDay.ID)
+	}
+	if mockDB.planned[1].MatchedActivityID == nil || *mockDB.planned[1].MatchedActivityID != activity.ID {
+		t.Error("Expected same-day plan to be linked to the activity")
+	}
+	if mockDB.planned[0].MatchedActivityID != nil {
+		t.Error("Next-day plan should not be linked")
+	}
+
+	// Already matched plans are no longer candidates
+	other := &models.Activity{ID: uuid.New(), UserID: "user-123", ActivityType: models.ActivityTypeRun, StartTime: start}
+	if matched := h.autoMatchPlannedActivity(context.Background(), other, time.UTC); matched != nil {
+		t.Errorf("Expected no match once the plan is taken, got %s", *matched)
+	}
+}
+
+func TestHandleLinkPlannedActivity(t *testing.T) {
+	activityID := "550e8400-e29b-41d4-a716-446655440000"
+
+	tests := []struct {
+		name           string
+		plannedID      string
+		body           string
+		activityOwner  string
+		expectedStatus int
+	}{
+		{"links owned activity", "", `{"activityId":"` + activityID + `"}`, "user-123", http.StatusOK},
+		{"activity owned by someone else", "", `{"activityId":"` + activityID + `"}`, "other-user", http.StatusNotFound},
+		{"unknown planned activity", uuid.New().String(), `{"activityId":"` + activityID + `"}`, "user-123", http.StatusNotFound},
+		{"invalid activity id", "", `{"activityId":"nope"}`, "user-123", http.StatusBadRequest},
+		{"invalid planned id", "nope", `{"activityId":"` + activityID + `"}`, "user-123", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockDB := newMatchingTestHandler()
+			plan := makePlan(models.PlannedActivityTypeRunning, time.Now(), nil, nil)
+			mockDB.planned = []models.PlannedActivity{plan}
+			mockDB.activities[activityID] = createTestActivity(activityID, tt.activityOwner)
+
+			plannedID := tt.plannedID
+			if plannedID == "" {
+				plannedID = plan.ID.String()
+			}
+
+			req := httptest.NewRequest(http.MethodPut, "/activities/plan/"+plannedID+"/match", strings.NewReader(tt.body))
+			req = withTestUser(req, "user@example.com", map[string]string{"id": plannedID})
+			w := httptest.NewRecorder()
+
+			h.HandleLinkPlannedActivity()(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Status code = %d, want %d (body: %s)", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK {
+				if mockDB.planned[0].MatchedActivityID == nil || mockDB.planned[0].MatchedActivityID.String() != activityID {
+					t.Error("Expected planned activity to be linked")
+				}
+			}
+		})
+	}
+}
+
+func TestHandleUnlinkPlannedActivity(t *testing.T) {
+	h, mockDB := newMatchingTestHandler()
+	matched := uuid.New()
+	plan := makePlan(models.PlannedActivityTypeRunning, time.Now(), nil, nil)
+	plan.MatchedActivityID = &matched
+	mockDB.planned = []models.PlannedActivity{plan}
+
+	req := httptest.NewRequest(http.MethodDelete, "/activities/plan/"+plan.ID.String()+"/match", nil)
+	req = withTestUser(req, "user@example.com", map[string]string{"id": plan.ID.String()})
+	w := httptest.NewRecorder()
+
+	h.HandleUnlinkPlannedActivity()(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Status code = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if mockDB.planned[0].MatchedActivityID != nil {
+		t.Error("Expected match to be cleared")
+	}
+}
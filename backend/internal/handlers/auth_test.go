@@ -49,7 +49,6 @@ func (m *mockDatabase) GetUserByID(ctx context.Context, userID string) (*models.
 	}
 	return nil, nil
 }
-
 func (m *mockDatabase) UpdateUser(ctx context.Context, userID string, updates map[string]interface{}) error {
 	return nil
 }
@@ -58,7 +57,7 @@ func (m *mockDatabase) UpdateUser(ctx context.Context, userID string, updates ma
 func (m *mockDatabase) CreateActivity(ctx context.Context, activity *models.Activity) error {
 	return nil
 }
-func (m *mockDatabase) GetActivitiesByUserID(ctx context.Context, userID string) ([]models.Activity, error) {
+func (m *mockDatabase) GetActivitiesByUserID(ctx context.Context, userID string, query models.ActivityListQuery) ([]models.Activity, error) {
 	return nil, nil
 }
 func (m *mockDatabase) GetActivitiesByUserIDAndDate(ctx context.Context, userID string, start_date time.Time, end_date time.Time) ([]models.Activity, []models.PlannedActivity, error) {
@@ -85,6 +84,24 @@ func (m *mockDatabase) DeletePlannedActivity(ctx context.Context, activityID str
 func (m *mockDatabase) UpdatePlannedActivity(ctx context.Context, activityID string, userID string, updates map[string]interface{}) error {
 	return nil
 }
+func (m *mockDatabase) GetUnmatchedPlannedActivities(ctx context.Context, userID string, start time.Time, end time.Time) ([]models.PlannedActivity, error) {
+	return nil, nil
+}
+func (m *mockDatabase) SetPlannedActivityMatch(ctx context.Context, plannedActivityID string, userID string, activityID *string) error {
+	return nil
+}
+func (m *mockDatabase) CreateRoute(ctx context.Context, route *models.Route) error {
+	return nil
+}
+func (m *mockDatabase) GetRoutesByUserID(ctx context.Context, userID string) ([]models.Route, error) {
+	return nil, nil
+}
+func (m *mockDatabase) GetRouteByID(ctx context.Context, routeID string, userID string) (*models.Route, error) {
+	return nil, nil
+}
+func (m *mockDatabase) DeleteRoute(ctx context.Context, routeID string, userID string) error {
+	return nil
+}
 func (m *mockDatabase) Connect(dsn string) error { return nil }
 func (m *mockDatabase) Close() error             { return nil }
 func (m *mockDatabase) Migrate() error           { return nil }
@@ -851,3 +868,86 @@ func (m *mockDatabase) GetTrainingPlanWorkouts(ctx context.Context, planID strin
 func (m *mockDatabase) CreateUserTrainingPlanWithPlannedActivities(ctx context.Context, userPlan *models.UserTrainingPlan, plannedActivities []models.PlannedActivity) error {
 	return nil
 }
+
+// Mocks for plan compliance
+func (m *mockDatabase) GetActivitiesByIDs(ctx context.Context, userID string, activityIDs []string) ([]models.Activity, error) {
+	return nil, nil
+}
+func (m *mockDatabase) GetUserTrainingPlanByID(ctx context.Context, userTrainingPlanID string, userID string) (*models.UserTrainingPlan, error) {
+	return nil, nil
+}
+func (m *mockDatabase) GetPlannedActivitiesByUserTrainingPlan(ctx context.Context, userTrainingPlanID string, userID string) ([]models.PlannedActivity, error) {
+	return nil, nil
+}
+
+// Mocks for bulk import
+func (m *mockDatabase) FindActivityIDByStartTime(ctx context.Context, userID string, startTime time.Time, tolerance time.Duration) (*string, error) {
+	return nil, nil
+}
+
+// Mocks for activity update and delete
+func (m *mockDatabase) UpdateActivity(ctx context.Context, activityID string, userID string, updates map[string]interface{}) error {
+	return nil
+}
+func (m *mockDatabase) DeleteActivity(ctx context.Context, activityID string, userID string) error {
+	return nil
+}
+
+// Mocks for activity splits
+func (m *mockDatabase) GetActivitySplits(ctx context.Context, activityID string, unit models.SplitUnit) ([]models.ActivitySplit, error) {
+	return nil, nil
+}
+func (m *mockDatabase) CreateActivitySplits(ctx context.Context, splits []models.ActivitySplit) error {
+	return nil
+}
+
+// Mocks for activity laps
+func (m *mockDatabase) GetActivityLaps(ctx context.Context, activityID string) ([]models.ActivityLap, error) {
+	return nil, nil
+}
+func (m *mockDatabase) CreateActivityLaps(ctx context.Context, laps []models.ActivityLap) error {
+	return nil
+}
+
+// Mocks for best efforts and personal records
+func (m *mockDatabase) SaveActivityBestEfforts(ctx context.Context, activity *models.Activity, efforts []models.BestEffort) ([]string, error) {
+	return nil, nil
+}
+func (m *mockDatabase) GetPersonalRecords(ctx context.Context, userID string, activityType *models.ActivityType) ([]models.PersonalRecord, error) {
+	return []models.PersonalRecord{}, nil
+}
+
+// Mocks for training load
+func (m *mockDatabase) SaveActivityTrainingStress(ctx context.Context, stress *models.ActivityTrainingStress) error {
+	return nil
+}
+func (m *mockDatabase) GetDailyTrainingStress(ctx context.Context, userID string, from time.Time) ([]models.DailyTrainingStress, error) {
+	return []models.DailyTrainingStress{}, nil
+}
+func (m *mockDatabase) GetTrainingLoad(ctx context.Context, userID string, from time.Time, to time.Time) ([]models.TrainingLoadDay, error) {
+	return []models.TrainingLoadDay{}, nil
+}
+func (m *mockDatabase) GetTrainingLoadBefore(ctx context.Context, userID string, day time.Time) (*models.TrainingLoadDay, error) {
+	return nil, nil
+}
+func (m *mockDatabase) ReplaceTrainingLoad(ctx context.Context, userID string, from time.Time, days []models.TrainingLoadDay) error {
+	return nil
+}
+
+// Mocks for training settings
+func (m *mockDatabase) GetUserTrainingSettings(ctx context.Context, userID string) (*models.TrainingSettings, error) {
+	return nil, nil
+}
+
+// Mocks for zones
+func (m *mockDatabase) ReplaceActivityZoneTimes(ctx context.Context, activityID string, zones []models.ActivityZoneTime) error {
+	return nil
+}
+func (m *mockDatabase) GetActivityZoneTimes(ctx context.Context, activityIDs []string) ([]models.ActivityZoneTime, error) {
+	return nil, nil
+}
+
+// Mocks for stats
+func (m *mockDatabase) GetActivityStats(ctx context.Context, userID string, period models.StatsPeriod, timezone string, from time.Time, to time.Time) ([]models.ActivityStats, error) {
+	return nil, nil
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// bestEffortTarget is a standard distance or duration tracked as a best effort
+type bestEffortTarget struct {
+	Effort    string
+	Kind      models.BestEffortKind
+	DistanceM float64 // for distance efforts
+	DurationS float64 // for duration efforts
+}
+
+// bestEffortTargets lists the best efforts tracked per activity type
+var bestEffortTargets = map[models.ActivityType][]bestEffortTarget{
+	models.ActivityTypeRun: {
+		{Effort: "400m", Kind: models.BestEffortKindDistance, DistanceM: 400},
+		{Effort: "1k", Kind: models.BestEffortKindDistance, DistanceM: 1000},
+		{Effort: "1mi", Kind: models.BestEffortKindDistance, DistanceM: 1609.344},
+		{Effort: "5k", Kind: models.BestEffortKindDistance, DistanceM: 5000},
+		{Effort: "10k", Kind: models.BestEffortKindDistance, DistanceM: 10000},
+		{Effort: "half_marathon", Kind: models.BestEffortKindDistance, DistanceM: 21097.5},
+		{Effort: "marathon", Kind: models.BestEffortKindDistance, DistanceM: 42195},
+	},
+	models.ActivityTypeRoadBike: {
+		{Effort: "5min", Kind: models.BestEffortKindDuration, DurationS: 5 * 60},
+		{Effort: "20min", Kind: models.BestEffortKindDuration, DurationS: 20 * 60},
+		{Effort: "60min", Kind: models.BestEffortKindDuration, DurationS: 60 * 60},
+		{Effort: "10k", Kind: models.BestEffortKindDistance, DistanceM: 10000},
+		{Effort: "40k", Kind: models.BestEffortKindDistance, DistanceM: 40000},
+	},
+}
+
+// PersonalRecordResult is a single record in the records response
+type PersonalRecordResult struct {
+	ActivityType string    `json:"activity_type"`
+	Effort       string    `json:"effort"`
+	Kind         string    `json:"kind"`
+	DistanceM    float64   `json:"distance_m"`
+	ElapsedS     float64   `json:"elapsed_s"`
+	ActivityID   string    `json:"activity_id"`
+	AchievedAt   time.Time `json:"achieved_at"`
+}
+
+// RecordsResponse is the response for GET /records
+type RecordsResponse struct {
+	Records []PersonalRecordResult `json:"records"`
+}
+
+// calculateBestEfforts finds the activity's best effort for each standard distance and duration of its type.
+// Efforts use elapsed time, and efforts longer than the activity are left out.
+func calculateBestEfforts(activityID uuid.UUID, activityType models.ActivityType, stream *FullResolutionStream) []models.BestEffort {
+	now := time.Now()
+	var efforts []models.BestEffort
+	for _, target := range bestEffortTargets[activityType] {
+		effort := models.BestEffort{
+			ActivityID: activityID,
+			Effort:     target.Effort,
+			Kind:       target.Kind,
+			DistanceM:  target.DistanceM,
+			ElapsedS:   target.DurationS,
+			CreatedAt:  now,
+		}
+
+		var ok bool
+		if target.Kind == models.BestEffortKindDistance {
+			effort.ElapsedS, effort.StartIndex, ok = fastestDistance(stream, target.DistanceM)
+		} else {
+			effort.DistanceM, effort.StartIndex, ok = longestDuration(stream, target.DurationS)
+		}
+		if ok {
+			efforts = append(efforts, effort)
+		}
+	}
+	return efforts
+}
+
+// fastestDistance returns the shortest time to cover distanceM and the index its window starts at.
+// Every sample is tried as the end of the window; the start is interpolated between two samples.
+func fastestDistance(stream *FullResolutionStream, distanceM float64) (float64, int, bool) {
+	n := len(stream.DistanceM)
+	best, bestStart, found := 0.0, 0, false
+
+	i := 0
+	for j := 1; j < n; j++ {
+		target := stream.DistanceM[j] - distanceM
+		if target < stream.DistanceM[0] {
+			continue
+		}
+		// Move the start to the last sample at or before the target distance
+		for i+1 < j && stream.DistanceM[i+1] <= target {
+			i++
+		}
+
+		start := stream.TimeS[i]
+		if d0, d1 := stream.DistanceM[i], stream.DistanceM[i+1]; d1 > d0 {
+			start = lerp(stream.TimeS[i], stream.TimeS[i+1], (target-d0)/(d1-d0))
+		}
+		if elapsed := stream.TimeS[j] - start; elapsed > 0 && (!found || elapsed < best) {
+			best, bestStart, found = elapsed, i, true
+		}
+	}
+	return best, bestStart, found
+}
+
+// longestDuration returns the longest distance covered in durationS and the index its window starts at.
+// Every sample is tried as the end of the window; the start is interpolated between two samples.
+func longestDuration(stream *FullResolutionStream, durationS float64) (float64, int, bool) {
+	n := len(stream.TimeS)
+	best, bestStart, found := 0.0, 0, false
+
+	i := 0
+	for j := 1; j < n; j++ {
+		target := stream.TimeS[j] - durationS
+		if target < stream.TimeS[0] {
+			continue
+		}
+		// Move the start to the last sample at or before the target time
+		for i+1 < j && stream.TimeS[i+1] <= target {
+			i++
+		}
+
+		start := stream.DistanceM[i]
+		if t0, t1 := stream.TimeS[i], stream.TimeS[i+1]; t1 > t0 {
+			start = lerp(stream.DistanceM[i], stream.DistanceM[i+1], (target-t0)/(t1-t0))
+		}
+		if distance := stream.DistanceM[j] - start; distance > 0 && (!found || distance > best) {
+			best, bestStart, found = distance, i, true
+		}
+	}
+	return best, bestStart, found
+}
+
+// saveBestEfforts computes and stores the best efforts of an activity for its type and returns the ones that
+// set a personal record
+func (h *Handler) saveBestEfforts(ctx context.Context, activity *models.Activity, fullStream *FullResolutionStream) []string {
+	efforts := calculateBestEfforts(activity.ID, activity.ActivityType, fullStream)
+	if len(efforts) == 0 {
+		return nil
+	}
+
+	newRecords, err := h.database.SaveActivityBestEfforts(ctx, activity, efforts)
+	if err != nil {
+		h.log.Error("Failed to save best efforts to database", err)
+		return nil
+	}
+	h.log.Debug(fmt.Sprintf("Successfully saved %d best efforts for activity: %s (%d new records)", len(efforts), activity.ID.String(), len(newRecords)))
+	return newRecords
+}
+
+// HandleGetRecords serves the authenticated user's personal records, optionally filtered by ?type=
+func (h *Handler) HandleGetRecords() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.Background()
+
+		var activityType *models.ActivityType
+		if v := r.URL.Query().Get("type"); v != "" {
+			t := models.ActivityType(v)
+			if _, ok := bestEffortTargets[t]; !ok {
+				http.Error(w, fmt.Sprintf("Invalid type: %s (must be running or road_biking)", v), http.StatusBadRequest)
+				return
+			}
+			activityType = &t
+		}
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		records, err := h.database.GetPersonalRecords(ctx, userID, activityType)
+		if err != nil {
+			h.log.Error("Failed to get personal records from database", err)
+			http.Error(w, "Failed to retrieve records", http.StatusInternalServerError)
+			return
+		}
+
+		response := RecordsResponse{
+			Records: make([]PersonalRecordResult, 0, len(records)),
+		}
+		for _, record := range records {
+			response.Records = append(response.Records, PersonalRecordResult{
+				ActivityType: string(record.ActivityType),
+				Effort:       record.Effort,
+				Kind:         string(record.Kind),
+				DistanceM:    record.DistanceM,
+				ElapsedS:     record.ElapsedS,
+				ActivityID:   record.ActivityID.String(),
+				AchievedAt:   record.AchievedAt,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestFastestDistance(t *testing.T) {
+	// 200 m at 2 m/s, then 400 m at 4 m/s, then 200 m at 2 m/s
+	stream := &FullResolutionStream{
+		TimeS:     []float64{0, 50, 100, 150, 200, 250, 300},
+		DistanceM: []float64{0, 100, 200, 400, 600, 700, 800},
+	}
+
+	elapsed, start, ok := fastestDistance(stream, 400)
+	if !ok || elapsed != 100 || start != 2 {
+		t.Errorf("fastestDistance(400) = %f from %d (ok %v), want 100 from 2", elapsed, start, ok)
+	}
+
+	// The window start falls between samples: 100 m at 2 m/s plus 400 m at 4 m/s
+	elapsed, _, ok = fastestDistance(stream, 500)
+	if !ok || math.Abs(elapsed-150) > 1e-9 {
+		t.Errorf("fastestDistance(500) = %f (ok %v), want 150", elapsed, ok)
+	}
+
+	if _, _, ok := fastestDistance(stream, 1000); ok {
+		t.Error("Expected no effort longer than the activity")
+	}
+}
+
+func TestLongestDuration(t *testing.T) {
+	// 2 m/s for 100 s, 5 m/s for 100 s, 2 m/s for 100 s
+	stream := &FullResolutionStream{
+		TimeS:     []float64{0, 50, 100, 150, 200, 250, 300},
+		DistanceM: []float64{0, 100, 200, 450, 700, 800, 900},
+	}
+
+	distance, start, ok := longestDuration(stream, 100)
+	if !ok || distance != 500 || start != 2 {
+		t.Errorf("longestDuration(100) = %f from %d (ok %v), want 500 from 2", distance, start, ok)
+	}
+
+	// The window start falls between samples: 25 s at 2 m/s plus 100 s at 5 m/s
+	distance, _, ok = longestDuration(stream, 125)
+	if !ok || math.Abs(distance-550) > 1e-9 {
+		t.Errorf("longestDuration(125) = %f (ok %v), want 550", distance, ok)
+	}
+
+	if _, _, ok := longestDuration(stream, 301); ok {
+		t.Error("Expected no effort longer than the activity")
+	}
+}
+
+func TestCalculateBestEfforts(t *testing.T) {
+	activityID := uuid.New()
+	stream, _ := uniformSplitStream(6000) // 100 m every 30 s for 30 minutes
+
+	t.Run("running", func(t *testing.T) {
+		efforts := calculateBestEfforts(activityID, models.ActivityTypeRun, stream)
+
+		expected := []string{"400m", "1k", "1mi", "5k"}
+		if len(efforts) != len(expected) {
+			t.Fatalf("Expected %d efforts, got %+v", len(expected), efforts)
+		}
+		for i, effort := range efforts {
+			if effort.Effort != expected[i] || effort.Kind != models.BestEffortKindDistance || effort.ActivityID != activityID {
+				t.Errorf("Unexpected effort %d: %+v", i, effort)
+			}
+			if math.Abs(effort.ElapsedS-effort.DistanceM*0.3) > 1e-6 {
+				t.Errorf("%s elapsed = %f, want %f", effort.Effort, effort.ElapsedS, effort.DistanceM*0.3)
+			}
+		}
+	})
+
+	t.Run("biking", func(t *testing.T) {
+		efforts := calculateBestEfforts(activityID, models.ActivityTypeRoadBike, stream)
+
+		if len(efforts) != 2 || efforts[0].Effort != "5min" || efforts[1].Effort != "20min" {
+			t.Fatalf("Expected 5min and 20min efforts, got %+v", efforts)
+		}
+		if efforts[1].Kind != models.BestEffortKindDuration || efforts[1].ElapsedS != 1200 || math.Abs(efforts[1].DistanceM-4000) > 1e-6 {
+			t.Errorf("Unexpected 20min effort: %+v", efforts[1])
+		}
+	})
+}
+
+func TestSaveBestEffortsFlagsNewRecords(t *testing.T) {
+	h, mockDB := newMatchingTestHandler()
+	ctx := context.Background()
+
+	save := func(secondsPer100m float64) []string {
+		stream := &FullResolutionStream{}
+		for i := 0; i <= 12; i++ {
+			stream.TimeS = append(stream.TimeS, float64(i)*secondsPer100m)
+			stream.DistanceM = append(stream.DistanceM, float64(i)*100)
+		}
+		activity := &models.Activity{ID: uuid.New(), UserID: "user-123", ActivityType: models.ActivityTypeRun}
+		return h.saveBestEfforts(ctx, activity, stream)
+	}
+
+	if records := save(30); len(records) != 2 || records[0] != "400m" || records[1] != "1k" {
+		t.Errorf("First activity should set every record, got %v", records)
+	}
+	if records := save(35); records != nil {
+		t.Errorf("Slower activity should set no record, got %v", records)
+	}
+	if records := save(25); len(records) != 2 {
+		t.Errorf("Faster activity should set both records, got %v", records)
+	}
+	if len(mockDB.bestEfforts) != 6 {
+		t.Errorf("Expected every activity's efforts to be stored, got %d", len(mockDB.bestEfforts))
+	}
+}
+
+func TestHandleGetRecords(t *testing.T) {
+	h, mockDB := newMatchingTestHandler()
+	activityID := uuid.New()
+	mockDB.records = []models.PersonalRecord{
+		{UserID: "user-123", ActivityType: models.ActivityTypeRun, Effort: "5k", Kind: models.BestEffortKindDistance, ActivityID: activityID, DistanceM: 5000, ElapsedS: 1290},
+		{UserID: "user-123", ActivityType: models.ActivityTypeRoadBike, Effort: "20min", Kind: models.BestEffortKindDuration, ActivityID: activityID, DistanceM: 11200, ElapsedS: 1200},
+		{UserID: "user-456", ActivityType: models.ActivityTypeRun, Effort: "5k", Kind: models.BestEffortKindDistance, ActivityID: uuid.New(), DistanceM: 5000, ElapsedS: 1100},
+	}
+
+	tests := []struct {
+		name            string
+		query           string
+		expectedStatus  int
+		expectedRecords int
+	}{
+		{"all types", "", http.StatusOK, 2},
+		{"running only", "?type=running", http.StatusOK, 1},
+		{"invalid type", "?type=swimming", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/records"+tt.query, nil)
+			req = withTestUser(req, "user@example.com", nil)
+			w := httptest.NewRecorder()
+			h.HandleGetRecords()(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response RecordsResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(response.Records) != tt.expectedRecords {
+				t.Errorf("Expected %d records, got %+v", tt.expectedRecords, response.Records)
+			}
+			for _, record := range response.Records {
+				if record.ActivityID != activityID.String() {
+					t.Errorf("Got another user's record: %+v", record)
+				}
+			}
+		})
+	}
+}
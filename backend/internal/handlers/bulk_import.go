@@ -0,0 +1,612 @@
+package handlers
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	maxImportArchiveSize = 4 * 1024 * 1024 * 1024 // 4GB, Strava exports of long-time users are large
+	maxImportFiles       = 10000
+	importFormMemory     = 32 * 1024 * 1024 // multipart parts above this spill to disk
+	importJobRetention   = 24 * time.Hour   // finished jobs are kept this long for progress polling
+)
+
+// ImportJobStatus is the lifecycle state of a bulk import
+type ImportJobStatus string
+
+const (
+	ImportJobRunning   ImportJobStatus = "running"
+	ImportJobCompleted ImportJobStatus = "completed"
+	ImportJobFailed    ImportJobStatus = "failed"
+)
+
+// ImportFileStatus is the outcome for a single file within a bulk import
+type ImportFileStatus string
+
+const (
+	ImportFilePending   ImportFileStatus = "pending"
+	ImportFileImported  ImportFileStatus = "imported"
+	ImportFileDuplicate ImportFileStatus = "duplicate"
+	ImportFileFailed    ImportFileStatus = "failed"
+)
+
+// ImportFileResult reports the progress of one activity file in the archive
+type ImportFileResult struct {
+	Filename   string           `json:"filename"`
+	Status     ImportFileStatus `json:"status"`
+	ActivityID *string          `json:"activity_id,omitempty"` // created activity, or the existing one for duplicates
+	Error      string           `json:"error,omitempty"`
+	NewRecords []string         `json:"new_records,omitempty"` // best efforts that set a personal record
+}
+
+// ImportJob tracks an asynchronous bulk import of an export archive
+type ImportJob struct {
+	ID             string             `json:"id"`
+	Status         ImportJobStatus    `json:"status"`
+	TotalFiles     int                `json:"total_files"`
+	ProcessedFiles int                `json:"processed_files"`
+	Imported       int                `json:"imported"`
+	Duplicates     int                `json:"duplicates"`
+	Failed         int                `json:"failed"`
+	Error          string             `json:"error,omitempty"`
+	Files          []ImportFileResult `json:"files"`
+	CreatedAt      time.Time          `json:"created_at"`
+	CompletedAt    *time.Time         `json:"completed_at,omitempty"`
+
+	userID string
+}
+
+// importJobRegistry keeps import jobs in memory while they run and for a while after they finish.
+// Jobs don't survive a restart; neither would the goroutine processing them.
+type importJobRegistry struct {
+	mu   sync.Mutex
+	jobs map[string]*ImportJob
+}
+
+func newImportJobRegistry() *importJobRegistry {
+	return &importJobRegistry{jobs: make(map[string]*ImportJob)}
+}
+
+// start registers a running job for the user, refusing a second concurrent import so duplicate
+// detection never races against itself
+func (r *importJobRegistry) start(userID string, filenames []string) (*ImportJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, job := range r.jobs {
+		if job.CompletedAt != nil && now.Sub(*job.CompletedAt) > importJobRetention {
+			delete(r.jobs, id)
+			continue
+		}
+		if job.userID == userID && job.Status == ImportJobRunning {
+			return nil, fmt.Errorf("an import is already running")
+		}
+	}
+
+	files := make([]ImportFileResult, len(filenames))
+	for i, name := range filenames {
+		files[i] = ImportFileResult{Filename: name, Status: ImportFilePending}
+	}
+
+	job := &ImportJob{
+		ID:         uuid.New().String(),
+		Status:     ImportJobRunning,
+		TotalFiles: len(filenames),
+		Files:      files,
+		CreatedAt:  now,
+		userID:     userID,
+	}
+	r.jobs[job.ID] = job
+	return job.snapshot(), nil
+}
+
+// get returns a copy of the user's job, or nil when it doesn't exist or belongs to someone else
+func (r *importJobRegistry) get(jobID string, userID string) *ImportJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[jobID]
+	if !ok || job.userID != userID {
+		return nil
+	}
+	return job.snapshot()
+}
+
+// recordFile stores the outcome of file i and updates the counters
+func (r *importJobRegistry) recordFile(jobID string, i int, result ImportFileResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[jobID]
+	if !ok || i >= len(job.Files) {
+		return
+	}
+	job.Files[i] = result
+	job.ProcessedFiles++
+	switch result.Status {
+	case ImportFileImported:
+		job.Imported++
+	case ImportFileDuplicate:
+		job.Duplicates++
+	case ImportFileFailed:
+		job.Failed++
+	}
+}
+
+// finish marks the job completed, or failed when errMsg is set
+func (r *importJobRegistry) finish(jobID string, errMsg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[jobID]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	job.CompletedAt = &now
+	job.Status = ImportJobCompleted
+	if errMsg != "" {
+		job.Status = ImportJobFailed
+		job.Error = errMsg
+	}
+}
+
+func (j *ImportJob) snapshot() *ImportJob {
+	c := *j
+	c.Files = append([]ImportFileResult(nil), j.Files...)
+	return &c
+}
+
+// importEntry is an activity file found in an export archive
+type importEntry struct {
+	name string // path within the archive, nested archives prefixed with their own path
+	file *zip.File
+}
+
+// exportArchive is an opened export with its activity files and Strava metadata
+type exportArchive struct {
+	entries  []importEntry
+	metadata map[string]stravaActivityRow // keyed by path within the archive
+	closers  []io.Closer
+	tempDirs []string
+}
+
+// Close releases nested archives and their temporary files
+func (a *exportArchive) Close() {
+	for _, c := range a.closers {
+		c.Close()
+	}
+	for _, dir := range a.tempDirs {
+		os.RemoveAll(dir)
+	}
+}
+
+// stravaActivityRow holds the fields we use from a Strava export's activities.csv
+type stravaActivityRow struct {
+	Name         string
+	Description  string
+	ActivityType models.ActivityType
+}
+
+// importableActivityExt returns the activity file extension for an archive entry, looking
+// through a trailing .gz, or "" when the entry isn't an activity file
+func importableActivityExt(name string) string {
+	name = strings.TrimSuffix(strings.ToLower(name), ".gz")
+	switch ext := path.Ext(name); ext {
+	case ".gpx", ".fit", ".tcx":
+		return ext
+	}
+	return ""
+}
+
+// openExportArchive scans a Strava or Garmin export. Garmin bulk exports wrap the uploaded
+// FIT files in nested zips, which are extracted to a temporary directory and scanned too.
+func openExportArchive(archivePath string) (*exportArchive, error) {
+	archive := &exportArchive{metadata: make(map[string]stravaActivityRow)}
+
+	if err := archive.scan(archivePath, "", 0); err != nil {
+		archive.Close()
+		return nil, err
+	}
+	return archive, nil
+}
+
+func (a *exportArchive) scan(archivePath string, prefix string, depth int) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open zip archive: %w", err)
+	}
+	a.closers = append(a.closers, zr)
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name := prefix + f.Name
+		lower := strings.ToLower(f.Name)
+
+		switch {
+		case importableActivityExt(f.Name) != "":
+			a.entries = append(a.entries, importEntry{name: name, file: f})
+
+		case path.Base(lower) == "activities.csv":
+			rows, err := readStravaActivitiesCSV(f, prefix+path.Dir(f.Name))
+			if err != nil {
+				return err
+			}
+			for k, v := range rows {
+				a.metadata[k] = v
+			}
+
+		case strings.HasSuffix(lower, ".zip") && depth == 0:
+			nestedPath, err := a.extractNested(f)
+			if err != nil {
+				return err
+			}
+			if err := a.scan(nestedPath, name+"/", depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// extractNested copies a nested zip to a temporary file so it can be opened for random access
+func (a *exportArchive) extractNested(f *zip.File) (string, error) {
+	dir, err := os.MkdirTemp("", "cadent-import-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	a.tempDirs = append(a.tempDirs, dir)
+
+	rc, err := f.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open nested archive %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	nestedPath := filepath.Join(dir, "nested.zip")
+	out, err := os.Create(nestedPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, io.LimitReader(rc, maxImportArchiveSize)); err != nil {
+		return "", fmt.Errorf("failed to extract nested archive %s: %w", f.Name, err)
+	}
+	return nestedPath, nil
+}
+
+// readStravaActivitiesCSV maps each activity file path in a Strava export to the name, description
+// and type the athlete gave it. Paths in the Filename column are relative to the CSV's directory.
+func readStravaActivitiesCSV(f *zip.File, dir string) (map[string]stravaActivityRow, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open activities.csv: %w", err)
+	}
+	defer rc.Close()
+
+	r := csv.NewReader(rc)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read activities.csv header: %w", err)
+	}
+
+	// Strava repeats some column names; the first occurrence is the one we want
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+	filenameCol, ok := columns["Filename"]
+	if !ok {
+		return nil, fmt.Errorf("activities.csv has no Filename column")
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := make(map[string]stravaActivityRow)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read activities.csv: %w", err)
+		}
+		if filenameCol >= len(record) || strings.TrimSpace(record[filenameCol]) == "" {
+			continue // manual entries have no file
+		}
+
+		key := path.Clean(path.Join(dir, strings.TrimSpace(record[filenameCol])))
+		rows[key] = stravaActivityRow{
+			Name:         field(record, "Activity Name"),
+			Description:  field(record, "Activity Description"),
+			ActivityType: mapStravaActivityType(field(record, "Activity Type")),
+		}
+	}
+
+	return rows, nil
+}
+
+// mapStravaActivityType maps Strava's activity type names (Run, Trail Run, Ride, Virtual Ride, ...)
+// to our models.ActivityType, returning "" for types we don't support
+func mapStravaActivityType(stravaType string) models.ActivityType {
+	normalized := strings.ToLower(strings.TrimSpace(stravaType))
+	switch {
+	case strings.HasSuffix(normalized, "run"):
+		return models.ActivityTypeRun
+	case strings.HasSuffix(normalized, "ride"):
+		return models.ActivityTypeRoadBike
+	default:
+		return ""
+	}
+}
+
+// readImportEntry reads an archive entry, transparently decompressing .gz files.
+// Returns the content and the activity filename without the .gz suffix.
+func readImportEntry(entry importEntry) ([]byte, string, error) {
+	rc, err := entry.file.Open()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer rc.Close()
+
+	var reader io.Reader = rc
+	name := path.Base(entry.name)
+	if strings.HasSuffix(strings.ToLower(name), ".gz") {
+		gz, err := gzip.NewReader(rc)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to decompress file: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+		name = name[:len(name)-len(".gz")]
+	}
+
+	// Apply the same per-file limit as single uploads, which also guards against zip bombs
+	content, err := io.ReadAll(io.LimitReader(reader, maxUploadSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if len(content) > maxUploadSize {
+		return nil, "", fmt.Errorf("file exceeds maximum size of 25MB")
+	}
+
+	return content, name, nil
+}
+
+// runImportJob processes every activity file of the archive in order, recording progress as it goes.
+// Files are processed sequentially so duplicate detection also catches repeats within the archive.
+func (h *Handler) runImportJob(ctx context.Context, jobID string, userID string, archive *exportArchive, opts activityFileOptions) {
+	defer archive.Close()
+	defer func() {
+		if rec := recover(); rec != nil {
+			h.log.Error(fmt.Sprintf("Import job %s panicked", jobID), fmt.Errorf("%v", rec))
+			h.imports.finish(jobID, "import aborted due to an internal error")
+		}
+	}()
+
+	for i, entry := range archive.entries {
+		result := h.importArchiveEntry(ctx, userID, entry, archive.metadata, opts)
+		h.imports.recordFile(jobID, i, result)
+	}
+
+	// Imported activities can land anywhere in the past, so the whole series is rebuilt once
+	if err := h.updateTrainingLoad(ctx, userID, time.Time{}); err != nil {
+		h.log.Error(fmt.Sprintf("Failed to update training load after import job %s", jobID), err)
+	}
+
+	h.imports.finish(jobID, "")
+	h.log.Info(fmt.Sprintf("Finished import job %s for user %s", jobID, userID))
+}
+
+func (h *Handler) importArchiveEntry(ctx context.Context, userID string, entry importEntry, metadata map[string]stravaActivityRow, opts activityFileOptions) ImportFileResult {
+	result := ImportFileResult{Filename: entry.name}
+
+	content, filename, err := readImportEntry(entry)
+	if err != nil {
+		result.Status = ImportFileFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	if row, ok := metadata[path.Clean(entry.name)]; ok {
+		opts.Title = row.Name
+		opts.Description = row.Description
+		opts.ActivityType = row.ActivityType
+	}
+
+	activity, _, newRecords, err := h.processActivityFile(ctx, userID, filename, content, opts)
+	if err != nil {
+		var duplicate *duplicateActivityError
+		if errors.As(err, &duplicate) {
+			result.Status = ImportFileDuplicate
+			result.ActivityID = &duplicate.ActivityID
+			return result
+		}
+		result.Status = ImportFileFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	activityID := activity.ID.String()
+	result.Status = ImportFileImported
+	result.ActivityID = &activityID
+	result.NewRecords = newRecords
+	return result
+}
+
+// HandleBulkImport accepts a Strava or Garmin export archive and imports its activity files in the background.
+// Progress is polled with HandleGetImportJob.
+func (h *Handler) HandleBulkImport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportArchiveSize)
+		if err := r.ParseMultipartForm(importFormMemory); err != nil {
+			h.log.Error("Failed to parse multipart form", err)
+			if strings.Contains(err.Error(), "Content-Type") || strings.Contains(err.Error(), "multipart") {
+				http.Error(w, "Request must use multipart/form-data Content-Type", http.StatusBadRequest)
+			} else {
+				http.Error(w, "File size exceeds maximum allowed size of 4GB", http.StatusRequestEntityTooLarge)
+			}
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			h.log.Error("Failed to get file from form", err)
+			http.Error(w, "Missing or invalid 'file' field in form data", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		if strings.ToLower(filepath.Ext(header.Filename)) != ".zip" {
+			http.Error(w, "Unsupported file type. Only ZIP archives are allowed", http.StatusBadRequest)
+			return
+		}
+
+		loc, err := parseTimezone(r.FormValue("timezone"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts := activityFileOptions{
+			Enrich:            r.FormValue("enrich") == "true",
+			Snap:              r.FormValue("snap") == "true",
+			Location:          loc,
+			SkipDuplicate:     true,
+			DeferTrainingLoad: true,
+		}
+
+		// The multipart temp file is removed when the request ends, so keep our own copy for the job
+		archivePath, err := copyToTempFile(file)
+		if err != nil {
+			h.log.Error("Failed to store import archive", err)
+			http.Error(w, "Failed to store import archive", http.StatusInternalServerError)
+			return
+		}
+
+		archive, err := openExportArchive(archivePath)
+		if err != nil {
+			os.Remove(archivePath)
+			h.log.Error("Failed to read import archive", err)
+			http.Error(w, "Invalid ZIP archive", http.StatusBadRequest)
+			return
+		}
+		archive.closers = append(archive.closers, removeOnClose(archivePath))
+
+		if len(archive.entries) == 0 {
+			archive.Close()
+			http.Error(w, "Archive contains no GPX, FIT or TCX files", http.StatusBadRequest)
+			return
+		}
+		if len(archive.entries) > maxImportFiles {
+			archive.Close()
+			http.Error(w, fmt.Sprintf("Archive contains more than %d activity files", maxImportFiles), http.StatusBadRequest)
+			return
+		}
+
+		filenames := make([]string, len(archive.entries))
+		for i, entry := range archive.entries {
+			filenames[i] = entry.name
+		}
+
+		job, err := h.imports.start(userID, filenames)
+		if err != nil {
+			archive.Close()
+			http.Error(w, "An import is already running", http.StatusConflict)
+			return
+		}
+
+		h.log.Info(fmt.Sprintf("Started import job %s for user %s with %d files", job.ID, userID, job.TotalFiles))
+
+		// The request context ends with the response, the job keeps running
+		go h.runImportJob(context.Background(), job.ID, userID, archive, opts)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(job)
+	}
+}
+
+// HandleGetImportJob returns the progress of a bulk import
+func (h *Handler) HandleGetImportJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		job := h.imports.get(chi.URLParam(r, "id"), userID)
+		if job == nil {
+			http.Error(w, "Import job not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(job)
+	}
+}
+
+func copyToTempFile(src io.Reader) (string, error) {
+	out, err := os.CreateTemp("", "cadent-import-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, src); err != nil {
+		os.Remove(out.Name())
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	return out.Name(), nil
+}
+
+// removeOnClose deletes a file when the archive is closed
+type removeOnClose string
+
+func (p removeOnClose) Close() error { return os.Remove(string(p)) }
@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/logger"
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/anish-chanda/cadent/backend/internal/store/local_store"
+)
+
+// untypedGPX has no activity type, so it relies on the Strava activities.csv
+const untypedGPX = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk>
+    <trkseg>
+      <trkpt lat="37.7749" lon="-122.4194"><ele>10</ele><time>2024-02-01T08:00:00Z</time></trkpt>
+      <trkpt lat="37.7759" lon="-122.4194"><ele>12</ele><time>2024-02-01T08:00:30Z</time></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+const testStravaCSV = "Activity ID,Activity Date,Activity Name,Activity Type,Activity Description,Elapsed Time,Filename,Elapsed Time\n" +
+	"1,\"Feb 1, 2024, 8:00:00 AM\",Lunch Run,Run,Felt good,30,activities/1.gpx.gz,30\n" +
+	"2,\"Feb 2, 2024, 8:00:00 AM\",Manual Entry,Run,,60,,60\n"
+
+func gzipBytes(t *testing.T, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(content)); err != nil {
+		t.Fatalf("Failed to gzip: %v", err)
+	}
+	gz.Close()
+	return buf.Bytes()
+}
+
+func zipBytes(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create zip entry: %v", err)
+		}
+		w.Write(content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// buildTestExport creates an archive mixing a Strava export layout with a nested Garmin zip
+func buildTestExport(t *testing.T) []byte {
+	t.Helper()
+	garminPart := zipBytes(t, map[string][]byte{"run.tcx": []byte(testTCX)})
+
+	return zipBytes(t, map[string][]byte{
+		"activities.csv":      []byte(testStravaCSV),
+		"activities/1.gpx.gz": gzipBytes(t, untypedGPX),
+		"activities/2.gpx":    []byte(testFullLODGPX),
+		"activities/3.gpx":    []byte(testFullLODGPX), // same activity exported twice
+		"activities/4.gpx":    []byte("not a gpx file"),
+		"profile.json":        []byte("{}"),
+		"DI_CONNECT/DI-Connect-Uploaded-Files/UploadedFiles_0-_Part1.zip": garminPart,
+	})
+}
+
+func writeTempArchive(t *testing.T, content []byte) string {
+	t.Helper()
+	archivePath := filepath.Join(t.TempDir(), "export.zip")
+	if err := os.WriteFile(archivePath, content, 0o644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	return archivePath
+}
+
+func setupImportHandler(t *testing.T) (*Handler, *MockDatabase) {
+	t.Helper()
+	testLogger := logger.New(logger.Config{Level: "error", Environment: "test"})
+	objectStore := local_store.NewLocalStore(*testLogger)
+	if err := objectStore.Connect("local://" + t.TempDir()); err != nil {
+		t.Fatalf("Failed to connect local store: %v", err)
+	}
+
+	mockDB := NewMockDatabase()
+	mockDB.usersByEmail["user@example.com"] = &models.UserRecord{ID: "user-123", Email: "user@example.com"}
+	return NewHandler(mockDB, nil, objectStore, testLogger), mockDB
+}
+
+func TestOpenExportArchive(t *testing.T) {
+	archive, err := openExportArchive(writeTempArchive(t, buildTestExport(t)))
+	if err != nil {
+		t.Fatalf("openExportArchive() error = %v", err)
+	}
+	defer archive.Close()
+
+	names := make(map[string]bool)
+	for _, entry := range archive.entries {
+		names[entry.name] = true
+	}
+	for _, want := range []string{
+		"activities/1.gpx.gz",
+		"activities/2.gpx",
+		"DI_CONNECT/DI-Connect-Uploaded-Files/UploadedFiles_0-_Part1.zip/run.tcx",
+	} {
+		if !names[want] {
+			t.Errorf("Expected entry %s, got %v", want, names)
+		}
+	}
+	if names["profile.json"] {
+		t.Error("Non-activity files should be skipped")
+	}
+
+	row, ok := archive.metadata["activities/1.gpx.gz"]
+	if !ok {
+		t.Fatalf("Expected activities.csv metadata, got %v", archive.metadata)
+	}
+	if row.Name != "Lunch Run" || row.Description != "Felt good" || row.ActivityType != models.ActivityTypeRun {
+		t.Errorf("metadata row = %+v", row)
+	}
+	if len(archive.metadata) != 1 {
+		t.Errorf("Manual entries without a file should be skipped, got %d rows", len(archive.metadata))
+	}
+}
+
+func TestOpenExportArchive_Invalid(t *testing.T) {
+	if _, err := openExportArchive(writeTempArchive(t, []byte("not a zip"))); err == nil {
+		t.Error("Expected error for invalid archive")
+	}
+}
+
+func TestMapStravaActivityType(t *testing.T) {
+	tests := map[string]models.ActivityType{
+		"Run":          models.ActivityTypeRun,
+		"Trail Run":    models.ActivityTypeRun,
+		"Ride":         models.ActivityTypeRoadBike,
+		"Virtual Ride": models.ActivityTypeRoadBike,
+		"Swim":         "",
+	}
+	for stravaType, want := range tests {
+		if got := mapStravaActivityType(stravaType); got != want {
+			t.Errorf("mapStravaActivityType(%q) = %q, want %q", stravaType, got, want)
+		}
+	}
+}
+
+func TestRunImportJob(t *testing.T) {
+	h, mockDB := setupImportHandler(t)
+
+	archive, err := openExportArchive(writeTempArchive(t, buildTestExport(t)))
+	if err != nil {
+		t.Fatalf("openExportArchive() error = %v", err)
+	}
+
+	filenames := make([]string, len(archive.entries))
+	for i, entry := range archive.entries {
+		filenames[i] = entry.name
+	}
+	job, err := h.imports.start("user-123", filenames)
+	if err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+
+	h.runImportJob(context.Background(), job.ID, "user-123", archive, activityFileOptions{Location: time.UTC, SkipDuplicate: true})
+
+	got := h.imports.get(job.ID, "user-123")
+	if got.Status != ImportJobCompleted || got.CompletedAt == nil {
+		t.Fatalf("job status = %s", got.Status)
+	}
+	if got.ProcessedFiles != 5 || got.Imported != 3 || got.Duplicates != 1 || got.Failed != 1 {
+		t.Errorf("job counters = %+v", got)
+	}
+	if len(mockDB.activities) != 3 {
+		t.Errorf("Expected 3 activities created, got %d", len(mockDB.activities))
+	}
+
+	results := make(map[string]ImportFileResult)
+	for _, f := range got.Files {
+		results[f.Filename] = f
+	}
+	if r := results["activities/4.gpx"]; r.Status != ImportFileFailed || r.Error == "" {
+		t.Errorf("invalid file result = %+v", r)
+	}
+
+	// The CSV supplies the title and type missing from the file
+	imported := results["activities/1.gpx.gz"]
+	if imported.Status != ImportFileImported || imported.ActivityID == nil {
+		t.Fatalf("gz file result = %+v", imported)
+	}
+	activity := mockDB.activities[*imported.ActivityID]
+	if activity.Title != "Lunch Run" || activity.ActivityType != models.ActivityTypeRun {
+		t.Errorf("activity = %s (%s), want Lunch Run (running)", activity.Title, activity.ActivityType)
+	}
+
+	// A second import of the same archive only finds duplicates
+	archive, err = openExportArchive(writeTempArchive(t, buildTestExport(t)))
+	if err != nil {
+		t.Fatalf("openExportArchive() error = %v", err)
+	}
+	for i, entry := range archive.entries {
+		filenames[i] = entry.name
+	}
+	job, _ = h.imports.start("user-123", filenames)
+	h.runImportJob(context.Background(), job.ID, "user-123", archive, activityFileOptions{Location: time.UTC, SkipDuplicate: true})
+
+	got = h.imports.get(job.ID, "user-123")
+	if got.Imported != 0 || got.Duplicates != 4 {
+		t.Errorf("re-import counters = %+v", got)
+	}
+}
+
+func TestImportJobRegistry(t *testing.T) {
+	r := newImportJobRegistry()
+
+	job, err := r.start("user-123", []string{"a.gpx"})
+	if err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+	if _, err := r.start("user-123", []string{"b.gpx"}); err == nil {
+		t.Error("Expected a second concurrent import to be refused")
+	}
+	if _, err := r.start("other-user", []string{"c.gpx"}); err != nil {
+		t.Errorf("Other users should be able to import: %v", err)
+	}
+
+	if r.get(job.ID, "other-user") != nil {
+		t.Error("Jobs should only be visible to their owner")
+	}
+
+	r.finish(job.ID, "")
+	if _, err := r.start("user-123", []string{"b.gpx"}); err != nil {
+		t.Errorf("Expected a new import once the previous one finished: %v", err)
+	}
+
+	// Finished jobs are pruned after the retention period
+	expired := time.Now().Add(-importJobRetention - time.Minute)
+	r.jobs[job.ID].CompletedAt = &expired
+	r.start("someone-else", nil)
+	if r.get(job.ID, "user-123") != nil {
+		t.Error("Expected expired job to be pruned")
+	}
+}
+
+func TestHandleBulkImport(t *testing.T) {
+	h, _ := setupImportHandler(t)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "export.zip")
+	part.Write(buildTestExport(t))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/activities/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req = withTestUser(req, "user@example.com", nil)
+	w := httptest.NewRecorder()
+
+	h.HandleBulkImport()(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Status code = %d, want %d (body: %s)", w.Code, http.StatusAccepted, w.Body.String())
+	}
+	var job ImportJob
+	if err := json.NewDecoder(w.Body).Decode(&job); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if job.TotalFiles != 5 || job.Status != ImportJobRunning {
+		t.Errorf("job = %+v", job)
+	}
+
+	// Poll until the background job finishes
+	deadline := time.Now().Add(10 * time.Second)
+	var progress ImportJob
+	for time.Now().Before(deadline) {
+		req := withTestUser(httptest.NewRequest(http.MethodGet, "/activities/import/"+job.ID, nil), "user@example.com", map[string]string{"id": job.ID})
+		w := httptest.NewRecorder()
+		h.HandleGetImportJob()(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status code = %d, want %d", w.Code, http.StatusOK)
+		}
+		json.NewDecoder(w.Body).Decode(&progress)
+		if progress.Status != ImportJobRunning {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if progress.Status != ImportJobCompleted || progress.Imported != 3 {
+		t.Errorf("progress = %+v", progress)
+	}
+}
+
+func TestHandleBulkImport_Rejects(t *testing.T) {
+	tests := []struct {
+		name           string
+		filename       string
+		content        []byte
+		expectedStatus int
+	}{
+		{"not a zip extension", "export.tar", []byte("x"), http.StatusBadRequest},
+		{"corrupt zip", "export.zip", []byte("not a zip"), http.StatusBadRequest},
+		{"no activity files", "export.zip", zipBytes(t, map[string][]byte{"profile.json": []byte("{}")}), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := setupImportHandler(t)
+
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			part, _ := mw.CreateFormFile("file", tt.filename)
+			part.Write(tt.content)
+			mw.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/activities/import", &body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			req = withTestUser(req, "user@example.com", nil)
+			w := httptest.NewRecorder()
+
+			h.HandleBulkImport()(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Status code = %d, want %d (body: %s)", w.Code, tt.expectedStatus, strings.TrimSpace(w.Body.String()))
+			}
+		})
+	}
+}
+
+func TestHandleGetImportJob_NotFound(t *testing.T) {
+	h, _ := setupImportHandler(t)
+	job, _ := h.imports.start("other-user", []string{"a.gpx"})
+
+	req := withTestUser(httptest.NewRequest(http.MethodGet, "/activities/import/"+job.ID, nil), "user@example.com", map[string]string{"id": job.ID})
+	w := httptest.NewRecorder()
+	h.HandleGetImportJob()(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Status code = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+)
+
+// ComplianceStatus describes how well a completed activity fulfilled its planned workout
+type ComplianceStatus string
+
+const (
+	ComplianceCompleted ComplianceStatus = "completed"
+	CompliancePartial   ComplianceStatus = "partial"
+	ComplianceMissed    ComplianceStatus = "missed"
+	ComplianceOver      ComplianceStatus = "over"
+)
+
+// Compliance thresholds as a fraction of the planned targets
+const (
+	complianceCompletedThreshold = 0.9 // at least 90% completion counts as completed
+	complianceOverThreshold      = 1.2 // a completed workout with any target beyond 120% counts as over
+)
+
+// MetricCompliance compares a single planned target against the actual value
+type MetricCompliance struct {
+	Metric  string  `json:"metric"` // distance, duration, elevation_gain, avg_speed or power
+	Planned float64 `json:"planned"`
+	Actual  float64 `json:"actual"`
+	Percent float64 `json:"percent"` // actual as a percentage of planned
+}
+
+// WorkoutCompliance is the compliance result for one planned activity
+type WorkoutCompliance struct {
+	Status  ComplianceStatus   `json:"status"`
+	Percent float64            `json:"percent"` // completion: mean of metric percentages, each capped at 100
+	Metrics []MetricCompliance `json:"metrics"`
+}
+
+// ComplianceSummary rolls up workout compliance over a period or plan
+type ComplianceSummary struct {
+	Evaluated int     `json:"evaluated"`
+	Completed int     `json:"completed"`
+	Partial   int     `json:"partial"`
+	Missed    int     `json:"missed"`
+	Over      int     `json:"over"`
+	Percent   float64 `json:"percent"` // mean of workout percentages, each capped at 100
+}
+
+// WeeklyCompliance is a rollup for one Monday-based week
+type WeeklyCompliance struct {
+	WeekStart string `json:"week_start"` // YYYY-MM-DD of the local Monday
+	ComplianceSummary
+}
+
+// TrainingPlanCompliance is a rollup for one training plan enrollment
+type TrainingPlanCompliance struct {
+	UserTrainingPlanID string `json:"user_training_plan_id"`
+	ComplianceSummary
+}
+
+// workoutActuals holds the activity values that planned targets are compared against.
+// Nil fields were not recorded and are skipped.
+type workoutActuals struct {
+	DistanceM      float64
+	DurationS      float64
+	ElevationGainM *float64
+	AvgSpeedMps    *float64
+	AvgPowerWatt   *float64
+}
+
+func actualsFromActivity(activity *models.Activity) workoutActuals {
+	actuals := workoutActuals{
+		DistanceM:      activity.DistanceM,
+		DurationS:      float64(activity.ElapsedTime),
+		ElevationGainM: activity.ElevationGainM,
+		AvgSpeedMps:    activity.AvgSpeedMps,
+	}
+	if activity.AvgPowerWatt != nil {
+		power := float64(*activity.AvgPowerWatt)
+		actuals.AvgPowerWatt = &power
+	}
+	return actuals
+}
+
+// isEvaluablePlannedType reports whether an unmatched plan of this type can be considered missed.
+// Rest, strength and mobility sessions aren't recorded as GPS activities, so they only count once linked.
+func isEvaluablePlannedType(plannedType models.PlannedActivityType) bool {
+	switch plannedType {
+	case models.PlannedActivityTypeRunning, models.PlannedActivityTypeRoadBiking, models.PlannedActivityTypeCrossTraining:
+		return true
+	}
+	return false
+}
+
+// evaluateWorkoutCompliance scores a planned activity against its matched activity.
+// Returns nil when there is nothing to evaluate yet (unmatched and the planned day hasn't passed in loc,
+// the same local day activities are matched on).
+func evaluateWorkoutCompliance(plan models.PlannedActivity, activity *models.Activity, now time.Time, loc *time.Location) *WorkoutCompliance {
+	if activity == nil {
+		if !isEvaluablePlannedType(plan.Type) {
+			return nil
+		}
+		_, dayEnd := localDayBounds(plan.StartTime, loc)
+		if now.Before(dayEnd) {
+			return nil
+		}
+		return &WorkoutCompliance{Status: ComplianceMissed, Percent: 0, Metrics: []MetricCompliance{}}
+	}
+
+	actuals := actualsFromActivity(activity)
+	metrics := make([]MetricCompliance, 0, 5)
+
+	addMetric := func(name string, planned *float64, actual *float64) {
+		if planned == nil || *planned <= 0 || actual == nil {
+			return
+		}
+		metrics = append(metrics, MetricCompliance{
+			Metric:  name,
+			Planned: *planned,
+			Actual:  *actual,
+			Percent: roundTo(*actual / *planned * 100, 1),
+		})
+	}
+
+	addMetric("distance", plan.PlannedDistanceM, &actuals.DistanceM)
+	if plan.PlannedDurationS != nil {
+		plannedDuration := float64(*plan.PlannedDurationS)
+		addMetric("duration", &plannedDuration, &actuals.DurationS)
+	}
+	addMetric("elevation_gain", plan.PlannedElevationGainM, actuals.ElevationGainM)
+	addMetric("avg_speed", plan.TargetAvgSpeedMps, actuals.AvgSpeedMps)
+	if plan.TargetPowerWatt != nil {
+		plannedPower := float64(*plan.TargetPowerWatt)
+		addMetric("power", &plannedPower, actuals.AvgPowerWatt)
+	}
+
+	// A linked workout without comparable targets is simply done
+	if len(metrics) == 0 {
+		return &WorkoutCompliance{Status: ComplianceCompleted, Percent: 100, Metrics: metrics}
+	}
+
+	// Capping keeps one target's excess from hiding another's shortfall
+	var total float64
+	over := false
+	for _, m := range metrics {
+		total += math.Min(m.Percent, 100)
+		over = over || m.Percent/100 > complianceOverThreshold
+	}
+	percent := roundTo(total/float64(len(metrics)), 1)
+
+	status := CompliancePartial
+	if percent/100 >= complianceCompletedThreshold {
+		status = ComplianceCompleted
+		if over {
+			status = ComplianceOver
+		}
+	}
+
+	return &WorkoutCompliance{Status: status, Percent: percent, Metrics: metrics}
+}
+
+// add folds a workout result into the summary; callers recompute Percent with finalize
+func (s *ComplianceSummary) add(c *WorkoutCompliance) {
+	if c == nil {
+		return
+	}
+	s.Evaluated++
+	switch c.Status {
+	case ComplianceCompleted:
+		s.Completed++
+	case CompliancePartial:
+		s.Partial++
+	case ComplianceMissed:
+		s.Missed++
+	case ComplianceOver:
+		s.Over++
+	}
+	// Percent accumulates the capped total until finalize turns it into a mean
+	s.Percent += math.Min(c.Percent, 100)
+}
+
+func (s *ComplianceSummary) finalize() {
+	if s.Evaluated > 0 {
+		s.Percent = roundTo(s.Percent/float64(s.Evaluated), 1)
+	}
+}
+
+// weekStart returns the Monday of the week containing t in loc
+func weekStart(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	offset := (int(t.Weekday()) + 6) % 7 // Monday = 0
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, loc)
+}
+
+// buildComplianceRollups evaluates every planned activity and groups the results per local week and per enrollment.
+// matched maps activity IDs to the user's activities referenced by the plans; loc is the user's timezone.
+func buildComplianceRollups(plans []models.PlannedActivity, matched map[string]*models.Activity, now time.Time, loc *time.Location) ([]PlannedActivityResult, []WeeklyCompliance, []TrainingPlanCompliance) {
+	results := make([]PlannedActivityResult, 0, len(plans))
+	weekly := make(map[string]*WeeklyCompliance)
+	byPlan := make(map[string]*TrainingPlanCompliance)
+
+	for i := range plans {
+		plan := plans[i]
+
+		var activity *models.Activity
+		if plan.MatchedActivityID != nil {
+			activity = matched[plan.MatchedActivityID.String()]
+		}
+
+		compliance := evaluateWorkoutCompliance(plan, activity, now, loc)
+		result := createPlannedActivityResult(&plan)
+		result.Compliance = compliance
+		results = append(results, result)
+
+		if compliance == nil {
+			continue
+		}
+
+		week := weekStart(plan.StartTime, loc).Format("2006-01-02")
+		if weekly[week] == nil {
+			weekly[week] = &WeeklyCompliance{WeekStart: week}
+		}
+		weekly[week].add(compliance)
+
+		if plan.UserTrainingPlanID != nil {
+			id := plan.UserTrainingPlanID.String()
+			if byPlan[id] == nil {
+				byPlan[id] = &TrainingPlanCompliance{UserTrainingPlanID: id}
+			}
+			byPlan[id].add(compliance)
+		}
+	}
+
+	weeklyResults := make([]WeeklyCompliance, 0, len(weekly))
+	for _, w := range weekly {
+		w.finalize()
+		weeklyResults = append(weeklyResults, *w)
+	}
+	sort.Slice(weeklyResults, func(i, j int) bool { return weeklyResults[i].WeekStart < weeklyResults[j].WeekStart })
+
+	planResults := make([]TrainingPlanCompliance, 0, len(byPlan))
+	for _, p := range byPlan {
+		p.finalize()
+		planResults = append(planResults, *p)
+	}
+	sort.Slice(planResults, func(i, j int) bool { return planResults[i].UserTrainingPlanID < planResults[j].UserTrainingPlanID })
+
+	return results, weeklyResults, planResults
+}
+
+// loadMatchedActivities returns the activities referenced by the plans, reusing already loaded ones
+func (h *Handler) loadMatchedActivities(ctx context.Context, userID string, plans []models.PlannedActivity, known []models.Activity) (map[string]*models.Activity, error) {
+	matched := make(map[string]*models.Activity, len(known))
+	for i := range known {
+		matched[known[i].ID.String()] = &known[i]
+	}
+
+	var missing []string
+	for _, plan := range plans {
+		if plan.MatchedActivityID == nil {
+			continue
+		}
+		id := plan.MatchedActivityID.String()
+		if _, ok := matched[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) == 0 {
+		return matched, nil
+	}
+
+	activities, err := h.database.GetActivitiesByIDs(ctx, userID, missing)
+	if err != nil {
+		return nil, err
+	}
+	for i := range activities {
+		matched[activities[i].ID.String()] = &activities[i]
+	}
+	return matched, nil
+}
+
+// roundTo rounds v to the given number of decimal places
+func roundTo(v float64, decimals int) float64 {
+	pow := math.Pow(10, float64(decimals))
+	return math.Round(v*pow) / pow
+}
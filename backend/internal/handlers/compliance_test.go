@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestEvaluateWorkoutCompliance(t *testing.T) {
+	start := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	now := start.Add(72 * time.Hour)
+
+	tests := []struct {
+		name     string
+		plan     models.PlannedActivity
+		activity *models.Activity
+		now      time.Time
+		status   ComplianceStatus
+		percent  float64
+	}{
+		{"on target", makePlan(models.PlannedActivityTypeRunning, start, floatPtr(10000), intPtr(3000)),
+			&models.Activity{DistanceM: 10000, ElapsedTime: 3000}, now, ComplianceCompleted, 100},
+		{"just completed", makePlan(models.PlannedActivityTypeRunning, start, floatPtr(10000), nil),
+			&models.Activity{DistanceM: 9000}, now, ComplianceCompleted, 90},
+		{"partial", makePlan(models.PlannedActivityTypeRunning, start, floatPtr(10000), nil),
+			&models.Activity{DistanceM: 5000}, now, CompliancePartial, 50},
+		{"over", makePlan(models.PlannedActivityTypeRunning, start, floatPtr(10000), nil),
+			&models.Activity{DistanceM: 15000}, now, ComplianceOver, 100},
+		// 100% distance and 80% duration average to exactly the completed threshold
+		{"averages metrics", makePlan(models.PlannedActivityTypeRunning, start, floatPtr(10000), intPtr(3000)),
+			&models.Activity{DistanceM: 10000, ElapsedTime: 2400}, now, ComplianceCompleted, 90},
+		// Running long doesn't make up for running short
+		{"mixed shortfall and excess", makePlan(models.PlannedActivityTypeRunning, start, floatPtr(10000), intPtr(3000)),
+			&models.Activity{DistanceM: 5000, ElapsedTime: 4500}, now, CompliancePartial, 75},
+		{"over on one target", makePlan(models.PlannedActivityTypeRunning, start, floatPtr(10000), intPtr(3000)),
+			&models.Activity{DistanceM: 10000, ElapsedTime: 4500}, now, ComplianceOver, 100},
+		{"linked without targets", makePlan(models.PlannedActivityTypeRunning, start, nil, nil),
+			&models.Activity{DistanceM: 1000}, now, ComplianceCompleted, 100},
+		{"missed", makePlan(models.PlannedActivityTypeRunning, start, floatPtr(10000), nil),
+			nil, now, ComplianceMissed, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluateWorkoutCompliance(tt.plan, tt.activity, tt.now, time.UTC)
+			if got == nil {
+				t.Fatal("evaluateWorkoutCompliance() = nil")
+			}
+			if got.Status != tt.status || got.Percent != tt.percent {
+				t.Errorf("evaluateWorkoutCompliance() = %s %.1f%%, want %s %.1f%%", got.Status, got.Percent, tt.status, tt.percent)
+			}
+		})
+	}
+}
+
+func TestEvaluateWorkoutCompliance_NotYetDue(t *testing.T) {
+	start := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+
+	// Later the same day the workout can still be done
+	plan := makePlan(models.PlannedActivityTypeRunning, start, floatPtr(10000), nil)
+	if got := evaluateWorkoutCompliance(plan, nil, start.Add(6*time.Hour), time.UTC); got != nil {
+		t.Errorf("Expected nil for a workout still due today, got %s", got.Status)
+	}
+
+	// Rest days never show as missed
+	rest := makePlan(models.PlannedActivityTypeResting, start, nil, nil)
+	if got := evaluateWorkoutCompliance(rest, nil, start.Add(72*time.Hour), time.UTC); got != nil {
+		t.Errorf("Expected nil for an unlinked rest day, got %s", got.Status)
+	}
+}
+
+func TestEvaluateWorkoutCompliance_LocalDay(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	// 6 pm on March 2 in Los Angeles is already March 3 in UTC
+	start := time.Date(2026, 3, 3, 2, 0, 0, 0, time.UTC)
+	plan := makePlan(models.PlannedActivityTypeRunning, start, floatPtr(10000), nil)
+	afterUTCDay := time.Date(2026, 3, 4, 1, 0, 0, 0, time.UTC) // 5 pm on March 3 in Los Angeles
+
+	if got := evaluateWorkoutCompliance(plan, nil, afterUTCDay, time.UTC); got == nil || got.Status != ComplianceMissed {
+		t.Errorf("Expected missed once the UTC day is over, got %v", got)
+	}
+	localDayEnd := time.Date(2026, 3, 3, 8, 0, 0, 0, time.UTC) // midnight in Los Angeles
+	if got := evaluateWorkoutCompliance(plan, nil, localDayEnd.Add(-time.Minute), losAngeles); got != nil {
+		t.Errorf("Expected nil before the local day is over, got %s", got.Status)
+	}
+	if got := evaluateWorkoutCompliance(plan, nil, localDayEnd, losAngeles); got == nil || got.Status != ComplianceMissed {
+		t.Errorf("Expected missed once the local day is over, got %v", got)
+	}
+}
+
+func TestBuildComplianceRollups(t *testing.T) {
+	monday := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	now := monday.Add(14 * 24 * time.Hour)
+	enrollment := uuid.New()
+
+	completedRun := &models.Activity{ID: uuid.New(), DistanceM: 10000}
+	partialRun := &models.Activity{ID: uuid.New(), DistanceM: 5000}
+	overRun := &models.Activity{ID: uuid.New(), DistanceM: 20000}
+
+	plans := []models.PlannedActivity{
+		makePlan(models.PlannedActivityTypeRunning, monday, floatPtr(10000), nil),
+		makePlan(models.PlannedActivityTypeRunning, monday.Add(2*24*time.Hour), floatPtr(10000), nil),
+		makePlan(models.PlannedActivityTypeRunning, monday.Add(6*24*time.Hour), floatPtr(10000), nil), // Sunday, same week
+		makePlan(models.PlannedActivityTypeRunning, monday.Add(7*24*time.Hour), floatPtr(10000), nil), // next Monday
+		makePlan(models.PlannedActivityTypeResting, monday.Add(8*24*time.Hour), nil, nil),
+	}
+	plans[0].MatchedActivityID = &completedRun.ID
+	plans[1].MatchedActivityID = &partialRun.ID
+	plans[3].MatchedActivityID = &overRun.ID
+	for i := range plans {
+		plans[i].UserTrainingPlanID = &enrollment
+	}
+
+	matched := map[string]*models.Activity{
+		completedRun.ID.String(): completedRun,
+		partialRun.ID.String():   partialRun,
+		overRun.ID.String():      overRun,
+	}
+
+	results, weekly, byPlan := buildComplianceRollups(plans, matched, now, time.UTC)
+
+	if len(results) != len(plans) {
+		t.Fatalf("len(results) = %d, want %d", len(results), len(plans))
+	}
+	if results[4].Compliance != nil {
+		t.Error("Rest day should not be evaluated")
+	}
+
+	if len(weekly) != 2 {
+		t.Fatalf("len(weekly) = %d, want 2", len(weekly))
+	}
+	first := weekly[0]
+	if first.WeekStart != "2026-03-02" || first.Evaluated != 3 || first.Completed != 1 || first.Partial != 1 || first.Missed != 1 {
+		t.Errorf("first week = %+v", first)
+	}
+	if first.Percent != 50 {
+		t.Errorf("first week percent = %v, want 50", first.Percent)
+	}
+	// Over-achievement is capped at 100% in rollups
+	if second := weekly[1]; second.WeekStart != "2026-03-09" || second.Over != 1 || second.Percent != 100 {
+		t.Errorf("second week = %+v", second)
+	}
+
+	if len(byPlan) != 1 || byPlan[0].UserTrainingPlanID != enrollment.String() || byPlan[0].Evaluated != 4 {
+		t.Fatalf("plan rollup = %+v", byPlan)
+	}
+	if byPlan[0].Percent != 62.5 {
+		t.Errorf("plan percent = %v, want 62.5", byPlan[0].Percent)
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	sunday := time.Date(2026, 3, 8, 23, 0, 0, 0, time.UTC)
+	if got := weekStart(sunday, time.UTC).Format("2006-01-02"); got != "2026-03-02" {
+		t.Errorf("weekStart(Sunday) = %s, want 2026-03-02", got)
+	}
+	monday := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	if got := weekStart(monday, time.UTC).Format("2006-01-02"); got != "2026-03-09" {
+		t.Errorf("weekStart(Monday) = %s, want 2026-03-09", got)
+	}
+
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	// 6 pm on Sunday in Los Angeles is already Monday in UTC
+	sundayEvening := time.Date(2026, 3, 9, 1, 0, 0, 0, time.UTC)
+	if got := weekStart(sundayEvening, losAngeles); got.Format("2006-01-02") != "2026-03-02" || got.Location() != losAngeles {
+		t.Errorf("weekStart(Sunday evening in Los Angeles) = %s, want Monday 2026-03-02 in Los Angeles", got)
+	}
+}
+
+func TestBuildComplianceRollups_LocalWeek(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	// 6 pm on Sunday March 8 in Los Angeles, which is Monday March 9 in UTC
+	sundayEvening := time.Date(2026, 3, 9, 1, 0, 0, 0, time.UTC)
+	run := &models.Activity{ID: uuid.New(), DistanceM: 10000}
+	plans := []models.PlannedActivity{
+		makePlan(models.PlannedActivityTypeRunning, sundayEvening, floatPtr(10000), nil),
+		makePlan(models.PlannedActivityTypeRunning, sundayEvening.Add(-48*time.Hour), floatPtr(10000), nil), // Friday
+	}
+	plans[0].MatchedActivityID = &run.ID
+	now := sundayEvening.Add(7 * 24 * time.Hour)
+
+	_, weekly, _ := buildComplianceRollups(plans, map[string]*models.Activity{run.ID.String(): run}, now, losAngeles)
+	if len(weekly) != 1 {
+		t.Fatalf("Expected both workouts in one local week, got %+v", weekly)
+	}
+	if week := weekly[0]; week.WeekStart != "2026-03-02" || week.Completed != 1 || week.Missed != 1 {
+		t.Errorf("week = %+v, want the week of Monday 2026-03-02 with one completed and one missed", week)
+	}
+
+	if _, weekly, _ = buildComplianceRollups(plans, map[string]*models.Activity{run.ID.String(): run}, now, time.UTC); len(weekly) != 2 {
+		t.Errorf("Expected the Sunday evening workout in the next UTC week, got %+v", weekly)
+	}
+}
+
+func TestHandleGetTrainingPlanProgress(t *testing.T) {
+	h, mockDB := newMatchingTestHandler()
+
+	enrollmentID := uuid.New()
+	mockDB.enrollments[enrollmentID.String()] = &models.UserTrainingPlan{
+		ID:     enrollmentID,
+		UserID: "user-123",
+		Title:  "10K Plan",
+	}
+
+	activityID := "550e8400-e29b-41d4-a716-446655440000"
+	mockDB.activities[activityID] = createTestActivity(activityID, "user-123")
+	matchedID := uuid.MustParse(activityID)
+
+	past := time.Now().Add(-7 * 24 * time.Hour)
+	done := makePlan(models.PlannedActivityTypeRunning, past, nil, nil)
+	done.UserTrainingPlanID = &enrollmentID
+	done.MatchedActivityID = &matchedID
+	missed := makePlan(models.PlannedActivityTypeRunning, past.Add(24*time.Hour), floatPtr(5000), nil)
+	missed.UserTrainingPlanID = &enrollmentID
+	unrelated := makePlan(models.PlannedActivityTypeRunning, past, nil, nil)
+	mockDB.planned = []models.PlannedActivity{done, missed, unrelated}
+
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+	}{
+		{"returns progress", enrollmentID.String(), http.StatusOK},
+		{"unknown enrollment", uuid.New().String(), http.StatusNotFound},
+		{"invalid id", "nope", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/training-plans/enrollments/"+tt.id+"/progress", nil)
+			req = withTestUser(req, "user@example.com", map[string]string{"id": tt.id})
+			w := httptest.NewRecorder()
+
+			h.HandleGetTrainingPlanProgress()(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Status code = %d, want %d (body: %s)", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp TrainingPlanProgressResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(resp.Workouts) != 2 {
+				t.Errorf("len(Workouts) = %d, want 2", len(resp.Workouts))
+			}
+			if resp.Summary.Evaluated != 2 || resp.Summary.Completed != 1 || resp.Summary.Missed != 1 {
+				t.Errorf("Summary = %+v", resp.Summary)
+			}
+			if resp.Summary.Percent != 50 {
+				t.Errorf("Summary.Percent = %v, want 50", resp.Summary.Percent)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+)
+
+// DecimationStrategy is how the points of a downsampled stream are picked
+type DecimationStrategy string
+
+const (
+	DecimationUniform DecimationStrategy = "uniform" // evenly spaced along the index axis
+	DecimationLTTB    DecimationStrategy = "lttb"    // largest-triangle-three-buckets, keeps climbs and spikes
+)
+
+// ParseDecimationStrategy validates a decimation strategy name
+func ParseDecimationStrategy(name string) (DecimationStrategy, error) {
+	switch strategy := DecimationStrategy(name); strategy {
+	case DecimationUniform, DecimationLTTB:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("invalid decimation strategy: %s (must be uniform or lttb)", name)
+	}
+}
+
+// SetStreamDecimation sets the strategy medium LOD streams are downsampled with at ingest
+func (h *Handler) SetStreamDecimation(strategy DecimationStrategy) {
+	h.decimation = strategy
+}
+
+// streamDecimation returns the configured ingest strategy, uniform unless set
+func (h *Handler) streamDecimation() DecimationStrategy {
+	if h.decimation == "" {
+		return DecimationUniform
+	}
+	return h.decimation
+}
+
+// decimationFromCodec reads the strategy recorded in a stream's codec metadata.
+// Streams stored before strategies were recorded were decimated uniformly.
+func decimationFromCodec(codec map[string]interface{}) DecimationStrategy {
+	if name, ok := codec["decimation"].(string); ok {
+		if strategy, err := ParseDecimationStrategy(name); err == nil {
+			return strategy
+		}
+	}
+	return DecimationUniform
+}
+
+// decimate picks about targetPoints indices of a stream along its index axis
+func decimate(fullStream *FullResolutionStream, indexBy models.StreamIndexBy, strategy DecimationStrategy, targetPoints int) []int {
+	axis := fullStream.DistanceM
+	if indexBy == models.StreamIndexByTime {
+		axis = fullStream.TimeS
+	}
+
+	if strategy == DecimationLTTB {
+		return decimateLTTB(axis, [][]float64{
+			fullStream.ElevationM,
+			fullStream.SpeedMps,
+			fullStream.HeartRateBpm,
+			fullStream.PowerWatt,
+		}, targetPoints)
+	}
+	if indexBy == models.StreamIndexByTime {
+		return decimateByTime(fullStream, targetPoints)
+	}
+	return decimateByDistance(fullStream, targetPoints)
+}
+
+// normalizeSeries scales values to [0, 1] so differently scaled series weigh the same.
+// Returns nil for series that are missing or flat.
+func normalizeSeries(values []float64, n int) []float64 {
+	if len(values) != n || n == 0 {
+		return nil
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	if hi == lo {
+		return nil
+	}
+	normalized := make([]float64, n)
+	for i, v := range values {
+		normalized[i] = (v - lo) / (hi - lo)
+	}
+	return normalized
+}
+
+// decimateLTTB performs largest-triangle-three-buckets decimation of the series plotted against axis.
+// The points between the first and last are split into targetPoints-2 buckets, and each bucket keeps the point
+// forming the largest triangle with the previously kept point and the average of the next bucket. Areas are
+// summed over all series after normalizing, so a sharp climb in elevation and a sprint spike in speed both
+// survive. Series that are missing or don't match the axis length are ignored.
+func decimateLTTB(axis []float64, series [][]float64, targetPoints int) []int {
+	n := len(axis)
+	if n <= targetPoints || targetPoints < 3 {
+		return decimateEvenly(axis, targetPoints)
+	}
+
+	x := normalizeSeries(axis, n)
+	if x == nil {
+		return []int{0, n - 1}
+	}
+	var ys [][]float64
+	for _, values := range series {
+		if y := normalizeSeries(values, n); y != nil {
+			ys = append(ys, y)
+		}
+	}
+	if len(ys) == 0 {
+		return decimateEvenly(axis, targetPoints)
+	}
+
+	bucketSize := float64(n-2) / float64(targetPoints-2)
+	bucketStart := func(bucket int) int { return int(math.Floor(float64(bucket)*bucketSize)) + 1 }
+
+	keepIndices := make([]int, 0, targetPoints)
+	keepIndices = append(keepIndices, 0) // Always keep first point
+
+	a := 0
+	avgY := make([]float64, len(ys))
+	for bucket := 0; bucket < targetPoints-2; bucket++ {
+		start, end := bucketStart(bucket), bucketStart(bucket+1)
+
+		// Average of the next bucket, which is the last point for the final bucket
+		nextStart, nextEnd := end, min(bucketStart(bucket+2), n-1)
+		if nextStart >= nextEnd {
+			nextStart, nextEnd = n-1, n
+		}
+		avgX := 0.0
+		for s := range avgY {
+			avgY[s] = 0
+		}
+		for i := nextStart; i < nextEnd; i++ {
+			avgX += x[i]
+			for s, y := range ys {
+				avgY[s] += y[i]
+			}
+		}
+		count := float64(nextEnd - nextStart)
+		avgX /= count
+		for s := range avgY {
+			avgY[s] /= count
+		}
+
+		best, bestArea := start, -1.0
+		for i := start; i < end; i++ {
+			area := 0.0
+			for s, y := range ys {
+				area += math.Abs((x[a]-avgX)*(y[i]-y[a]) - (x[a]-x[i])*(avgY[s]-y[a]))
+			}
+			if area > bestArea {
+				best, bestArea = i, area
+			}
+		}
+
+		keepIndices = append(keepIndices, best)
+		a = best
+	}
+
+	// Always keep last point
+	return append(keepIndices, n-1)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/anish-chanda/cadent/backend/internal/logger"
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// spikyStream is a steady 3 m/s effort sampled every second with a sprint spike and a short steep climb
+func spikyStream(n int) *FullResolutionStream {
+	stream := &FullResolutionStream{
+		TimeS:      make([]float64, n),
+		DistanceM:  make([]float64, n),
+		ElevationM: make([]float64, n),
+		SpeedMps:   make([]float64, n),
+	}
+	for i := 0; i < n; i++ {
+		stream.TimeS[i] = float64(i)
+		stream.DistanceM[i] = 3 * float64(i)
+		stream.ElevationM[i] = 100
+		stream.SpeedMps[i] = 3
+		if i >= 211 {
+			stream.ElevationM[i] = 100 + 4*float64(min(i-211, 5)) // 20 m in 5 s
+		}
+	}
+	stream.SpeedMps[537] = 9
+	return stream
+}
+
+func containsIndex(indices []int, want int) bool {
+	for _, idx := range indices {
+		if idx == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestParseDecimationStrategy(t *testing.T) {
+	for _, name := range []string{"uniform", "lttb"} {
+		if strategy, err := ParseDecimationStrategy(name); err != nil || string(strategy) != name {
+			t.Errorf("ParseDecimationStrategy(%q) = %q, %v", name, strategy, err)
+		}
+	}
+	if _, err := ParseDecimationStrategy("douglas-peucker"); err == nil {
+		t.Error("Expected an error for an unknown strategy")
+	}
+
+	if got := decimationFromCodec(map[string]interface{}{"name": "dibs"}); got != DecimationUniform {
+		t.Errorf("Expected streams without a recorded strategy to be uniform, got %q", got)
+	}
+	if got := decimationFromCodec(map[string]interface{}{"decimation": "lttb"}); got != DecimationLTTB {
+		t.Errorf("Expected the recorded strategy, got %q", got)
+	}
+}
+
+func TestDecimateLTTB(t *testing.T) {
+	stream := spikyStream(1000)
+
+	indices := decimate(stream, models.StreamIndexByDistance, DecimationLTTB, 50)
+	if len(indices) != 50 || indices[0] != 0 || indices[len(indices)-1] != 999 {
+		t.Fatalf("Expected 50 points from first to last, got %d: %v", len(indices), indices)
+	}
+	for i := 1; i < len(indices); i++ {
+		if indices[i] <= indices[i-1] {
+			t.Fatalf("Expected strictly increasing indices, got %v", indices)
+		}
+	}
+	if !containsIndex(indices, 537) {
+		t.Error("Expected LTTB to keep the sprint spike")
+	}
+	if !containsIndex(indices, 211) && !containsIndex(indices, 216) {
+		t.Errorf("Expected LTTB to keep a corner of the climb, got %v", indices)
+	}
+
+	if containsIndex(decimate(stream, models.StreamIndexByDistance, DecimationUniform, 50), 537) {
+		t.Error("Expected uniform decimation to miss the spike, the test no longer shows the difference")
+	}
+
+	t.Run("small and flat streams", func(t *testing.T) {
+		if got := decimateLTTB([]float64{0, 1, 2}, [][]float64{{1, 2, 3}}, 10); len(got) != 3 {
+			t.Errorf("Expected all points of a short stream, got %v", got)
+		}
+		axis, flat := make([]float64, 100), make([]float64, 100)
+		for i := range axis {
+			axis[i], flat[i] = float64(i), 3
+		}
+		if got := decimateLTTB(axis, [][]float64{nil, flat}, 10); len(got) != 10 {
+			t.Errorf("Expected flat streams to fall back to even spacing, got %v", got)
+		}
+	})
+}
+
+func TestGetLowLODStreams_LTTB(t *testing.T) {
+	activityID := uuid.New()
+	testLogger := logger.New(logger.Config{Level: "info", Environment: "test", ServiceName: "test-service"})
+
+	for _, strategy := range []DecimationStrategy{DecimationUniform, DecimationLTTB} {
+		t.Run(string(strategy), func(t *testing.T) {
+			streams, err := createMediumLODStreams(activityID, spikyStream(1000), strategy)
+			if err != nil {
+				t.Fatalf("createMediumLODStreams() error = %v", err)
+			}
+			if streams[0].Codec["decimation"] != string(strategy) {
+				t.Fatalf("Expected the strategy in the codec, got %v", streams[0].Codec)
+			}
+			mockDB := NewMockDatabase()
+			mockDB.activityStreams[activityID.String()+string(models.StreamLODMedium)] = streams
+
+			result, numPoints, _, decimation, err := getLowLODStreams(context.Background(), mockDB, activityID.String(),
+				models.StreamIndexByDistance, []models.StreamType{models.StreamTypeSpeed}, testLogger)
+			if err != nil {
+				t.Fatalf("getLowLODStreams() error = %v", err)
+			}
+			if decimation != strategy || len(result) != 1 || result[0].Type != models.StreamTypeSpeed {
+				t.Fatalf("Expected only the speed stream decimated with %s, got %s and %+v", strategy, decimation, result)
+			}
+			if strategy == DecimationLTTB && numPoints != LowLODTargetPoints {
+				t.Errorf("Expected %d points, got %d", LowLODTargetPoints, numPoints)
+			}
+
+			spike := false
+			for _, v := range result[0].Values {
+				spike = spike || v == 9
+			}
+			if spike != (strategy == DecimationLTTB) {
+				t.Errorf("Spike kept = %v with %s decimation", spike, strategy)
+			}
+		})
+	}
+}
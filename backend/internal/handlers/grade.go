@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"math"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+)
+
+// Grade stream constants
+const (
+	GradeWindowM   = 50.0 // distance the grade at a point is measured over, centered on the point
+	MaxGradePct    = 45.0 // grades are clamped to ±45%, steeper values are elevation noise
+	gradeMinWindow = 5.0  // windows shorter than this, such as a standing start, have no meaningful grade
+)
+
+// gradeStream computes the grade in percent at every point as the elevation change over a GradeWindowM
+// window of distance centered on the point. Measuring over distance instead of between neighbouring points
+// smooths out GPS and barometer noise and is unaffected by stops, where points pile up at the same distance.
+// Returns nil when distance or elevation is missing.
+func gradeStream(distanceM, elevationM []float64) []float64 {
+	n := len(distanceM)
+	if n == 0 || len(elevationM) != n {
+		return nil
+	}
+
+	total := distanceM[n-1]
+	grades := make([]float64, n)
+	for i, d := range distanceM {
+		lower := math.Max(d-GradeWindowM/2, 0)
+		upper := math.Min(d+GradeWindowM/2, total)
+		if upper-lower < gradeMinWindow {
+			continue
+		}
+		rise := interpolateAt(distanceM, elevationM, upper) - interpolateAt(distanceM, elevationM, lower)
+		grades[i] = math.Max(-MaxGradePct, math.Min(MaxGradePct, rise/(upper-lower)*100))
+	}
+	return grades
+}
+
+// runningCost is the metabolic cost of running in J/kg/m at a grade given as a fraction, after Minetti et al.
+// (2002). Running flat costs 3.6 J/kg/m, the cheapest grade is about -20%.
+func runningCost(grade float64) float64 {
+	g := math.Max(-MaxGradePct/100, math.Min(MaxGradePct/100, grade))
+	return 155.4*math.Pow(g, 5) - 30.4*math.Pow(g, 4) - 43.3*math.Pow(g, 3) + 46.3*g*g + 19.5*g + 3.6
+}
+
+// gradeAdjustedSpeed returns a run's average grade-adjusted pace as the flat speed in m/s costing the same
+// effort: every segment's distance is scaled by the cost of running it at its grade relative to running
+// flat, and the flat-equivalent distance is divided by movingSeconds. Returns nil for other activity types
+// and for streams without grade.
+func gradeAdjustedSpeed(activityType models.ActivityType, fullStream *FullResolutionStream, movingSeconds float64) *float64 {
+	if activityType != models.ActivityTypeRun || movingSeconds <= 0 {
+		return nil
+	}
+	if len(fullStream.GradePct) == 0 || len(fullStream.GradePct) != len(fullStream.DistanceM) {
+		return nil
+	}
+
+	flatCost := runningCost(0)
+	var flatDistance float64
+	for i := 1; i < len(fullStream.DistanceM); i++ {
+		segment := fullStream.DistanceM[i] - fullStream.DistanceM[i-1]
+		grade := (fullStream.GradePct[i-1] + fullStream.GradePct[i]) / 200 // mean of both ends, as a fraction
+		flatDistance += segment * runningCost(grade) / flatCost
+	}
+	if flatDistance <= 0 {
+		return nil
+	}
+
+	speed := flatDistance / movingSeconds
+	return &speed
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/anish-chanda/cadent/backend/internal/logger"
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// hillStream is a run at 3 m/s over 1 km flat, 1 km climbing at 8% and 1 km descending at 8%, with a
+// 30 s stop at the top
+func hillStream() *FullResolutionStream {
+	stream := &FullResolutionStream{}
+	var t, d float64
+	add := func() {
+		elevation := 100.0
+		switch {
+		case d > 2000:
+			elevation = 180 - 0.08*(d-2000)
+		case d > 1000:
+			elevation = 100 + 0.08*(d-1000)
+		}
+		stream.TimeS = append(stream.TimeS, t)
+		stream.DistanceM = append(stream.DistanceM, d)
+		stream.ElevationM = append(stream.ElevationM, elevation)
+		stream.SpeedMps = append(stream.SpeedMps, 3)
+	}
+	for d < 3000 {
+		add()
+		if d == 2001 {
+			for i := 0; i < 30; i++ {
+				t++
+				add()
+			}
+		}
+		t, d = t+1, d+3
+	}
+	stream.GradePct = gradeStream(stream.DistanceM, stream.ElevationM)
+	return stream
+}
+
+func TestGradeStream(t *testing.T) {
+	stream := hillStream()
+	gradeAt := func(distance float64) float64 {
+		for i, d := range stream.DistanceM {
+			if d >= distance {
+				return stream.GradePct[i]
+			}
+		}
+		t.Fatalf("no point at %fm", distance)
+		return 0
+	}
+
+	tests := []struct {
+		name     string
+		distance float64
+		expected float64
+	}{
+		{"flat start", 0, 0},
+		{"flat", 500, 0},
+		{"climb", 1500, 8},
+		{"descent", 2500, -8},
+		{"halfway into the climb", 1000, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gradeAt(tt.distance); math.Abs(got-tt.expected) > 0.5 {
+				t.Errorf("grade at %.0fm = %.2f%%, want %.0f%%", tt.distance, got, tt.expected)
+			}
+		})
+	}
+
+	t.Run("stop", func(t *testing.T) {
+		for i, d := range stream.DistanceM {
+			if d == 2001 && math.Abs(stream.GradePct[i]) > 0.5 {
+				t.Errorf("grade while stopped at the top = %.2f%%, want about 0", stream.GradePct[i])
+			}
+		}
+	})
+
+	t.Run("noise is clamped", func(t *testing.T) {
+		grades := gradeStream([]float64{0, 10, 20, 30}, []float64{0, 0, 30, 30})
+		for _, g := range grades {
+			if math.Abs(g) > MaxGradePct {
+				t.Errorf("grade %.2f%% exceeds the clamp", g)
+			}
+		}
+	})
+
+	if gradeStream([]float64{0, 1}, []float64{0}) != nil {
+		t.Error("Expected nil grade for misaligned streams")
+	}
+}
+
+func TestGradeAdjustedSpeed(t *testing.T) {
+	if got := runningCost(0); got != 3.6 {
+		t.Errorf("runningCost(0) = %f, want 3.6", got)
+	}
+	if runningCost(0.1) <= runningCost(0) || runningCost(-0.1) >= runningCost(0) {
+		t.Error("Expected climbing to cost more and gentle descents less than running flat")
+	}
+
+	flat := &FullResolutionStream{DistanceM: []float64{0, 1000, 2000}, GradePct: []float64{0, 0, 0}}
+	if gap := gradeAdjustedSpeed(models.ActivityTypeRun, flat, 600); gap == nil || math.Abs(*gap-2000.0/600) > 1e-9 {
+		t.Errorf("Expected the moving speed on flat ground, got %v", gap)
+	}
+
+	stream := hillStream()
+	movingSeconds := 1000.0
+	gap := gradeAdjustedSpeed(models.ActivityTypeRun, stream, movingSeconds)
+	if gap == nil {
+		t.Fatal("Expected a grade-adjusted speed")
+	}
+	// An 8% climb costs about 50% more than flat and an 8% descent about 35% less
+	speed := stream.DistanceM[len(stream.DistanceM)-1] / movingSeconds
+	if *gap <= speed || *gap > speed*1.1 {
+		t.Errorf("grade-adjusted speed = %.3f, want a little above the moving speed %.3f", *gap, speed)
+	}
+
+	if gradeAdjustedSpeed(models.ActivityTypeRoadBike, stream, movingSeconds) != nil {
+		t.Error("Expected no grade-adjusted speed for rides")
+	}
+	if gradeAdjustedSpeed(models.ActivityTypeRun, &FullResolutionStream{DistanceM: []float64{0, 10}}, 5) != nil {
+		t.Error("Expected no grade-adjusted speed without a grade stream")
+	}
+}
+
+func TestGradeStreams(t *testing.T) {
+	activityID := uuid.New()
+	testLogger := logger.New(logger.Config{Level: "info", Environment: "test", ServiceName: "test-service"})
+
+	streams, err := createMediumLODStreams(activityID, hillStream(), DecimationUniform)
+	if err != nil {
+		t.Fatalf("createMediumLODStreams() error = %v", err)
+	}
+	mockDB := NewMockDatabase()
+	mockDB.activityStreams[activityID.String()+string(models.StreamLODMedium)] = streams
+
+	result, _, _, _, err := getMediumLODStreams(context.Background(), mockDB, activityID.String(), models.StreamIndexByDistance,
+		[]models.StreamType{models.StreamTypeDistance, models.StreamTypeGrade}, testLogger)
+	if err != nil {
+		t.Fatalf("getMediumLODStreams() error = %v", err)
+	}
+	if len(result) != 2 || len(result[0].Values) != len(result[1].Values) {
+		t.Fatalf("Expected aligned distance and grade streams, got %+v", result)
+	}
+	for i, d := range result[0].Values {
+		if d > 1100 && d < 1900 && math.Abs(result[1].Values[i]-8) > 0.5 {
+			t.Errorf("grade at %.0fm = %.1f%%, want 8%%", d, result[1].Values[i])
+		}
+	}
+}
+
+func TestCreateActivityResult_GradeAdjustedPace(t *testing.T) {
+	activity := createTestActivity(uuid.New().String(), "user-123")
+	activity.ActivityType = models.ActivityTypeRun
+	gap := 4.0
+	activity.AvgGAPMps = &gap
+
+	stats := createActivityResult(activity).Stats
+	if stats.GradeAdjustedSpeed == nil || *stats.GradeAdjustedSpeed != 4 {
+		t.Errorf("GradeAdjustedSpeed = %v, want 4", stats.GradeAdjustedSpeed)
+	}
+	if p := stats.Derived.GradeAdjustedPaceSPerKm; p == nil || *p != 250 {
+		t.Errorf("GradeAdjustedPaceSPerKm = %v, want 250", p)
+	}
+
+	activity.AvgGAPMps = nil
+	if stats := createActivityResult(activity).Stats; stats.GradeAdjustedSpeed != nil || stats.Derived.GradeAdjustedPaceSPerKm != nil {
+		t.Error("Expected no grade-adjusted pace for activities without one")
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"github.com/anish-chanda/cadent/backend/internal/db"
+	"github.com/anish-chanda/cadent/backend/internal/elevation"
 	"github.com/anish-chanda/cadent/backend/internal/logger"
 	"github.com/anish-chanda/cadent/backend/internal/store"
 	"github.com/anish-chanda/cadent/backend/internal/valhalla"
@@ -13,6 +14,18 @@ type Handler struct {
 	valhallaClient *valhalla.Client
 	objectStore    store.ObjectStore
 	log            *logger.ServiceLogger
+
+	// fullStreams caches parsed original files for lod=full stream requests; nil disables caching
+	fullStreams *fullStreamCache
+
+	// elevation looks up heights for uploaded tracks; nil falls back to valhallaClient
+	elevation elevation.Provider
+
+	// decimation picks the points of medium LOD streams at ingest; empty means uniform
+	decimation DecimationStrategy
+
+	// imports tracks running and recently finished bulk imports
+	imports *importJobRegistry
 }
 
 // NewHandler creates a handler set with shared dependencies.
@@ -22,5 +35,33 @@ func NewHandler(database db.Database, valhallaClient *valhalla.Client, objectSto
 		valhallaClient: valhallaClient,
 		objectStore:    objectStore,
 		log:            log,
+		imports:        newImportJobRegistry(),
+	}
+}
+
+// EnableFullStreamCache keeps up to capacity parsed activity files in memory for lod=full requests.
+// A capacity of zero or less leaves caching disabled.
+func (h *Handler) EnableFullStreamCache(capacity int) {
+	if capacity <= 0 {
+		h.fullStreams = nil
+		return
+	}
+	h.fullStreams = newFullStreamCache(capacity)
+}
+
+// SetElevationProvider sets where heights for uploaded tracks are looked up, e.g. local DEM tiles
+// instead of the Valhalla /height API
+func (h *Handler) SetElevationProvider(provider elevation.Provider) {
+	h.elevation = provider
+}
+
+// elevationProvider returns the configured elevation provider, the Valhalla client unless set
+func (h *Handler) elevationProvider() elevation.Provider {
+	if h.elevation != nil {
+		return h.elevation
+	}
+	if h.valhallaClient == nil {
+		return nil // avoid a non-nil interface holding a nil client
 	}
+	return h.valhallaClient
 }
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// deviceLap is a lap parsed from an activity file. Summary values are nil when the file
+// doesn't carry them and are then computed from the samples.
+type deviceLap struct {
+	StartT      int64 // unix milliseconds
+	ElapsedS    *float64
+	DistanceM   *float64
+	AvgSpeedMps *float64
+	AvgHRBpm    *int16
+	MaxHRBpm    *int16
+}
+
+// LapResult is a single lap in the laps response
+type LapResult struct {
+	Lap         int       `json:"lap"`         // 1-based
+	StartIndex  int       `json:"start_index"` // index into the full resolution streams
+	StartTime   time.Time `json:"start_time"`
+	ElapsedS    float64   `json:"elapsed_s"`
+	DistanceM   float64   `json:"distance_m"`
+	AvgSpeedMps *float64  `json:"avg_speed_mps"`
+	AvgHRBpm    *int16    `json:"avg_hr_bpm,omitempty"`
+	MaxHRBpm    *int16    `json:"max_hr_bpm,omitempty"`
+}
+
+// LapsResponse is the response for GET /activities/{id}/laps
+type LapsResponse struct {
+	ActivityID string      `json:"activity_id"`
+	Laps       []LapResult `json:"laps"`
+}
+
+// buildActivityLaps places device laps on the samples and fills in the summary values the file didn't carry.
+// A lap covers its samples up to the one before the next lap's first sample. Laps without samples
+// (e.g. recorded indoors without GPS) are dropped.
+func buildActivityLaps(activityID uuid.UUID, samples []Sample, stream *FullResolutionStream, laps []deviceLap) []models.ActivityLap {
+	if len(laps) == 0 || len(samples) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(laps, func(i, j int) bool { return laps[i].StartT < laps[j].StartT })
+	startIndices := make([]int, len(laps))
+	for i, lap := range laps {
+		startIndices[i] = sort.Search(len(samples), func(k int) bool { return samples[k].T >= lap.StartT })
+	}
+
+	now := time.Now()
+	var result []models.ActivityLap
+	for i, lap := range laps {
+		start, end := startIndices[i], len(samples)-1
+		if i+1 < len(laps) {
+			end = startIndices[i+1] - 1
+		}
+		if start >= len(samples) || start > end {
+			continue
+		}
+
+		activityLap := models.ActivityLap{
+			ActivityID:  activityID,
+			LapIndex:    len(result) + 1,
+			StartIndex:  start,
+			StartTime:   time.UnixMilli(samples[start].T).UTC(),
+			ElapsedS:    stream.TimeS[end] - stream.TimeS[start],
+			DistanceM:   stream.DistanceM[end] - stream.DistanceM[start],
+			AvgSpeedMps: lap.AvgSpeedMps,
+			AvgHRBpm:    lap.AvgHRBpm,
+			MaxHRBpm:    lap.MaxHRBpm,
+			CreatedAt:   now,
+		}
+		if lap.ElapsedS != nil {
+			activityLap.ElapsedS = *lap.ElapsedS
+		}
+		if lap.DistanceM != nil {
+			activityLap.DistanceM = *lap.DistanceM
+		}
+		if activityLap.AvgSpeedMps == nil && activityLap.ElapsedS > 0 {
+			speed := activityLap.DistanceM / activityLap.ElapsedS
+			activityLap.AvgSpeedMps = &speed
+		}
+		if activityLap.AvgHRBpm == nil || activityLap.MaxHRBpm == nil {
+			avgHR, maxHR := summarizeSensor(samples[start:end+1], func(s Sample) *int { return s.HR }, false)
+			if activityLap.AvgHRBpm == nil {
+				activityLap.AvgHRBpm = avgHR
+			}
+			if activityLap.MaxHRBpm == nil {
+				activityLap.MaxHRBpm = maxHR
+			}
+		}
+
+		result = append(result, activityLap)
+	}
+
+	return result
+}
+
+// saveActivityLaps stores the device laps of a new activity
+func (h *Handler) saveActivityLaps(ctx context.Context, activityID uuid.UUID, samples []Sample, fullStream *FullResolutionStream, deviceLaps []deviceLap) {
+	laps := buildActivityLaps(activityID, samples, fullStream, deviceLaps)
+	if len(laps) == 0 {
+		return
+	}
+
+	if err := h.database.CreateActivityLaps(ctx, laps); err != nil {
+		h.log.Error("Failed to save activity laps to database", err)
+		return
+	}
+	h.log.Debug(fmt.Sprintf("Successfully saved %d laps for activity: %s", len(laps), activityID.String()))
+}
+
+// HandleGetActivityLaps serves the laps recorded for an activity
+func (h *Handler) HandleGetActivityLaps() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.Background()
+
+		activityID := chi.URLParam(r, "id")
+		if _, err := uuid.Parse(activityID); err != nil {
+			http.Error(w, "Invalid activity ID format", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		activity, err := h.database.GetActivityByID(ctx, activityID)
+		if err != nil {
+			h.log.Error("Failed to get activity from database", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if activity == nil || activity.UserID != userID {
+			http.Error(w, "Activity not found", http.StatusNotFound) // Return 404 instead of 403
+			return
+		}
+
+		laps, err := h.database.GetActivityLaps(ctx, activityID)
+		if err != nil {
+			h.log.Error("Failed to get activity laps from database", err)
+			http.Error(w, "Failed to retrieve laps", http.StatusInternalServerError)
+			return
+		}
+
+		response := LapsResponse{
+			ActivityID: activityID,
+			Laps:       make([]LapResult, 0, len(laps)),
+		}
+		for _, lap := range laps {
+			response.Laps = append(response.Laps, LapResult{
+				Lap:         lap.LapIndex,
+				StartIndex:  lap.StartIndex,
+				StartTime:   lap.StartTime,
+				ElapsedS:    lap.ElapsedS,
+				DistanceM:   lap.DistanceM,
+				AvgSpeedMps: lap.AvgSpeedMps,
+				AvgHRBpm:    lap.AvgHRBpm,
+				MaxHRBpm:    lap.MaxHRBpm,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}
+}
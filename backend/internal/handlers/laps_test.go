@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/muktihari/fit/encoder"
+	"github.com/muktihari/fit/profile/filedef"
+	"github.com/muktihari/fit/profile/mesgdef"
+	"github.com/muktihari/fit/profile/typedef"
+)
+
+// lapTestSamples returns one sample every 10 s heading north at ~3 m/s with a heart rate of 130 + i
+func lapTestSamples(n int) []Sample {
+	samples := make([]Sample, n)
+	for i := range samples {
+		hr := 130 + i
+		samples[i] = Sample{T: int64(1_700_000_000_000 + i*10_000), Lat: 40.0 + float64(i)*0.00027, Lon: -74.0, HR: &hr}
+	}
+	return samples
+}
+
+func TestBuildActivityLaps(t *testing.T) {
+	activityID := uuid.New()
+	samples := lapTestSamples(10)
+	stream := processFullResolutionStreams(samples, nil, nil)
+
+	t.Run("computed from samples", func(t *testing.T) {
+		laps := buildActivityLaps(activityID, samples, stream, []deviceLap{
+			{StartT: samples[4].T + 5000}, // out of order, between samples 4 and 5
+			{StartT: samples[0].T},
+		})
+
+		if len(laps) != 2 {
+			t.Fatalf("Expected 2 laps, got %d", len(laps))
+		}
+		if laps[0].LapIndex != 1 || laps[0].StartIndex != 0 || laps[1].LapIndex != 2 || laps[1].StartIndex != 5 {
+			t.Errorf("Unexpected lap placement: %+v", laps)
+		}
+		if laps[0].ElapsedS != 40 || laps[1].ElapsedS != 40 {
+			t.Errorf("Elapsed = %f and %f, want 40", laps[0].ElapsedS, laps[1].ElapsedS)
+		}
+		if math.Abs(laps[0].DistanceM-stream.DistanceM[4]) > 1e-9 {
+			t.Errorf("First lap distance = %f, want %f", laps[0].DistanceM, stream.DistanceM[4])
+		}
+		if laps[0].AvgSpeedMps == nil || math.Abs(*laps[0].AvgSpeedMps-laps[0].DistanceM/40) > 1e-9 {
+			t.Errorf("AvgSpeedMps = %v, want %f", laps[0].AvgSpeedMps, laps[0].DistanceM/40)
+		}
+		if laps[1].AvgHRBpm == nil || *laps[1].AvgHRBpm != 137 || *laps[1].MaxHRBpm != 139 {
+			t.Errorf("Second lap HR = %v/%v, want 137/139", laps[1].AvgHRBpm, laps[1].MaxHRBpm)
+		}
+		if !laps[1].StartTime.Equal(time.UnixMilli(samples[5].T)) {
+			t.Errorf("StartTime = %v, want %v", laps[1].StartTime, time.UnixMilli(samples[5].T))
+		}
+	})
+
+	t.Run("device values win", func(t *testing.T) {
+		elapsed, distance, speed := 95.0, 250.0, 2.6
+		hr, maxHR := int16(150), int16(160)
+		laps := buildActivityLaps(activityID, samples, stream, []deviceLap{
+			{StartT: samples[0].T, ElapsedS: &elapsed, DistanceM: &distance, AvgSpeedMps: &speed, AvgHRBpm: &hr, MaxHRBpm: &maxHR},
+		})
+
+		if len(laps) != 1 {
+			t.Fatalf("Expected 1 lap, got %d", len(laps))
+		}
+		lap := laps[0]
+		if lap.ElapsedS != elapsed || lap.DistanceM != distance || *lap.AvgSpeedMps != speed || *lap.AvgHRBpm != hr || *lap.MaxHRBpm != maxHR {
+			t.Errorf("Expected device values, got %+v", lap)
+		}
+	})
+
+	t.Run("heart rate filled in independently", func(t *testing.T) {
+		maxHR, avgHR := int16(175), int16(120)
+		laps := buildActivityLaps(activityID, samples, stream, []deviceLap{
+			{StartT: samples[0].T, MaxHRBpm: &maxHR},
+			{StartT: samples[5].T, AvgHRBpm: &avgHR},
+		})
+
+		if len(laps) != 2 {
+			t.Fatalf("Expected 2 laps, got %d", len(laps))
+		}
+		if laps[0].AvgHRBpm == nil || *laps[0].AvgHRBpm != 132 || *laps[0].MaxHRBpm != maxHR {
+			t.Errorf("First lap HR = %v/%v, want 132 from samples and the device max 175", laps[0].AvgHRBpm, laps[0].MaxHRBpm)
+		}
+		if *laps[1].AvgHRBpm != avgHR || laps[1].MaxHRBpm == nil || *laps[1].MaxHRBpm != 139 {
+			t.Errorf("Second lap HR = %v/%v, want the device average 120 and 139 from samples", laps[1].AvgHRBpm, laps[1].MaxHRBpm)
+		}
+	})
+
+	t.Run("laps without samples are dropped", func(t *testing.T) {
+		laps := buildActivityLaps(activityID, samples, stream, []deviceLap{
+			{StartT: samples[0].T},
+			{StartT: samples[3].T + 1000},
+			{StartT: samples[3].T + 2000}, // starts before the next sample too
+			{StartT: samples[9].T + 60_000},
+		})
+
+		if len(laps) != 2 {
+			t.Fatalf("Expected 2 laps, got %+v", laps)
+		}
+		if laps[1].LapIndex != 2 || laps[1].StartIndex != 4 || laps[1].ElapsedS != 50 {
+			t.Errorf("Unexpected second lap: %+v", laps[1])
+		}
+	})
+
+	t.Run("no laps", func(t *testing.T) {
+		if laps := buildActivityLaps(activityID, samples, stream, nil); laps != nil {
+			t.Errorf("Expected no laps, got %v", laps)
+		}
+	})
+}
+
+func TestProcessFITFileLaps(t *testing.T) {
+	start := time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC)
+	fitActivity := filedef.NewActivity()
+	fitActivity.FileId = *mesgdef.NewFileId(nil).SetType(typedef.FileActivity).SetTimeCreated(start)
+
+	for i := 0; i < 10; i++ {
+		fitActivity.Records = append(fitActivity.Records, mesgdef.NewRecord(nil).
+			SetTimestamp(start.Add(time.Duration(i*10)*time.Second)).
+			SetPositionLat(int32((40.0+float64(i)*0.00027)*11930465)).
+			SetPositionLong(int32(-74.0*11930465)))
+	}
+	fitActivity.Laps = append(fitActivity.Laps,
+		mesgdef.NewLap(nil).
+			SetTimestamp(start.Add(50*time.Second)).
+			SetStartTime(start).
+			SetTotalElapsedTime(50_000). // 50 s
+			SetTotalDistance(15_000).    // 150 m
+			SetAvgSpeed(3_000).          // 3 m/s
+			SetAvgHeartRate(142).
+			SetMaxHeartRate(155),
+		mesgdef.NewLap(nil).
+			SetTimestamp(start.Add(90*time.Second)).
+			SetStartTime(start.Add(50*time.Second)).
+			SetEnhancedAvgSpeed(3_250), // 3.25 m/s, no other summary values
+	)
+
+	fit := fitActivity.ToFIT(nil)
+	var buf bytes.Buffer
+	if err := encoder.New(&buf).Encode(&fit); err != nil {
+		t.Fatalf("Failed to encode FIT file: %v", err)
+	}
+
+	_, metadata, _, err := processFITFile(buf.Bytes(), "test.fit")
+	if err != nil {
+		t.Fatalf("processFITFile() error = %v", err)
+	}
+	if len(metadata.Laps) != 2 {
+		t.Fatalf("Expected 2 laps, got %d", len(metadata.Laps))
+	}
+
+	first := metadata.Laps[0]
+	if first.StartT != start.UnixMilli() {
+		t.Errorf("StartT = %d, want %d", first.StartT, start.UnixMilli())
+	}
+	if first.ElapsedS == nil || *first.ElapsedS != 50 || first.DistanceM == nil || *first.DistanceM != 150 {
+		t.Errorf("Unexpected first lap duration/distance: %v %v", first.ElapsedS, first.DistanceM)
+	}
+	if first.AvgSpeedMps == nil || *first.AvgSpeedMps != 3 || *first.AvgHRBpm != 142 || *first.MaxHRBpm != 155 {
+		t.Errorf("Unexpected first lap speed/HR: %+v", first)
+	}
+
+	second := metadata.Laps[1]
+	if second.ElapsedS != nil || second.DistanceM != nil || second.AvgHRBpm != nil {
+		t.Errorf("Expected missing values to stay nil, got %+v", second)
+	}
+	if second.AvgSpeedMps == nil || *second.AvgSpeedMps != 3.25 {
+		t.Errorf("AvgSpeedMps = %v, want 3.25", second.AvgSpeedMps)
+	}
+}
+
+func TestProcessGPXFileSegmentLaps(t *testing.T) {
+	gpx := `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk>
+    <type>running</type>
+    <trkseg>
+      <trkpt lat="40.0000" lon="-74.0"><time>2024-05-01T07:00:00Z</time></trkpt>
+      <trkpt lat="40.0010" lon="-74.0"><time>2024-05-01T07:00:30Z</time></trkpt>
+    </trkseg>
+    <trkseg>
+      <trkpt lat="40.0020" lon="-74.0"><time>2024-05-01T07:02:00Z</time></trkpt>
+      <trkpt lat="40.0030" lon="-74.0"><time>2024-05-01T07:02:30Z</time></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+	samples, metadata, _, err := processGPXFile([]byte(gpx), "test.gpx")
+	if err != nil {
+		t.Fatalf("processGPXFile() error = %v", err)
+	}
+	if len(metadata.Laps) != 2 {
+		t.Fatalf("Expected 2 laps, got %d", len(metadata.Laps))
+	}
+	if metadata.Laps[1].StartT != samples[2].T {
+		t.Errorf("Second lap StartT = %d, want %d", metadata.Laps[1].StartT, samples[2].T)
+	}
+
+	// The gap between segments belongs to neither lap
+	laps := buildActivityLaps(uuid.New(), samples, processFullResolutionStreams(samples, nil, nil), metadata.Laps)
+	if len(laps) != 2 || laps[0].ElapsedS != 30 || laps[1].ElapsedS != 30 {
+		t.Errorf("Unexpected laps: %+v", laps)
+	}
+
+	single := `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk><type>running</type><trkseg>
+    <trkpt lat="40.0000" lon="-74.0"><time>2024-05-01T07:00:00Z</time></trkpt>
+    <trkpt lat="40.0010" lon="-74.0"><time>2024-05-01T07:00:30Z</time></trkpt>
+  </trkseg></trk>
+</gpx>`
+	if _, metadata, _, err := processGPXFile([]byte(single), "single.gpx"); err != nil || metadata.Laps != nil {
+		t.Errorf("Expected no laps for a single segment, got %v (err %v)", metadata.Laps, err)
+	}
+}
+
+func TestHandleGetActivityLaps(t *testing.T) {
+	h, mockDB := newMatchingTestHandler()
+	mockDB.usersByEmail["other@example.com"] = &models.UserRecord{ID: "user-456", Email: "other@example.com"}
+	activity := createTestActivity(uuid.New().String(), "user-123")
+	mockDB.activities[activity.ID.String()] = activity
+	samples := lapTestSamples(10)
+	mockDB.laps = buildActivityLaps(activity.ID, samples, processFullResolutionStreams(samples, nil, nil), []deviceLap{
+		{StartT: samples[0].T},
+		{StartT: samples[5].T},
+	})
+
+	tests := []struct {
+		name           string
+		activityID     string
+		email          string
+		expectedStatus int
+	}{
+		{"own activity", activity.ID.String(), "user@example.com", http.StatusOK},
+		{"invalid id", "not-a-uuid", "user@example.com", http.StatusBadRequest},
+		{"other user's activity", activity.ID.String(), "other@example.com", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/activities/"+tt.activityID+"/laps", nil)
+			req = withTestUser(req, tt.email, map[string]string{"id": tt.activityID})
+			w := httptest.NewRecorder()
+			h.HandleGetActivityLaps()(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response LapsResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(response.Laps) != 2 || response.Laps[1].Lap != 2 || response.Laps[1].StartIndex != 5 {
+				t.Errorf("Unexpected laps: %+v", response.Laps)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"math"
+	"sort"
+)
+
+// Moving time detection thresholds, used when the recording device didn't report timer pauses
+const (
+	MinMovingSpeedMps   = 0.5  // segments slower than this (a slow walk) count as stopped
+	MaxMovingGapSeconds = 30.0 // longer gaps between samples are auto-pauses or signal loss
+)
+
+// TimerPause is a period where the recording device's timer was stopped
+type TimerPause struct {
+	StartT int64 `json:"start_t"` // unix milliseconds when the timer stopped
+	EndT   int64 `json:"end_t"`   // unix milliseconds when the timer restarted
+}
+
+// timerEvent is a timer start or stop reported by the recording device
+type timerEvent struct {
+	T    int64 // unix milliseconds
+	Stop bool
+}
+
+// timerPausesFromEvents pairs timer stop events with the next start into pauses.
+// A trailing stop ends the recording and isn't a pause. Returns nil when there are no events
+// so callers fall back to speed-based detection.
+func timerPausesFromEvents(events []timerEvent) []TimerPause {
+	if len(events) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].T < events[j].T })
+
+	pauses := []TimerPause{}
+	stopped := false
+	var stoppedAt int64
+	for _, e := range events {
+		switch {
+		case e.Stop && !stopped:
+			stopped = true
+			stoppedAt = e.T
+		case !e.Stop && stopped:
+			stopped = false
+			if e.T > stoppedAt {
+				pauses = append(pauses, TimerPause{StartT: stoppedAt, EndT: e.T})
+			}
+		}
+	}
+	return pauses
+}
+
+// calculateMovingSeconds returns the time spent moving, see cumulativeMovingSeconds
+func calculateMovingSeconds(samples []Sample, pauses []TimerPause) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	moving := cumulativeMovingSeconds(samples, pauses)
+	return moving[len(moving)-1]
+}
+
+// cumulativeMovingSeconds returns the moving time up to each sample.
+// Timer pauses are authoritative when non-nil (an empty slice means the timer never stopped);
+// otherwise a segment between two samples counts as moving when it is faster than
+// MinMovingSpeedMps and shorter than MaxMovingGapSeconds.
+func cumulativeMovingSeconds(samples []Sample, pauses []TimerPause) []float64 {
+	moving := make([]float64, len(samples))
+	for i := 1; i < len(samples); i++ {
+		moving[i] = moving[i-1]
+
+		start, end := samples[i-1].T, samples[i].T
+		if end <= start {
+			continue
+		}
+
+		if pauses != nil {
+			pausedMs := int64(0)
+			for _, p := range pauses {
+				if overlap := min(p.EndT, end) - max(p.StartT, start); overlap > 0 {
+					pausedMs += overlap
+				}
+			}
+			moving[i] += math.Max(0, float64(end-start-pausedMs)/1000.0)
+			continue
+		}
+
+		dt := float64(end-start) / 1000.0
+		if dt > MaxMovingGapSeconds {
+			continue
+		}
+		distance := haversineDistance(samples[i-1].Lat, samples[i-1].Lon, samples[i].Lat, samples[i].Lon)
+		if distance/dt >= MinMovingSpeedMps {
+			moving[i] += dt
+		}
+	}
+	return moving
+}
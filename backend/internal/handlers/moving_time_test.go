@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/muktihari/fit/encoder"
+	"github.com/muktihari/fit/profile/filedef"
+	"github.com/muktihari/fit/profile/mesgdef"
+	"github.com/muktihari/fit/profile/typedef"
+)
+
+// movingTestSamples returns one sample per second heading north at ~3 m/s,
+// standing still for stopSeconds after the fifth sample
+func movingTestSamples(stopSeconds int) []Sample {
+	var samples []Sample
+	lat := 40.0
+	t := int64(1_700_000_000_000)
+	for i := 0; i < 10; i++ {
+		samples = append(samples, Sample{T: t, Lat: lat, Lon: -74.0})
+		t += 1000
+		if i == 4 {
+			for j := 0; j < stopSeconds; j++ {
+				samples = append(samples, Sample{T: t, Lat: lat, Lon: -74.0})
+				t += 1000
+			}
+		}
+		lat += 0.000027 // ~3 m
+	}
+	return samples
+}
+
+func TestCalculateMovingSeconds(t *testing.T) {
+	t.Run("continuous movement", func(t *testing.T) {
+		samples := movingTestSamples(0)
+		if got := calculateMovingSeconds(samples, nil); got != 9 {
+			t.Errorf("Expected 9 moving seconds, got %f", got)
+		}
+	})
+
+	t.Run("stop detected from speed", func(t *testing.T) {
+		samples := movingTestSamples(60)
+		elapsed := calculateElapsedSeconds(samples)
+		moving := calculateMovingSeconds(samples, nil)
+		// The segment leaving the stop still moves, so only the 60 stationary seconds are excluded
+		if elapsed-moving != 60 {
+			t.Errorf("Expected 60 paused seconds, got elapsed %f moving %f", elapsed, moving)
+		}
+	})
+
+	t.Run("long gap between samples counts as paused", func(t *testing.T) {
+		samples := []Sample{
+			{T: 0, Lat: 40.0, Lon: -74.0},
+			{T: 10_000, Lat: 40.0003, Lon: -74.0},
+			{T: 310_000, Lat: 40.01, Lon: -74.0}, // recorder auto-paused for 5 minutes
+		}
+		if got := calculateMovingSeconds(samples, nil); got != 10 {
+			t.Errorf("Expected 10 moving seconds, got %f", got)
+		}
+	})
+
+	t.Run("timer pauses are authoritative", func(t *testing.T) {
+		samples := movingTestSamples(60)
+		first := samples[0].T
+		pauses := []TimerPause{
+			{StartT: first + 2000, EndT: first + 12_000},
+			{StartT: first - 5000, EndT: first + 1000},                                    // clipped to the recording
+			{StartT: samples[len(samples)-1].T, EndT: samples[len(samples)-1].T + 60_000}, // after the last sample
+		}
+		elapsed := calculateElapsedSeconds(samples)
+		if got := calculateMovingSeconds(samples, pauses); got != elapsed-11 {
+			t.Errorf("Expected %f moving seconds, got %f", elapsed-11, got)
+		}
+	})
+
+	t.Run("empty timer pauses mean the timer never stopped", func(t *testing.T) {
+		samples := movingTestSamples(60)
+		if got := calculateMovingSeconds(samples, []TimerPause{}); got != calculateElapsedSeconds(samples) {
+			t.Errorf("Expected moving time to equal elapsed time, got %f", got)
+		}
+	})
+}
+
+func TestTimerPausesFromEvents(t *testing.T) {
+	if pauses := timerPausesFromEvents(nil); pauses != nil {
+		t.Errorf("Expected nil pauses without events, got %v", pauses)
+	}
+
+	pauses := timerPausesFromEvents([]timerEvent{
+		{T: 0},
+		{T: 50_000, Stop: true},
+		{T: 20_000, Stop: true}, // out of order
+		{T: 30_000},
+		{T: 40_000}, // repeated start
+		{T: 60_000},
+		{T: 90_000, Stop: true}, // end of recording
+	})
+	expected := []TimerPause{{StartT: 20_000, EndT: 30_000}, {StartT: 50_000, EndT: 60_000}}
+	if len(pauses) != len(expected) {
+		t.Fatalf("Expected %d pauses, got %v", len(expected), pauses)
+	}
+	for i := range expected {
+		if pauses[i] != expected[i] {
+			t.Errorf("Pause %d = %+v, want %+v", i, pauses[i], expected[i])
+		}
+	}
+
+	if pauses := timerPausesFromEvents([]timerEvent{{T: 0}, {T: 90_000, Stop: true}}); pauses == nil || len(pauses) != 0 {
+		t.Errorf("Expected empty non-nil pauses when the timer never paused, got %v", pauses)
+	}
+}
+
+func TestProcessFITFileTimerPauses(t *testing.T) {
+	start := time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC)
+	fitActivity := filedef.NewActivity()
+	fitActivity.FileId = *mesgdef.NewFileId(nil).SetType(typedef.FileActivity).SetTimeCreated(start)
+
+	for i := 0; i < 120; i += 10 {
+		fitActivity.Records = append(fitActivity.Records, mesgdef.NewRecord(nil).
+			SetTimestamp(start.Add(time.Duration(i)*time.Second)).
+			SetPositionLat(int32((40.0+float64(i)*0.00003)*11930465)).
+			SetPositionLong(int32(-74.0*11930465)))
+	}
+	for _, e := range []struct {
+		at        time.Duration
+		eventType typedef.EventType
+	}{
+		{0, typedef.EventTypeStart},
+		{30 * time.Second, typedef.EventTypeStopAll},
+		{70 * time.Second, typedef.EventTypeStart},
+		{110 * time.Second, typedef.EventTypeStopAll},
+	} {
+		fitActivity.Events = append(fitActivity.Events, mesgdef.NewEvent(nil).
+			SetTimestamp(start.Add(e.at)).
+			SetEvent(typedef.EventTimer).
+			SetEventType(e.eventType))
+	}
+	// Non-timer events don't affect pauses
+	fitActivity.Events = append(fitActivity.Events, mesgdef.NewEvent(nil).
+		SetTimestamp(start.Add(50*time.Second)).
+		SetEvent(typedef.EventLap).
+		SetEventType(typedef.EventTypeStop))
+
+	fit := fitActivity.ToFIT(nil)
+	var buf bytes.Buffer
+	if err := encoder.New(&buf).Encode(&fit); err != nil {
+		t.Fatalf("Failed to encode FIT file: %v", err)
+	}
+
+	samples, metadata, _, err := processFITFile(buf.Bytes(), "test.fit")
+	if err != nil {
+		t.Fatalf("processFITFile() error = %v", err)
+	}
+	if len(metadata.TimerPauses) != 1 {
+		t.Fatalf("Expected 1 timer pause, got %v", metadata.TimerPauses)
+	}
+	if got := calculateMovingSeconds(samples, metadata.TimerPauses); got != 70 {
+		t.Errorf("Expected 70 moving seconds, got %f", got)
+	}
+}
+
+func TestCreateActivityResultMovingStats(t *testing.T) {
+	movingTime := 3000
+	activity := &models.Activity{
+		ActivityType: models.ActivityTypeRun,
+		ElapsedTime:  3600,
+		MovingTime:   &movingTime,
+		DistanceM:    10000,
+		AvgSpeedMps:  floatPtr(10000.0 / 3600.0),
+	}
+
+	stats := createActivityResult(activity).Stats
+	if stats.MovingSeconds == nil || *stats.MovingSeconds != 3000 {
+		t.Fatalf("MovingSeconds = %v, want 3000", stats.MovingSeconds)
+	}
+	if stats.MovingAvgSpeed == nil || math.Abs(*stats.MovingAvgSpeed-10000.0/3000.0) > 1e-9 {
+		t.Errorf("MovingAvgSpeed = %v, want %f", stats.MovingAvgSpeed, 10000.0/3000.0)
+	}
+	if stats.Derived.MovingPaceSPerKm == nil || math.Abs(*stats.Derived.MovingPaceSPerKm-300) > 1e-9 {
+		t.Errorf("MovingPaceSPerKm = %v, want 300", stats.Derived.MovingPaceSPerKm)
+	}
+	if stats.Derived.PaceSPerKm == nil || math.Abs(*stats.Derived.PaceSPerKm-360) > 1e-9 {
+		t.Errorf("PaceSPerKm = %v, want 360", stats.Derived.PaceSPerKm)
+	}
+
+	// Activities processed before moving time detection have no moving stats
+	activity.MovingTime = nil
+	stats = createActivityResult(activity).Stats
+	if stats.MovingSeconds != nil || stats.MovingAvgSpeed != nil || stats.Derived.MovingPaceSPerKm != nil {
+		t.Errorf("Expected no moving stats without moving time, got %+v", stats)
+	}
+}
@@ -22,6 +22,9 @@ type CreatePlannedActivityRequest struct {
 	PlannedElevationGainMeter        *float64 `json:"plannedElevationGainMeter"`
 	TargetAverageSpeedMeterPerSecond *float64 `json:"targetAverageSpeedMeterPerSecond"`
 	TargetPowerWatt                  *int     `json:"targetPowerWatt"`
+
+	// Saved route to follow; its distance and elevation gain fill in planned metrics that aren't set
+	RouteID *string `json:"routeId"`
 }
 
 func isValidPlannedActivityType(actType string) bool {
@@ -82,6 +85,21 @@ func (h *Handler) HandleCreatePlannedActivity() http.HandlerFunc {
 			TargetPowerWatt:       req.TargetPowerWatt,
 		}
 
+		if req.RouteID != nil {
+			route, status, err := h.plannedActivityRoute(ctx, userID, strings.TrimSpace(*req.RouteID))
+			if err != nil {
+				sendError(w, status, err.Error())
+				return
+			}
+			plan.RouteID = &route.ID
+			if plan.PlannedDistanceM == nil {
+				plan.PlannedDistanceM = &route.DistanceM
+			}
+			if plan.PlannedElevationGainM == nil {
+				plan.PlannedElevationGainM = route.ElevationGainM
+			}
+		}
+
 		saved, err := h.database.CreatePlannedActivity(ctx, plan)
 		if err != nil {
 			h.log.Error("Database failed", err)
@@ -303,6 +321,24 @@ func (h *Handler) HandleUpdatePlannedActivity() http.HandlerFunc {
 			}
 		}
 
+		if raw, ok := rawFields["routeId"]; ok {
+			if string(raw) == "null" {
+				updates["route_id"] = nil
+			} else {
+				var routeID string
+				if err := json.Unmarshal(raw, &routeID); err != nil {
+					sendError(w, http.StatusBadRequest, "Invalid route ID format")
+					return
+				}
+				route, status, err := h.plannedActivityRoute(ctx, userID, strings.TrimSpace(routeID))
+				if err != nil {
+					sendError(w, status, err.Error())
+					return
+				}
+				updates["route_id"] = route.ID
+			}
+		}
+
 		if len(updates) == 0 {
 			sendError(w, http.StatusBadRequest, "No updates provided")
 			return
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/anish-chanda/cadent/backend/internal/compression"
+	"github.com/anish-chanda/cadent/backend/internal/geo"
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/anish-chanda/cadent/backend/internal/valhalla"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// MaxRouteWaypoints limits how many points a route can be built through
+const MaxRouteWaypoints = 25
+
+// CreateRouteRequest builds a route through waypoints, in order
+type CreateRouteRequest struct {
+	Name        string                 `json:"name"`
+	Description *string                `json:"description"`
+	Type        string                 `json:"type"` // running or road_biking
+	Waypoints   []models.RouteWaypoint `json:"waypoints"`
+}
+
+// RouteProfile is a route's elevation profile, one entry per polyline point
+type RouteProfile struct {
+	DistanceM  []float64 `json:"distance_m"`
+	ElevationM []float64 `json:"elevation_m"`
+}
+
+// RouteResult is a route as returned by the API
+type RouteResult struct {
+	models.Route
+	Profile *RouteProfile `json:"profile,omitempty"` // only for single routes, nil without heights
+}
+
+// routeLegsPolyline joins the shapes of a routed trip's legs into one precision 6 polyline. Each leg starts where
+// the previous one ended, so repeated points at the joins are dropped.
+func routeLegsPolyline(trip valhalla.Trip) (string, []geo.Point, error) {
+	var points []geo.Point
+	for i, leg := range trip.Legs {
+		legPoints, err := geo.Decode6(leg.Shape)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to decode shape of leg %d: %w", i, err)
+		}
+		if len(points) > 0 && len(legPoints) > 0 && geo.SamePoint(points[len(points)-1], legPoints[0]) {
+			legPoints = legPoints[1:]
+		}
+		points = append(points, legPoints...)
+	}
+	if len(points) < 2 {
+		return "", nil, fmt.Errorf("route has %d points", len(points))
+	}
+	return geo.Encode6(points), points, nil
+}
+
+// buildRoute routes through the request's waypoints with Valhalla and fills in the route's path, distance and, when
+// an elevation provider is available, its elevation gain, loss and profile
+func (h *Handler) buildRoute(ctx context.Context, route *models.Route) error {
+	locations := make([]valhalla.Location, len(route.Waypoints))
+	for i, wp := range route.Waypoints {
+		locations[i] = valhalla.Location{Lat: wp.Lat, Lon: wp.Lon}
+	}
+
+	resp, err := h.valhallaClient.Route(ctx, valhalla.RouteRequest{Locations: locations, Costing: valhallaCosting(route.Type)})
+	if err != nil {
+		return err
+	}
+
+	polyline, points, err := routeLegsPolyline(resp.Trip)
+	if err != nil {
+		return err
+	}
+	route.Polyline = polyline
+	route.DistanceM = resp.Trip.Summary.Length * 1000
+
+	elevationChange, heights := getElevationDataAndHeights(ctx, h.elevationProvider(), polyline, h.log)
+	if elevationChange == nil || len(heights) != len(points) {
+		return nil
+	}
+	route.ElevationGainM = &elevationChange.GainMeters
+	route.ElevationLossM = &elevationChange.LossMeters
+
+	distances := make([]float64, len(points))
+	for i := 1; i < len(points); i++ {
+		distances[i] = distances[i-1] + haversineDistance(points[i-1].Lat, points[i-1].Lon, points[i].Lat, points[i].Lon)
+	}
+	distanceBytes, distanceCodec := compressDIBS(distances, 2)
+	elevationBytes, elevationCodec := compressDIBS(heights, 2)
+	if distanceCodec["error"] != nil || elevationCodec["error"] != nil {
+		h.log.Warn("Failed to compress route elevation profile, saving the route without it")
+		return nil
+	}
+	route.ProfileDistanceMBytes = distanceBytes
+	route.ProfileElevationMBytes = elevationBytes
+	return nil
+}
+
+// routeProfile decompresses a route's elevation profile, nil when the route has none
+func routeProfile(route *models.Route) (*RouteProfile, error) {
+	if len(route.ProfileDistanceMBytes) == 0 || len(route.ProfileElevationMBytes) == 0 {
+		return nil, nil
+	}
+
+	distances, err := compression.Decompress(route.ProfileDistanceMBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress route distances: %w", err)
+	}
+	elevations, err := compression.Decompress(route.ProfileElevationMBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress route elevations: %w", err)
+	}
+	if len(distances) != len(elevations) {
+		return nil, fmt.Errorf("route profile has %d distances for %d elevations", len(distances), len(elevations))
+	}
+	return &RouteProfile{DistanceM: distances, ElevationM: elevations}, nil
+}
+
+// validateRouteWaypoints checks there are 2 to MaxRouteWaypoints waypoints with valid coordinates
+func validateRouteWaypoints(waypoints []models.RouteWaypoint) error {
+	if len(waypoints) < 2 || len(waypoints) > MaxRouteWaypoints {
+		return fmt.Errorf("between 2 and %d waypoints are required", MaxRouteWaypoints)
+	}
+	for i, wp := range waypoints {
+		if wp.Lat < -90 || wp.Lat > 90 || wp.Lon < -180 || wp.Lon > 180 {
+			return fmt.Errorf("waypoint %d has invalid coordinates", i)
+		}
+	}
+	return nil
+}
+
+// HandleCreateRoute builds a route through waypoints with Valhalla and saves it
+func (h *Handler) HandleCreateRoute() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		var req CreateRouteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.log.Error("Failed to decode route request", err)
+			sendError(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			sendError(w, http.StatusBadRequest, "Name is required")
+			return
+		}
+		activityType := models.ActivityType(req.Type)
+		if activityType != models.ActivityTypeRun && activityType != models.ActivityTypeRoadBike {
+			sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid type: %s. Supported types: running, road_biking", req.Type))
+			return
+		}
+		if err := validateRouteWaypoints(req.Waypoints); err != nil {
+			sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if h.valhallaClient == nil {
+			sendError(w, http.StatusServiceUnavailable, "Routing is not available")
+			return
+		}
+
+		route := &models.Route{
+			UserID:      userID,
+			Name:        name,
+			Description: req.Description,
+			Type:        activityType,
+			Waypoints:   req.Waypoints,
+		}
+		if err := h.buildRoute(ctx, route); err != nil {
+			if errors.Is(err, valhalla.ErrCircuitOpen) {
+				sendError(w, http.StatusServiceUnavailable, "Routing is temporarily unavailable")
+				return
+			}
+			h.log.Error("Failed to build route", err)
+			sendError(w, http.StatusBadGateway, "Failed to find a route through the waypoints")
+			return
+		}
+
+		if err := h.database.CreateRoute(ctx, route); err != nil {
+			h.log.Error("Failed to save route", err)
+			sendError(w, http.StatusInternalServerError, "Failed to save route")
+			return
+		}
+		h.log.Info(fmt.Sprintf("Created route %s: %.0f m through %d waypoints", route.ID, route.DistanceM, len(route.Waypoints)))
+
+		profile, err := routeProfile(route)
+		if err != nil {
+			h.log.Error("Failed to read route elevation profile", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(RouteResult{Route: *route, Profile: profile})
+	}
+}
+
+// HandleGetRoutes lists the user's routes, newest first, without elevation profiles
+func (h *Handler) HandleGetRoutes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		routes, err := h.database.GetRoutesByUserID(ctx, userID)
+		if err != nil {
+			h.log.Error("Failed to get routes", err)
+			sendError(w, http.StatusInternalServerError, "Failed to get routes")
+			return
+		}
+
+		results := make([]RouteResult, len(routes))
+		for i, route := range routes {
+			results[i] = RouteResult{Route: route}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"routes": results})
+	}
+}
+
+// HandleGetRoute returns one of the user's routes with its elevation profile
+func (h *Handler) HandleGetRoute() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		routeID := chi.URLParam(r, "id")
+		if _, err := uuid.Parse(routeID); err != nil {
+			sendError(w, http.StatusBadRequest, "Invalid route ID")
+			return
+		}
+
+		route, err := h.database.GetRouteByID(ctx, routeID, userID)
+		if err != nil {
+			h.log.Error("Failed to get route", err)
+			sendError(w, http.StatusInternalServerError, "Failed to get route")
+			return
+		}
+		if route == nil {
+			sendError(w, http.StatusNotFound, "Route not found")
+			return
+		}
+
+		profile, err := routeProfile(route)
+		if err != nil {
+			h.log.Error(fmt.Sprintf("Failed to read elevation profile of route %s", routeID), err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(RouteResult{Route: *route, Profile: profile})
+	}
+}
+
+// HandleDeleteRoute deletes one of the user's routes. Planned activities following it keep their plan without a route.
+func (h *Handler) HandleDeleteRoute() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		routeID := chi.URLParam(r, "id")
+		if _, err := uuid.Parse(routeID); err != nil {
+			sendError(w, http.StatusBadRequest, "Invalid route ID")
+			return
+		}
+
+		if err := h.database.DeleteRoute(ctx, routeID, userID); err != nil {
+			if err.Error() == "route not found" {
+				sendError(w, http.StatusNotFound, "Route not found")
+				return
+			}
+			h.log.Error("Failed to delete route", err)
+			sendError(w, http.StatusInternalServerError, "Failed to delete route")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// plannedActivityRoute looks up the route a planned activity should follow, returning an error meant for the
+// client when routeID is not one of the user's routes
+func (h *Handler) plannedActivityRoute(ctx context.Context, userID string, routeID string) (*models.Route, int, error) {
+	if _, err := uuid.Parse(routeID); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("Invalid route ID")
+	}
+
+	route, err := h.database.GetRouteByID(ctx, routeID, userID)
+	if err != nil {
+		h.log.Error("Failed to get route for planned activity", err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("Failed to get route")
+	}
+	if route == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("Route not found")
+	}
+	return route, http.StatusOK, nil
+}
@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/anish-chanda/cadent/backend/internal/geo"
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/anish-chanda/cadent/backend/internal/valhalla"
+	"github.com/google/uuid"
+)
+
+// routeTestPoints is a route north then east through Golden Gate Park; legs join at index 2
+var routeTestPoints = []geo.Point{
+	{Lat: 37.7694, Lon: -122.4862},
+	{Lat: 37.7704, Lon: -122.4862},
+	{Lat: 37.7714, Lon: -122.4862},
+	{Lat: 37.7714, Lon: -122.4850},
+	{Lat: 37.7714, Lon: -122.4838},
+}
+
+// newFakeRouter serves /route with two legs meeting at routeTestPoints[2] and records the last request
+func newFakeRouter(t *testing.T, status int) (*httptest.Server, *valhalla.RouteRequest) {
+	t.Helper()
+	var received valhalla.RouteRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/route" {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &received); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if status != http.StatusOK {
+			http.Error(w, `{"error_code":442,"error":"No path could be found for input"}`, status)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(valhalla.RouteResponse{Trip: valhalla.Trip{
+			Legs: []valhalla.RouteLeg{
+				{Shape: geo.Encode6(routeTestPoints[:3])},
+				{Shape: geo.Encode6(routeTestPoints[2:])},
+			},
+			Summary: valhalla.RouteSummary{Length: 0.428, Time: 150},
+		}})
+	}))
+	t.Cleanup(server.Close)
+	return server, &received
+}
+
+func TestRouteLegsPolyline(t *testing.T) {
+	polyline, points, err := routeLegsPolyline(valhalla.Trip{Legs: []valhalla.RouteLeg{
+		{Shape: geo.Encode6(routeTestPoints[:3])},
+		{Shape: geo.Encode6(routeTestPoints[2:])},
+	}})
+	if err != nil {
+		t.Fatalf("routeLegsPolyline() error = %v", err)
+	}
+	if len(points) != len(routeTestPoints) {
+		t.Fatalf("Expected %d points with the repeated join dropped, got %d", len(routeTestPoints), len(points))
+	}
+	decoded, _ := geo.Decode6(polyline)
+	for i, p := range decoded {
+		if !geo.SamePoint(p, routeTestPoints[i]) {
+			t.Errorf("Point %d = %v, want %v", i, p, routeTestPoints[i])
+		}
+	}
+
+	if _, _, err := routeLegsPolyline(valhalla.Trip{Legs: []valhalla.RouteLeg{{Shape: "!"}}}); err == nil {
+		t.Error("Expected an error for an invalid shape")
+	}
+	if _, _, err := routeLegsPolyline(valhalla.Trip{Legs: []valhalla.RouteLeg{{Shape: geo.Encode6(routeTestPoints[:1])}}}); err == nil {
+		t.Error("Expected an error for a route with a single point")
+	}
+}
+
+func TestHandleCreateRoute(t *testing.T) {
+	validBody := `{"name":" Park loop ","type":"running","waypoints":[{"lat":37.7694,"lon":-122.4862},{"lat":37.7714,"lon":-122.4862},{"lat":37.7714,"lon":-122.4838}]}`
+
+	t.Run("builds and saves a route", func(t *testing.T) {
+		server, received := newFakeRouter(t, http.StatusOK)
+		h, mockDB := newMatchingTestHandler()
+		h.valhallaClient = valhalla.NewClient(server.URL)
+		h.SetElevationProvider(&fakeElevationProvider{heights: []*float64{floatPtr(10), floatPtr(14), floatPtr(20), floatPtr(18), floatPtr(25)}})
+
+		req := withTestUser(httptest.NewRequest(http.MethodPost, "/routes", strings.NewReader(validBody)), "user@example.com", nil)
+		w := httptest.NewRecorder()
+		h.HandleCreateRoute()(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Status code = %d, want 201 (body: %s)", w.Code, w.Body.String())
+		}
+		if received.Costing != valhalla.CostingPedestrian || len(received.Locations) != 3 {
+			t.Errorf("Routed with %q through %d locations, want pedestrian through 3", received.Costing, len(received.Locations))
+		}
+
+		var result RouteResult
+		if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if result.Name != "Park loop" || result.UserID != "user-123" || math.Abs(result.DistanceM-428) > 1e-9 {
+			t.Errorf("Unexpected route %+v", result.Route)
+		}
+		if points, _ := geo.Decode6(result.Polyline); len(points) != len(routeTestPoints) {
+			t.Errorf("Expected the joined legs in the polyline, got %d points", len(points))
+		}
+		if result.ElevationGainM == nil || *result.ElevationGainM <= 0 {
+			t.Errorf("Expected elevation gain from the provider, got %v", result.ElevationGainM)
+		}
+
+		if result.Profile == nil || len(result.Profile.ElevationM) != 5 || len(result.Profile.DistanceM) != 5 {
+			t.Fatalf("Expected a 5 point elevation profile, got %+v", result.Profile)
+		}
+		if result.Profile.ElevationM[2] != 20 || result.Profile.DistanceM[0] != 0 {
+			t.Errorf("Profile = %+v", result.Profile)
+		}
+		if last := result.Profile.DistanceM[4]; math.Abs(last-428) > 10 {
+			t.Errorf("Profile ends at %.1fm, want about 428m", last)
+		}
+
+		stored := mockDB.routes[result.ID.String()]
+		if stored == nil || stored.Polyline != result.Polyline || len(stored.ProfileElevationMBytes) == 0 {
+			t.Error("Expected the route and its profile to be saved")
+		}
+	})
+
+	t.Run("without heights", func(t *testing.T) {
+		server, received := newFakeRouter(t, http.StatusOK)
+		h, _ := newMatchingTestHandler()
+		h.valhallaClient = valhalla.NewClient(server.URL)
+		h.SetElevationProvider(&fakeElevationProvider{err: io.ErrUnexpectedEOF})
+
+		body := strings.Replace(validBody, `"running"`, `"road_biking"`, 1)
+		req := withTestUser(httptest.NewRequest(http.MethodPost, "/routes", strings.NewReader(body)), "user@example.com", nil)
+		w := httptest.NewRecorder()
+		h.HandleCreateRoute()(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Status code = %d, want 201 (body: %s)", w.Code, w.Body.String())
+		}
+		if received.Costing != valhalla.CostingBicycle {
+			t.Errorf("Costing = %q, want bicycle for rides", received.Costing)
+		}
+		var result RouteResult
+		_ = json.NewDecoder(w.Body).Decode(&result)
+		if result.Profile != nil || result.ElevationGainM != nil {
+			t.Error("Expected the route to be saved without an elevation profile")
+		}
+	})
+
+	errorTests := []struct {
+		name           string
+		body           string
+		routerStatus   int // 0 for no Valhalla client
+		expectedStatus int
+	}{
+		{"missing name", `{"type":"running","waypoints":[{"lat":1,"lon":1},{"lat":2,"lon":2}]}`, http.StatusOK, http.StatusBadRequest},
+		{"invalid type", `{"name":"x","type":"swim","waypoints":[{"lat":1,"lon":1},{"lat":2,"lon":2}]}`, http.StatusOK, http.StatusBadRequest},
+		{"single waypoint", `{"name":"x","type":"running","waypoints":[{"lat":1,"lon":1}]}`, http.StatusOK, http.StatusBadRequest},
+		{"invalid coordinates", `{"name":"x","type":"running","waypoints":[{"lat":91,"lon":1},{"lat":2,"lon":2}]}`, http.StatusOK, http.StatusBadRequest},
+		{"invalid json", `{"name":`, http.StatusOK, http.StatusBadRequest},
+		{"no routing", validBody, 0, http.StatusServiceUnavailable},
+		{"no route found", validBody, http.StatusBadRequest, http.StatusBadGateway},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockDB := newMatchingTestHandler()
+			if tt.routerStatus != 0 {
+				server, _ := newFakeRouter(t, tt.routerStatus)
+				h.valhallaClient = valhalla.NewClient(server.URL)
+			}
+
+			req := withTestUser(httptest.NewRequest(http.MethodPost, "/routes", strings.NewReader(tt.body)), "user@example.com", nil)
+			w := httptest.NewRecorder()
+			h.HandleCreateRoute()(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Status code = %d, want %d (body: %s)", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if len(mockDB.routes) != 0 {
+				t.Error("Expected no route to be saved")
+			}
+		})
+	}
+}
+
+func TestHandleRoutes(t *testing.T) {
+	h, mockDB := newMatchingTestHandler()
+	distances, _ := compressDIBS([]float64{0, 100, 200}, 2)
+	elevations, _ := compressDIBS([]float64{10, 12, 11}, 2)
+	own := &models.Route{ID: uuid.New(), UserID: "user-123", Name: "Mine", Type: models.ActivityTypeRun,
+		ProfileDistanceMBytes: distances, ProfileElevationMBytes: elevations}
+	other := &models.Route{ID: uuid.New(), UserID: "other-user", Name: "Theirs", Type: models.ActivityTypeRun}
+	mockDB.routes[own.ID.String()] = own
+	mockDB.routes[other.ID.String()] = other
+
+	t.Run("list", func(t *testing.T) {
+		req := withTestUser(httptest.NewRequest(http.MethodGet, "/routes", nil), "user@example.com", nil)
+		w := httptest.NewRecorder()
+		h.HandleGetRoutes()(w, req)
+
+		var body struct {
+			Routes []RouteResult `json:"routes"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("Status code = %d, error = %v", w.Code, err)
+		}
+		if len(body.Routes) != 1 || body.Routes[0].Name != "Mine" || body.Routes[0].Profile != nil {
+			t.Errorf("Expected only the user's route without its profile, got %+v", body.Routes)
+		}
+	})
+
+	getTests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+	}{
+		{"own route", own.ID.String(), http.StatusOK},
+		{"someone else's route", other.ID.String(), http.StatusNotFound},
+		{"invalid id", "nope", http.StatusBadRequest},
+	}
+	for _, tt := range getTests {
+		t.Run("get "+tt.name, func(t *testing.T) {
+			req := withTestUser(httptest.NewRequest(http.MethodGet, "/routes/"+tt.id, nil), "user@example.com", map[string]string{"id": tt.id})
+			w := httptest.NewRecorder()
+			h.HandleGetRoute()(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Status code = %d, want %d", w.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus == http.StatusOK {
+				var result RouteResult
+				_ = json.NewDecoder(w.Body).Decode(&result)
+				if result.Profile == nil || len(result.Profile.ElevationM) != 3 || result.Profile.DistanceM[2] != 200 {
+					t.Errorf("Expected the elevation profile, got %+v", result.Profile)
+				}
+			}
+		})
+	}
+
+	t.Run("delete", func(t *testing.T) {
+		for _, tt := range []struct {
+			id             string
+			expectedStatus int
+		}{
+			{other.ID.String(), http.StatusNotFound},
+			{own.ID.String(), http.StatusNoContent},
+			{own.ID.String(), http.StatusNotFound},
+		} {
+			req := withTestUser(httptest.NewRequest(http.MethodDelete, "/routes/"+tt.id, nil), "user@example.com", map[string]string{"id": tt.id})
+			w := httptest.NewRecorder()
+			h.HandleDeleteRoute()(w, req)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Delete %s: status code = %d, want %d", tt.id, w.Code, tt.expectedStatus)
+			}
+		}
+		if mockDB.routes[other.ID.String()] == nil {
+			t.Error("Expected someone else's route to be kept")
+		}
+	})
+}
+
+func TestPlannedActivityRoute(t *testing.T) {
+	h, mockDB := newMatchingTestHandler()
+	gain := 35.0
+	route := &models.Route{ID: uuid.New(), UserID: "user-123", Name: "Hills", Type: models.ActivityTypeRun, DistanceM: 8000, ElevationGainM: &gain}
+	other := &models.Route{ID: uuid.New(), UserID: "other-user", Name: "Theirs", Type: models.ActivityTypeRun}
+	mockDB.routes[route.ID.String()] = route
+	mockDB.routes[other.ID.String()] = other
+
+	createTests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		distanceM      float64
+	}{
+		{"route fills in planned metrics", `{"title":"Hill run","activityType":"running","startTime":"2024-06-01T07:00:00Z","routeId":"` + route.ID.String() + `"}`, http.StatusCreated, 8000},
+		{"planned metrics take precedence", `{"title":"Hill run","activityType":"running","startTime":"2024-06-01T07:00:00Z","plannedDistanceMeter":10000,"routeId":"` + route.ID.String() + `"}`, http.StatusCreated, 10000},
+		{"someone else's route", `{"title":"Hill run","activityType":"running","startTime":"2024-06-01T07:00:00Z","routeId":"` + other.ID.String() + `"}`, http.StatusNotFound, 0},
+		{"invalid route id", `{"title":"Hill run","activityType":"running","startTime":"2024-06-01T07:00:00Z","routeId":"nope"}`, http.StatusBadRequest, 0},
+	}
+	for _, tt := range createTests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB.planned = nil
+			req := withTestUser(httptest.NewRequest(http.MethodPost, "/activities/plan", strings.NewReader(tt.body)), "user@example.com", nil)
+			w := httptest.NewRecorder()
+			h.HandleCreatePlannedActivity()(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Status code = %d, want %d (body: %s)", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusCreated {
+				if len(mockDB.planned) != 0 {
+					t.Error("Expected no planned activity to be saved")
+				}
+				return
+			}
+			plan := mockDB.planned[0]
+			if plan.RouteID == nil || *plan.RouteID != route.ID {
+				t.Errorf("RouteID = %v, want %s", plan.RouteID, route.ID)
+			}
+			if plan.PlannedDistanceM == nil || *plan.PlannedDistanceM != tt.distanceM {
+				t.Errorf("PlannedDistanceM = %v, want %.0f", plan.PlannedDistanceM, tt.distanceM)
+			}
+			if plan.PlannedElevationGainM == nil || *plan.PlannedElevationGainM != gain {
+				t.Errorf("PlannedElevationGainM = %v, want the route's gain", plan.PlannedElevationGainM)
+			}
+			if result := createPlannedActivityResult(&plan); result.RouteID == nil || *result.RouteID != route.ID.String() {
+				t.Error("Expected the route in the planned activity result")
+			}
+		})
+	}
+
+	updateTests := []struct {
+		name           string
+		routeID        string
+		expectedStatus int
+		expected       interface{}
+	}{
+		{"set route", `"` + route.ID.String() + `"`, http.StatusOK, route.ID},
+		{"clear route", `null`, http.StatusOK, nil},
+		{"someone else's route", `"` + other.ID.String() + `"`, http.StatusNotFound, nil},
+	}
+	for _, tt := range updateTests {
+		t.Run("update "+tt.name, func(t *testing.T) {
+			mockDB.plannedUpdates = nil
+			body := `{"id":"` + uuid.New().String() + `","routeId":` + tt.routeID + `}`
+			req := withTestUser(httptest.NewRequest(http.MethodPatch, "/activities/plan", strings.NewReader(body)), "user@example.com", nil)
+			w := httptest.NewRecorder()
+			h.HandleUpdatePlannedActivity()(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Status code = %d, want %d (body: %s)", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			value, ok := mockDB.plannedUpdates["route_id"]
+			if !ok || value != tt.expected {
+				t.Errorf("route_id update = %v, want %v", value, tt.expected)
+			}
+		})
+	}
+}
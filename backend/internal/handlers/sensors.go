@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"math"
+)
+
+// summarizeSensor returns the rounded average and maximum of a per-sample sensor reading.
+// Samples without a reading are skipped, as are zero readings unless includeZeros is set.
+// Returns nils when no sample carries a usable reading.
+func summarizeSensor(samples []Sample, reading func(Sample) *int, includeZeros bool) (*int16, *int16) {
+	var sum, count, max int
+	for _, s := range samples {
+		v := reading(s)
+		if v == nil || *v < 0 || (*v == 0 && !includeZeros) {
+			continue
+		}
+		sum += *v
+		count++
+		if *v > max {
+			max = *v
+		}
+	}
+
+	if count == 0 {
+		return nil, nil
+	}
+
+	avg := int16(math.Round(float64(sum) / float64(count)))
+	maxValue := int16(math.Min(float64(max), math.MaxInt16))
+	return &avg, &maxValue
+}
+
+// sensorStream builds a full-resolution sensor stream, interpolating samples without a reading.
+// Returns nil when no sample carries the sensor so absent sensors aren't stored as flat zero streams.
+func sensorStream(samples []Sample, reading func(Sample) *float64) []float64 {
+	raw := make([]*float64, len(samples))
+	found := false
+	for i, s := range samples {
+		if v := reading(s); v != nil {
+			raw[i] = v
+			found = true
+		}
+	}
+
+	if !found {
+		return nil
+	}
+	return interpolateElevationNulls(raw)
+}
+
+// intReading converts an optional integer sensor reading to float64
+func intReading(v *int) *float64 {
+	if v == nil {
+		return nil
+	}
+	f := float64(*v)
+	return &f
+}
+
+// compressSensorStream decimates and DIBS-compresses an optional sensor stream.
+// Returns nil when the stream is absent.
+func compressSensorStream(values []float64, keepIndices []int, decimalPlaces int) []byte {
+	if values == nil {
+		return nil
+	}
+
+	decimated := make([]float64, len(keepIndices))
+	for i, idx := range keepIndices {
+		decimated[i] = values[idx]
+	}
+
+	compressed, _ := compressDIBS(decimated, decimalPlaces)
+	return compressed
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/anish-chanda/cadent/backend/internal/compression"
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestSummarizeSensor(t *testing.T) {
+	samples := []Sample{
+		{HR: intPtr(120), Power: intPtr(0)},
+		{HR: intPtr(0), Power: intPtr(300)},
+		{HR: nil, Power: nil},
+		{HR: intPtr(141), Power: intPtr(150)},
+	}
+
+	avg, max := summarizeSensor(samples, func(s Sample) *int { return s.HR }, false)
+	if avg == nil || *avg != 131 || max == nil || *max != 141 {
+		t.Errorf("HR summary = %v/%v, want 131/141", avg, max)
+	}
+
+	// Zero power is coasting and pulls the average down
+	avg, max = summarizeSensor(samples, func(s Sample) *int { return s.Power }, true)
+	if avg == nil || *avg != 150 || max == nil || *max != 300 {
+		t.Errorf("power summary = %v/%v, want 150/300", avg, max)
+	}
+
+	avg, max = summarizeSensor(samples, func(s Sample) *int { return s.Cadence }, false)
+	if avg != nil || max != nil {
+		t.Errorf("cadence summary = %v/%v, want nil/nil", avg, max)
+	}
+}
+
+func TestSensorStream(t *testing.T) {
+	samples := []Sample{{HR: intPtr(100)}, {}, {HR: intPtr(110)}, {}}
+
+	values := sensorStream(samples, func(s Sample) *float64 { return intReading(s.HR) })
+	want := []float64{100, 105, 110, 110}
+	if len(values) != len(want) {
+		t.Fatalf("len(values) = %d, want %d", len(values), len(want))
+	}
+	for i := range want {
+		if values[i] != want[i] {
+			t.Errorf("values[%d] = %f, want %f", i, values[i], want[i])
+		}
+	}
+
+	if values := sensorStream(samples, func(s Sample) *float64 { return s.Temperature }); values != nil {
+		t.Errorf("Expected nil stream for missing sensor, got %v", values)
+	}
+}
+
+func TestCreateCompressedStreams_SensorStreams(t *testing.T) {
+	samples := []Sample{
+		{T: 1000, Lat: 40.0, Lon: -74.0, HR: intPtr(120), Power: intPtr(200)},
+		{T: 2000, Lat: 40.001, Lon: -74.001, HR: intPtr(125), Power: intPtr(210)},
+		{T: 3000, Lat: 40.002, Lon: -74.002, HR: intPtr(130), Power: intPtr(0)},
+	}
+	fullStream := processFullResolutionStreams(samples, nil, nil)
+	if err := validateStreamAlignment(fullStream, len(samples)); err != nil {
+		t.Fatalf("validateStreamAlignment() error = %v", err)
+	}
+
+	streams, err := createCompressedStreams(uuid.New(), models.StreamIndexByDistance, []int{0, 2}, fullStream)
+	if err != nil {
+		t.Fatalf("createCompressedStreams() error = %v", err)
+	}
+
+	stream := streams[0]
+	if stream.CadenceRpmBytes != nil || stream.TemperatureCBytes != nil {
+		t.Error("Expected no cadence or temperature stream when samples carry none")
+	}
+
+	heartRate, err := compression.Decompress(stream.HeartRateBpmBytes)
+	if err != nil {
+		t.Fatalf("Decompress(heart rate) error = %v", err)
+	}
+	if len(heartRate) != 2 || heartRate[0] != 120 || heartRate[1] != 130 {
+		t.Errorf("heart rate = %v, want [120 130]", heartRate)
+	}
+
+	power, err := compression.Decompress(stream.PowerWattBytes)
+	if err != nil {
+		t.Fatalf("Decompress(power) error = %v", err)
+	}
+	if len(power) != 2 || power[0] != 200 || power[1] != 0 {
+		t.Errorf("power = %v, want [200 0]", power)
+	}
+}
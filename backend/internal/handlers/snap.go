@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/anish-chanda/cadent/backend/internal/geo"
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/anish-chanda/cadent/backend/internal/valhalla"
+)
+
+// valhallaCosting returns the Valhalla costing model used to match tracks and plan routes for an activity type
+func valhallaCosting(activityType models.ActivityType) string {
+	if activityType == models.ActivityTypeRoadBike {
+		return valhalla.CostingBicycle
+	}
+	return valhalla.CostingPedestrian
+}
+
+// snapSamples map-matches the positions of samples to the road and path network. It returns a copy of samples
+// with every matched or interpolated point moved onto the matched path, rounded to the precision of the stored
+// polyline; unmatched points, such as stretches away from any road, keep their GPS position. Timestamps and
+// sensor values are left alone so the snapped track stays aligned with the original file.
+func snapSamples(ctx context.Context, client *valhalla.Client, activityType models.ActivityType, samples []Sample) ([]Sample, error) {
+	points := make([]geo.Point, len(samples))
+	for i, s := range samples {
+		points[i] = geo.Point{Lat: s.Lat, Lon: s.Lon}
+	}
+
+	resp, err := client.TraceAttributes(ctx, valhalla.TraceRequest{
+		EncodedPolyline: geo.Encode6(points),
+		Costing:         valhallaCosting(activityType),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.MatchedPoints) != len(samples) {
+		return nil, fmt.Errorf("map matching returned %d points for %d samples", len(resp.MatchedPoints), len(samples))
+	}
+
+	snapped := make([]Sample, len(samples))
+	copy(snapped, samples)
+	var moved int
+	for i, p := range resp.MatchedPoints {
+		if p.Type == valhalla.MatchTypeUnmatched {
+			continue
+		}
+		snapped[i].Lat = math.Round(p.Lat*1e6) / 1e6
+		snapped[i].Lon = math.Round(p.Lon*1e6) / 1e6
+		moved++
+	}
+	if moved == 0 {
+		return nil, fmt.Errorf("no points of the track matched the map")
+	}
+	return snapped, nil
+}
+
+// snapTrack snaps an activity's samples to the map for snap=true requests. It returns the snapped samples and the
+// raw track's polyline to keep on the activity. When matching is unavailable or fails the raw samples are returned
+// with a nil polyline, so the activity is still saved unsnapped.
+func (h *Handler) snapTrack(ctx context.Context, activityType models.ActivityType, samples []Sample) ([]Sample, *string) {
+	if h.valhallaClient == nil {
+		h.log.Warn("Map matching requested without a Valhalla client, keeping the raw track")
+		return samples, nil
+	}
+
+	snapped, err := snapSamples(ctx, h.valhallaClient, activityType, samples)
+	if errors.Is(err, valhalla.ErrCircuitOpen) {
+		h.log.Warn("Valhalla is unavailable, keeping the raw track")
+		return samples, nil
+	}
+	if err != nil {
+		h.log.Error("Failed to snap track to the map, keeping the raw track", err)
+		return samples, nil
+	}
+
+	rawPolyline, _, _ := processGPSData(samples)
+	return snapped, &rawPolyline
+}
+
+// applySnappedTrack moves samples parsed from a snapped activity's original file, which keeps the raw GPS
+// positions, onto the snapped track stored as the activity's polyline. Activities that were not snapped are
+// left alone.
+func applySnappedTrack(activity *models.Activity, samples []Sample) error {
+	if activity.RawPolyline == nil || activity.Polyline == nil {
+		return nil
+	}
+
+	points, err := geo.Decode6(*activity.Polyline)
+	if err != nil {
+		return fmt.Errorf("failed to decode snapped track: %w", err)
+	}
+	if len(points) != len(samples) {
+		return fmt.Errorf("snapped track has %d points for %d samples", len(points), len(samples))
+	}
+
+	for i, p := range points {
+		samples[i].Lat, samples[i].Lon = p.Lat, p.Lon
+	}
+	return nil
+}
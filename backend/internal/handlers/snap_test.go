@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/geo"
+	"github.com/anish-chanda/cadent/backend/internal/logger"
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/anish-chanda/cadent/backend/internal/store/local_store"
+	"github.com/anish-chanda/cadent/backend/internal/valhalla"
+)
+
+// snapRoadLon is the longitude of the straight north-south road the fake map matcher snaps to
+const snapRoadLon = -122.4194
+
+// driftingGPX runs 1 km north along snapRoadLon with GPS drift zigzagging 35 m either side of the road
+func driftingGPX() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk>
+    <type>running</type>
+    <trkseg>
+`)
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		lon := snapRoadLon + 0.0004
+		if i%2 == 1 {
+			lon = snapRoadLon - 0.0004
+		}
+		fmt.Fprintf(&b, "      <trkpt lat=\"%.6f\" lon=\"%.6f\"><ele>10</ele><time>%s</time></trkpt>\n",
+			37.7749+0.001*float64(i), lon, start.Add(time.Duration(i)*30*time.Second).Format(time.RFC3339))
+	}
+	b.WriteString("    </trkseg>\n  </trk>\n</gpx>")
+	return b.String()
+}
+
+// newFakeMapMatcher serves /trace_attributes, snapping every point but the last onto snapRoadLon
+func newFakeMapMatcher(t *testing.T) (*httptest.Server, *valhalla.TraceRequest) {
+	t.Helper()
+	var received valhalla.TraceRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/trace_attributes" {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &received); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		points, err := geo.Decode6(received.EncodedPolyline)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resp := valhalla.TraceResponse{}
+		for i, p := range points {
+			matched := valhalla.MatchedPoint{Lat: p.Lat, Lon: snapRoadLon + 1e-9, Type: valhalla.MatchTypeMatched}
+			if i == len(points)-1 {
+				matched = valhalla.MatchedPoint{Lat: p.Lat, Lon: p.Lon, Type: valhalla.MatchTypeUnmatched}
+			}
+			resp.MatchedPoints = append(resp.MatchedPoints, matched)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server, &received
+}
+
+func TestSnapSamples(t *testing.T) {
+	server, received := newFakeMapMatcher(t)
+	client := valhalla.NewClient(server.URL)
+
+	samples, _, _, err := processGPXFile([]byte(driftingGPX()), "drift.gpx")
+	if err != nil {
+		t.Fatalf("processGPXFile() error = %v", err)
+	}
+	raw := append([]Sample(nil), samples...)
+
+	snapped, err := snapSamples(context.Background(), client, models.ActivityTypeRoadBike, samples)
+	if err != nil {
+		t.Fatalf("snapSamples() error = %v", err)
+	}
+	if received.Costing != valhalla.CostingBicycle {
+		t.Errorf("Costing = %q, want bicycle for rides", received.Costing)
+	}
+	if len(snapped) != len(samples) {
+		t.Fatalf("Expected %d snapped samples, got %d", len(samples), len(snapped))
+	}
+
+	for i, s := range snapped {
+		if s.T != samples[i].T || s.Lat != samples[i].Lat {
+			t.Errorf("Sample %d: time or latitude changed", i)
+		}
+		if i < len(snapped)-1 && s.Lon != snapRoadLon {
+			t.Errorf("Sample %d: lon = %.9f, want snapped and rounded to %.6f", i, s.Lon, snapRoadLon)
+		}
+		if samples[i] != raw[i] {
+			t.Errorf("Sample %d: input samples must not be modified", i)
+		}
+	}
+	if last := len(snapped) - 1; snapped[last].Lon != samples[last].Lon {
+		t.Error("Expected the unmatched point to keep its GPS position")
+	}
+
+	_, rawDistance, _ := processGPSData(samples)
+	_, snappedDistance, _ := processGPSData(snapped)
+	if snappedDistance >= rawDistance || math.Abs(snappedDistance-1000) > 50 {
+		t.Errorf("Snapped distance = %.0fm, raw %.0fm, want about 1000m", snappedDistance, rawDistance)
+	}
+
+	if valhallaCosting(models.ActivityTypeRun) != valhalla.CostingPedestrian {
+		t.Error("Expected runs to be matched as pedestrian")
+	}
+
+	t.Run("empty track", func(t *testing.T) {
+		if _, err := snapSamples(context.Background(), client, models.ActivityTypeRun, samples[:0]); err == nil {
+			t.Error("Expected an error for an empty track")
+		}
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "no route", http.StatusBadRequest)
+		}))
+		defer failing.Close()
+		if _, err := snapSamples(context.Background(), valhalla.NewClient(failing.URL), models.ActivityTypeRun, samples); err == nil {
+			t.Error("Expected an error when map matching fails")
+		}
+	})
+}
+
+func TestProcessActivityFile_Snap(t *testing.T) {
+	server, _ := newFakeMapMatcher(t)
+	testLogger := logger.New(logger.Config{Level: "error", Environment: "test"})
+	objectStore := local_store.NewLocalStore(*testLogger)
+	if err := objectStore.Connect("local://" + t.TempDir()); err != nil {
+		t.Fatalf("Failed to connect local store: %v", err)
+	}
+	h := NewHandler(NewMockDatabase(), valhalla.NewClient(server.URL), objectStore, testLogger)
+	ctx := context.Background()
+	content := driftingGPX()
+
+	activity, _, _, err := h.processActivityFile(ctx, "user-123", "drift.gpx", []byte(content), activityFileOptions{Snap: true, Location: time.UTC})
+	if err != nil {
+		t.Fatalf("processActivityFile() error = %v", err)
+	}
+	if activity.RawPolyline == nil {
+		t.Fatal("Expected the raw track to be kept")
+	}
+
+	rawPoints, _ := geo.Decode6(*activity.RawPolyline)
+	snappedPoints, _ := geo.Decode6(*activity.Polyline)
+	if len(rawPoints) != 10 || len(snappedPoints) != 10 {
+		t.Fatalf("Expected 10 raw and snapped points, got %d and %d", len(rawPoints), len(snappedPoints))
+	}
+	if math.Abs(rawPoints[0].Lon-snapRoadLon) < 1e-4 || math.Abs(snappedPoints[0].Lon-snapRoadLon) > 1e-6 {
+		t.Errorf("First point raw %v, snapped %v; want the drift kept in the raw track only", rawPoints[0], snappedPoints[0])
+	}
+	if math.Abs(activity.DistanceM-1000) > 50 {
+		t.Errorf("DistanceM = %.0f, want the snapped distance of about 1000m", activity.DistanceM)
+	}
+	if result := createActivityResult(activity); result.RawPolyline == nil || *result.RawPolyline != *activity.RawPolyline {
+		t.Error("Expected the raw track in the activity result")
+	}
+
+	// The stored file keeps the raw positions
+	reader, err := objectStore.GetObject(ctx, *activity.FileURL)
+	if err != nil {
+		t.Fatalf("GetObject() error = %v", err)
+	}
+	stored, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(stored) != content {
+		t.Error("Expected the original file to be stored unchanged")
+	}
+
+	// Full resolution streams follow the snapped track
+	streams, _, _, err := h.getFullLODStreams(ctx, activity, []models.StreamType{models.StreamTypeDistance, models.StreamTypeLatLng})
+	if err != nil {
+		t.Fatalf("getFullLODStreams() error = %v", err)
+	}
+	if distance := streams[0].Values; math.Abs(distance[len(distance)-1]-activity.DistanceM) > 0.01 {
+		t.Errorf("Full resolution distance = %.2f, want %.2f", distance[len(distance)-1], activity.DistanceM)
+	}
+	if lon := streams[1].LatLng[3][1]; math.Abs(lon-snapRoadLon) > 1e-6 {
+		t.Errorf("Full resolution lon = %f, want snapped to %f", lon, snapRoadLon)
+	}
+
+	t.Run("without map matching", func(t *testing.T) {
+		h := NewHandler(NewMockDatabase(), nil, objectStore, testLogger)
+		activity, _, _, err := h.processActivityFile(ctx, "user-123", "drift.gpx", []byte(content), activityFileOptions{Snap: true, Location: time.UTC})
+		if err != nil {
+			t.Fatalf("processActivityFile() error = %v", err)
+		}
+		if activity.RawPolyline != nil || activity.DistanceM < 1100 {
+			t.Errorf("Expected the raw track when map matching is unavailable, got %.0fm", activity.DistanceM)
+		}
+	})
+}
+
+func TestApplySnappedTrack(t *testing.T) {
+	samples := []Sample{{T: 1, Lat: 1, Lon: 1}, {T: 2, Lat: 2, Lon: 2}}
+	activity := &models.Activity{}
+	if err := applySnappedTrack(activity, samples); err != nil || samples[0].Lat != 1 {
+		t.Errorf("Expected unsnapped activities to be left alone, got %v", err)
+	}
+
+	snapped := geo.Encode6([]geo.Point{{Lat: 1.5, Lon: 1.5}, {Lat: 2.5, Lon: 2.5}})
+	raw := geo.Encode6([]geo.Point{{Lat: 1, Lon: 1}, {Lat: 2, Lon: 2}})
+	activity.Polyline, activity.RawPolyline = &snapped, &raw
+	if err := applySnappedTrack(activity, samples); err != nil {
+		t.Fatalf("applySnappedTrack() error = %v", err)
+	}
+	if samples[1].Lat != 2.5 || samples[1].Lon != 2.5 || samples[1].T != 2 {
+		t.Errorf("Expected the snapped position, got %+v", samples[1])
+	}
+
+	if err := applySnappedTrack(activity, samples[:1]); err == nil {
+		t.Error("Expected an error when the snapped track doesn't align with the samples")
+	}
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// minPartialSplitM is the shortest trailing split kept; anything shorter would only show a meaningless pace
+const minPartialSplitM = 10.0
+
+// SplitResult is a single split in the splits response
+type SplitResult struct {
+	Split          int      `json:"split"` // 1-based
+	DistanceM      float64  `json:"distance_m"`
+	ElapsedS       float64  `json:"elapsed_s"`
+	MovingS        float64  `json:"moving_s"`
+	PaceSPerUnit   *float64 `json:"pace_s_per_unit"` // moving seconds per km or mile, nil when the split never moved
+	ElevationGainM float64  `json:"elevation_gain_m"`
+	ElevationLossM float64  `json:"elevation_loss_m"`
+	AvgHRBpm       *int16   `json:"avg_hr_bpm,omitempty"`
+}
+
+// SplitsResponse is the response for GET /activities/{id}/splits
+type SplitsResponse struct {
+	ActivityID string           `json:"activity_id"`
+	Unit       models.SplitUnit `json:"unit"`
+	Splits     []SplitResult    `json:"splits"`
+}
+
+// splitLengthM returns the length of a full split in meters
+func splitLengthM(unit models.SplitUnit) float64 {
+	if unit == models.SplitUnitMile {
+		return 1609.344
+	}
+	return 1000.0
+}
+
+// calculateSplits divides a full-resolution stream into consecutive splits of one unit.
+// movingS holds the cumulative moving seconds per sample (see cumulativeMovingSeconds).
+// Split boundaries that fall between two samples are linearly interpolated.
+func calculateSplits(activityID uuid.UUID, stream *FullResolutionStream, movingS []float64, unit models.SplitUnit) []models.ActivitySplit {
+	n := len(stream.DistanceM)
+	if n < 2 || len(movingS) != n {
+		return nil
+	}
+
+	splitLength := splitLengthM(unit)
+	now := time.Now()
+	var splits []models.ActivitySplit
+
+	startDistance, startTime, startMoving := stream.DistanceM[0], stream.TimeS[0], movingS[0]
+	prevElevation := stream.ElevationM[0]
+	var gain, loss, hrSum, hrSeconds float64
+
+	// accumulate adds the part of segment i between fractions from and to to the current split
+	accumulate := func(i int, from, to float64) {
+		elevation := lerp(stream.ElevationM[i-1], stream.ElevationM[i], to)
+		if diff := elevation - prevElevation; diff > 0 {
+			gain += diff
+		} else {
+			loss -= diff
+		}
+		prevElevation = elevation
+
+		if stream.HeartRateBpm != nil && stream.HeartRateBpm[i] > 0 {
+			seconds := (to - from) * (stream.TimeS[i] - stream.TimeS[i-1])
+			hrSum += stream.HeartRateBpm[i] * seconds
+			hrSeconds += seconds
+		}
+	}
+
+	// closeSplit records the current split ending at the given distance, time and moving time
+	closeSplit := func(distance, t, moving float64) {
+		split := models.ActivitySplit{
+			ActivityID:     activityID,
+			Unit:           unit,
+			SplitIndex:     len(splits) + 1,
+			DistanceM:      distance - startDistance,
+			ElapsedS:       t - startTime,
+			MovingS:        moving - startMoving,
+			ElevationGainM: gain,
+			ElevationLossM: loss,
+			CreatedAt:      now,
+		}
+		if hrSeconds > 0 {
+			avg := int16(math.Round(hrSum / hrSeconds))
+			split.AvgHRBpm = &avg
+		}
+		splits = append(splits, split)
+
+		startDistance, startTime, startMoving = distance, t, moving
+		gain, loss, hrSum, hrSeconds = 0, 0, 0, 0
+	}
+
+	for i := 1; i < n; i++ {
+		d0, d1 := stream.DistanceM[i-1], stream.DistanceM[i]
+		from := 0.0
+
+		// A long segment can cross several split boundaries
+		for boundary := startDistance + splitLength; d1 > d0 && d1 >= boundary; boundary = startDistance + splitLength {
+			f := (boundary - d0) / (d1 - d0)
+			accumulate(i, from, f)
+			closeSplit(boundary, lerp(stream.TimeS[i-1], stream.TimeS[i], f), lerp(movingS[i-1], movingS[i], f))
+			from = f
+		}
+		accumulate(i, from, 1)
+	}
+
+	if stream.DistanceM[n-1]-startDistance >= minPartialSplitM {
+		closeSplit(stream.DistanceM[n-1], stream.TimeS[n-1], movingS[n-1])
+	}
+
+	return splits
+}
+
+// lerp linearly interpolates between a and b
+func lerp(a, b, f float64) float64 {
+	return a + f*(b-a)
+}
+
+// saveActivitySplits computes and stores km and mile splits for a new activity
+func (h *Handler) saveActivitySplits(ctx context.Context, activityID uuid.UUID, fullStream *FullResolutionStream, samples []Sample, pauses []TimerPause) {
+	movingS := cumulativeMovingSeconds(samples, pauses)
+
+	var splits []models.ActivitySplit
+	for _, unit := range []models.SplitUnit{models.SplitUnitKm, models.SplitUnitMile} {
+		splits = append(splits, calculateSplits(activityID, fullStream, movingS, unit)...)
+	}
+	if len(splits) == 0 {
+		return
+	}
+
+	if err := h.database.CreateActivitySplits(ctx, splits); err != nil {
+		h.log.Error("Failed to save activity splits to database", err)
+		return
+	}
+	h.log.Debug(fmt.Sprintf("Successfully saved %d splits for activity: %s", len(splits), activityID.String()))
+}
+
+// createSplitResult converts a stored split to its response form
+func createSplitResult(split models.ActivitySplit) SplitResult {
+	result := SplitResult{
+		Split:          split.SplitIndex,
+		DistanceM:      split.DistanceM,
+		ElapsedS:       split.ElapsedS,
+		MovingS:        split.MovingS,
+		ElevationGainM: split.ElevationGainM,
+		ElevationLossM: split.ElevationLossM,
+		AvgHRBpm:       split.AvgHRBpm,
+	}
+	if split.MovingS > 0 && split.DistanceM > 0 {
+		pace := split.MovingS / (split.DistanceM / splitLengthM(split.Unit))
+		result.PaceSPerUnit = &pace
+	}
+	return result
+}
+
+// HandleGetActivitySplits serves an activity's km or mile splits
+func (h *Handler) HandleGetActivitySplits() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.Background()
+
+		activityID := chi.URLParam(r, "id")
+		if _, err := uuid.Parse(activityID); err != nil {
+			http.Error(w, "Invalid activity ID format", http.StatusBadRequest)
+			return
+		}
+
+		unit := models.SplitUnit(r.URL.Query().Get("unit"))
+		switch unit {
+		case "":
+			unit = models.SplitUnitKm
+		case models.SplitUnitKm, models.SplitUnitMile:
+		default:
+			http.Error(w, fmt.Sprintf("Invalid unit: %s (must be km or mi)", unit), http.StatusBadRequest)
+			return
+		}
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		activity, err := h.database.GetActivityByID(ctx, activityID)
+		if err != nil {
+			h.log.Error("Failed to get activity from database", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if activity == nil || activity.UserID != userID {
+			http.Error(w, "Activity not found", http.StatusNotFound) // Return 404 instead of 403
+			return
+		}
+
+		splits, err := h.database.GetActivitySplits(ctx, activityID, unit)
+		if err != nil {
+			h.log.Error("Failed to get activity splits from database", err)
+			http.Error(w, "Failed to retrieve splits", http.StatusInternalServerError)
+			return
+		}
+
+		response := SplitsResponse{
+			ActivityID: activityID,
+			Unit:       unit,
+			Splits:     make([]SplitResult, 0, len(splits)),
+		}
+		for _, split := range splits {
+			response.Splits = append(response.Splits, createSplitResult(split))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// uniformSplitStream returns a stream covering totalM at 100 m every 30 s, climbing 1 m per sample
+func uniformSplitStream(totalM float64) (*FullResolutionStream, []float64) {
+	stream := &FullResolutionStream{}
+	var moving []float64
+	for d, i := 0.0, 0; d <= totalM; d, i = d+100, i+1 {
+		stream.TimeS = append(stream.TimeS, float64(i)*30)
+		stream.DistanceM = append(stream.DistanceM, d)
+		stream.ElevationM = append(stream.ElevationM, float64(i))
+		stream.SpeedMps = append(stream.SpeedMps, 100.0/30.0)
+		moving = append(moving, float64(i)*30)
+	}
+	return stream, moving
+}
+
+func TestCalculateSplits(t *testing.T) {
+	activityID := uuid.New()
+
+	t.Run("km splits with partial last split", func(t *testing.T) {
+		stream, moving := uniformSplitStream(2500)
+		splits := calculateSplits(activityID, stream, moving, models.SplitUnitKm)
+
+		if len(splits) != 3 {
+			t.Fatalf("Expected 3 splits, got %d", len(splits))
+		}
+		expectedDistance := []float64{1000, 1000, 500}
+		for i, split := range splits {
+			if split.SplitIndex != i+1 || split.Unit != models.SplitUnitKm || split.ActivityID != activityID {
+				t.Errorf("Split %d has unexpected identity: %+v", i, split)
+			}
+			if math.Abs(split.DistanceM-expectedDistance[i]) > 1e-9 {
+				t.Errorf("Split %d distance = %f, want %f", i+1, split.DistanceM, expectedDistance[i])
+			}
+			if math.Abs(split.ElapsedS-expectedDistance[i]*0.3) > 1e-9 || split.MovingS != split.ElapsedS {
+				t.Errorf("Split %d elapsed = %f moving = %f, want %f", i+1, split.ElapsedS, split.MovingS, expectedDistance[i]*0.3)
+			}
+			if math.Abs(split.ElevationGainM-expectedDistance[i]/100) > 1e-9 || split.ElevationLossM != 0 {
+				t.Errorf("Split %d gain = %f loss = %f", i+1, split.ElevationGainM, split.ElevationLossM)
+			}
+			if split.AvgHRBpm != nil {
+				t.Errorf("Split %d: expected no heart rate without a heart rate stream", i+1)
+			}
+		}
+	})
+
+	t.Run("mile boundaries are interpolated", func(t *testing.T) {
+		stream, moving := uniformSplitStream(2000)
+		splits := calculateSplits(activityID, stream, moving, models.SplitUnitMile)
+
+		if len(splits) != 2 {
+			t.Fatalf("Expected 2 splits, got %d", len(splits))
+		}
+		if math.Abs(splits[0].DistanceM-1609.344) > 1e-9 || math.Abs(splits[0].ElapsedS-1609.344*0.3) > 1e-9 {
+			t.Errorf("First mile = %f m in %f s", splits[0].DistanceM, splits[0].ElapsedS)
+		}
+		if math.Abs(splits[0].DistanceM+splits[1].DistanceM-2000) > 1e-9 {
+			t.Errorf("Splits should cover the whole distance, got %f", splits[0].DistanceM+splits[1].DistanceM)
+		}
+		if math.Abs(splits[0].ElevationGainM+splits[1].ElevationGainM-20) > 1e-9 {
+			t.Errorf("Splits should share the total gain, got %f", splits[0].ElevationGainM+splits[1].ElevationGainM)
+		}
+	})
+
+	t.Run("long segment crossing several boundaries", func(t *testing.T) {
+		stream := &FullResolutionStream{
+			TimeS:      []float64{0, 600, 700},
+			DistanceM:  []float64{0, 3000, 3005},
+			ElevationM: []float64{0, 0, 0},
+			SpeedMps:   []float64{0, 5, 5},
+		}
+		splits := calculateSplits(activityID, stream, []float64{0, 600, 700}, models.SplitUnitKm)
+
+		// The 5 m trailing split is too short to keep
+		if len(splits) != 3 {
+			t.Fatalf("Expected 3 splits, got %d", len(splits))
+		}
+		for i, split := range splits {
+			if math.Abs(split.ElapsedS-200) > 1e-9 {
+				t.Errorf("Split %d elapsed = %f, want 200", i+1, split.ElapsedS)
+			}
+		}
+	})
+
+	t.Run("moving time and heart rate", func(t *testing.T) {
+		stream := &FullResolutionStream{
+			TimeS:        []float64{0, 200, 500, 700},
+			DistanceM:    []float64{0, 500, 500, 1000},
+			ElevationM:   []float64{100, 110, 110, 95},
+			SpeedMps:     []float64{0, 2.5, 0, 2.5},
+			HeartRateBpm: []float64{0, 140, 100, 160},
+		}
+		// The 300 s stop in the middle doesn't count as moving
+		splits := calculateSplits(activityID, stream, []float64{0, 200, 200, 400}, models.SplitUnitKm)
+
+		if len(splits) != 1 {
+			t.Fatalf("Expected 1 split, got %d", len(splits))
+		}
+		split := splits[0]
+		if split.ElapsedS != 700 || split.MovingS != 400 {
+			t.Errorf("Elapsed = %f moving = %f, want 700 and 400", split.ElapsedS, split.MovingS)
+		}
+		if split.ElevationGainM != 10 || split.ElevationLossM != 15 {
+			t.Errorf("Gain = %f loss = %f, want 10 and 15", split.ElevationGainM, split.ElevationLossM)
+		}
+		// Time-weighted: (140*200 + 100*300 + 160*200) / 700
+		if split.AvgHRBpm == nil || *split.AvgHRBpm != 129 {
+			t.Errorf("AvgHRBpm = %v, want 129", split.AvgHRBpm)
+		}
+	})
+
+	t.Run("too few points", func(t *testing.T) {
+		stream := &FullResolutionStream{TimeS: []float64{0}, DistanceM: []float64{0}, ElevationM: []float64{0}}
+		if splits := calculateSplits(activityID, stream, []float64{0}, models.SplitUnitKm); splits != nil {
+			t.Errorf("Expected no splits, got %v", splits)
+		}
+	})
+}
+
+func TestCreateSplitResult(t *testing.T) {
+	result := createSplitResult(models.ActivitySplit{Unit: models.SplitUnitMile, SplitIndex: 3, DistanceM: 804.672, ElapsedS: 300, MovingS: 240})
+	if result.Split != 3 {
+		t.Errorf("Split = %d, want 3", result.Split)
+	}
+	if result.PaceSPerUnit == nil || math.Abs(*result.PaceSPerUnit-480) > 1e-9 {
+		t.Errorf("PaceSPerUnit = %v, want 480", result.PaceSPerUnit)
+	}
+
+	if result := createSplitResult(models.ActivitySplit{Unit: models.SplitUnitKm, DistanceM: 1000, ElapsedS: 60}); result.PaceSPerUnit != nil {
+		t.Errorf("Expected no pace without moving time, got %f", *result.PaceSPerUnit)
+	}
+}
+
+func TestHandleGetActivitySplits(t *testing.T) {
+	h, mockDB := newMatchingTestHandler()
+	activity := createTestActivity(uuid.New().String(), "user-123")
+	mockDB.activities[activity.ID.String()] = activity
+	stream, moving := uniformSplitStream(2500)
+	for _, unit := range []models.SplitUnit{models.SplitUnitKm, models.SplitUnitMile} {
+		mockDB.splits = append(mockDB.splits, calculateSplits(activity.ID, stream, moving, unit)...)
+	}
+
+	tests := []struct {
+		name           string
+		activityID     string
+		query          string
+		email          string
+		expectedStatus int
+		expectedSplits int
+	}{
+		{"default unit is km", activity.ID.String(), "", "user@example.com", http.StatusOK, 3},
+		{"miles", activity.ID.String(), "?unit=mi", "user@example.com", http.StatusOK, 2},
+		{"invalid unit", activity.ID.String(), "?unit=yd", "user@example.com", http.StatusBadRequest, 0},
+		{"invalid id", "not-a-uuid", "", "user@example.com", http.StatusBadRequest, 0},
+		{"unknown activity", uuid.New().String(), "", "user@example.com", http.StatusNotFound, 0},
+		{"other user's activity", activity.ID.String(), "", "other@example.com", http.StatusNotFound, 0},
+	}
+	mockDB.usersByEmail["other@example.com"] = &models.UserRecord{ID: "user-456", Email: "other@example.com"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/activities/"+tt.activityID+"/splits"+tt.query, nil)
+			req = withTestUser(req, tt.email, map[string]string{"id": tt.activityID})
+			w := httptest.NewRecorder()
+			h.HandleGetActivitySplits()(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response SplitsResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(response.Splits) != tt.expectedSplits {
+				t.Errorf("Expected %d splits, got %d", tt.expectedSplits, len(response.Splits))
+			}
+			for i, split := range response.Splits {
+				if split.Split != i+1 || split.PaceSPerUnit == nil {
+					t.Errorf("Unexpected split %d: %+v", i, split)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+)
+
+// MaxStatsPeriods limits how many periods GET /stats returns
+const MaxStatsPeriods = 156
+
+// defaultStatsPeriods is how many periods GET /stats returns without a count
+var defaultStatsPeriods = map[models.StatsPeriod]int{
+	models.StatsPeriodWeek:  12,
+	models.StatsPeriodMonth: 12,
+	models.StatsPeriodYear:  5,
+}
+
+// StatsTotals are summed activity statistics
+type StatsTotals struct {
+	Count          int     `json:"count"`
+	DistanceM      float64 `json:"distance_m"`
+	MovingTimeS    float64 `json:"moving_time_s"`
+	ElevationGainM float64 `json:"elevation_gain_m"`
+	TSS            float64 `json:"tss"`
+}
+
+// StatsPeriodResult is the totals of one week, month or year, overall and per activity type, next to the same
+// period a year earlier
+type StatsPeriodResult struct {
+	Start              string                 `json:"start"` // local date the period starts on, YYYY-MM-DD
+	Totals             StatsTotals            `json:"totals"`
+	ByType             map[string]StatsTotals `json:"by_type"`
+	PreviousYear       StatsTotals            `json:"previous_year"`
+	PreviousYearByType map[string]StatsTotals `json:"previous_year_by_type"`
+}
+
+// YearToDateStats is the totals from January 1st through now, next to the same span of the previous year
+type YearToDateStats struct {
+	Year               int                    `json:"year"`
+	Through            string                 `json:"through"` // today's local date, YYYY-MM-DD
+	Totals             StatsTotals            `json:"totals"`
+	ByType             map[string]StatsTotals `json:"by_type"`
+	PreviousYear       StatsTotals            `json:"previous_year"`
+	PreviousYearByType map[string]StatsTotals `json:"previous_year_by_type"`
+}
+
+// StatsResponse is the response for GET /stats
+type StatsResponse struct {
+	Period     string              `json:"period"`
+	Timezone   string              `json:"timezone"`
+	Periods    []StatsPeriodResult `json:"periods"` // oldest first, ending with the current period
+	YearToDate YearToDateStats     `json:"year_to_date"`
+}
+
+// statsPeriodStart returns local midnight of the first day of the period containing t in loc
+func statsPeriodStart(t time.Time, loc *time.Location, period models.StatsPeriod) time.Time {
+	local := t.In(loc)
+	switch period {
+	case models.StatsPeriodYear:
+		return time.Date(local.Year(), 1, 1, 0, 0, 0, 0, loc)
+	case models.StatsPeriodMonth:
+		return time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, loc)
+	default:
+		offset := (int(local.Weekday()) + 6) % 7 // Monday = 0
+		return time.Date(local.Year(), local.Month(), local.Day()-offset, 0, 0, 0, 0, loc)
+	}
+}
+
+// addStatsPeriods moves a period start by n periods
+func addStatsPeriods(start time.Time, period models.StatsPeriod, n int) time.Time {
+	switch period {
+	case models.StatsPeriodYear:
+		return start.AddDate(n, 0, 0)
+	case models.StatsPeriodMonth:
+		return start.AddDate(0, n, 0)
+	default:
+		return start.AddDate(0, 0, 7*n)
+	}
+}
+
+// previousYearPeriod returns the start of the period a period is compared with a year earlier.
+// Weeks go back 52 weeks so they still start on a Monday.
+func previousYearPeriod(start time.Time, period models.StatsPeriod) time.Time {
+	if period == models.StatsPeriodWeek {
+		return start.AddDate(0, 0, -364)
+	}
+	return start.AddDate(-1, 0, 0)
+}
+
+// add adds one period's stats of an activity type to the totals
+func (t *StatsTotals) add(stat models.ActivityStats) {
+	t.Count += stat.Count
+	t.DistanceM += stat.DistanceM
+	t.MovingTimeS += stat.MovingTimeS
+	t.ElevationGainM += stat.ElevationGainM
+	t.TSS += stat.TSS
+}
+
+// sumStats totals stats overall and per activity type
+func sumStats(stats []models.ActivityStats) (StatsTotals, map[string]StatsTotals) {
+	var totals StatsTotals
+	byType := make(map[string]StatsTotals)
+	for _, stat := range stats {
+		totals.add(stat)
+		typeTotals := byType[string(stat.ActivityType)]
+		typeTotals.add(stat)
+		byType[string(stat.ActivityType)] = typeTotals
+	}
+	return totals, byType
+}
+
+// buildStatsPeriods lays out every period in [first, end), including empty ones, with its stats and those of
+// the same period a year earlier
+func buildStatsPeriods(stats []models.ActivityStats, period models.StatsPeriod, first, end time.Time) []StatsPeriodResult {
+	byStart := make(map[string][]models.ActivityStats)
+	for _, stat := range stats {
+		key := stat.PeriodStart.Format("2006-01-02")
+		byStart[key] = append(byStart[key], stat)
+	}
+
+	var result []StatsPeriodResult
+	for start := first; start.Before(end); start = addStatsPeriods(start, period, 1) {
+		key := start.Format("2006-01-02")
+		periodResult := StatsPeriodResult{Start: key}
+		periodResult.Totals, periodResult.ByType = sumStats(byStart[key])
+		periodResult.PreviousYear, periodResult.PreviousYearByType = sumStats(byStart[previousYearPeriod(start, period).Format("2006-01-02")])
+		result = append(result, periodResult)
+	}
+	return result
+}
+
+// HandleGetStats serves the authenticated user's distance, moving time, elevation gain, activity count and
+// training stress per week, month or year (?period=, default week), overall and per activity type.
+// count sets how many periods up to the current one are returned. Periods and year to date follow the
+// calendar of timezone (IANA name, default UTC). Every period and the year to date come with the same span
+// a year earlier for comparison.
+func (h *Handler) HandleGetStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.Background()
+		params := r.URL.Query()
+
+		period := models.StatsPeriodWeek
+		if v := params.Get("period"); v != "" {
+			period = models.StatsPeriod(v)
+			if _, ok := defaultStatsPeriods[period]; !ok {
+				http.Error(w, fmt.Sprintf("Invalid period: %s (must be week, month or year)", v), http.StatusBadRequest)
+				return
+			}
+		}
+
+		count := defaultStatsPeriods[period]
+		if v := params.Get("count"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > MaxStatsPeriods {
+				http.Error(w, fmt.Sprintf("Invalid count (must be between 1 and %d)", MaxStatsPeriods), http.StatusBadRequest)
+				return
+			}
+			count = n
+		}
+
+		loc, err := parseTimezone(params.Get("timezone"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		now := time.Now()
+		first := addStatsPeriods(statsPeriodStart(now, loc, period), period, -(count - 1))
+		end := addStatsPeriods(statsPeriodStart(now, loc, period), period, 1)
+		stats, err := h.database.GetActivityStats(ctx, userID, period, loc.String(), previousYearPeriod(first, period), end)
+		if err != nil {
+			h.log.Error("Failed to get activity stats from database", err)
+			http.Error(w, "Failed to retrieve stats", http.StatusInternalServerError)
+			return
+		}
+
+		yearStart := statsPeriodStart(now, loc, models.StatsPeriodYear)
+		yearToDate, err := h.database.GetActivityStats(ctx, userID, models.StatsPeriodYear, loc.String(), yearStart, now)
+		if err != nil {
+			h.log.Error("Failed to get year to date stats from database", err)
+			http.Error(w, "Failed to retrieve stats", http.StatusInternalServerError)
+			return
+		}
+		previousYearToDate, err := h.database.GetActivityStats(ctx, userID, models.StatsPeriodYear, loc.String(), yearStart.AddDate(-1, 0, 0), now.AddDate(-1, 0, 0))
+		if err != nil {
+			h.log.Error("Failed to get year to date stats from database", err)
+			http.Error(w, "Failed to retrieve stats", http.StatusInternalServerError)
+			return
+		}
+
+		response := StatsResponse{
+			Period:   string(period),
+			Timezone: loc.String(),
+			Periods:  buildStatsPeriods(stats, period, first, end),
+			YearToDate: YearToDateStats{
+				Year:    yearStart.Year(),
+				Through: now.In(loc).Format("2006-01-02"),
+			},
+		}
+		response.YearToDate.Totals, response.YearToDate.ByType = sumStats(yearToDate)
+		response.YearToDate.PreviousYear, response.YearToDate.PreviousYearByType = sumStats(previousYearToDate)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}
+}
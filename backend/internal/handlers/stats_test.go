@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestStatsPeriodStart(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		t        time.Time
+		loc      *time.Location
+		period   models.StatsPeriod
+		expected string
+	}{
+		{"week in UTC", time.Date(2024, 6, 9, 23, 30, 0, 0, time.UTC), time.UTC, models.StatsPeriodWeek, "2024-06-03"},
+		{"week in local time", time.Date(2024, 6, 9, 23, 30, 0, 0, time.UTC), berlin, models.StatsPeriodWeek, "2024-06-10"},
+		{"month across new year", time.Date(2024, 12, 31, 23, 30, 0, 0, time.UTC), berlin, models.StatsPeriodMonth, "2025-01-01"},
+		{"year", time.Date(2024, 12, 31, 23, 30, 0, 0, time.UTC), time.UTC, models.StatsPeriodYear, "2024-01-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := statsPeriodStart(tt.t, tt.loc, tt.period)
+			if start.Format("2006-01-02") != tt.expected || start.Hour() != 0 || start.Location() != tt.loc {
+				t.Errorf("statsPeriodStart() = %v, want local midnight of %s", start, tt.expected)
+			}
+		})
+	}
+
+	monday := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	if got := previousYearPeriod(monday, models.StatsPeriodWeek); got.Weekday() != time.Monday || got.Year() != 2023 {
+		t.Errorf("Expected the previous year's week to start on a Monday, got %v", got)
+	}
+}
+
+func TestBuildStatsPeriods(t *testing.T) {
+	month := func(year int, m time.Month) time.Time { return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC) }
+	stats := []models.ActivityStats{
+		{PeriodStart: month(2023, 7), ActivityType: models.ActivityTypeRun, Count: 1, DistanceM: 8000},
+		{PeriodStart: month(2024, 5), ActivityType: models.ActivityTypeRun, Count: 2, DistanceM: 10000, TSS: 80},
+		{PeriodStart: month(2024, 7), ActivityType: models.ActivityTypeRun, Count: 3, DistanceM: 21000, MovingTimeS: 6300},
+		{PeriodStart: month(2024, 7), ActivityType: models.ActivityTypeRoadBike, Count: 1, DistanceM: 60000, MovingTimeS: 7200, ElevationGainM: 900},
+	}
+
+	periods := buildStatsPeriods(stats, models.StatsPeriodMonth, month(2024, 5), month(2024, 8))
+	if len(periods) != 3 || periods[0].Start != "2024-05-01" || periods[2].Start != "2024-07-01" {
+		t.Fatalf("Expected May through July, got %+v", periods)
+	}
+
+	if june := periods[1]; june.Totals != (StatsTotals{}) || june.ByType == nil || len(june.ByType) != 0 {
+		t.Errorf("Expected an empty June, got %+v", june)
+	}
+
+	july := periods[2]
+	expected := StatsTotals{Count: 4, DistanceM: 81000, MovingTimeS: 13500, ElevationGainM: 900}
+	if july.Totals != expected {
+		t.Errorf("July totals = %+v, want %+v", july.Totals, expected)
+	}
+	if len(july.ByType) != 2 || july.ByType["road_biking"].DistanceM != 60000 || july.ByType["running"].Count != 3 {
+		t.Errorf("Unexpected July totals by type: %+v", july.ByType)
+	}
+	if july.PreviousYear.DistanceM != 8000 || july.PreviousYearByType["running"].Count != 1 {
+		t.Errorf("Expected July 2023 as the previous year, got %+v", july.PreviousYear)
+	}
+}
+
+func TestHandleGetStats(t *testing.T) {
+	h, mockDB := newMatchingTestHandler()
+	now := time.Now()
+	movingS, gain := 1500, 50.0
+
+	current := createTestActivity(uuid.New().String(), "user-123")
+	current.ActivityType = models.ActivityTypeRun
+	current.StartTime = now.Add(-time.Minute)
+	current.DistanceM, current.ElapsedTime, current.MovingTime, current.ElevationGainM = 5000, 1600, &movingS, &gain
+	lastYear := createTestActivity(uuid.New().String(), "user-123")
+	lastYear.ActivityType = models.ActivityTypeRoadBike
+	lastYear.StartTime = now.AddDate(-1, 0, 0).Add(-time.Minute)
+	lastYear.DistanceM, lastYear.ElapsedTime = 40000, 5400
+	otherUser := createTestActivity(uuid.New().String(), "user-456")
+	otherUser.StartTime = now.Add(-time.Minute)
+	for _, activity := range []*models.Activity{current, lastYear, otherUser} {
+		mockDB.activities[activity.ID.String()] = activity
+	}
+	mockDB.trainingStress = []models.ActivityTrainingStress{{ActivityID: current.ID, UserID: "user-123", TSS: 40}}
+
+	t.Run("yearly", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/stats?period=year&count=2", nil)
+		req = withTestUser(req, "user@example.com", nil)
+		w := httptest.NewRecorder()
+		h.HandleGetStats()(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response StatsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		if response.Period != "year" || response.Timezone != "UTC" || len(response.Periods) != 2 {
+			t.Fatalf("Unexpected response: %+v", response)
+		}
+		this := response.Periods[1]
+		expected := StatsTotals{Count: 1, DistanceM: 5000, MovingTimeS: 1500, ElevationGainM: 50, TSS: 40}
+		if this.Totals != expected || this.ByType["running"] != expected {
+			t.Errorf("Current year totals = %+v, want %+v", this.Totals, expected)
+		}
+		if this.PreviousYear.DistanceM != 40000 || response.Periods[0].ByType["road_biking"].MovingTimeS != 5400 {
+			t.Errorf("Expected last year's ride in the comparison, got %+v", response.Periods)
+		}
+
+		ytd := response.YearToDate
+		if ytd.Year != now.UTC().Year() || ytd.Totals != expected || ytd.PreviousYear.Count != 1 {
+			t.Errorf("Unexpected year to date stats: %+v", ytd)
+		}
+	})
+
+	t.Run("weekly default", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/stats?timezone=Europe/Berlin", nil)
+		req = withTestUser(req, "user@example.com", nil)
+		w := httptest.NewRecorder()
+		h.HandleGetStats()(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response StatsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Timezone != "Europe/Berlin" || len(response.Periods) != defaultStatsPeriods[models.StatsPeriodWeek] {
+			t.Errorf("Expected 12 weeks in Europe/Berlin, got %s with %d periods", response.Timezone, len(response.Periods))
+		}
+	})
+
+	errorTests := []struct {
+		name           string
+		query          string
+		dbErr          error
+		expectedStatus int
+	}{
+		{"invalid period", "period=day", nil, http.StatusBadRequest},
+		{"count too small", "count=0", nil, http.StatusBadRequest},
+		{"count too large", "count=157", nil, http.StatusBadRequest},
+		{"invalid timezone", "timezone=Mars/Olympus", nil, http.StatusBadRequest},
+		{"database error", "", errors.New("connection refused"), http.StatusInternalServerError},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			delete(mockDB.errors, "GetActivityStats")
+			if tt.dbErr != nil {
+				mockDB.errors["GetActivityStats"] = tt.dbErr
+			}
+			req := httptest.NewRequest(http.MethodGet, "/stats?"+tt.query, nil)
+			req = withTestUser(req, "user@example.com", nil)
+			w := httptest.NewRecorder()
+			h.HandleGetStats()(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
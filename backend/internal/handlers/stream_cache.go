@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultFullStreamCacheSize is the number of parsed activity files kept in memory for lod=full requests
+const DefaultFullStreamCacheSize = 32
+
+// fullStreamCache is a small LRU cache of full-resolution streams keyed by the original file's object key.
+// It saves re-downloading and re-parsing the GPX/FIT file when the same activity is viewed repeatedly.
+type fullStreamCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front = most recently used
+	entries  map[string]*list.Element
+}
+
+type fullStreamCacheEntry struct {
+	key    string
+	stream *FullResolutionStream
+}
+
+// newFullStreamCache creates a cache holding at most capacity streams
+func newFullStreamCache(capacity int) *fullStreamCache {
+	return &fullStreamCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached stream for key, marking it as recently used
+func (c *fullStreamCache) Get(key string) (*FullResolutionStream, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*fullStreamCacheEntry).stream, true
+}
+
+// Put stores a stream for key, evicting the least recently used entry when full
+func (c *fullStreamCache) Put(key string, stream *FullResolutionStream) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*fullStreamCacheEntry).stream = stream
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&fullStreamCacheEntry{key: key, stream: stream})
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*fullStreamCacheEntry).key)
+	}
+}
+
+// Invalidate drops the cached stream for key, e.g. after the underlying file changes
+func (c *fullStreamCache) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+// Len returns the number of cached streams
+func (c *fullStreamCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import "testing"
+
+func TestFullStreamCache_GetPut(t *testing.T) {
+	cache := newFullStreamCache(2)
+
+	if _, ok := cache.Get("missing"); ok {
+		t.Error("Get() on empty cache should miss")
+	}
+
+	stream := &FullResolutionStream{TimeS: []float64{0, 1}}
+	cache.Put("a", stream)
+
+	got, ok := cache.Get("a")
+	if !ok || got != stream {
+		t.Errorf("Get(a) = %v, %v; want cached stream", got, ok)
+	}
+}
+
+func TestFullStreamCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newFullStreamCache(2)
+
+	cache.Put("a", &FullResolutionStream{})
+	cache.Put("b", &FullResolutionStream{})
+
+	// Touch "a" so "b" becomes the eviction candidate
+	cache.Get("a")
+	cache.Put("c", &FullResolutionStream{})
+
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", cache.Len())
+	}
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Error("Expected a to remain cached")
+	}
+	if _, ok := cache.Get("c"); !ok {
+		t.Error("Expected c to be cached")
+	}
+}
+
+func TestFullStreamCache_Invalidate(t *testing.T) {
+	cache := newFullStreamCache(2)
+	cache.Put("a", &FullResolutionStream{})
+
+	cache.Invalidate("a")
+	cache.Invalidate("missing") // no-op
+
+	if _, ok := cache.Get("a"); ok {
+		t.Error("Expected a to be invalidated")
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", cache.Len())
+	}
+}
+
+func TestEnableFullStreamCache(t *testing.T) {
+	h := &Handler{}
+
+	h.EnableFullStreamCache(0)
+	if h.fullStreams != nil {
+		t.Error("Capacity 0 should leave caching disabled")
+	}
+
+	h.EnableFullStreamCache(8)
+	if h.fullStreams == nil || h.fullStreams.capacity != 8 {
+		t.Error("Expected cache with capacity 8")
+	}
+}
@@ -3,8 +3,12 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/anish-chanda/cadent/backend/internal/compression"
@@ -22,14 +26,50 @@ const (
 
 // StreamsRequest represents the query parameters for requesting activity streams
 type StreamsRequest struct {
-	LOD   models.StreamLOD    `json:"lod"`   // Level of detail: medium, low, or full
-	Types []models.StreamType `json:"types"` // Types: time, distance, elevation, speed
+	LOD     models.StreamLOD     `json:"lod"`      // Level of detail: medium, low, or full
+	IndexBy models.StreamIndexBy `json:"index_by"` // Downsampling axis: distance (default) or time
+	Types   []models.StreamType  `json:"types"`    // Types: time, distance, elevation, speed, heart_rate, cadence, power, temperature, latlng, grade
 }
 
-// StreamData represents decompressed stream data for a specific type
+// StreamData represents decompressed stream data for a specific type.
+// latlng streams carry [lat, lon] pairs in LatLng, every other type carries Values.
 type StreamData struct {
 	Type   models.StreamType `json:"type"`
-	Values []float64         `json:"values"`
+	Values []float64         `json:"values,omitempty"`
+	LatLng [][2]float64      `json:"latlng,omitempty"`
+}
+
+// numPoints returns the number of points in the stream
+func (s StreamData) numPoints() int {
+	if s.Type == models.StreamTypeLatLng {
+		return len(s.LatLng)
+	}
+	return len(s.Values)
+}
+
+// pick returns the stream reduced to the points at keepIndices, skipping indices past its end
+func (s StreamData) pick(keepIndices []int) StreamData {
+	picked := StreamData{Type: s.Type}
+	for _, idx := range keepIndices {
+		if idx >= s.numPoints() {
+			continue
+		}
+		if s.Type == models.StreamTypeLatLng {
+			picked.LatLng = append(picked.LatLng, s.LatLng[idx])
+		} else {
+			picked.Values = append(picked.Values, s.Values[idx])
+		}
+	}
+	return picked
+}
+
+// zipLatLng pairs aligned latitude and longitude arrays
+func zipLatLng(lat, lon []float64) [][2]float64 {
+	latlng := make([][2]float64, len(lat))
+	for i := range lat {
+		latlng[i] = [2]float64{lat[i], lon[i]}
+	}
+	return latlng
 }
 
 // StreamsResponse represents the response containing requested stream data
@@ -39,6 +79,7 @@ type StreamsResponse struct {
 	IndexBy           models.StreamIndexBy `json:"index_by"`
 	NumPoints         int                  `json:"num_points"`
 	OriginalNumPoints int                  `json:"original_num_points"`
+	Decimation        DecimationStrategy   `json:"decimation,omitempty"` // how points were picked, omitted for full LOD
 	Streams           []StreamData         `json:"streams"`
 }
 
@@ -101,11 +142,16 @@ func (h *Handler) HandleGetActivityStreams() http.HandlerFunc {
 		// Get activity streams from database based on LOD
 		var responseStreams []StreamData
 		var numPoints, originalNumPoints int
+		var decimation DecimationStrategy // empty for full resolution
 
 		switch req.LOD {
 		case models.StreamLODMedium:
 			// Get medium LOD from database
-			responseStreams, numPoints, originalNumPoints, err = getMediumLODStreams(ctx, h.database, activityID, req.Types, h.log)
+			responseStreams, numPoints, originalNumPoints, decimation, err = getMediumLODStreams(ctx, h.database, activityID, req.IndexBy, req.Types, h.log)
+			if errors.Is(err, errStreamIndexUnavailable) {
+				http.Error(w, fmt.Sprintf("Streams indexed by %s not available", req.IndexBy), http.StatusNotFound)
+				return
+			}
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -113,17 +159,27 @@ func (h *Handler) HandleGetActivityStreams() http.HandlerFunc {
 
 		case models.StreamLODLow:
 			// Calculate low LOD on the fly from medium LOD
-			responseStreams, numPoints, originalNumPoints, err = getLowLODStreams(ctx, h.database, activityID, req.Types, h.log)
+			responseStreams, numPoints, originalNumPoints, decimation, err = getLowLODStreams(ctx, h.database, activityID, req.IndexBy, req.Types, h.log)
+			if errors.Is(err, errStreamIndexUnavailable) {
+				http.Error(w, fmt.Sprintf("Streams indexed by %s not available", req.IndexBy), http.StatusNotFound)
+				return
+			}
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
 
 		case models.StreamLODFull:
-			// TODO: Get full resolution from FIT file in object storage
-			h.log.Error("Full LOD streams not yet implemented - requires FIT file parsing", nil)
-			http.Error(w, "Full resolution streams not available", http.StatusNotImplemented)
-			return
+			// Rebuild full resolution from the original file in object storage
+			responseStreams, numPoints, originalNumPoints, err = h.getFullLODStreams(ctx, activity, req.Types)
+			if errors.Is(err, errFullStreamUnavailable) {
+				http.Error(w, "Full resolution streams not available", http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
 
 		default:
 			http.Error(w, fmt.Sprintf("Unsupported LOD: %s", req.LOD), http.StatusBadRequest)
@@ -140,9 +196,10 @@ func (h *Handler) HandleGetActivityStreams() http.HandlerFunc {
 		response := StreamsResponse{
 			ActivityID:        activityID,
 			LOD:               req.LOD,
-			IndexBy:           models.StreamIndexByDistance,
+			IndexBy:           req.IndexBy,
 			NumPoints:         numPoints,
 			OriginalNumPoints: originalNumPoints,
+			Decimation:        decimation,
 			Streams:           responseStreams,
 		}
 
@@ -157,25 +214,58 @@ func (h *Handler) HandleGetActivityStreams() http.HandlerFunc {
 	}
 }
 
-// getMediumLODStreams retrieves medium LOD streams from database
-func getMediumLODStreams(ctx context.Context, database db.Database, activityID string, requestedTypes []models.StreamType, log *logger.ServiceLogger) ([]StreamData, int, int, error) {
+// errStreamIndexUnavailable is returned when an activity has medium LOD streams, but none downsampled along the
+// requested axis. Activities created before time indexing only have distance-indexed streams.
+var errStreamIndexUnavailable = errors.New("no medium LOD streams for the requested index")
+
+// getMediumLODStreams retrieves medium LOD streams downsampled along indexBy from database, along with the
+// strategy their points were picked by
+func getMediumLODStreams(ctx context.Context, database db.Database, activityID string, indexBy models.StreamIndexBy, requestedTypes []models.StreamType, log *logger.ServiceLogger) ([]StreamData, int, int, DecimationStrategy, error) {
 	activityStreams, err := database.GetActivityStreams(ctx, activityID, models.StreamLODMedium)
 	if err != nil {
 		log.Error("Failed to get activity streams from database", err)
-		return nil, 0, 0, fmt.Errorf("internal server error")
+		return nil, 0, 0, "", fmt.Errorf("internal server error")
 	}
 
 	if len(activityStreams) == 0 {
-		return nil, 0, 0, fmt.Errorf("no medium LOD streams found")
+		return nil, 0, 0, "", fmt.Errorf("no medium LOD streams found")
 	}
 
-	// For now, we assume a single activity stream record contains all stream types
+	// For now, we assume a single activity stream record per index mode contains all stream types
 	// TODO: we will change this to streams stored per row later
-	activityStream := activityStreams[0]
+	var activityStream *models.ActivityStream
+	for i := range activityStreams {
+		if activityStreams[i].IndexBy == indexBy {
+			activityStream = &activityStreams[i]
+			break
+		}
+	}
+	if activityStream == nil {
+		return nil, 0, 0, "", errStreamIndexUnavailable
+	}
 	responseStreams := make([]StreamData, 0, len(requestedTypes))
 
 	// Decompress requested stream types
 	for _, streamType := range requestedTypes {
+		if streamType == models.StreamTypeLatLng {
+			// Streams stored before coordinates were added have none
+			if len(activityStream.LatBytes) == 0 || len(activityStream.LonBytes) == 0 {
+				log.Info(fmt.Sprintf("No compressed data for stream type %s", streamType))
+				continue
+			}
+			lat, latErr := compression.Decompress(activityStream.LatBytes)
+			lon, lonErr := compression.Decompress(activityStream.LonBytes)
+			if err := errors.Join(latErr, lonErr); err != nil || len(lat) != len(lon) {
+				log.Error(fmt.Sprintf("Failed to decompress %s stream", streamType), err)
+				return nil, 0, 0, "", fmt.Errorf("failed to decompress %s stream data", streamType)
+			}
+			responseStreams = append(responseStreams, StreamData{
+				Type:   streamType,
+				LatLng: zipLatLng(lat, lon),
+			})
+			continue
+		}
+
 		var compressedData []byte
 
 		switch streamType {
@@ -187,6 +277,16 @@ func getMediumLODStreams(ctx context.Context, database db.Database, activityID s
 			compressedData = activityStream.ElevationMBytes
 		case models.StreamTypeSpeed:
 			compressedData = activityStream.SpeedMpsBytes
+		case models.StreamTypeHeartRate:
+			compressedData = activityStream.HeartRateBpmBytes
+		case models.StreamTypeCadence:
+			compressedData = activityStream.CadenceRpmBytes
+		case models.StreamTypePower:
+			compressedData = activityStream.PowerWattBytes
+		case models.StreamTypeTemperature:
+			compressedData = activityStream.TemperatureCBytes
+		case models.StreamTypeGrade:
+			compressedData = activityStream.GradePctBytes
 		default:
 			log.Error(fmt.Sprintf("Unknown stream type: %s", streamType), nil)
 			continue
@@ -201,7 +301,7 @@ func getMediumLODStreams(ctx context.Context, database db.Database, activityID s
 		decompressed, err := compression.Decompress(compressedData)
 		if err != nil {
 			log.Error(fmt.Sprintf("Failed to decompress %s stream", streamType), err)
-			return nil, 0, 0, fmt.Errorf("failed to decompress %s stream data", streamType)
+			return nil, 0, 0, "", fmt.Errorf("failed to decompress %s stream data", streamType)
 		}
 
 		responseStreams = append(responseStreams, StreamData{
@@ -210,60 +310,306 @@ func getMediumLODStreams(ctx context.Context, database db.Database, activityID s
 		})
 	}
 
-	return responseStreams, activityStream.NumPoints, activityStream.OriginalNumPoints, nil
+	return responseStreams, activityStream.NumPoints, activityStream.OriginalNumPoints, decimationFromCodec(activityStream.Codec), nil
+}
+
+// lttbShapeTypes are the streams shape-preserving decimation picks points by, besides the index axis
+var lttbShapeTypes = []models.StreamType{
+	models.StreamTypeTime,
+	models.StreamTypeDistance,
+	models.StreamTypeElevation,
+	models.StreamTypeSpeed,
+	models.StreamTypeHeartRate,
+	models.StreamTypePower,
 }
 
-// getLowLODStreams calculates low LOD streams by further decimating medium LOD data
-func getLowLODStreams(ctx context.Context, database db.Database, activityID string, requestedTypes []models.StreamType, log *logger.ServiceLogger) ([]StreamData, int, int, error) {
-	// First get medium LOD streams
-	mediumStreams, _, originalNumPoints, err := getMediumLODStreams(ctx, database, activityID, requestedTypes, log)
+// getLowLODStreams calculates low LOD streams by further decimating medium LOD data with the strategy the
+// medium LOD was decimated with
+func getLowLODStreams(ctx context.Context, database db.Database, activityID string, indexBy models.StreamIndexBy, requestedTypes []models.StreamType, log *logger.ServiceLogger) ([]StreamData, int, int, DecimationStrategy, error) {
+	// First get medium LOD streams, including those LTTB needs as it isn't known yet how they were decimated
+	requested := make(map[models.StreamType]bool, len(requestedTypes))
+	fetchTypes := append([]models.StreamType{}, requestedTypes...)
+	for _, streamType := range requestedTypes {
+		requested[streamType] = true
+	}
+	for _, streamType := range lttbShapeTypes {
+		if !requested[streamType] {
+			fetchTypes = append(fetchTypes, streamType)
+		}
+	}
+	fetched, _, originalNumPoints, decimation, err := getMediumLODStreams(ctx, database, activityID, indexBy, fetchTypes, log)
 	if err != nil {
-		return nil, 0, 0, err
+		return nil, 0, 0, "", err
+	}
+
+	var mediumStreams []StreamData
+	byType := make(map[models.StreamType][]float64, len(fetched))
+	for _, stream := range fetched {
+		byType[stream.Type] = stream.Values
+		if requested[stream.Type] {
+			mediumStreams = append(mediumStreams, stream)
+		}
 	}
 
 	if len(mediumStreams) == 0 {
-		return nil, 0, 0, fmt.Errorf("no medium LOD data to downsample")
+		return nil, 0, 0, "", fmt.Errorf("no medium LOD data to downsample")
 	}
 
 	// Decimate medium LOD to create low LOD (~100-200 points)
 	targetLowPoints := LowLODTargetPoints
 	responseStreams := make([]StreamData, 0, len(mediumStreams))
 
+	if decimation == DecimationLTTB && mediumStreams[0].numPoints() > targetLowPoints {
+		shape := &FullResolutionStream{
+			TimeS:        byType[models.StreamTypeTime],
+			DistanceM:    byType[models.StreamTypeDistance],
+			ElevationM:   byType[models.StreamTypeElevation],
+			SpeedMps:     byType[models.StreamTypeSpeed],
+			HeartRateBpm: byType[models.StreamTypeHeartRate],
+			PowerWatt:    byType[models.StreamTypePower],
+		}
+		keepIndices := decimate(shape, indexBy, DecimationLTTB, targetLowPoints)
+
+		for _, stream := range mediumStreams {
+			responseStreams = append(responseStreams, stream.pick(keepIndices))
+		}
+		return responseStreams, responseStreams[0].numPoints(), originalNumPoints, decimation, nil
+	}
+
 	for _, stream := range mediumStreams {
-		if len(stream.Values) <= targetLowPoints {
+		n := stream.numPoints()
+		if n <= targetLowPoints {
 			// Already small enough
 			responseStreams = append(responseStreams, stream)
 		} else {
 			// Simple decimation: take every nth point
-			step := len(stream.Values) / targetLowPoints
+			step := n / targetLowPoints
 			if step < 1 {
 				step = 1
 			}
 
-			decimated := make([]float64, 0, targetLowPoints)
-			for i := 0; i < len(stream.Values); i += step {
-				decimated = append(decimated, stream.Values[i])
+			keepIndices := make([]int, 0, targetLowPoints+1)
+			for i := 0; i < n; i += step {
+				keepIndices = append(keepIndices, i)
 			}
 
-			// Always include the last point
-			if len(decimated) > 0 && decimated[len(decimated)-1] != stream.Values[len(stream.Values)-1] {
-				decimated = append(decimated, stream.Values[len(stream.Values)-1])
+			// Always include the last point, by index so every stream keeps the same points
+			if keepIndices[len(keepIndices)-1] != n-1 {
+				keepIndices = append(keepIndices, n-1)
 			}
 
-			responseStreams = append(responseStreams, StreamData{
-				Type:   stream.Type,
-				Values: decimated,
-			})
+			responseStreams = append(responseStreams, stream.pick(keepIndices))
 		}
 	}
 
 	// Calculate the actual number of points in the decimated data
 	actualPoints := 0
 	if len(responseStreams) > 0 {
-		actualPoints = len(responseStreams[0].Values)
+		actualPoints = responseStreams[0].numPoints()
 	}
 
-	return responseStreams, actualPoints, originalNumPoints, nil
+	return responseStreams, actualPoints, originalNumPoints, decimation, nil
+}
+
+// errFullStreamUnavailable is returned when an activity has no readable original file to rebuild full LOD from
+var errFullStreamUnavailable = errors.New("full resolution streams not available")
+
+// getFullLODStreams rebuilds full-resolution streams by re-parsing the activity's original GPX/FIT file
+func (h *Handler) getFullLODStreams(ctx context.Context, activity *models.Activity, requestedTypes []models.StreamType) ([]StreamData, int, int, error) {
+	fullStream, err := h.loadFullResolutionStream(ctx, activity)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	responseStreams := make([]StreamData, 0, len(requestedTypes))
+	for _, streamType := range requestedTypes {
+		if streamType == models.StreamTypeLatLng {
+			if fullStream.Lat == nil || fullStream.Lon == nil {
+				h.log.Info(fmt.Sprintf("No full resolution data for stream type %s", streamType))
+				continue
+			}
+			responseStreams = append(responseStreams, StreamData{
+				Type:   streamType,
+				LatLng: zipLatLng(fullStream.Lat, fullStream.Lon),
+			})
+			continue
+		}
+
+		var values []float64
+
+		switch streamType {
+		case models.StreamTypeTime:
+			values = fullStream.TimeS
+		case models.StreamTypeDistance:
+			values = fullStream.DistanceM
+		case models.StreamTypeElevation:
+			values = fullStream.ElevationM
+		case models.StreamTypeSpeed:
+			values = fullStream.SpeedMps
+		case models.StreamTypeHeartRate:
+			values = fullStream.HeartRateBpm
+		case models.StreamTypeCadence:
+			values = fullStream.CadenceRpm
+		case models.StreamTypePower:
+			values = fullStream.PowerWatt
+		case models.StreamTypeTemperature:
+			values = fullStream.TemperatureC
+		case models.StreamTypeGrade:
+			values = fullStream.GradePct
+		default:
+			h.log.Error(fmt.Sprintf("Unknown stream type: %s", streamType), nil)
+			continue
+		}
+
+		// Sensor streams are absent when the original file didn't record them
+		if values == nil {
+			h.log.Info(fmt.Sprintf("No full resolution data for stream type %s", streamType))
+			continue
+		}
+
+		responseStreams = append(responseStreams, StreamData{
+			Type:   streamType,
+			Values: values,
+		})
+	}
+
+	numPoints := len(fullStream.TimeS)
+	return responseStreams, numPoints, numPoints, nil
+}
+
+// loadFullResolutionStream returns the full-resolution stream for an activity, using the cache when enabled
+func (h *Handler) loadFullResolutionStream(ctx context.Context, activity *models.Activity) (*FullResolutionStream, error) {
+	if activity.FileURL == nil || *activity.FileURL == "" {
+		h.log.Info(fmt.Sprintf("Activity %s has no stored original file", activity.ID.String()))
+		return nil, errFullStreamUnavailable
+	}
+	fileKey := *activity.FileURL
+
+	if h.fullStreams != nil {
+		if cached, ok := h.fullStreams.Get(fileKey); ok {
+			h.log.Debug(fmt.Sprintf("Full stream cache hit for %s", fileKey))
+			return cached, nil
+		}
+	}
+
+	_, _, fullStream, err := h.parseStoredActivityFile(ctx, activity)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.fullStreams != nil {
+		h.fullStreams.Put(fileKey, fullStream)
+	}
+
+	return fullStream, nil
+}
+
+// parseStoredActivityFile reads and parses an activity's stored original file into its samples, file metadata
+// and full-resolution stream. The stream is not cached; use loadFullResolutionStream for reads.
+func (h *Handler) parseStoredActivityFile(ctx context.Context, activity *models.Activity) ([]Sample, ActivityMetadata, *FullResolutionStream, error) {
+	if activity.FileURL == nil || *activity.FileURL == "" {
+		h.log.Info(fmt.Sprintf("Activity %s has no stored original file", activity.ID.String()))
+		return nil, ActivityMetadata{}, nil, errFullStreamUnavailable
+	}
+	fileKey := *activity.FileURL
+
+	reader, err := h.objectStore.GetObject(ctx, fileKey)
+	if err != nil {
+		if strings.Contains(err.Error(), "object not found") {
+			h.log.Info(fmt.Sprintf("Original file %s missing from object store", fileKey))
+			return nil, ActivityMetadata{}, nil, errFullStreamUnavailable
+		}
+		h.log.Error("Failed to read original activity file", err)
+		return nil, ActivityMetadata{}, nil, fmt.Errorf("failed to read original activity file")
+	}
+	defer reader.Close()
+
+	fileContent, err := io.ReadAll(reader)
+	if err != nil {
+		h.log.Error("Failed to read original activity file", err)
+		return nil, ActivityMetadata{}, nil, fmt.Errorf("failed to read original activity file")
+	}
+
+	var samples []Sample
+	var metadata ActivityMetadata
+	var hasElevation bool
+	switch ext := strings.ToLower(filepath.Ext(fileKey)); ext {
+	case ".gpx":
+		samples, metadata, hasElevation, err = processGPXFile(fileContent, fileKey)
+	case ".fit":
+		samples, metadata, hasElevation, err = processFITFile(fileContent, fileKey)
+	case ".tcx":
+		samples, metadata, hasElevation, err = processTCXFile(fileContent, fileKey)
+	default:
+		h.log.Error(fmt.Sprintf("Unsupported original file type: %s", ext), nil)
+		return nil, ActivityMetadata{}, nil, errFullStreamUnavailable
+	}
+	if err != nil {
+		h.log.Error("Failed to parse original activity file", err)
+		return nil, ActivityMetadata{}, nil, fmt.Errorf("failed to parse original activity file")
+	}
+
+	// Snapped activities keep the raw track in the file; move it onto the stored snapped track
+	if err := applySnappedTrack(activity, samples); err != nil {
+		h.log.Error(fmt.Sprintf("Failed to apply snapped track to activity %s, using the raw track", activity.ID.String()), err)
+	}
+
+	// Files without embedded elevation were enriched at upload time; reuse the medium LOD
+	// elevation profile so full resolution doesn't fall back to a flat line.
+	var elevationHeights []float64
+	if !hasElevation {
+		elevationHeights = h.elevationFromMediumLOD(ctx, activity.ID.String(), samples)
+	}
+
+	fullStream := processFullResolutionStreams(samples, nil, elevationHeights)
+	if err := validateStreamAlignment(fullStream, len(samples)); err != nil {
+		h.log.Error("Stream alignment validation failed", err)
+		return nil, ActivityMetadata{}, nil, fmt.Errorf("internal stream processing error")
+	}
+
+	return samples, metadata, fullStream, nil
+}
+
+// elevationFromMediumLOD interpolates per-sample elevation from the stored medium LOD distance/elevation streams.
+// Returns nil when no medium LOD elevation is available.
+func (h *Handler) elevationFromMediumLOD(ctx context.Context, activityID string, samples []Sample) []float64 {
+	medium, _, _, _, err := getMediumLODStreams(ctx, h.database, activityID, models.StreamIndexByDistance, []models.StreamType{models.StreamTypeDistance, models.StreamTypeElevation}, h.log)
+	if err != nil || len(medium) != 2 {
+		return nil
+	}
+	mediumDistance, mediumElevation := medium[0].Values, medium[1].Values
+	if len(mediumDistance) == 0 || len(mediumDistance) != len(mediumElevation) {
+		return nil
+	}
+
+	// Distance along the full-resolution track, matching processFullResolutionStreams
+	heights := make([]float64, len(samples))
+	var cumulativeDistance float64
+	for i := range samples {
+		if i > 0 {
+			cumulativeDistance += haversineDistance(samples[i-1].Lat, samples[i-1].Lon, samples[i].Lat, samples[i].Lon)
+		}
+		heights[i] = interpolateAt(mediumDistance, mediumElevation, cumulativeDistance)
+	}
+	return heights
+}
+
+// interpolateAt linearly interpolates ys at x given ascending xs, clamping outside the range
+func interpolateAt(xs, ys []float64, x float64) float64 {
+	idx := sort.SearchFloat64s(xs, x)
+	if idx == 0 {
+		return ys[0]
+	}
+	if idx >= len(xs) {
+		return ys[len(ys)-1]
+	}
+
+	x0, x1 := xs[idx-1], xs[idx]
+	if x1 == x0 {
+		return ys[idx]
+	}
+	ratio := (x - x0) / (x1 - x0)
+	return ys[idx-1] + ratio*(ys[idx]-ys[idx-1])
 }
 
 // parseStreamRequest parses query parameters into a StreamsRequest
@@ -287,6 +633,16 @@ func parseStreamRequest(r *http.Request) (*StreamsRequest, error) {
 		return nil, fmt.Errorf("invalid lod value: %s (must be medium, low, or full)", lodParam)
 	}
 
+	// Parse index_by parameter (optional, defaults to distance)
+	switch indexParam := r.URL.Query().Get("index_by"); indexParam {
+	case "", "distance":
+		req.IndexBy = models.StreamIndexByDistance
+	case "time":
+		req.IndexBy = models.StreamIndexByTime
+	default:
+		return nil, fmt.Errorf("invalid index_by value: %s (must be distance or time)", indexParam)
+	}
+
 	// Parse types parameter (required)
 	typesParam := r.URL.Query().Get("type")
 	if typesParam == "" {
@@ -308,8 +664,20 @@ func parseStreamRequest(r *http.Request) (*StreamsRequest, error) {
 			req.Types = append(req.Types, models.StreamTypeElevation)
 		case "speed":
 			req.Types = append(req.Types, models.StreamTypeSpeed)
+		case "heart_rate":
+			req.Types = append(req.Types, models.StreamTypeHeartRate)
+		case "cadence":
+			req.Types = append(req.Types, models.StreamTypeCadence)
+		case "power":
+			req.Types = append(req.Types, models.StreamTypePower)
+		case "temperature":
+			req.Types = append(req.Types, models.StreamTypeTemperature)
+		case "latlng":
+			req.Types = append(req.Types, models.StreamTypeLatLng)
+		case "grade":
+			req.Types = append(req.Types, models.StreamTypeGrade)
 		default:
-			return nil, fmt.Errorf("invalid type value: %s (must be one of: time, distance, elevation, speed)", typeStr)
+			return nil, fmt.Errorf("invalid type value: %s (must be one of: time, distance, elevation, speed, heart_rate, cadence, power, temperature, latlng, grade)", typeStr)
 		}
 	}
 
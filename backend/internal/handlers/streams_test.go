@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -14,6 +16,7 @@ import (
 	"github.com/anish-chanda/cadent/backend/internal/compression"
 	"github.com/anish-chanda/cadent/backend/internal/logger"
 	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/anish-chanda/cadent/backend/internal/store/local_store"
 	"github.com/google/uuid"
 )
 
@@ -22,8 +25,21 @@ import (
 type MockDatabase struct {
 	activities      map[string]*models.Activity
 	activityStreams map[string][]models.ActivityStream
+	splits          []models.ActivitySplit
+	laps            []models.ActivityLap
+	bestEfforts     []models.BestEffort
+	records         []models.PersonalRecord
+	trainingStress  []models.ActivityTrainingStress
+	trainingLoad    []models.TrainingLoadDay
+	zoneTimes       []models.ActivityZoneTime
 	users           map[string]*models.UserRecord
+	userSettings    map[string]*models.TrainingSettings
+	userUpdates     map[string]interface{} // columns of the last UpdateUser call
 	usersByEmail    map[string]*models.UserRecord
+	planned         []models.PlannedActivity
+	plannedUpdates  map[string]interface{} // columns of the last UpdatePlannedActivity call
+	routes          map[string]*models.Route
+	enrollments     map[string]*models.UserTrainingPlan
 	errors          map[string]error
 }
 
@@ -33,12 +49,17 @@ func NewMockDatabase() *MockDatabase {
 		activityStreams: make(map[string][]models.ActivityStream),
 		users:           make(map[string]*models.UserRecord),
 		usersByEmail:    make(map[string]*models.UserRecord),
+		userSettings:    make(map[string]*models.TrainingSettings),
+		enrollments:     make(map[string]*models.UserTrainingPlan),
+		routes:          make(map[string]*models.Route),
 		errors:          make(map[string]error),
 	}
 }
 
 func (m *MockDatabase) CreatePlannedActivity(ctx context.Context, plan *models.PlannedActivity) (*models.PlannedActivity, error) {
-	return &models.PlannedActivity{}, nil
+	plan.ID = uuid.New()
+	m.planned = append(m.planned, *plan)
+	return plan, nil
 }
 
 func (m *MockDatabase) GetActivityByID(ctx context.Context, activityID string) (*models.Activity, error) {
@@ -88,16 +109,113 @@ func (m *MockDatabase) GetUserByEmail(ctx context.Context, email string) (*model
 // Implement other required methods as no-ops for this test
 func (m *MockDatabase) CreateUser(ctx context.Context, user *models.UserRecord) error { return nil }
 func (m *MockDatabase) UpdateUser(ctx context.Context, userID string, updates map[string]interface{}) error {
+	m.userUpdates = updates
 	return nil
 }
+func (m *MockDatabase) GetUserTrainingSettings(ctx context.Context, userID string) (*models.TrainingSettings, error) {
+	if err := m.errors["GetUserTrainingSettings"]; err != nil {
+		return nil, err
+	}
+	return m.userSettings[userID], nil
+}
 func (m *MockDatabase) CreateActivity(ctx context.Context, activity *models.Activity) error {
+	if err := m.errors["CreateActivity"]; err != nil {
+		return err
+	}
+	m.activities[activity.ID.String()] = activity
+	return nil
+}
+func (m *MockDatabase) UpdateActivity(ctx context.Context, activityID string, userID string, updates map[string]interface{}) error {
+	if err := m.errors["UpdateActivity"]; err != nil {
+		return err
+	}
+	activity, exists := m.activities[activityID]
+	if !exists || activity.UserID != userID {
+		return errors.New("activity not found")
+	}
+	for field, value := range updates {
+		switch field {
+		case "title":
+			activity.Title = value.(string)
+		case "description":
+			if value == nil {
+				activity.Description = nil
+			} else {
+				desc := value.(string)
+				activity.Description = &desc
+			}
+		case "type":
+			if string(activity.ActivityType) == value.(string) {
+				continue
+			}
+			activity.ActivityType = models.ActivityType(value.(string))
+			if _, ok := updates["avg_gap_mps"]; !ok {
+				activity.AvgGAPMps = nil
+			}
+			m.dropBestEfforts(activity.ID)
+			m.dropTrainingStress(activity.ID)
+			_ = m.ReplaceActivityZoneTimes(ctx, activityID, nil)
+		case "perceived_effort":
+			if value == nil {
+				activity.PerceivedEffort = nil
+			} else {
+				effort := value.(int16)
+				activity.PerceivedEffort = &effort
+			}
+		case "avg_gap_mps":
+			activity.AvgGAPMps = value.(*float64)
+		}
+	}
+	return nil
+}
+func (m *MockDatabase) DeleteActivity(ctx context.Context, activityID string, userID string) error {
+	if err := m.errors["DeleteActivity"]; err != nil {
+		return err
+	}
+	activity, exists := m.activities[activityID]
+	if !exists || activity.UserID != userID {
+		return errors.New("activity not found")
+	}
+	delete(m.activities, activityID)
+	for key := range m.activityStreams {
+		if strings.HasPrefix(key, activityID) {
+			delete(m.activityStreams, key)
+		}
+	}
+	for i := range m.planned {
+		if m.planned[i].MatchedActivityID != nil && m.planned[i].MatchedActivityID.String() == activityID {
+			m.planned[i].MatchedActivityID = nil
+		}
+	}
 	return nil
 }
 func (m *MockDatabase) CreateActivityStreams(ctx context.Context, streams []models.ActivityStream) error {
 	return nil
 }
-func (m *MockDatabase) GetActivitiesByUserID(ctx context.Context, userID string) ([]models.Activity, error) {
-	return nil, nil
+
+// GetActivitiesByUserID supports the default newest-first ordering, cursors and limits
+func (m *MockDatabase) GetActivitiesByUserID(ctx context.Context, userID string, query models.ActivityListQuery) ([]models.Activity, error) {
+	var activities []models.Activity
+	for _, activity := range m.activities {
+		if activity.UserID != userID {
+			continue
+		}
+		if query.After != nil && !activity.StartTime.Before(query.After.StartTime) &&
+			(!activity.StartTime.Equal(query.After.StartTime) || activity.ID.String() >= query.After.ID.String()) {
+			continue
+		}
+		activities = append(activities, *activity)
+	}
+	sort.Slice(activities, func(i, j int) bool {
+		if !activities[i].StartTime.Equal(activities[j].StartTime) {
+			return activities[i].StartTime.After(activities[j].StartTime)
+		}
+		return activities[i].ID.String() > activities[j].ID.String()
+	})
+	if query.Limit > 0 && len(activities) > query.Limit {
+		activities = activities[:query.Limit]
+	}
+	return activities, nil
 }
 
 func (m *MockDatabase) GetActivitiesByUserIDAndDate(ctx context.Context, userID string, start_date time.Time, end_date time.Time) ([]models.Activity, []models.PlannedActivity, error) {
@@ -111,8 +229,71 @@ func (m *MockDatabase) DeletePlannedActivity(ctx context.Context, activityID str
 	return nil
 }
 func (m *MockDatabase) UpdatePlannedActivity(ctx context.Context, activityID string, userID string, updates map[string]interface{}) error {
+	m.plannedUpdates = updates
+	return nil
+}
+func (m *MockDatabase) GetUnmatchedPlannedActivities(ctx context.Context, userID string, start time.Time, end time.Time) ([]models.PlannedActivity, error) {
+	if err := m.errors["GetUnmatchedPlannedActivities"]; err != nil {
+		return nil, err
+	}
+	var result []models.PlannedActivity
+	for _, plan := range m.planned {
+		if plan.UserID == userID && plan.MatchedActivityID == nil && !plan.StartTime.Before(start) && plan.StartTime.Before(end) {
+			result = append(result, plan)
+		}
+	}
+	return result, nil
+}
+func (m *MockDatabase) SetPlannedActivityMatch(ctx context.Context, plannedActivityID string, userID string, activityID *string) error {
+	if err := m.errors["SetPlannedActivityMatch"]; err != nil {
+		return err
+	}
+	for i := range m.planned {
+		if m.planned[i].ID.String() != plannedActivityID || m.planned[i].UserID != userID {
+			continue
+		}
+		m.planned[i].MatchedActivityID = nil
+		if activityID != nil {
+			id := uuid.MustParse(*activityID)
+			m.planned[i].MatchedActivityID = &id
+		}
+		return nil
+	}
+	return errors.New("planned activity not found")
+}
+func (m *MockDatabase) CreateRoute(ctx context.Context, route *models.Route) error {
+	if err := m.errors["CreateRoute"]; err != nil {
+		return err
+	}
+	route.ID = uuid.New()
+	route.CreatedAt = time.Now()
+	route.UpdatedAt = route.CreatedAt
+	stored := *route
+	m.routes[route.ID.String()] = &stored
 	return nil
 }
+func (m *MockDatabase) GetRoutesByUserID(ctx context.Context, userID string) ([]models.Route, error) {
+	routes := []models.Route{}
+	for _, route := range m.routes {
+		if route.UserID == userID {
+			routes = append(routes, *route)
+		}
+	}
+	return routes, nil
+}
+func (m *MockDatabase) GetRouteByID(ctx context.Context, routeID string, userID string) (*models.Route, error) {
+	if route, ok := m.routes[routeID]; ok && route.UserID == userID {
+		return route, nil
+	}
+	return nil, nil
+}
+func (m *MockDatabase) DeleteRoute(ctx context.Context, routeID string, userID string) error {
+	if route, ok := m.routes[routeID]; ok && route.UserID == userID {
+		delete(m.routes, routeID)
+		return nil
+	}
+	return errors.New("route not found")
+}
 func (m *MockDatabase) Connect(dsn string) error { return nil }
 func (m *MockDatabase) Close() error             { return nil }
 func (m *MockDatabase) Migrate() error           { return nil }
@@ -128,6 +309,312 @@ func (m *MockDatabase) GetTrainingPlanWorkouts(ctx context.Context, planID strin
 func (m *MockDatabase) CreateUserTrainingPlanWithPlannedActivities(ctx context.Context, userPlan *models.UserTrainingPlan, plannedActivities []models.PlannedActivity) error {
 	return nil
 }
+func (m *MockDatabase) GetActivitiesByIDs(ctx context.Context, userID string, activityIDs []string) ([]models.Activity, error) {
+	var result []models.Activity
+	for _, id := range activityIDs {
+		if activity, ok := m.activities[id]; ok && activity.UserID == userID {
+			result = append(result, *activity)
+		}
+	}
+	return result, nil
+}
+func (m *MockDatabase) FindActivityIDByStartTime(ctx context.Context, userID string, startTime time.Time, tolerance time.Duration) (*string, error) {
+	if err := m.errors["FindActivityIDByStartTime"]; err != nil {
+		return nil, err
+	}
+	for id, activity := range m.activities {
+		if activity.UserID == userID && activity.StartTime.Sub(startTime).Abs() <= tolerance {
+			return &id, nil
+		}
+	}
+	return nil, nil
+}
+func (m *MockDatabase) GetUserTrainingPlanByID(ctx context.Context, userTrainingPlanID string, userID string) (*models.UserTrainingPlan, error) {
+	if err := m.errors["GetUserTrainingPlanByID"]; err != nil {
+		return nil, err
+	}
+	plan, ok := m.enrollments[userTrainingPlanID]
+	if !ok || plan.UserID != userID {
+		return nil, nil
+	}
+	return plan, nil
+}
+func (m *MockDatabase) GetPlannedActivitiesByUserTrainingPlan(ctx context.Context, userTrainingPlanID string, userID string) ([]models.PlannedActivity, error) {
+	var result []models.PlannedActivity
+	for _, plan := range m.planned {
+		if plan.UserID == userID && plan.UserTrainingPlanID != nil && plan.UserTrainingPlanID.String() == userTrainingPlanID {
+			result = append(result, plan)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockDatabase) GetActivitySplits(ctx context.Context, activityID string, unit models.SplitUnit) ([]models.ActivitySplit, error) {
+	if err, exists := m.errors["GetActivitySplits"]; exists {
+		return nil, err
+	}
+	var result []models.ActivitySplit
+	for _, split := range m.splits {
+		if split.ActivityID.String() == activityID && split.Unit == unit {
+			result = append(result, split)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockDatabase) CreateActivitySplits(ctx context.Context, splits []models.ActivitySplit) error {
+	m.splits = append(m.splits, splits...)
+	return nil
+}
+
+func (m *MockDatabase) GetActivityLaps(ctx context.Context, activityID string) ([]models.ActivityLap, error) {
+	var result []models.ActivityLap
+	for _, lap := range m.laps {
+		if lap.ActivityID.String() == activityID {
+			result = append(result, lap)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockDatabase) CreateActivityLaps(ctx context.Context, laps []models.ActivityLap) error {
+	m.laps = append(m.laps, laps...)
+	return nil
+}
+
+func (m *MockDatabase) SaveActivityBestEfforts(ctx context.Context, activity *models.Activity, efforts []models.BestEffort) ([]string, error) {
+	if err := m.errors["SaveActivityBestEfforts"]; err != nil {
+		return nil, err
+	}
+	m.bestEfforts = append(m.bestEfforts, efforts...)
+
+	var newRecords []string
+	for _, effort := range efforts {
+		if m.upsertRecord(activity, effort) {
+			newRecords = append(newRecords, effort.Effort)
+		}
+	}
+	return newRecords, nil
+}
+
+// upsertRecord stores effort as a personal record when it beats the stored one, like personalRecordUpsert
+func (m *MockDatabase) upsertRecord(activity *models.Activity, effort models.BestEffort) bool {
+	record := models.PersonalRecord{
+		UserID:       activity.UserID,
+		ActivityType: activity.ActivityType,
+		Effort:       effort.Effort,
+		Kind:         effort.Kind,
+		ActivityID:   activity.ID,
+		DistanceM:    effort.DistanceM,
+		ElapsedS:     effort.ElapsedS,
+		AchievedAt:   activity.StartTime,
+	}
+
+	index := -1
+	for i, existing := range m.records {
+		if existing.UserID == record.UserID && existing.ActivityType == record.ActivityType && existing.Effort == record.Effort {
+			index = i
+		}
+	}
+	switch {
+	case index < 0:
+		m.records = append(m.records, record)
+	case effort.Kind == models.BestEffortKindDistance && effort.ElapsedS < m.records[index].ElapsedS,
+		effort.Kind == models.BestEffortKindDuration && effort.DistanceM > m.records[index].DistanceM:
+		m.records[index] = record
+	default:
+		return false
+	}
+	return true
+}
+
+// dropBestEfforts removes an activity's best efforts and the records they held, then refills the records from
+// the remaining efforts, like UpdateActivity does on a type change
+func (m *MockDatabase) dropBestEfforts(activityID uuid.UUID) {
+	var efforts []models.BestEffort
+	for _, effort := range m.bestEfforts {
+		if effort.ActivityID != activityID {
+			efforts = append(efforts, effort)
+		}
+	}
+	m.bestEfforts = efforts
+
+	var records []models.PersonalRecord
+	for _, record := range m.records {
+		if record.ActivityID != activityID {
+			records = append(records, record)
+		}
+	}
+	m.records = records
+
+	for _, effort := range m.bestEfforts {
+		if activity, ok := m.activities[effort.ActivityID.String()]; ok {
+			m.upsertRecord(activity, effort)
+		}
+	}
+}
+
+func (m *MockDatabase) GetPersonalRecords(ctx context.Context, userID string, activityType *models.ActivityType) ([]models.PersonalRecord, error) {
+	if err := m.errors["GetPersonalRecords"]; err != nil {
+		return nil, err
+	}
+	var result []models.PersonalRecord
+	for _, record := range m.records {
+		if record.UserID == userID && (activityType == nil || record.ActivityType == *activityType) {
+			result = append(result, record)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockDatabase) SaveActivityTrainingStress(ctx context.Context, stress *models.ActivityTrainingStress) error {
+	for i := range m.trainingStress {
+		if m.trainingStress[i].ActivityID == stress.ActivityID {
+			m.trainingStress[i] = *stress
+			return nil
+		}
+	}
+	m.trainingStress = append(m.trainingStress, *stress)
+	return nil
+}
+
+// dropTrainingStress removes an activity's training stress score, like UpdateActivity does on a type change
+func (m *MockDatabase) dropTrainingStress(activityID uuid.UUID) {
+	var kept []models.ActivityTrainingStress
+	for _, stress := range m.trainingStress {
+		if stress.ActivityID != activityID {
+			kept = append(kept, stress)
+		}
+	}
+	m.trainingStress = kept
+}
+
+func (m *MockDatabase) GetDailyTrainingStress(ctx context.Context, userID string, from time.Time) ([]models.DailyTrainingStress, error) {
+	sums := make(map[time.Time]float64)
+	for _, stress := range m.trainingStress {
+		if stress.UserID == userID && !stress.Day.Before(from) {
+			sums[stress.Day] += stress.TSS
+		}
+	}
+	result := []models.DailyTrainingStress{}
+	for day, tss := range sums {
+		result = append(result, models.DailyTrainingStress{Day: day, TSS: tss})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Day.Before(result[j].Day) })
+	return result, nil
+}
+
+func (m *MockDatabase) GetTrainingLoad(ctx context.Context, userID string, from time.Time, to time.Time) ([]models.TrainingLoadDay, error) {
+	result := []models.TrainingLoadDay{}
+	for _, day := range m.trainingLoad {
+		if day.UserID == userID && !day.Day.Before(from) && !day.Day.After(to) {
+			result = append(result, day)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockDatabase) GetTrainingLoadBefore(ctx context.Context, userID string, day time.Time) (*models.TrainingLoadDay, error) {
+	var result *models.TrainingLoadDay
+	for i := range m.trainingLoad {
+		if m.trainingLoad[i].UserID == userID && m.trainingLoad[i].Day.Before(day) {
+			result = &m.trainingLoad[i]
+		}
+	}
+	return result, nil
+}
+
+func (m *MockDatabase) ReplaceTrainingLoad(ctx context.Context, userID string, from time.Time, days []models.TrainingLoadDay) error {
+	var kept []models.TrainingLoadDay
+	for _, day := range m.trainingLoad {
+		if day.UserID != userID || day.Day.Before(from) {
+			kept = append(kept, day)
+		}
+	}
+	m.trainingLoad = append(kept, days...)
+	return nil
+}
+
+func (m *MockDatabase) ReplaceActivityZoneTimes(ctx context.Context, activityID string, zones []models.ActivityZoneTime) error {
+	var kept []models.ActivityZoneTime
+	for _, zone := range m.zoneTimes {
+		if zone.ActivityID.String() != activityID {
+			kept = append(kept, zone)
+		}
+	}
+	m.zoneTimes = append(kept, zones...)
+	return nil
+}
+
+func (m *MockDatabase) GetActivityZoneTimes(ctx context.Context, activityIDs []string) ([]models.ActivityZoneTime, error) {
+	if err := m.errors["GetActivityZoneTimes"]; err != nil {
+		return nil, err
+	}
+	result := []models.ActivityZoneTime{}
+	for _, zone := range m.zoneTimes {
+		for _, id := range activityIDs {
+			if zone.ActivityID.String() == id {
+				result = append(result, zone)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (m *MockDatabase) GetActivityStats(ctx context.Context, userID string, period models.StatsPeriod, timezone string, from time.Time, to time.Time) ([]models.ActivityStats, error) {
+	if err := m.errors["GetActivityStats"]; err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+	type key struct {
+		start        string
+		activityType models.ActivityType
+	}
+	sums := make(map[key]*models.ActivityStats)
+	for _, activity := range m.activities {
+		if activity.UserID != userID || activity.StartTime.Before(from) || !activity.StartTime.Before(to) {
+			continue
+		}
+		start := statsPeriodStart(activity.StartTime, loc, period)
+		k := key{start.Format("2006-01-02"), activity.ActivityType}
+		if sums[k] == nil {
+			sums[k] = &models.ActivityStats{
+				PeriodStart:  time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC),
+				ActivityType: activity.ActivityType,
+			}
+		}
+		stat := sums[k]
+		stat.Count++
+		stat.DistanceM += activity.DistanceM
+		movingS := activity.ElapsedTime
+		if activity.MovingTime != nil {
+			movingS = *activity.MovingTime
+		}
+		stat.MovingTimeS += float64(movingS)
+		if activity.ElevationGainM != nil {
+			stat.ElevationGainM += *activity.ElevationGainM
+		}
+		for _, stress := range m.trainingStress {
+			if stress.ActivityID == activity.ID {
+				stat.TSS += stress.TSS
+			}
+		}
+	}
+	result := []models.ActivityStats{}
+	for _, stat := range sums {
+		result = append(result, *stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].PeriodStart.Equal(result[j].PeriodStart) {
+			return result[i].PeriodStart.Before(result[j].PeriodStart)
+		}
+		return result[i].ActivityType < result[j].ActivityType
+	})
+	return result, nil
+}
 
 // Test helper functions
 func createTestActivity(activityID, userID string) *models.Activity {
@@ -201,6 +688,24 @@ func TestParseStreamRequest_ValidInput(t *testing.T) {
 				models.StreamTypeSpeed,
 			},
 		},
+		{
+			name: "medium_lod_latlng",
+			queryParams: url.Values{
+				"lod":  {"medium"},
+				"type": {"distance,latlng"},
+			},
+			expectedLOD:   models.StreamLODMedium,
+			expectedTypes: []models.StreamType{models.StreamTypeDistance, models.StreamTypeLatLng},
+		},
+		{
+			name: "low_lod_grade",
+			queryParams: url.Values{
+				"lod":  {"low"},
+				"type": {"elevation,grade"},
+			},
+			expectedLOD:   models.StreamLODLow,
+			expectedTypes: []models.StreamType{models.StreamTypeElevation, models.StreamTypeGrade},
+		},
 	}
 
 	for _, tt := range tests {
@@ -342,8 +847,8 @@ func TestGetMediumLODStreams(t *testing.T) {
 
 	requestedTypes := []models.StreamType{models.StreamTypeTime, models.StreamTypeDistance}
 
-	resultStreams, numPoints, originalNumPoints, err := getMediumLODStreams(
-		context.Background(), mockDB, activityID, requestedTypes, testLogger)
+	resultStreams, numPoints, originalNumPoints, _, err := getMediumLODStreams(
+		context.Background(), mockDB, activityID, models.StreamIndexByDistance, requestedTypes, testLogger)
 
 	if err != nil {
 		t.Errorf("getMediumLODStreams() error = %v, want nil", err)
@@ -377,8 +882,8 @@ func TestGetMediumLODStreams_NoStreamsFound(t *testing.T) {
 
 	requestedTypes := []models.StreamType{models.StreamTypeTime}
 
-	_, _, _, err := getMediumLODStreams(
-		context.Background(), mockDB, activityID, requestedTypes, testLogger)
+	_, _, _, _, err := getMediumLODStreams(
+		context.Background(), mockDB, activityID, models.StreamIndexByDistance, requestedTypes, testLogger)
 
 	if err == nil {
 		t.Error("getMediumLODStreams() should return error when no streams found")
@@ -391,6 +896,151 @@ func TestGetMediumLODStreams_NoStreamsFound(t *testing.T) {
 	}
 }
 
+func TestParseStreamRequest_IndexBy(t *testing.T) {
+	tests := []struct {
+		indexBy     string
+		expected    models.StreamIndexBy
+		expectError bool
+	}{
+		{"", models.StreamIndexByDistance, false},
+		{"distance", models.StreamIndexByDistance, false},
+		{"time", models.StreamIndexByTime, false},
+		{"speed", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.indexBy, func(t *testing.T) {
+			query := url.Values{"lod": {"medium"}, "type": {"speed"}}
+			if tt.indexBy != "" {
+				query.Set("index_by", tt.indexBy)
+			}
+			result, err := parseStreamRequest(&http.Request{URL: &url.URL{RawQuery: query.Encode()}})
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "invalid index_by value") {
+					t.Errorf("Expected an invalid index_by error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseStreamRequest() error = %v", err)
+			}
+			if result.IndexBy != tt.expected {
+				t.Errorf("IndexBy = %s, want %s", result.IndexBy, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetMediumLODStreams_IndexBy(t *testing.T) {
+	activityID := "550e8400-e29b-41d4-a716-446655440000"
+	mockDB := NewMockDatabase()
+	testLogger := logger.New(logger.Config{Level: "info", Environment: "test", ServiceName: "test-service"})
+	requestedTypes := []models.StreamType{models.StreamTypeTime}
+
+	// Activities created before time indexing only have distance-indexed streams
+	mockDB.activityStreams[activityID+string(models.StreamLODMedium)] = createTestActivityStreams(activityID, models.StreamLODMedium)
+	_, _, _, _, err := getMediumLODStreams(context.Background(), mockDB, activityID, models.StreamIndexByTime, requestedTypes, testLogger)
+	if !errors.Is(err, errStreamIndexUnavailable) {
+		t.Fatalf("Expected errStreamIndexUnavailable, got %v", err)
+	}
+
+	byTime := createTestActivityStreams(activityID, models.StreamLODMedium)[0]
+	byTime.IndexBy = models.StreamIndexByTime
+	byTime.NumPoints = 4
+	mockDB.activityStreams[activityID+string(models.StreamLODMedium)] = append(mockDB.activityStreams[activityID+string(models.StreamLODMedium)], byTime)
+
+	_, numPoints, _, _, err := getMediumLODStreams(context.Background(), mockDB, activityID, models.StreamIndexByTime, requestedTypes, testLogger)
+	if err != nil {
+		t.Fatalf("getMediumLODStreams() error = %v", err)
+	}
+	if numPoints != 4 {
+		t.Errorf("Expected the time-indexed record, got %d points", numPoints)
+	}
+
+	_, numPoints, _, _, err = getMediumLODStreams(context.Background(), mockDB, activityID, models.StreamIndexByDistance, requestedTypes, testLogger)
+	if err != nil || numPoints != 5 {
+		t.Errorf("Expected the distance-indexed record, got %d points and error %v", numPoints, err)
+	}
+}
+
+func TestLatLngStreams(t *testing.T) {
+	activityID := uuid.New()
+	testLogger := logger.New(logger.Config{Level: "info", Environment: "test", ServiceName: "test-service"})
+
+	// A 5000 s track heading north, one sample per second with coordinates beyond 6 decimal places
+	samples := make([]Sample, 5000)
+	for i := range samples {
+		samples[i] = Sample{T: int64(i) * 1000, Lat: 47.3769 + float64(i)*0.0000271234, Lon: 8.5417 - float64(i%7)*0.0000001234}
+	}
+	fullStream := processFullResolutionStreams(samples, nil, nil)
+	streams, err := createMediumLODStreams(activityID, fullStream, DecimationUniform)
+	if err != nil {
+		t.Fatalf("createMediumLODStreams() error = %v", err)
+	}
+	mockDB := NewMockDatabase()
+	mockDB.activityStreams[activityID.String()+string(models.StreamLODMedium)] = streams
+
+	requestedTypes := []models.StreamType{models.StreamTypeTime, models.StreamTypeLatLng}
+	checkAligned := func(t *testing.T, result []StreamData) {
+		t.Helper()
+		if len(result) != 2 || result[1].Type != models.StreamTypeLatLng || len(result[1].Values) != 0 {
+			t.Fatalf("Expected time and latlng streams, got %+v", result)
+		}
+		times, latlng := result[0].Values, result[1].LatLng
+		if len(times) != len(latlng) {
+			t.Fatalf("Expected %d coordinates aligned with time, got %d", len(times), len(latlng))
+		}
+		for i, point := range latlng {
+			sample := samples[int(math.Round(times[i]))]
+			if math.Abs(point[0]-sample.Lat) > 5e-7 || math.Abs(point[1]-sample.Lon) > 5e-7 {
+				t.Fatalf("Point %d = %v, want the sample at %gs (%f, %f)", i, point, times[i], sample.Lat, sample.Lon)
+			}
+		}
+	}
+
+	for _, indexBy := range []models.StreamIndexBy{models.StreamIndexByDistance, models.StreamIndexByTime} {
+		t.Run("medium by "+string(indexBy), func(t *testing.T) {
+			result, numPoints, _, _, err := getMediumLODStreams(context.Background(), mockDB, activityID.String(), indexBy, requestedTypes, testLogger)
+			if err != nil {
+				t.Fatalf("getMediumLODStreams() error = %v", err)
+			}
+			checkAligned(t, result)
+			if numPoints != result[1].numPoints() {
+				t.Errorf("NumPoints = %d, want %d", numPoints, result[1].numPoints())
+			}
+		})
+	}
+
+	t.Run("low", func(t *testing.T) {
+		result, numPoints, _, _, err := getLowLODStreams(context.Background(), mockDB, activityID.String(), models.StreamIndexByDistance, requestedTypes, testLogger)
+		if err != nil {
+			t.Fatalf("getLowLODStreams() error = %v", err)
+		}
+		checkAligned(t, result)
+		if numPoints != len(result[1].LatLng) || numPoints > LowLODTargetPoints+5 {
+			t.Errorf("Expected about %d points, got %d", LowLODTargetPoints, numPoints)
+		}
+	})
+
+	t.Run("streams stored without coordinates", func(t *testing.T) {
+		mockDB.activityStreams[activityID.String()+string(models.StreamLODMedium)] = createTestActivityStreams(activityID.String(), models.StreamLODMedium)
+		result, _, _, _, err := getMediumLODStreams(context.Background(), mockDB, activityID.String(), models.StreamIndexByDistance, requestedTypes, testLogger)
+		if err != nil || len(result) != 1 || result[0].Type != models.StreamTypeTime {
+			t.Errorf("Expected only the time stream, got %+v and error %v", result, err)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		jsonData, err := json.Marshal(StreamData{Type: models.StreamTypeLatLng, LatLng: [][2]float64{{47.3769, 8.5417}}})
+		if err != nil {
+			t.Fatalf("Failed to marshal StreamData: %v", err)
+		}
+		if string(jsonData) != `{"type":"latlng","latlng":[[47.3769,8.5417]]}` {
+			t.Errorf("Unexpected JSON: %s", jsonData)
+		}
+	})
+}
+
 func TestStreamData_JSONSerialization(t *testing.T) {
 	streamData := StreamData{
 		Type:   models.StreamTypeElevation,
@@ -584,7 +1234,7 @@ func TestHandleGetActivityStreams(t *testing.T) {
 			expectedError:  "Activity not found", // Returns 404 instead of 403 for security
 		},
 		{
-			name:        "full LOD not implemented",
+			name:        "full LOD without stored file",
 			activityID:  "550e8400-e29b-41d4-a716-446655440000",
 			queryParams: "lod=full&type=time,distance",
 			userEmail:   "user@example.com",
@@ -600,7 +1250,7 @@ func TestHandleGetActivityStreams(t *testing.T) {
 					Title:  "Test Activity",
 				}
 			},
-			expectedStatus: 501,
+			expectedStatus: 404,
 			expectedError:  "Full resolution streams not available",
 		},
 		{
@@ -875,23 +1525,30 @@ func TestHandleGetActivityStreams(t *testing.T) {
 
 				switch req.LOD {
 				case models.StreamLODMedium:
-					responseStreams, numPoints, originalNumPoints, err = getMediumLODStreams(ctx, mockDB, activityID, req.Types, mockLog)
+					responseStreams, numPoints, originalNumPoints, _, err = getMediumLODStreams(ctx, mockDB, activityID, req.IndexBy, req.Types, mockLog)
 					if err != nil {
 						http.Error(w, "Internal server error", http.StatusInternalServerError)
 						return
 					}
 
 				case models.StreamLODLow:
-					responseStreams, numPoints, originalNumPoints, err = getLowLODStreams(ctx, mockDB, activityID, req.Types, mockLog)
+					responseStreams, numPoints, originalNumPoints, _, err = getLowLODStreams(ctx, mockDB, activityID, req.IndexBy, req.Types, mockLog)
 					if err != nil {
 						http.Error(w, "Internal server error", http.StatusInternalServerError)
 						return
 					}
 
 				case models.StreamLODFull:
-					mockLog.Error("Full LOD streams not yet implemented", nil)
-					http.Error(w, "Full resolution streams not available", http.StatusNotImplemented)
-					return
+					h := &Handler{database: mockDB, log: mockLog}
+					responseStreams, numPoints, originalNumPoints, err = h.getFullLODStreams(ctx, activity, req.Types)
+					if errors.Is(err, errFullStreamUnavailable) {
+						http.Error(w, "Full resolution streams not available", http.StatusNotFound)
+						return
+					}
+					if err != nil {
+						http.Error(w, "Internal server error", http.StatusInternalServerError)
+						return
+					}
 
 				default:
 					http.Error(w, "Unsupported LOD: "+string(req.LOD), http.StatusBadRequest)
@@ -957,3 +1614,143 @@ func TestHandleGetActivityStreams(t *testing.T) {
 		})
 	}
 }
+
+const testFullLODGPX = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk>
+    <name>Morning Run</name>
+    <type>running</type>
+    <trkseg>
+      <trkpt lat="37.7749" lon="-122.4194"><ele>10</ele><time>2024-01-01T08:00:00Z</time></trkpt>
+      <trkpt lat="37.7759" lon="-122.4194"><ele>12</ele><time>2024-01-01T08:00:30Z</time></trkpt>
+      <trkpt lat="37.7769" lon="-122.4194"><ele>15</ele><time>2024-01-01T08:01:00Z</time></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+func setupFullLODHandler(t *testing.T, fileKey string, content string) (*Handler, *models.Activity) {
+	t.Helper()
+
+	testLogger := logger.New(logger.Config{Level: "error", Environment: "test"})
+	objectStore := local_store.NewLocalStore(*testLogger)
+	if err := objectStore.Connect("local://" + t.TempDir()); err != nil {
+		t.Fatalf("Failed to connect local store: %v", err)
+	}
+
+	if content != "" {
+		if err := objectStore.PutObject(context.Background(), fileKey, strings.NewReader(content), int64(len(content))); err != nil {
+			t.Fatalf("Failed to store test file: %v", err)
+		}
+	}
+
+	activity := createTestActivity("550e8400-e29b-41d4-a716-446655440000", "user-123")
+	activity.FileURL = &fileKey
+
+	h := NewHandler(NewMockDatabase(), nil, objectStore, testLogger)
+	return h, activity
+}
+
+func TestGetFullLODStreams_FromGPX(t *testing.T) {
+	fileKey := "activities/user-123/550e8400-e29b-41d4-a716-446655440000.gpx"
+	h, activity := setupFullLODHandler(t, fileKey, testFullLODGPX)
+
+	streams, numPoints, originalNumPoints, err := h.getFullLODStreams(context.Background(), activity,
+		[]models.StreamType{models.StreamTypeTime, models.StreamTypeDistance, models.StreamTypeElevation, models.StreamTypeSpeed})
+	if err != nil {
+		t.Fatalf("getFullLODStreams() error = %v", err)
+	}
+
+	if numPoints != 3 || originalNumPoints != 3 {
+		t.Errorf("numPoints = %d, originalNumPoints = %d, want 3 and 3", numPoints, originalNumPoints)
+	}
+	if len(streams) != 4 {
+		t.Fatalf("Expected 4 streams, got %d", len(streams))
+	}
+
+	if got := streams[0].Values; got[0] != 0 || got[1] != 30 || got[2] != 60 {
+		t.Errorf("Time stream = %v, want [0 30 60]", got)
+	}
+	if got := streams[1].Values; got[2] < 200 || got[2] > 250 {
+		t.Errorf("Final distance = %f, want ~222m", got[2])
+	}
+	if got := streams[2].Values; got[0] != 10 || got[1] != 12 || got[2] != 15 {
+		t.Errorf("Elevation stream = %v, want [10 12 15]", got)
+	}
+}
+
+func TestGetFullLODStreams_UsesCache(t *testing.T) {
+	fileKey := "activities/user-123/550e8400-e29b-41d4-a716-446655440000.gpx"
+	h, activity := setupFullLODHandler(t, fileKey, testFullLODGPX)
+	h.EnableFullStreamCache(4)
+
+	ctx := context.Background()
+	types := []models.StreamType{models.StreamTypeTime}
+	if _, _, _, err := h.getFullLODStreams(ctx, activity, types); err != nil {
+		t.Fatalf("getFullLODStreams() error = %v", err)
+	}
+
+	// Remove the original file; the second request must be served from the cache
+	if err := h.objectStore.DeleteObject(ctx, fileKey); err != nil {
+		t.Fatalf("Failed to delete test file: %v", err)
+	}
+
+	streams, numPoints, _, err := h.getFullLODStreams(ctx, activity, types)
+	if err != nil {
+		t.Fatalf("getFullLODStreams() with cache error = %v", err)
+	}
+	if numPoints != 3 || len(streams) != 1 {
+		t.Errorf("Cached response has %d points and %d streams, want 3 and 1", numPoints, len(streams))
+	}
+}
+
+func TestGetFullLODStreams_Unavailable(t *testing.T) {
+	fileKey := "activities/user-123/550e8400-e29b-41d4-a716-446655440000.gpx"
+
+	t.Run("missing object", func(t *testing.T) {
+		h, activity := setupFullLODHandler(t, fileKey, "")
+		_, _, _, err := h.getFullLODStreams(context.Background(), activity, []models.StreamType{models.StreamTypeTime})
+		if !errors.Is(err, errFullStreamUnavailable) {
+			t.Errorf("Expected errFullStreamUnavailable, got %v", err)
+		}
+	})
+
+	t.Run("no file url", func(t *testing.T) {
+		h, activity := setupFullLODHandler(t, fileKey, "")
+		activity.FileURL = nil
+		_, _, _, err := h.getFullLODStreams(context.Background(), activity, []models.StreamType{models.StreamTypeTime})
+		if !errors.Is(err, errFullStreamUnavailable) {
+			t.Errorf("Expected errFullStreamUnavailable, got %v", err)
+		}
+	})
+
+	t.Run("corrupt file", func(t *testing.T) {
+		h, activity := setupFullLODHandler(t, fileKey, "not a gpx file")
+		_, _, _, err := h.getFullLODStreams(context.Background(), activity, []models.StreamType{models.StreamTypeTime})
+		if err == nil || errors.Is(err, errFullStreamUnavailable) {
+			t.Errorf("Expected parse error, got %v", err)
+		}
+	})
+}
+
+func TestInterpolateAt(t *testing.T) {
+	xs := []float64{0, 100, 200}
+	ys := []float64{10, 20, 40}
+
+	tests := []struct {
+		x        float64
+		expected float64
+	}{
+		{-5, 10},
+		{0, 10},
+		{50, 15},
+		{150, 30},
+		{200, 40},
+		{500, 40},
+	}
+
+	for _, tt := range tests {
+		if got := interpolateAt(xs, ys, tt.x); got != tt.expected {
+			t.Errorf("interpolateAt(%v) = %v, want %v", tt.x, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// Fitness/fatigue model time constants in days
+const (
+	ctlTimeConstantDays = 42.0
+	atlTimeConstantDays = 7.0
+)
+
+// minHRCoverage is the fraction of moving time that needs heart rate data for hrTSS; below it rTSS is used
+const minHRCoverage = 0.5
+
+// Training load range limits in days
+const (
+	DefaultTrainingLoadRangeDays = 90
+	MaxTrainingLoadRangeDays     = 731
+)
+
+// trainingThresholds are the personal values training stress is scored against
+type trainingThresholds struct {
+	RestingHR         float64
+	MaxHR             float64
+	ThresholdHR       float64 // lactate threshold heart rate
+	ThresholdSpeedMps float64 // running threshold pace as a speed
+	TRIMPWeight       float64 // Banister TRIMP exponent, 1.92 for men and 1.67 for women
+}
+
+// defaultTrainingThresholds are population defaults: 60/190 bpm resting/max, 170 bpm threshold and 5:00/km threshold pace
+var defaultTrainingThresholds = trainingThresholds{
+	RestingHR:         60,
+	MaxHR:             190,
+	ThresholdHR:       170,
+	ThresholdSpeedMps: 1000.0 / 300.0,
+	TRIMPWeight:       1.92,
+}
+
+// trainingThresholdsFor fills in the defaults with a user's settings. An unset max heart rate is estimated
+// from the birth year (Tanaka, 208 - 0.7 x age) and an unset threshold heart rate is 90% of a personal max.
+func trainingThresholdsFor(settings *models.TrainingSettings, now time.Time) trainingThresholds {
+	thresholds := defaultTrainingThresholds
+	if settings == nil {
+		return thresholds
+	}
+
+	personalMax := true
+	switch {
+	case settings.MaxHRBpm != nil:
+		thresholds.MaxHR = float64(*settings.MaxHRBpm)
+	case settings.BirthYear != nil:
+		thresholds.MaxHR = 208 - 0.7*float64(now.Year()-int(*settings.BirthYear))
+	default:
+		personalMax = false
+	}
+
+	if settings.RestingHRBpm != nil {
+		thresholds.RestingHR = float64(*settings.RestingHRBpm)
+	}
+	if settings.ThresholdHRBpm != nil {
+		thresholds.ThresholdHR = float64(*settings.ThresholdHRBpm)
+	} else if personalMax {
+		thresholds.ThresholdHR = 0.9 * thresholds.MaxHR
+	}
+	if settings.ThresholdPaceSPerKm != nil {
+		thresholds.ThresholdSpeedMps = 1000 / *settings.ThresholdPaceSPerKm
+	}
+	if settings.Sex != nil && *settings.Sex == models.SexFemale {
+		thresholds.TRIMPWeight = 1.67
+	}
+	return thresholds
+}
+
+// userTrainingSettings returns a user's training settings, or nil to score against the defaults
+// when they can't be loaded
+func (h *Handler) userTrainingSettings(ctx context.Context, userID string) *models.TrainingSettings {
+	settings, err := h.database.GetUserTrainingSettings(ctx, userID)
+	if err != nil {
+		h.log.Error("Failed to get training settings from database", err)
+		return nil
+	}
+	return settings
+}
+
+// TrainingLoadDayResult is one day in the training load response
+type TrainingLoadDayResult struct {
+	Date string  `json:"date"` // YYYY-MM-DD
+	TSS  float64 `json:"tss"`
+	CTL  float64 `json:"ctl"` // fitness
+	ATL  float64 `json:"atl"` // fatigue
+	TSB  float64 `json:"tsb"` // form
+}
+
+// TrainingLoadSummary sums up how the series moved over the requested range
+type TrainingLoadSummary struct {
+	TotalTSS        float64 `json:"total_tss"`
+	StartCTL        float64 `json:"start_ctl"`
+	EndCTL          float64 `json:"end_ctl"`
+	RampRatePerWeek float64 `json:"ramp_rate_per_week"` // average CTL change per 7 days
+	MinTSB          float64 `json:"min_tsb"`
+}
+
+// TrainingLoadResponse is the response for GET /training-load
+type TrainingLoadResponse struct {
+	From               string                  `json:"from"`
+	To                 string                  `json:"to"`
+	UserTrainingPlanID *string                 `json:"user_training_plan_id,omitempty"`
+	Days               []TrainingLoadDayResult `json:"days"`
+	Summary            TrainingLoadSummary     `json:"summary"`
+}
+
+// trainingDay returns the calendar day of t in loc as midnight UTC, the form days are stored in
+func trainingDay(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// banisterTRIMP returns the Banister training impulse per minute at a heart rate
+func banisterTRIMP(hr float64, thresholds trainingThresholds) float64 {
+	reserve := (hr - thresholds.RestingHR) / (thresholds.MaxHR - thresholds.RestingHR)
+	reserve = math.Max(0, math.Min(1, reserve))
+	return reserve * 0.64 * math.Exp(thresholds.TRIMPWeight*reserve)
+}
+
+// calculateTrainingStress scores an activity's training stress. hrTSS compares the activity's TRIMP with an hour
+// at threshold heart rate; without enough heart rate data runs fall back to rTSS from their moving pace.
+// movingS holds the cumulative moving seconds per sample (see cumulativeMovingSeconds).
+// Returns nil when the activity can't be scored.
+func calculateTrainingStress(activity *models.Activity, stream *FullResolutionStream, movingS []float64, thresholds trainingThresholds, day time.Time) *models.ActivityTrainingStress {
+	n := len(stream.TimeS)
+	if n < 2 || len(movingS) != n {
+		return nil
+	}
+	movingTotal := movingS[n-1] - movingS[0]
+	if movingTotal <= 0 {
+		return nil
+	}
+	hours := movingTotal / 3600
+
+	stress := &models.ActivityTrainingStress{
+		ActivityID: activity.ID,
+		UserID:     activity.UserID,
+		Day:        day,
+		CreatedAt:  time.Now(),
+	}
+
+	if stream.HeartRateBpm != nil {
+		var trimp, hrSeconds float64
+		for i := 1; i < n; i++ {
+			dt := movingS[i] - movingS[i-1]
+			if dt <= 0 || stream.HeartRateBpm[i] <= 0 {
+				continue
+			}
+			trimp += dt / 60 * banisterTRIMP(stream.HeartRateBpm[i], thresholds)
+			hrSeconds += dt
+		}
+
+		if hrSeconds >= minHRCoverage*movingTotal {
+			// Moving time without heart rate is assumed to be at the same effort
+			trimp *= movingTotal / hrSeconds
+			stress.TSS = trimp / (60 * banisterTRIMP(thresholds.ThresholdHR, thresholds)) * 100
+			stress.IntensityFactor = math.Sqrt(stress.TSS / (hours * 100))
+			stress.Method = models.TrainingStressMethodHR
+			return stress
+		}
+	}
+
+	if activity.ActivityType != models.ActivityTypeRun {
+		return nil
+	}
+	speed := (stream.DistanceM[n-1] - stream.DistanceM[0]) / movingTotal
+	stress.IntensityFactor = speed / thresholds.ThresholdSpeedMps
+	stress.TSS = hours * stress.IntensityFactor * stress.IntensityFactor * 100
+	stress.Method = models.TrainingStressMethodPace
+	return stress
+}
+
+// nextTrainingLoadDay advances the fitness/fatigue model by one day with that day's TSS
+func nextTrainingLoadDay(prev models.TrainingLoadDay, day time.Time, tss float64) models.TrainingLoadDay {
+	return models.TrainingLoadDay{
+		UserID: prev.UserID,
+		Day:    day,
+		TSS:    tss,
+		CTL:    prev.CTL + (tss-prev.CTL)/ctlTimeConstantDays,
+		ATL:    prev.ATL + (tss-prev.ATL)/atlTimeConstantDays,
+		TSB:    prev.CTL - prev.ATL,
+	}
+}
+
+// calculateTrainingLoad runs the model over daily stress, one day at a time from the day after seed
+// (or the first stressed day without a seed) through the last stressed day.
+func calculateTrainingLoad(userID string, seed *models.TrainingLoadDay, stress []models.DailyTrainingStress) []models.TrainingLoadDay {
+	if len(stress) == 0 {
+		return nil
+	}
+
+	prev := models.TrainingLoadDay{UserID: userID}
+	day := stress[0].Day
+	if seed != nil {
+		prev = *seed
+		day = seed.Day.AddDate(0, 0, 1)
+	}
+
+	var days []models.TrainingLoadDay
+	next := 0
+	for last := stress[len(stress)-1].Day; !day.After(last); day = day.AddDate(0, 0, 1) {
+		tss := 0.0
+		if next < len(stress) && stress[next].Day.Equal(day) {
+			tss = stress[next].TSS
+			next++
+		}
+		prev = nextTrainingLoadDay(prev, day, tss)
+		days = append(days, prev)
+	}
+	return days
+}
+
+// fillTrainingLoad returns one day per calendar day between from and to. Stored days are used as they are;
+// days outside the stored series decay from the day before.
+func fillTrainingLoad(userID string, seed *models.TrainingLoadDay, stored []models.TrainingLoadDay, from time.Time, to time.Time) []models.TrainingLoadDay {
+	prev := models.TrainingLoadDay{UserID: userID}
+	if seed != nil {
+		// The seed may lie well before from, so decay it up to the day before
+		prev = *seed
+		for day := seed.Day.AddDate(0, 0, 1); day.Before(from); day = day.AddDate(0, 0, 1) {
+			prev = nextTrainingLoadDay(prev, day, 0)
+		}
+	}
+
+	var days []models.TrainingLoadDay
+	next := 0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if next < len(stored) && stored[next].Day.Equal(day) {
+			prev = stored[next]
+			next++
+		} else {
+			prev = nextTrainingLoadDay(prev, day, 0)
+		}
+		days = append(days, prev)
+	}
+	return days
+}
+
+// summarizeTrainingLoad sums up a filled series
+func summarizeTrainingLoad(days []models.TrainingLoadDay) TrainingLoadSummary {
+	var summary TrainingLoadSummary
+	if len(days) == 0 {
+		return summary
+	}
+
+	summary.StartCTL = days[0].CTL
+	summary.EndCTL = days[len(days)-1].CTL
+	summary.MinTSB = days[0].TSB
+	for _, day := range days {
+		summary.TotalTSS += day.TSS
+		summary.MinTSB = math.Min(summary.MinTSB, day.TSB)
+	}
+	if len(days) > 1 {
+		summary.RampRatePerWeek = (summary.EndCTL - summary.StartCTL) / float64(len(days)-1) * 7
+	}
+	return summary
+}
+
+// updateTrainingLoad recomputes a user's stored training load series from the given day on.
+// A zero from rebuilds the whole series.
+func (h *Handler) updateTrainingLoad(ctx context.Context, userID string, from time.Time) error {
+	seed, err := h.database.GetTrainingLoadBefore(ctx, userID, from)
+	if err != nil {
+		return err
+	}
+
+	// The series has no gaps, so it continues right after the seed
+	start := from
+	if seed != nil {
+		start = seed.Day.AddDate(0, 0, 1)
+	}
+
+	stress, err := h.database.GetDailyTrainingStress(ctx, userID, start)
+	if err != nil {
+		return err
+	}
+
+	days := calculateTrainingLoad(userID, seed, stress)
+	if err := h.database.ReplaceTrainingLoad(ctx, userID, start, days); err != nil {
+		return err
+	}
+	h.log.Debug(fmt.Sprintf("Updated %d training load days for user: %s", len(days), userID))
+	return nil
+}
+
+// saveTrainingStress scores and stores the training stress of an activity on its local day in loc.
+// With updateLoad the user's training load series is brought up to date as well.
+func (h *Handler) saveTrainingStress(ctx context.Context, activity *models.Activity, fullStream *FullResolutionStream, samples []Sample, pauses []TimerPause, thresholds trainingThresholds, loc *time.Location, updateLoad bool) {
+	day := trainingDay(activity.StartTime, loc)
+	stress := calculateTrainingStress(activity, fullStream, cumulativeMovingSeconds(samples, pauses), thresholds, day)
+	if stress == nil {
+		return
+	}
+
+	if err := h.database.SaveActivityTrainingStress(ctx, stress); err != nil {
+		h.log.Error("Failed to save training stress to database", err)
+		return
+	}
+	h.log.Debug(fmt.Sprintf("Successfully saved training stress %.1f (%s) for activity: %s", stress.TSS, stress.Method, activity.ID.String()))
+
+	if updateLoad {
+		if err := h.updateTrainingLoad(ctx, activity.UserID, day); err != nil {
+			h.log.Error("Failed to update training load", err)
+		}
+	}
+}
+
+// HandleGetTrainingLoad serves the user's daily fitness (CTL), fatigue (ATL) and form (TSB).
+// The range is set by from and to (YYYY-MM-DD, inclusive) and defaults to the last 90 days.
+// With user_training_plan_id the range defaults to the plan enrollment's start date through today.
+func (h *Handler) HandleGetTrainingLoad() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.Background()
+		params := r.URL.Query()
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		to := trainingDay(time.Now(), time.UTC)
+		from := to.AddDate(0, 0, -(DefaultTrainingLoadRangeDays - 1))
+
+		var enrollmentID *string
+		if v := params.Get("user_training_plan_id"); v != "" {
+			if _, err := uuid.Parse(v); err != nil {
+				http.Error(w, "Invalid training plan enrollment ID", http.StatusBadRequest)
+				return
+			}
+			enrollment, err := h.database.GetUserTrainingPlanByID(ctx, v, userID)
+			if err != nil {
+				h.log.Error("Failed to get training plan enrollment from database", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if enrollment == nil {
+				http.Error(w, "Training plan enrollment not found", http.StatusNotFound)
+				return
+			}
+			enrollmentID = &v
+			from = trainingDay(enrollment.StartDate, time.UTC)
+		}
+
+		if v := params.Get("from"); v != "" {
+			if from, err = time.Parse("2006-01-02", v); err != nil {
+				http.Error(w, "Invalid from date format (use YYYY-MM-DD)", http.StatusBadRequest)
+				return
+			}
+		}
+		if v := params.Get("to"); v != "" {
+			if to, err = time.Parse("2006-01-02", v); err != nil {
+				http.Error(w, "Invalid to date format (use YYYY-MM-DD)", http.StatusBadRequest)
+				return
+			}
+		}
+		if to.Before(from) {
+			http.Error(w, "Invalid date range: to must be greater than or equal to from", http.StatusBadRequest)
+			return
+		}
+		if to.Sub(from) >= MaxTrainingLoadRangeDays*24*time.Hour {
+			http.Error(w, fmt.Sprintf("Date range too large: at most %d days", MaxTrainingLoadRangeDays), http.StatusBadRequest)
+			return
+		}
+
+		seed, err := h.database.GetTrainingLoadBefore(ctx, userID, from)
+		if err != nil {
+			h.log.Error("Failed to get training load from database", err)
+			http.Error(w, "Failed to retrieve training load", http.StatusInternalServerError)
+			return
+		}
+		stored, err := h.database.GetTrainingLoad(ctx, userID, from, to)
+		if err != nil {
+			h.log.Error("Failed to get training load from database", err)
+			http.Error(w, "Failed to retrieve training load", http.StatusInternalServerError)
+			return
+		}
+
+		days := fillTrainingLoad(userID, seed, stored, from, to)
+		response := TrainingLoadResponse{
+			From:               from.Format("2006-01-02"),
+			To:                 to.Format("2006-01-02"),
+			UserTrainingPlanID: enrollmentID,
+			Days:               make([]TrainingLoadDayResult, 0, len(days)),
+			Summary:            summarizeTrainingLoad(days),
+		}
+		for _, day := range days {
+			response.Days = append(response.Days, TrainingLoadDayResult{
+				Date: day.Day.Format("2006-01-02"),
+				TSS:  day.TSS,
+				CTL:  day.CTL,
+				ATL:  day.ATL,
+				TSB:  day.TSB,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}
+}
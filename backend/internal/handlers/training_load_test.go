@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// steadyStream returns a stream of durationS seconds sampled every 10 s at a constant speed and heart rate (0 for none)
+func steadyStream(durationS int, speedMps float64, hr float64) (*FullResolutionStream, []float64) {
+	stream := &FullResolutionStream{}
+	var moving []float64
+	for t := 0; t <= durationS; t += 10 {
+		stream.TimeS = append(stream.TimeS, float64(t))
+		stream.DistanceM = append(stream.DistanceM, float64(t)*speedMps)
+		moving = append(moving, float64(t))
+		if hr > 0 {
+			stream.HeartRateBpm = append(stream.HeartRateBpm, hr)
+		}
+	}
+	return stream, moving
+}
+
+func TestCalculateTrainingStress(t *testing.T) {
+	thresholds := defaultTrainingThresholds
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	run := &models.Activity{ID: uuid.New(), UserID: "user-123", ActivityType: models.ActivityTypeRun}
+	ride := &models.Activity{ID: uuid.New(), UserID: "user-123", ActivityType: models.ActivityTypeRoadBike}
+
+	t.Run("an hour at threshold heart rate scores 100", func(t *testing.T) {
+		stream, moving := steadyStream(3600, 2.5, thresholds.ThresholdHR)
+		stress := calculateTrainingStress(ride, stream, moving, thresholds, day)
+
+		if stress == nil || stress.Method != models.TrainingStressMethodHR {
+			t.Fatalf("Expected hrTSS, got %+v", stress)
+		}
+		if math.Abs(stress.TSS-100) > 1e-9 || math.Abs(stress.IntensityFactor-1) > 1e-9 {
+			t.Errorf("TSS = %f IF = %f, want 100 and 1", stress.TSS, stress.IntensityFactor)
+		}
+		if stress.ActivityID != ride.ID || stress.UserID != "user-123" || !stress.Day.Equal(day) {
+			t.Errorf("Unexpected identity: %+v", stress)
+		}
+	})
+
+	t.Run("easier heart rate scores less", func(t *testing.T) {
+		stream, moving := steadyStream(3600, 2.5, 140)
+		stress := calculateTrainingStress(run, stream, moving, thresholds, day)
+		if stress == nil || stress.TSS >= 100 || stress.TSS <= 0 {
+			t.Errorf("Expected a TSS below 100, got %+v", stress)
+		}
+	})
+
+	t.Run("runs without heart rate use pace", func(t *testing.T) {
+		stream, moving := steadyStream(1800, thresholds.ThresholdSpeedMps*1.1, 0)
+		stress := calculateTrainingStress(run, stream, moving, thresholds, day)
+
+		if stress == nil || stress.Method != models.TrainingStressMethodPace {
+			t.Fatalf("Expected rTSS, got %+v", stress)
+		}
+		// Half an hour at 110% of threshold speed: 0.5 * 1.1^2 * 100
+		if math.Abs(stress.TSS-60.5) > 1e-9 || math.Abs(stress.IntensityFactor-1.1) > 1e-9 {
+			t.Errorf("TSS = %f IF = %f, want 60.5 and 1.1", stress.TSS, stress.IntensityFactor)
+		}
+	})
+
+	t.Run("sparse heart rate falls back to pace", func(t *testing.T) {
+		stream, moving := steadyStream(3600, thresholds.ThresholdSpeedMps, thresholds.ThresholdHR)
+		for i := 10; i < len(stream.HeartRateBpm); i++ {
+			stream.HeartRateBpm[i] = 0
+		}
+		stress := calculateTrainingStress(run, stream, moving, thresholds, day)
+		if stress == nil || stress.Method != models.TrainingStressMethodPace || math.Abs(stress.TSS-100) > 1e-9 {
+			t.Errorf("Expected rTSS of 100, got %+v", stress)
+		}
+	})
+
+	t.Run("rides without heart rate are not scored", func(t *testing.T) {
+		stream, moving := steadyStream(3600, 8, 0)
+		if stress := calculateTrainingStress(ride, stream, moving, thresholds, day); stress != nil {
+			t.Errorf("Expected no score, got %+v", stress)
+		}
+	})
+}
+
+func TestCalculateTrainingLoad(t *testing.T) {
+	day1 := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	stress := []models.DailyTrainingStress{
+		{Day: day1, TSS: 100},
+		{Day: day1.AddDate(0, 0, 2), TSS: 50},
+	}
+
+	days := calculateTrainingLoad("user-123", nil, stress)
+	if len(days) != 3 {
+		t.Fatalf("Expected 3 days including the rest day, got %d", len(days))
+	}
+
+	ctl1, atl1 := 100/ctlTimeConstantDays, 100/atlTimeConstantDays
+	if math.Abs(days[0].CTL-ctl1) > 1e-9 || math.Abs(days[0].ATL-atl1) > 1e-9 || days[0].TSB != 0 {
+		t.Errorf("Unexpected first day: %+v", days[0])
+	}
+	if days[1].TSS != 0 || !days[1].Day.Equal(day1.AddDate(0, 0, 1)) || math.Abs(days[1].TSB-(ctl1-atl1)) > 1e-9 {
+		t.Errorf("Unexpected rest day: %+v", days[1])
+	}
+	if days[1].CTL >= days[0].CTL || days[1].ATL >= days[0].ATL {
+		t.Errorf("Fitness and fatigue should decay on a rest day: %+v", days[1])
+	}
+	if days[2].UserID != "user-123" || days[2].TSS != 50 {
+		t.Errorf("Unexpected last day: %+v", days[2])
+	}
+
+	// Continuing from a seed gives the same series as computing it in one go
+	continued := calculateTrainingLoad("user-123", &days[0], stress[1:])
+	if len(continued) != 2 || continued[1] != days[2] {
+		t.Errorf("Continued series %+v differs from %+v", continued, days[1:])
+	}
+
+	if days := calculateTrainingLoad("user-123", nil, nil); days != nil {
+		t.Errorf("Expected no days without stress, got %v", days)
+	}
+}
+
+func TestFillTrainingLoad(t *testing.T) {
+	day1 := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	stored := calculateTrainingLoad("user-123", nil, []models.DailyTrainingStress{
+		{Day: day1, TSS: 100},
+		{Day: day1.AddDate(0, 0, 1), TSS: 80},
+	})
+
+	// Two days before the series, the series itself and two days of decay after it
+	days := fillTrainingLoad("user-123", nil, stored, day1.AddDate(0, 0, -2), day1.AddDate(0, 0, 3))
+	if len(days) != 6 {
+		t.Fatalf("Expected 6 days, got %d", len(days))
+	}
+	if days[0].CTL != 0 || days[1].CTL != 0 || days[2] != stored[0] || days[3] != stored[1] {
+		t.Errorf("Unexpected filled series: %+v", days)
+	}
+	if days[4].TSS != 0 || days[4].CTL >= days[3].CTL || days[5].CTL >= days[4].CTL {
+		t.Errorf("Expected decay after the series: %+v", days[4:])
+	}
+
+	// Starting after the stored series decays from its last day
+	later := fillTrainingLoad("user-123", &stored[1], nil, day1.AddDate(0, 0, 4), day1.AddDate(0, 0, 5))
+	if len(later) != 2 || math.Abs(later[0].CTL-days[5].CTL*(1-1/ctlTimeConstantDays)) > 1e-9 {
+		t.Errorf("Unexpected decayed series: %+v", later)
+	}
+
+	summary := summarizeTrainingLoad(days)
+	if summary.TotalTSS != 180 || summary.StartCTL != 0 || summary.EndCTL != days[5].CTL {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	if math.Abs(summary.RampRatePerWeek-days[5].CTL/5*7) > 1e-9 || summary.MinTSB >= 0 {
+		t.Errorf("Unexpected ramp rate or form: %+v", summary)
+	}
+}
+
+func TestUpdateTrainingLoad(t *testing.T) {
+	h, mockDB := newMatchingTestHandler()
+	ctx := context.Background()
+	day1 := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	addStress := func(day time.Time, tss float64) {
+		mockDB.trainingStress = append(mockDB.trainingStress, models.ActivityTrainingStress{ActivityID: uuid.New(), UserID: "user-123", Day: day, TSS: tss})
+		if err := h.updateTrainingLoad(ctx, "user-123", day); err != nil {
+			t.Fatalf("updateTrainingLoad() error = %v", err)
+		}
+	}
+
+	addStress(day1, 100)
+	addStress(day1.AddDate(0, 0, 5), 60)
+	addStress(day1.AddDate(0, 0, 2), 80) // backfilled activity recomputes the following days
+
+	expected := calculateTrainingLoad("user-123", nil, []models.DailyTrainingStress{
+		{Day: day1, TSS: 100},
+		{Day: day1.AddDate(0, 0, 2), TSS: 80},
+		{Day: day1.AddDate(0, 0, 5), TSS: 60},
+	})
+	if len(mockDB.trainingLoad) != len(expected) {
+		t.Fatalf("Expected %d stored days, got %d", len(expected), len(mockDB.trainingLoad))
+	}
+	for i := range expected {
+		if mockDB.trainingLoad[i] != expected[i] {
+			t.Errorf("Day %d = %+v, want %+v", i, mockDB.trainingLoad[i], expected[i])
+		}
+	}
+
+	// Removing the last activity drops its day from the series
+	mockDB.trainingStress = append(mockDB.trainingStress[:1], mockDB.trainingStress[2])
+	if err := h.updateTrainingLoad(ctx, "user-123", day1.AddDate(0, 0, 5)); err != nil {
+		t.Fatalf("updateTrainingLoad() error = %v", err)
+	}
+	last := mockDB.trainingLoad[len(mockDB.trainingLoad)-1]
+	if !last.Day.Before(day1.AddDate(0, 0, 5)) {
+		t.Errorf("Expected the series to end before the removed day, got %+v", last)
+	}
+}
+
+func TestHandleGetTrainingLoad(t *testing.T) {
+	h, mockDB := newMatchingTestHandler()
+	day1 := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	mockDB.trainingLoad = calculateTrainingLoad("user-123", nil, []models.DailyTrainingStress{{Day: day1, TSS: 100}})
+	enrollmentID := uuid.New()
+	mockDB.enrollments[enrollmentID.String()] = &models.UserTrainingPlan{ID: enrollmentID, UserID: "user-123", StartDate: day1.AddDate(0, 0, -1)}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedDays   int
+	}{
+		{"explicit range", "?from=2024-05-31&to=2024-06-03", http.StatusOK, 4},
+		{"plan enrollment start", "?user_training_plan_id=" + enrollmentID.String() + "&to=2024-06-02", http.StatusOK, 3},
+		{"unknown enrollment", "?user_training_plan_id=" + uuid.New().String(), http.StatusNotFound, 0},
+		{"invalid enrollment", "?user_training_plan_id=abc", http.StatusBadRequest, 0},
+		{"invalid date", "?from=06/01/2024", http.StatusBadRequest, 0},
+		{"reversed range", "?from=2024-06-03&to=2024-06-01", http.StatusBadRequest, 0},
+		{"range too large", "?from=2020-01-01&to=2024-06-01", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/training-load"+tt.query, nil)
+			req = withTestUser(req, "user@example.com", nil)
+			w := httptest.NewRecorder()
+			h.HandleGetTrainingLoad()(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response TrainingLoadResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(response.Days) != tt.expectedDays {
+				t.Fatalf("Expected %d days, got %+v", tt.expectedDays, response.Days)
+			}
+			if response.Days[0].CTL != 0 || response.Days[1].Date != "2024-06-01" || response.Days[1].TSS != 100 {
+				t.Errorf("Unexpected days: %+v", response.Days)
+			}
+			if response.Summary.TotalTSS != 100 || response.Summary.RampRatePerWeek <= 0 {
+				t.Errorf("Unexpected summary: %+v", response.Summary)
+			}
+		})
+	}
+}
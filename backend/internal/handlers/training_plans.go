@@ -11,6 +11,7 @@ import (
 
 	"github.com/anish-chanda/cadent/backend/internal/models"
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
@@ -33,6 +34,16 @@ type ImportTrainingPlanDryRunRequest struct {
 	Description             *string   `json:"description,omitempty"`
 }
 
+// TrainingPlanProgressResponse reports compliance for every workout of a training plan enrollment
+type TrainingPlanProgressResponse struct {
+	UserTrainingPlanID string                  `json:"user_training_plan_id"`
+	Title              string                  `json:"title"`
+	StartDate          time.Time               `json:"start_date"`
+	Summary            ComplianceSummary       `json:"summary"`
+	Weeks              []WeeklyCompliance      `json:"weeks"`
+	Workouts           []PlannedActivityResult `json:"workouts"`
+}
+
 type scheduledTrainingPlanWorkout struct {
 	workout         models.TrainingPlanWorkout
 	planSequence    int
@@ -120,6 +131,73 @@ func (h *Handler) HandleGetTrainingPlanWorkouts() http.HandlerFunc {
 	}
 }
 
+// HandleGetTrainingPlanProgress returns per-workout compliance with weekly and overall rollups for an enrollment.
+// Weeks and the day after which an unmatched workout counts as missed follow the optional timezone (UTC by default).
+func (h *Handler) HandleGetTrainingPlanProgress() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		userID, err := h.getAuthenticatedUserID(ctx, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		enrollmentID := strings.TrimSpace(chi.URLParam(r, "id"))
+		if _, err := uuid.Parse(enrollmentID); err != nil {
+			sendError(w, http.StatusBadRequest, "Invalid training plan enrollment ID")
+			return
+		}
+
+		loc, err := parseTimezone(r.URL.Query().Get("timezone"))
+		if err != nil {
+			sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		enrollment, err := h.database.GetUserTrainingPlanByID(ctx, enrollmentID, userID)
+		if err != nil {
+			h.log.Error("Database failed to get training plan enrollment", err)
+			sendError(w, http.StatusInternalServerError, "Failed to retrieve training plan progress")
+			return
+		}
+		if enrollment == nil {
+			sendError(w, http.StatusNotFound, "Training plan enrollment not found")
+			return
+		}
+
+		plannedActivities, err := h.database.GetPlannedActivitiesByUserTrainingPlan(ctx, enrollmentID, userID)
+		if err != nil {
+			h.log.Error("Database failed to get training plan workouts", err)
+			sendError(w, http.StatusInternalServerError, "Failed to retrieve training plan progress")
+			return
+		}
+
+		matched, err := h.loadMatchedActivities(ctx, userID, plannedActivities, nil)
+		if err != nil {
+			h.log.Error("Database failed to get matched activities", err)
+			sendError(w, http.StatusInternalServerError, "Failed to retrieve training plan progress")
+			return
+		}
+
+		workouts, weeks, plans := buildComplianceRollups(plannedActivities, matched, time.Now(), loc)
+
+		response := TrainingPlanProgressResponse{
+			UserTrainingPlanID: enrollmentID,
+			Title:              enrollment.Title,
+			StartDate:          enrollment.StartDate,
+			Weeks:              weeks,
+			Workouts:           workouts,
+		}
+		if len(plans) > 0 {
+			response.Summary = plans[0].ComplianceSummary
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
 func (h *Handler) HandleImportTrainingPlan() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,6 +17,7 @@ import (
 	"github.com/anish-chanda/cadent/backend/internal/valhalla"
 	"github.com/google/uuid"
 	"github.com/muktihari/fit/decoder"
+	"github.com/muktihari/fit/profile/typedef"
 	"github.com/muktihari/fit/proto"
 	gpxlib "github.com/twpayne/go-gpx"
 )
@@ -23,16 +26,52 @@ const (
 	maxUploadSize = 25 * 1024 * 1024 // 25MB in bytes
 )
 
-// ActivityMetadata holds parsed metadata from a GPX/FIT file
+// ActivityMetadata holds parsed metadata from a GPX/FIT/TCX file
 type ActivityMetadata struct {
 	Title        string
 	Description  string
 	ActivityType models.ActivityType // must be one of our supported activity types
+	TimerPauses  []TimerPause        // nil when the file has no timer events
+	Laps         []deviceLap         // nil when the file has no lap structure
+}
+
+// duplicateStartTolerance is how close two start times must be for a file to count as already imported
+const duplicateStartTolerance = 2 * time.Second
+
+// activityFileOptions controls how an uploaded activity file becomes an activity
+type activityFileOptions struct {
+	Enrich            bool
+	Snap              bool                // map-match the track to the road and path network, keeping the raw track
+	Title             string              // overrides the title from the file when set
+	Description       string              // overrides the description from the file when set
+	ActivityType      models.ActivityType // used when the file carries no activity type
+	Location          *time.Location      // local day used for planned activity matching and training stress
+	SkipDuplicate     bool                // reject files starting at the same time as an existing activity
+	DeferTrainingLoad bool                // leave the training load series to the caller, which updates it once for many files
+}
+
+// activityFileError is a failed activity file import with the HTTP status it maps to
+type activityFileError struct {
+	status  int
+	message string
+}
+
+func (e *activityFileError) Error() string { return e.message }
+
+// duplicateActivityError reports a file that was already imported as ActivityID
+type duplicateActivityError struct {
+	ActivityID string
+}
+
+func (e *duplicateActivityError) Error() string {
+	return fmt.Sprintf("activity already imported as %s", e.ActivityID)
 }
 
 // UploadResponse is the response returned after successfully uploading an activity
 type UploadResponse struct {
-	ID string `json:"id"`
+	ID                       string   `json:"id"`
+	MatchedPlannedActivityID *string  `json:"matched_planned_activity_id,omitempty"`
+	NewRecords               []string `json:"new_records,omitempty"` // best efforts that set a personal record
 }
 
 func (h *Handler) HandleActivityUpload() http.HandlerFunc {
@@ -68,16 +107,22 @@ func (h *Handler) HandleActivityUpload() http.HandlerFunc {
 		ext := strings.ToLower(filepath.Ext(filename))
 
 		// Validate file type
-		if ext != ".gpx" && ext != ".fit" {
-			http.Error(w, "Unsupported file type. Only GPX and FIT files are allowed", http.StatusBadRequest)
+		if ext != ".gpx" && ext != ".fit" && ext != ".tcx" {
+			http.Error(w, "Unsupported file type. Only GPX, FIT and TCX files are allowed", http.StatusBadRequest)
 			return
 		}
 
 		// Get form parameters
 		enrichParam := r.FormValue("enrich")
 		shouldEnrich := enrichParam == "true"
+		shouldSnap := r.FormValue("snap") == "true"
 		titleOverride := r.FormValue("title")
 		descriptionOverride := r.FormValue("description")
+		loc, err := parseTimezone(r.FormValue("timezone"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
 		// Get authenticated user ID
 		userID, err := h.getAuthenticatedUserID(ctx, r)
@@ -94,176 +139,241 @@ func (h *Handler) HandleActivityUpload() http.HandlerFunc {
 			return
 		}
 
-		// Parse file based on type to extract samples, metadata and whether elevation is embedded
-		var samples []Sample
-		var metadata ActivityMetadata
-		var hasElevation bool
-		switch ext {
-		case ".gpx":
-			samples, metadata, hasElevation, err = processGPXFile(fileContent, filename)
-		case ".fit":
-			samples, metadata, hasElevation, err = processFITFile(fileContent, filename)
-		}
-
+		activity, matchedPlanID, newRecords, err := h.processActivityFile(ctx, userID, filename, fileContent, activityFileOptions{
+			Enrich:      shouldEnrich,
+			Snap:        shouldSnap,
+			Title:       titleOverride,
+			Description: descriptionOverride,
+			Location:    loc,
+		})
 		if err != nil {
-			h.log.Error(fmt.Sprintf("Failed to process %s file", strings.ToUpper(ext[1:])), err)
-			http.Error(w, fmt.Sprintf("Failed to process %s file: %v", strings.ToUpper(ext[1:]), err), http.StatusBadRequest)
+			var fileErr *activityFileError
+			if errors.As(err, &fileErr) {
+				http.Error(w, fileErr.message, fileErr.status)
+				return
+			}
+			h.log.Error("Failed to process uploaded file", err)
+			http.Error(w, "Failed to process uploaded file", http.StatusInternalServerError)
 			return
 		}
 
-		// Validate we have minimum samples
-		if len(samples) < 2 {
-			http.Error(w, "File must contain at least 2 GPS points", http.StatusBadRequest)
-			return
+		// Return response with activity ID
+		response := UploadResponse{
+			ID:                       activity.ID.String(),
+			MatchedPlannedActivityID: matchedPlanID,
+			NewRecords:               newRecords,
 		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(response)
+	}
+}
 
-		// Override metadata with form values if provided
-		if titleOverride != "" {
-			metadata.Title = titleOverride
-		}
-		if descriptionOverride != "" {
-			metadata.Description = descriptionOverride
-		}
+// processActivityFile runs an uploaded GPX/FIT/TCX file through parsing, enrichment, storage and
+// planned activity matching. Failures the caller should report are returned as *activityFileError,
+// already imported files (with opts.SkipDuplicate) as *duplicateActivityError.
+// Besides the activity it returns the matched planned activity and the personal records it set.
+func (h *Handler) processActivityFile(ctx context.Context, userID string, filename string, fileContent []byte, opts activityFileOptions) (*models.Activity, *string, []string, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
 
-		// Use default title if still empty
-		if metadata.Title == "" {
-			metadata.Title = fmt.Sprintf("Uploaded %s Activity", strings.ToUpper(ext[1:]))
-		}
+	// Parse file based on type to extract samples, metadata and whether elevation is embedded
+	var samples []Sample
+	var metadata ActivityMetadata
+	var hasElevation bool
+	var err error
+	switch ext {
+	case ".gpx":
+		samples, metadata, hasElevation, err = processGPXFile(fileContent, filename)
+	case ".fit":
+		samples, metadata, hasElevation, err = processFITFile(fileContent, filename)
+	case ".tcx":
+		samples, metadata, hasElevation, err = processTCXFile(fileContent, filename)
+	default:
+		return nil, nil, nil, &activityFileError{status: http.StatusBadRequest, message: "Unsupported file type. Only GPX, FIT and TCX files are allowed"}
+	}
 
-		// Validate activity type is present (must come from file)
-		if metadata.ActivityType == "" {
-			http.Error(w, "Activity type not found in file. GPX/FIT file must contain activity type metadata", http.StatusBadRequest)
-			return
-		}
+	if err != nil {
+		h.log.Error(fmt.Sprintf("Failed to process %s file", strings.ToUpper(ext[1:])), err)
+		return nil, nil, nil, &activityFileError{status: http.StatusBadRequest, message: fmt.Sprintf("Failed to process %s file: %v", strings.ToUpper(ext[1:]), err)}
+	}
 
-		// Validate activity type
-		if metadata.ActivityType != models.ActivityTypeRun && metadata.ActivityType != models.ActivityTypeRoadBike {
-			h.log.Error("Invalid activity type", fmt.Errorf("unsupported activity_type: %s", metadata.ActivityType))
-			http.Error(w, fmt.Sprintf("Invalid activity_type: %s. Supported types: running, road_biking", metadata.ActivityType), http.StatusBadRequest)
-			return
+	// Validate we have minimum samples
+	if len(samples) < 2 {
+		return nil, nil, nil, &activityFileError{status: http.StatusBadRequest, message: "File must contain at least 2 GPS points"}
+	}
+
+	if opts.SkipDuplicate {
+		startTime := time.UnixMilli(samples[0].T)
+		existingID, err := h.database.FindActivityIDByStartTime(ctx, userID, startTime, duplicateStartTolerance)
+		if err != nil {
+			h.log.Error("Failed to check for duplicate activity", err)
+			return nil, nil, nil, &activityFileError{status: http.StatusInternalServerError, message: "Failed to check for duplicate activity"}
 		}
+		if existingID != nil {
+			return nil, nil, nil, &duplicateActivityError{ActivityID: *existingID}
+		}
+	}
 
-		// Process GPS data to create polyline and calculate metrics
-		polyline, totalDistance, bounds := processGPSData(samples)
+	// Override metadata with form values if provided
+	if opts.Title != "" {
+		metadata.Title = opts.Title
+	}
+	if opts.Description != "" {
+		metadata.Description = opts.Description
+	}
+	if metadata.ActivityType == "" {
+		metadata.ActivityType = opts.ActivityType
+	}
 
-		// Call Valhalla if enrichment requested AND file does not already have elevation data.
-		var elevationData *valhalla.ElevationChange
-		var elevationHeights []float64
+	// Use default title if still empty
+	if metadata.Title == "" {
+		metadata.Title = fmt.Sprintf("Uploaded %s Activity", strings.ToUpper(ext[1:]))
+	}
 
-		if shouldEnrich && !hasElevation {
-			elevationData, elevationHeights = getElevationDataAndHeights(ctx, h.valhallaClient, polyline, h.log)
+	// Validate activity type is present (must come from file)
+	if metadata.ActivityType == "" {
+		return nil, nil, nil, &activityFileError{status: http.StatusBadRequest, message: "Activity type not found in file. GPX/FIT/TCX file must contain activity type metadata"}
+	}
 
-			// For GPX uploads we can write the elevation back into the file so the stored copy
-			// reflects the enriched data.
-			if ext == ".gpx" && elevationHeights != nil {
-				if updated, enrichErr := enrichGPXWithElevation(fileContent, elevationHeights); enrichErr != nil {
-					h.log.Error("Failed to enrich GPX with elevation data, storing original", enrichErr)
-				} else {
-					fileContent = updated
-				}
-			}
+	// Validate activity type
+	if metadata.ActivityType != models.ActivityTypeRun && metadata.ActivityType != models.ActivityTypeRoadBike {
+		h.log.Error("Invalid activity type", fmt.Errorf("unsupported activity_type: %s", metadata.ActivityType))
+		return nil, nil, nil, &activityFileError{status: http.StatusBadRequest, message: fmt.Sprintf("Invalid activity_type: %s. Supported types: running, road_biking", metadata.ActivityType)}
+	}
 
-			// For FIT uploads we enrich the samples and regenerate the FIT file
-			if ext == ".fit" && elevationHeights != nil {
-				for i := range samples {
-					if i < len(elevationHeights) {
-						h := elevationHeights[i]
-						samples[i].Ele = &h
-					}
-				}
-			}
+	// Process GPS data to create polyline and calculate metrics
+	polyline, totalDistance, bounds := processGPSData(samples)
 
-		} else if hasElevation {
-			// File already carries elevation, derive stats from the embedded sample values so the
-			// activity record has valid gain/loss/max/min.
-			elevationData = calculateElevationStatsFromSamples(samples)
-		}
+	// Look up elevation if enrichment requested AND file does not already have elevation data.
+	var elevationData *valhalla.ElevationChange
+	var elevationHeights []float64
 
-		// Calculate time-based metrics
-		elapsedSeconds := calculateElapsedSeconds(samples)
-		avgSpeedMs := calculateAverageSpeed(totalDistance, elapsedSeconds)
+	if opts.Enrich && !hasElevation {
+		elevationData, elevationHeights = getElevationDataAndHeights(ctx, h.elevationProvider(), polyline, h.log)
 
-		// Build activity model with metadata and calculated stats
-		var descPtr *string
-		if metadata.Description != "" {
-			descPtr = &metadata.Description
-		}
-		uploadReq := CreateActivityRequest{
-			ClientActivityID: uuid.New(),
-			ActivityType:     string(metadata.ActivityType),
-			Title:            metadata.Title,
-			Description:      descPtr,
-			Samples:          samples,
-		}
-		activity := buildActivityModel(uploadReq, userID, polyline, totalDistance, bounds, elevationData, elapsedSeconds, avgSpeedMs)
-		if ext == ".fit" && elevationHeights != nil && enrichParam == "true" {
-			if err := createFITFile(ctx, activity, samples, h.objectStore, h.log); err != nil {
-				h.log.Error("Failed to regenerate enriched FIT file", err)
+		// For GPX uploads we can write the elevation back into the file so the stored copy
+		// reflects the enriched data.
+		if ext == ".gpx" && elevationHeights != nil {
+			if updated, enrichErr := enrichGPXWithElevation(fileContent, elevationHeights); enrichErr != nil {
+				h.log.Error("Failed to enrich GPX with elevation data, storing original", enrichErr)
+			} else {
+				fileContent = updated
 			}
 		}
-		// Store the file to disk in original format (GPX or FIT), including elevation if enrichment was applied
-		originalFileKey := fmt.Sprintf("activities/%s/%s%s", userID, activity.ID.String(), ext)
-		fileReader := bytes.NewReader(fileContent)
-		if err := h.objectStore.PutObject(ctx, originalFileKey, fileReader, int64(len(fileContent))); err != nil {
-			h.log.Error("Failed to store uploaded file", err)
-			http.Error(w, "Failed to store uploaded file", http.StatusInternalServerError)
-			return
+
+		// For FIT uploads we enrich the samples and regenerate the FIT file
+		if ext == ".fit" && elevationHeights != nil {
+			for i := range samples {
+				if i < len(elevationHeights) {
+					h := elevationHeights[i]
+					samples[i].Ele = &h
+				}
+			}
 		}
-		activity.FileURL = &originalFileKey
 
-		// Process full-resolution streams from samples
-		fullStream := processFullResolutionStreams(samples, elevationData, elevationHeights)
+	} else if hasElevation {
+		// File already carries elevation, derive stats from the embedded sample values so the
+		// activity record has valid gain/loss/max/min.
+		elevationData = calculateElevationStatsFromSamples(samples)
+	}
 
-		// Validate stream alignment
-		if err := validateStreamAlignment(fullStream, len(samples)); err != nil {
-			h.log.Error("Stream alignment validation failed", err)
-			http.Error(w, "Internal stream processing error", http.StatusInternalServerError)
-			return
+	// Snap the track to the map if requested; the stored file keeps the raw GPS positions
+	rawSamples := samples
+	var rawPolyline *string
+	if opts.Snap {
+		samples, rawPolyline = h.snapTrack(ctx, metadata.ActivityType, samples)
+		if rawPolyline != nil {
+			polyline, totalDistance, bounds = processGPSData(samples)
 		}
+	}
 
-		// Create medium LOD streams using distance decimation
-		keepIndices := decimateByDistance(fullStream, MediumLODTargetPoints)
+	// Calculate time-based metrics
+	elapsedSeconds := calculateElapsedSeconds(samples)
+	movingSeconds := calculateMovingSeconds(samples, metadata.TimerPauses)
+	avgSpeedMs := calculateAverageSpeed(totalDistance, elapsedSeconds)
 
-		// Create compressed medium LOD streams
-		activityStreams, err := createCompressedStreams(activity.ID, keepIndices, fullStream)
-		if err != nil {
-			h.log.Error("Failed to create compressed streams", err)
-			http.Error(w, "Failed to process stream data", http.StatusInternalServerError)
-			return
+	// Build activity model with metadata and calculated stats
+	var descPtr *string
+	if metadata.Description != "" {
+		descPtr = &metadata.Description
+	}
+	uploadReq := CreateActivityRequest{
+		ClientActivityID: uuid.New(),
+		ActivityType:     string(metadata.ActivityType),
+		Title:            metadata.Title,
+		Description:      descPtr,
+		Samples:          samples,
+		Pauses:           metadata.TimerPauses,
+	}
+	activity := buildActivityModel(uploadReq, userID, polyline, totalDistance, bounds, elevationData, elapsedSeconds, movingSeconds, avgSpeedMs)
+	activity.RawPolyline = rawPolyline
+	if ext == ".fit" && elevationHeights != nil && opts.Enrich {
+		if err := createFITFile(ctx, activity, rawSamples, h.objectStore, h.log); err != nil {
+			h.log.Error("Failed to regenerate enriched FIT file", err)
 		}
+	}
+	// Store the file to disk in original format (GPX, FIT or TCX), including elevation if enrichment was applied
+	originalFileKey := fmt.Sprintf("activities/%s/%s%s", userID, activity.ID.String(), ext)
+	fileReader := bytes.NewReader(fileContent)
+	if err := h.objectStore.PutObject(ctx, originalFileKey, fileReader, int64(len(fileContent))); err != nil {
+		h.log.Error("Failed to store uploaded file", err)
+		return nil, nil, nil, &activityFileError{status: http.StatusInternalServerError, message: "Failed to store uploaded file"}
+	}
+	activity.FileURL = &originalFileKey
 
-		// Save activity to database
-		if err := h.database.CreateActivity(ctx, activity); err != nil {
-			h.log.Error("Failed to save activity to database", err)
-			http.Error(w, "Failed to save activity", http.StatusInternalServerError)
-			return
-		}
+	// Process full-resolution streams from samples
+	fullStream := processFullResolutionStreams(samples, elevationData, elevationHeights)
 
-		// Save activity streams to database
-		if len(activityStreams) > 0 {
-			if err := h.database.CreateActivityStreams(ctx, activityStreams); err != nil {
-				h.log.Error("Failed to save activity streams to database", err)
-				// Log error but don't fail the request since main activity was saved
-				h.log.Info("Activity created successfully but streams failed to save")
-			} else {
-				h.log.Debug(fmt.Sprintf("Successfully saved %d streams for activity: %s", len(activityStreams), activity.ID.String()))
-			}
-		}
+	// Validate stream alignment
+	if err := validateStreamAlignment(fullStream, len(samples)); err != nil {
+		h.log.Error("Stream alignment validation failed", err)
+		return nil, nil, nil, &activityFileError{status: http.StatusInternalServerError, message: "Internal stream processing error"}
+	}
+	activity.AvgGAPMps = gradeAdjustedSpeed(activity.ActivityType, fullStream, movingSeconds)
+
+	// Create compressed medium LOD streams, decimated by distance and by time
+	activityStreams, err := createMediumLODStreams(activity.ID, fullStream, h.streamDecimation())
+	if err != nil {
+		h.log.Error("Failed to create compressed streams", err)
+		return nil, nil, nil, &activityFileError{status: http.StatusInternalServerError, message: "Failed to process stream data"}
+	}
 
-		h.log.Info(fmt.Sprintf("Successfully processed %s file upload for user %s, activity: %s", strings.ToUpper(ext[1:]), userID, activity.ID.String()))
+	// Save activity to database
+	if err := h.database.CreateActivity(ctx, activity); err != nil {
+		h.log.Error("Failed to save activity to database", err)
+		return nil, nil, nil, &activityFileError{status: http.StatusInternalServerError, message: "Failed to save activity"}
+	}
 
-		// Return response with activity ID
-		response := UploadResponse{
-			ID: activity.ID.String(),
+	// Save activity streams to database
+	if len(activityStreams) > 0 {
+		if err := h.database.CreateActivityStreams(ctx, activityStreams); err != nil {
+			h.log.Error("Failed to save activity streams to database", err)
+			// Log error but don't fail the request since main activity was saved
+			h.log.Info("Activity created successfully but streams failed to save")
+		} else {
+			h.log.Debug(fmt.Sprintf("Successfully saved %d streams for activity: %s", len(activityStreams), activity.ID.String()))
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		_ = json.NewEncoder(w).Encode(response)
 	}
+
+	// Splits and the other derived data are computed from the same full-resolution stream. Like the streams,
+	// each is only logged when it fails to save: the activity itself is already stored.
+	h.saveActivitySplits(ctx, activity.ID, fullStream, samples, metadata.TimerPauses)
+	h.saveActivityLaps(ctx, activity.ID, samples, fullStream, metadata.Laps)
+	newRecords := h.saveBestEfforts(ctx, activity, fullStream)
+	settings := h.userTrainingSettings(ctx, activity.UserID)
+	h.saveTrainingStress(ctx, activity, fullStream, samples, metadata.TimerPauses, trainingThresholdsFor(settings, time.Now()), opts.Location, !opts.DeferTrainingLoad)
+	h.saveZoneTimes(ctx, activity, fullStream, samples, metadata.TimerPauses, settings)
+
+	h.log.Info(fmt.Sprintf("Successfully processed %s file upload for user %s, activity: %s", strings.ToUpper(ext[1:]), userID, activity.ID.String()))
+
+	// Link to the best planned activity on the same local day
+	matchedPlanID := h.autoMatchPlannedActivity(ctx, activity, opts.Location)
+
+	return activity, matchedPlanID, newRecords, nil
 }
 
 // processGPXFile parses a GPX file and extracts samples, metadata, and whether embedded elevation is present.
-// Parsers must convert activity types from GPX/FIT formats to our models.ActivityType enum.
+// Parsers must convert activity types from GPX/FIT/TCX formats to our models.ActivityType enum.
 func processGPXFile(fileContent []byte, filename string) ([]Sample, ActivityMetadata, bool, error) {
 	g, err := gpxlib.Read(bytes.NewReader(fileContent))
 	if err != nil {
@@ -275,10 +385,12 @@ func processGPXFile(fileContent []byte, filename string) ([]Sample, ActivityMeta
 	}
 
 	var samples []Sample
+	var segmentLaps []deviceLap
 	hasElevation := false
 
 	for _, trk := range g.Trk {
 		for _, seg := range trk.TrkSeg {
+			segmentStart := len(samples)
 			for _, pt := range seg.TrkPt {
 				if pt.Time.IsZero() {
 					continue // skip points without a valid timestamp
@@ -296,8 +408,23 @@ func processGPXFile(fileContent []byte, filename string) ([]Sample, ActivityMeta
 					hasElevation = true
 				}
 
+				if pt.Extensions != nil {
+					ext := parseGPXTrackPointExtensions(pt.Extensions.XML)
+					s.HR = ext.HeartRate
+					s.Cadence = ext.Cadence
+					s.Temperature = ext.Temperature
+					if ext.Power != nil {
+						s.Power = ext.Power
+					} else {
+						s.Power = ext.PowerInWatts
+					}
+				}
+
 				samples = append(samples, s)
 			}
+			if len(samples) > segmentStart {
+				segmentLaps = append(segmentLaps, deviceLap{StartT: samples[segmentStart].T})
+			}
 		}
 	}
 
@@ -325,9 +452,43 @@ func processGPXFile(fileContent []byte, filename string) ([]Sample, ActivityMeta
 		metadata.ActivityType = mapGPXActivityType(g.Trk[0].Type)
 	}
 
+	// Each track segment is a lap, but a single segment carries no lap structure
+	if len(segmentLaps) > 1 {
+		metadata.Laps = segmentLaps
+	}
+
 	return samples, metadata, hasElevation, nil
 }
 
+// gpxTrackPointExtensions holds the sensor readings Garmin, Strava and Wahoo write into GPX track points.
+// Elements are matched by local name, so the namespace prefix used by the producer doesn't matter.
+type gpxTrackPointExtensions struct {
+	HeartRate   *int     `xml:"TrackPointExtension>hr"`
+	Cadence     *int     `xml:"TrackPointExtension>cad"`
+	Temperature *float64 `xml:"TrackPointExtension>atemp"`
+	// Power has no standard schema: Strava writes a bare <power>, Garmin uses its PowerExtension
+	Power        *int `xml:"power"`
+	PowerInWatts *int `xml:"PowerInWatts"`
+}
+
+// parseGPXTrackPointExtensions reads sensor readings from the inner XML of a track point's <extensions>.
+// Unparseable extensions are ignored rather than failing the whole file.
+func parseGPXTrackPointExtensions(innerXML []byte) gpxTrackPointExtensions {
+	var ext gpxTrackPointExtensions
+	if len(bytes.TrimSpace(innerXML)) == 0 {
+		return ext
+	}
+
+	wrapped := make([]byte, 0, len(innerXML)+len("<extensions></extensions>"))
+	wrapped = append(wrapped, "<extensions>"...)
+	wrapped = append(wrapped, innerXML...)
+	wrapped = append(wrapped, "</extensions>"...)
+	if err := xml.Unmarshal(wrapped, &ext); err != nil {
+		return gpxTrackPointExtensions{}
+	}
+	return ext
+}
+
 // mapGPXActivityType maps a raw GPX/Strava/Garmin activity type string to our models.ActivityType.
 func mapGPXActivityType(gpxType string) models.ActivityType {
 	normalized := strings.ToLower(strings.TrimSpace(gpxType))
@@ -401,7 +562,7 @@ func calculateElevationStatsFromSamples(samples []Sample) *valhalla.ElevationCha
 }
 
 // processFITFile parses a FIT file and extracts samples, metadata, and whether embedded elevation is present.
-// Parsers must convert activity types from GPX/FIT formats to our models.ActivityType enum.
+// Parsers must convert activity types from GPX/FIT/TCX formats to our models.ActivityType enum.
 func processFITFile(fileContent []byte, filename string) ([]Sample, ActivityMetadata, bool, error) {
 	dec := decoder.New(bytes.NewReader(fileContent))
 
@@ -412,6 +573,7 @@ func processFITFile(fileContent []byte, filename string) ([]Sample, ActivityMeta
 
 	var samples []Sample
 	var metadata ActivityMetadata
+	var timerEvents []timerEvent
 	hasElevation := false
 
 	for _, msg := range fitFile.Messages {
@@ -428,8 +590,19 @@ func processFITFile(fileContent []byte, filename string) ([]Sample, ActivityMeta
 
 		case "session":
 			parseFITSessionMessage(msg, &metadata)
+
+		case "event":
+			if event, ok := parseFITTimerEvent(msg); ok {
+				timerEvents = append(timerEvents, event)
+			}
+
+		case "lap":
+			if lap, ok := parseFITLapMessage(msg); ok {
+				metadata.Laps = append(metadata.Laps, lap)
+			}
 		}
 	}
+	metadata.TimerPauses = timerPausesFromEvents(timerEvents)
 
 	if len(samples) == 0 {
 		return nil, ActivityMetadata{}, false, fmt.Errorf("FIT file contains no valid record messages")
@@ -448,6 +621,7 @@ func parseFITRecordMessage(msg proto.Message) (Sample, bool) {
 		hasTime      bool
 		hasLat       bool
 		hasLon       bool
+		sample       Sample
 	)
 
 	for _, field := range msg.Fields {
@@ -470,6 +644,31 @@ func parseFITRecordMessage(msg proto.Message) (Sample, bool) {
 		case "altitude", "enhanced_altitude":
 			altitude = float64(uint32(field.Value.Uint32()))
 			hasAltitude = true
+
+		// Sensor fields are written with their invalid sentinel (e.g. 0xFF) when the sensor dropped out
+		case "heart_rate":
+			if field.Value.Valid(field.BaseType) {
+				hr := int(field.Value.Uint8())
+				sample.HR = &hr
+			}
+
+		case "cadence":
+			if field.Value.Valid(field.BaseType) {
+				cadence := int(field.Value.Uint8())
+				sample.Cadence = &cadence
+			}
+
+		case "power":
+			if field.Value.Valid(field.BaseType) {
+				power := int(field.Value.Uint16())
+				sample.Power = &power
+			}
+
+		case "temperature":
+			if field.Value.Valid(field.BaseType) {
+				temperature := float64(field.Value.Int8())
+				sample.Temperature = &temperature
+			}
 		}
 	}
 
@@ -480,11 +679,9 @@ func parseFITRecordMessage(msg proto.Message) (Sample, bool) {
 	garminEpoch := time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
 	timestamp := garminEpoch.Add(time.Duration(timestampSec) * time.Second)
 
-	sample := Sample{
-		T:   timestamp.UnixMilli(),
-		Lat: semicirclesToDegrees(latSemi),
-		Lon: semicirclesToDegrees(lonSemi),
-	}
+	sample.T = timestamp.UnixMilli()
+	sample.Lat = semicirclesToDegrees(latSemi)
+	sample.Lon = semicirclesToDegrees(lonSemi)
 
 	if hasAltitude {
 		ele := altitude
@@ -494,6 +691,98 @@ func parseFITRecordMessage(msg proto.Message) (Sample, bool) {
 	return sample, true
 }
 
+// parseFITTimerEvent extracts a timer start or stop from an event message.
+// Other events (laps, sensor alerts, ...) are ignored.
+func parseFITTimerEvent(msg proto.Message) (timerEvent, bool) {
+	var (
+		timestampSec uint32
+		hasTime      bool
+		hasType      bool
+		isTimer      bool
+		event        timerEvent
+	)
+
+	for _, field := range msg.Fields {
+		switch field.Name {
+		case "timestamp":
+			timestampSec = field.Value.Uint32()
+			hasTime = true
+		case "event":
+			isTimer = typedef.Event(field.Value.Uint8()) == typedef.EventTimer
+		case "event_type":
+			switch typedef.EventType(field.Value.Uint8()) {
+			case typedef.EventTypeStart:
+				hasType = true
+			case typedef.EventTypeStop, typedef.EventTypeStopAll, typedef.EventTypeStopDisable, typedef.EventTypeStopDisableAll:
+				event.Stop = true
+				hasType = true
+			}
+		}
+	}
+
+	if !hasTime || !isTimer || !hasType {
+		return timerEvent{}, false
+	}
+
+	garminEpoch := time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+	event.T = garminEpoch.Add(time.Duration(timestampSec) * time.Second).UnixMilli()
+	return event, true
+}
+
+// parseFITLapMessage extracts a lap's start time and the summary values the device recorded.
+// Values are stored scaled in FIT (e.g. distance in centimeters); fields at their invalid sentinel are skipped.
+func parseFITLapMessage(msg proto.Message) (deviceLap, bool) {
+	var (
+		lap           deviceLap
+		hasStart      bool
+		avgSpeed      *float64
+		enhancedSpeed *float64
+	)
+
+	scaled := func(raw float64, scale float64) *float64 {
+		v := raw / scale
+		return &v
+	}
+
+	for _, field := range msg.Fields {
+		if !field.Value.Valid(field.BaseType) {
+			continue
+		}
+
+		switch field.Name {
+		case "start_time":
+			garminEpoch := time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+			lap.StartT = garminEpoch.Add(time.Duration(field.Value.Uint32()) * time.Second).UnixMilli()
+			hasStart = true
+		case "total_elapsed_time":
+			lap.ElapsedS = scaled(float64(field.Value.Uint32()), 1000)
+		case "total_distance":
+			lap.DistanceM = scaled(float64(field.Value.Uint32()), 100)
+		case "avg_speed":
+			avgSpeed = scaled(float64(field.Value.Uint16()), 1000)
+		case "enhanced_avg_speed":
+			enhancedSpeed = scaled(float64(field.Value.Uint32()), 1000)
+		case "avg_heart_rate":
+			hr := int16(field.Value.Uint8())
+			lap.AvgHRBpm = &hr
+		case "max_heart_rate":
+			hr := int16(field.Value.Uint8())
+			lap.MaxHRBpm = &hr
+		}
+	}
+
+	if !hasStart {
+		return deviceLap{}, false
+	}
+
+	// The enhanced field has the wider range, so prefer it when a device writes both
+	lap.AvgSpeedMps = avgSpeed
+	if enhancedSpeed != nil {
+		lap.AvgSpeedMps = enhancedSpeed
+	}
+	return lap, true
+}
+
 func semicirclesToDegrees(semicircles int32) float64 {
 	return float64(semicircles) * (180.0 / (1 << 31))
 }
@@ -536,3 +825,118 @@ func mapFITSportEnum(v uint8) models.ActivityType {
 		return models.ActivityType("")
 	}
 }
+
+// tcxDatabase mirrors the parts of a Garmin Training Center (TCX) document we import
+type tcxDatabase struct {
+	Activities []tcxActivity `xml:"Activities>Activity"`
+}
+
+type tcxActivity struct {
+	Sport string   `xml:"Sport,attr"`
+	ID    string   `xml:"Id"`
+	Notes string   `xml:"Notes"`
+	Laps  []tcxLap `xml:"Lap"`
+}
+
+type tcxLap struct {
+	Trackpoints []tcxTrackpoint `xml:"Track>Trackpoint"`
+}
+
+type tcxTrackpoint struct {
+	Time      string       `xml:"Time"`
+	Position  *tcxPosition `xml:"Position"`
+	Altitude  *float64     `xml:"AltitudeMeters"`
+	HeartRate *int         `xml:"HeartRateBpm>Value"`
+	Cadence   *int         `xml:"Cadence"`
+	// Running cadence and power are only available in the Garmin ActivityExtension TPX block
+	RunCadence *int `xml:"Extensions>TPX>RunCadence"`
+	Watts      *int `xml:"Extensions>TPX>Watts"`
+}
+
+type tcxPosition struct {
+	Lat float64 `xml:"LatitudeDegrees"`
+	Lon float64 `xml:"LongitudeDegrees"`
+}
+
+// processTCXFile parses a TCX file and extracts samples, metadata, and whether embedded elevation is present.
+// Parsers must convert activity types from GPX/FIT/TCX formats to our models.ActivityType enum.
+func processTCXFile(fileContent []byte, filename string) ([]Sample, ActivityMetadata, bool, error) {
+	var doc tcxDatabase
+	if err := xml.Unmarshal(fileContent, &doc); err != nil {
+		return nil, ActivityMetadata{}, false, fmt.Errorf("failed to parse TCX file: %w", err)
+	}
+
+	if len(doc.Activities) == 0 {
+		return nil, ActivityMetadata{}, false, fmt.Errorf("TCX file contains no activities")
+	}
+
+	// Multisport files are rare in exports; only the first activity is imported
+	act := doc.Activities[0]
+
+	var samples []Sample
+	hasElevation := false
+
+	for _, lap := range act.Laps {
+		for _, tp := range lap.Trackpoints {
+			// Indoor trackpoints carry no position and can't be placed on the map
+			if tp.Position == nil {
+				continue
+			}
+
+			t, err := time.Parse(time.RFC3339, strings.TrimSpace(tp.Time))
+			if err != nil {
+				continue // skip points without a valid timestamp
+			}
+
+			s := Sample{
+				T:     t.UnixMilli(),
+				Lat:   tp.Position.Lat,
+				Lon:   tp.Position.Lon,
+				HR:    tp.HeartRate,
+				Power: tp.Watts,
+			}
+
+			if tp.Cadence != nil {
+				s.Cadence = tp.Cadence
+			} else if tp.RunCadence != nil {
+				s.Cadence = tp.RunCadence
+			}
+
+			if tp.Altitude != nil {
+				ele := *tp.Altitude
+				s.Ele = &ele
+				hasElevation = true
+			}
+
+			samples = append(samples, s)
+		}
+	}
+
+	if len(samples) == 0 {
+		return nil, ActivityMetadata{}, false, fmt.Errorf("TCX file contains no track points with position and valid timestamps")
+	}
+
+	metadata := ActivityMetadata{
+		ActivityType: mapTCXSport(act.Sport),
+		Description:  strings.TrimSpace(act.Notes),
+	}
+
+	// TCX has no activity name; the Id is the start time, so use it for a dated title like FIT
+	if start, err := time.Parse(time.RFC3339, strings.TrimSpace(act.ID)); err == nil {
+		metadata.Title = "Activity on " + start.Format("2006-01-02")
+	}
+
+	return samples, metadata, hasElevation, nil
+}
+
+// mapTCXSport maps the TCX Sport attribute (Running, Biking, Other) to our models.ActivityType.
+func mapTCXSport(sport string) models.ActivityType {
+	switch strings.ToLower(strings.TrimSpace(sport)) {
+	case "running":
+		return models.ActivityTypeRun
+	case "biking":
+		return models.ActivityTypeRoadBike
+	default:
+		return models.ActivityType("")
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+)
+
+const testTCX = `<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2" xmlns:ns3="http://www.garmin.com/xmlschemas/ActivityExtension/v2">
+  <Activities>
+    <Activity Sport="Running">
+      <Id>2024-05-04T07:30:00Z</Id>
+      <Notes>Easy shakeout</Notes>
+      <Lap StartTime="2024-05-04T07:30:00Z">
+        <Track>
+          <Trackpoint>
+            <Time>2024-05-04T07:30:00Z</Time>
+            <Position><LatitudeDegrees>37.7749</LatitudeDegrees><LongitudeDegrees>-122.4194</LongitudeDegrees></Position>
+            <AltitudeMeters>12.5</AltitudeMeters>
+            <HeartRateBpm><Value>120</Value></HeartRateBpm>
+            <Extensions><ns3:TPX><ns3:RunCadence>84</ns3:RunCadence><ns3:Watts>310</ns3:Watts></ns3:TPX></Extensions>
+          </Trackpoint>
+          <Trackpoint>
+            <Time>2024-05-04T07:30:05Z</Time>
+          </Trackpoint>
+          <Trackpoint>
+            <Time>2024-05-04T07:30:10Z</Time>
+            <Position><LatitudeDegrees>37.7750</LatitudeDegrees><LongitudeDegrees>-122.4195</LongitudeDegrees></Position>
+            <AltitudeMeters>13.0</AltitudeMeters>
+            <HeartRateBpm><Value>131</Value></HeartRateBpm>
+          </Trackpoint>
+        </Track>
+      </Lap>
+    </Activity>
+  </Activities>
+</TrainingCenterDatabase>`
+
+func TestProcessTCXFile(t *testing.T) {
+	samples, metadata, hasElevation, err := processTCXFile([]byte(testTCX), "run.tcx")
+	if err != nil {
+		t.Fatalf("processTCXFile() error = %v", err)
+	}
+
+	// The trackpoint without a position is skipped
+	if len(samples) != 2 {
+		t.Fatalf("len(samples) = %d, want 2", len(samples))
+	}
+	if !hasElevation {
+		t.Error("Expected embedded elevation to be detected")
+	}
+	if metadata.ActivityType != models.ActivityTypeRun {
+		t.Errorf("ActivityType = %s, want %s", metadata.ActivityType, models.ActivityTypeRun)
+	}
+	if metadata.Title != "Activity on 2024-05-04" || metadata.Description != "Easy shakeout" {
+		t.Errorf("metadata = %+v", metadata)
+	}
+
+	first := samples[0]
+	if first.T != 1714807800000 || first.Lat != 37.7749 || first.Lon != -122.4194 {
+		t.Errorf("first sample = %+v", first)
+	}
+	if first.Ele == nil || *first.Ele != 12.5 {
+		t.Errorf("first elevation = %v, want 12.5", first.Ele)
+	}
+	if first.HR == nil || *first.HR != 120 {
+		t.Errorf("first HR = %v, want 120", first.HR)
+	}
+	if first.Cadence == nil || *first.Cadence != 84 {
+		t.Errorf("first cadence = %v, want 84", first.Cadence)
+	}
+	if first.Power == nil || *first.Power != 310 {
+		t.Errorf("first power = %v, want 310", first.Power)
+	}
+	if samples[1].Cadence != nil {
+		t.Errorf("second cadence = %v, want nil", *samples[1].Cadence)
+	}
+}
+
+func TestProcessTCXFile_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"not xml", "not a tcx file"},
+		{"no activities", `<TrainingCenterDatabase><Activities></Activities></TrainingCenterDatabase>`},
+		{"no positions", `<TrainingCenterDatabase><Activities><Activity Sport="Biking"><Lap><Track>
+			<Trackpoint><Time>2024-05-04T07:30:00Z</Time></Trackpoint>
+		</Track></Lap></Activity></Activities></TrainingCenterDatabase>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, _, err := processTCXFile([]byte(tt.content), "bad.tcx"); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestMapTCXSport(t *testing.T) {
+	tests := map[string]models.ActivityType{
+		"Running": models.ActivityTypeRun,
+		"Biking":  models.ActivityTypeRoadBike,
+		"Other":   "",
+	}
+	for sport, want := range tests {
+		if got := mapTCXSport(sport); got != want {
+			t.Errorf("mapTCXSport(%q) = %q, want %q", sport, got, want)
+		}
+	}
+}
+
+func TestParseGPXTrackPointExtensions(t *testing.T) {
+	garmin := []byte(`<gpxtpx:TrackPointExtension><gpxtpx:atemp>21.5</gpxtpx:atemp><gpxtpx:hr>142</gpxtpx:hr><gpxtpx:cad>88</gpxtpx:cad></gpxtpx:TrackPointExtension>`)
+	ext := parseGPXTrackPointExtensions(garmin)
+	if ext.HeartRate == nil || *ext.HeartRate != 142 {
+		t.Errorf("HeartRate = %v, want 142", ext.HeartRate)
+	}
+	if ext.Cadence == nil || *ext.Cadence != 88 {
+		t.Errorf("Cadence = %v, want 88", ext.Cadence)
+	}
+	if ext.Temperature == nil || *ext.Temperature != 21.5 {
+		t.Errorf("Temperature = %v, want 21.5", ext.Temperature)
+	}
+	if ext.Power != nil || ext.PowerInWatts != nil {
+		t.Errorf("Expected no power, got %v / %v", ext.Power, ext.PowerInWatts)
+	}
+
+	strava := parseGPXTrackPointExtensions([]byte(`<power>245</power>`))
+	if strava.Power == nil || *strava.Power != 245 {
+		t.Errorf("Power = %v, want 245", strava.Power)
+	}
+
+	if empty := parseGPXTrackPointExtensions([]byte("  ")); empty.HeartRate != nil {
+		t.Errorf("Expected empty extensions, got %+v", empty)
+	}
+	if broken := parseGPXTrackPointExtensions([]byte(`<hr>1`)); broken.HeartRate != nil {
+		t.Errorf("Expected malformed extensions to be ignored, got %+v", broken)
+	}
+}
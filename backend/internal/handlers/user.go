@@ -6,21 +6,45 @@ import (
 	"net/http"
 	"net/mail"
 	"strings"
+	"time"
 
+	"github.com/anish-chanda/cadent/backend/internal/models"
 	"github.com/go-pkgz/auth/v2/token"
 )
 
 // UserResponse represents the user data returned to clients
 type UserResponse struct {
-	ID    string `json:"id"`
-	Email string `json:"email"`
-	Name  string `json:"name"`
+	ID               string                   `json:"id"`
+	Email            string                   `json:"email"`
+	Name             string                   `json:"name"`
+	Settings         *models.TrainingSettings `json:"settings,omitempty"`
+	RecomputeStarted bool                     `json:"recompute_started,omitempty"`
 }
 
 // UserUpdateRequest represents the request body for updating user data
 type UserUpdateRequest struct {
 	Name  *string `json:"name"`
 	Email *string `json:"email"`
+
+	// Settings is a partial training settings update; an explicit null unsets a setting
+	Settings map[string]json.RawMessage `json:"settings"`
+	// Recompute rescores past activities against the updated settings in the background.
+	// Timezone (IANA name, defaults to UTC) sets the local day of each activity, like for uploads.
+	Recompute bool   `json:"recompute"`
+	Timezone  string `json:"timezone"`
+}
+
+// newUserResponse builds the profile returned to clients; settings may be nil when none could be loaded
+func newUserResponse(user *models.UserRecord, settings *models.TrainingSettings) UserResponse {
+	if settings == nil {
+		settings = &models.TrainingSettings{}
+	}
+	return UserResponse{
+		ID:       user.ID,
+		Email:    user.Email,
+		Name:     user.Name,
+		Settings: settings,
+	}
 }
 
 func (h *Handler) HandleGetUser() http.HandlerFunc {
@@ -56,13 +80,16 @@ func (h *Handler) HandleGetUser() http.HandlerFunc {
 			return
 		}
 
-		// Return user profile
-		response := UserResponse{
-			ID:    dbUser.ID,
-			Email: dbUser.Email,
-			Name:  dbUser.Name,
+		settings, err := h.database.GetUserTrainingSettings(ctx, dbUser.ID)
+		if err != nil {
+			h.log.Error("Failed to get training settings from database", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
 		}
 
+		// Return user profile
+		response := newUserResponse(dbUser, settings)
+
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 	}
@@ -136,17 +163,49 @@ func (h *Handler) HandleUpdateUser() http.HandlerFunc {
 			//this might trigger email verification in future
 		}
 
-		if len(updates) == 0 {
+		if len(updateReq.Settings) > 0 {
+			current, err := h.database.GetUserTrainingSettings(ctx, dbUser.ID)
+			if err != nil {
+				h.log.Error("Failed to get training settings from database", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if current == nil {
+				current = &models.TrainingSettings{}
+			}
+
+			_, settingUpdates, err := applyTrainingSettings(*current, updateReq.Settings, time.Now())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			for column, value := range settingUpdates {
+				updates[column] = value
+			}
+		}
+
+		var loc *time.Location
+		if updateReq.Recompute {
+			loc, err = parseTimezone(updateReq.Timezone)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		if len(updates) == 0 && !updateReq.Recompute {
 			http.Error(w, "No updates provided", http.StatusBadRequest)
 			return
 		}
 
 		// Update user in database
-		err = h.database.UpdateUser(ctx, dbUser.ID, updates)
-		if err != nil {
-			h.log.Error("Failed to update user in database", err)
-			http.Error(w, "Failed to update user", http.StatusInternalServerError)
-			return
+		if len(updates) > 0 {
+			err = h.database.UpdateUser(ctx, dbUser.ID, updates)
+			if err != nil {
+				h.log.Error("Failed to update user in database", err)
+				http.Error(w, "Failed to update user", http.StatusInternalServerError)
+				return
+			}
 		}
 
 		// Get updated user data, might have changed by other instances
@@ -157,13 +216,22 @@ func (h *Handler) HandleUpdateUser() http.HandlerFunc {
 			return
 		}
 
-		// Return updated user profile
-		response := UserResponse{
-			ID:    updatedUser.ID,
-			Email: updatedUser.Email,
-			Name:  updatedUser.Name,
+		settings, err := h.database.GetUserTrainingSettings(ctx, dbUser.ID)
+		if err != nil {
+			h.log.Error("Failed to get updated training settings", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
 		}
 
+		// Rescoring reads every stored activity file, so it runs after the response
+		if updateReq.Recompute {
+			go h.recomputeDerivedMetrics(context.Background(), dbUser.ID, loc)
+		}
+
+		// Return updated user profile
+		response := newUserResponse(updatedUser, settings)
+		response.RecomputeStarted = updateReq.Recompute
+
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 	}
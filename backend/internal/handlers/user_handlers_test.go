@@ -76,7 +76,7 @@ func (m *UserMockDatabase) CreateUser(ctx context.Context, user *models.UserReco
 func (m *UserMockDatabase) CreateActivity(ctx context.Context, activity *models.Activity) error {
 	return nil
 }
-func (m *UserMockDatabase) GetActivitiesByUserID(ctx context.Context, userID string) ([]models.Activity, error) {
+func (m *UserMockDatabase) GetActivitiesByUserID(ctx context.Context, userID string, query models.ActivityListQuery) ([]models.Activity, error) {
 	return nil, nil
 }
 
@@ -80,10 +80,9 @@ func (m *IntegrationUserMockDB) CreateUser(ctx context.Context, user *models.Use
 func (m *IntegrationUserMockDB) CreateActivity(ctx context.Context, activity *models.Activity) error {
 	return nil
 }
-func (m *IntegrationUserMockDB) GetActivitiesByUserID(ctx context.Context, userID string) ([]models.Activity, error) {
+func (m *IntegrationUserMockDB) GetActivitiesByUserID(ctx context.Context, userID string, query models.ActivityListQuery) ([]models.Activity, error) {
 	return nil, nil
 }
-
 func (m *IntegrationUserMockDB) GetActivitiesByUserIDAndDate(ctx context.Context, userID string, start_date time.Time, end_date time.Time) ([]models.Activity, []models.PlannedActivity, error) {
 	return nil, nil, nil
 }
@@ -110,6 +109,24 @@ func (m *IntegrationUserMockDB) DeletePlannedActivity(ctx context.Context, activ
 func (m *IntegrationUserMockDB) UpdatePlannedActivity(ctx context.Context, activityID string, userID string, updates map[string]interface{}) error {
 	return nil
 }
+func (m *IntegrationUserMockDB) GetUnmatchedPlannedActivities(ctx context.Context, userID string, start time.Time, end time.Time) ([]models.PlannedActivity, error) {
+	return nil, nil
+}
+func (m *IntegrationUserMockDB) SetPlannedActivityMatch(ctx context.Context, plannedActivityID string, userID string, activityID *string) error {
+	return nil
+}
+func (m *IntegrationUserMockDB) CreateRoute(ctx context.Context, route *models.Route) error {
+	return nil
+}
+func (m *IntegrationUserMockDB) GetRoutesByUserID(ctx context.Context, userID string) ([]models.Route, error) {
+	return nil, nil
+}
+func (m *IntegrationUserMockDB) GetRouteByID(ctx context.Context, routeID string, userID string) (*models.Route, error) {
+	return nil, nil
+}
+func (m *IntegrationUserMockDB) DeleteRoute(ctx context.Context, routeID string, userID string) error {
+	return nil
+}
 func (m *IntegrationUserMockDB) Connect(dsn string) error { return nil }
 func (m *IntegrationUserMockDB) Close() error             { return nil }
 func (m *IntegrationUserMockDB) Migrate() error           { return nil }
@@ -735,3 +752,86 @@ func (m *IntegrationUserMockDB) GetTrainingPlanWorkouts(ctx context.Context, pla
 func (m *IntegrationUserMockDB) CreateUserTrainingPlanWithPlannedActivities(ctx context.Context, userPlan *models.UserTrainingPlan, plannedActivities []models.PlannedActivity) error {
 	return nil
 }
+
+// Mocks for plan compliance
+func (m *IntegrationUserMockDB) GetActivitiesByIDs(ctx context.Context, userID string, activityIDs []string) ([]models.Activity, error) {
+	return nil, nil
+}
+func (m *IntegrationUserMockDB) GetUserTrainingPlanByID(ctx context.Context, userTrainingPlanID string, userID string) (*models.UserTrainingPlan, error) {
+	return nil, nil
+}
+func (m *IntegrationUserMockDB) GetPlannedActivitiesByUserTrainingPlan(ctx context.Context, userTrainingPlanID string, userID string) ([]models.PlannedActivity, error) {
+	return nil, nil
+}
+
+// Mocks for bulk import
+func (m *IntegrationUserMockDB) FindActivityIDByStartTime(ctx context.Context, userID string, startTime time.Time, tolerance time.Duration) (*string, error) {
+	return nil, nil
+}
+
+// Mocks for activity update and delete
+func (m *IntegrationUserMockDB) UpdateActivity(ctx context.Context, activityID string, userID string, updates map[string]interface{}) error {
+	return nil
+}
+func (m *IntegrationUserMockDB) DeleteActivity(ctx context.Context, activityID string, userID string) error {
+	return nil
+}
+
+// Mocks for activity splits
+func (m *IntegrationUserMockDB) GetActivitySplits(ctx context.Context, activityID string, unit models.SplitUnit) ([]models.ActivitySplit, error) {
+	return nil, nil
+}
+func (m *IntegrationUserMockDB) CreateActivitySplits(ctx context.Context, splits []models.ActivitySplit) error {
+	return nil
+}
+
+// Mocks for activity laps
+func (m *IntegrationUserMockDB) GetActivityLaps(ctx context.Context, activityID string) ([]models.ActivityLap, error) {
+	return nil, nil
+}
+func (m *IntegrationUserMockDB) CreateActivityLaps(ctx context.Context, laps []models.ActivityLap) error {
+	return nil
+}
+
+// Mocks for best efforts and personal records
+func (m *IntegrationUserMockDB) SaveActivityBestEfforts(ctx context.Context, activity *models.Activity, efforts []models.BestEffort) ([]string, error) {
+	return nil, nil
+}
+func (m *IntegrationUserMockDB) GetPersonalRecords(ctx context.Context, userID string, activityType *models.ActivityType) ([]models.PersonalRecord, error) {
+	return []models.PersonalRecord{}, nil
+}
+
+// Mocks for training load
+func (m *IntegrationUserMockDB) SaveActivityTrainingStress(ctx context.Context, stress *models.ActivityTrainingStress) error {
+	return nil
+}
+func (m *IntegrationUserMockDB) GetDailyTrainingStress(ctx context.Context, userID string, from time.Time) ([]models.DailyTrainingStress, error) {
+	return []models.DailyTrainingStress{}, nil
+}
+func (m *IntegrationUserMockDB) GetTrainingLoad(ctx context.Context, userID string, from time.Time, to time.Time) ([]models.TrainingLoadDay, error) {
+	return []models.TrainingLoadDay{}, nil
+}
+func (m *IntegrationUserMockDB) GetTrainingLoadBefore(ctx context.Context, userID string, day time.Time) (*models.TrainingLoadDay, error) {
+	return nil, nil
+}
+func (m *IntegrationUserMockDB) ReplaceTrainingLoad(ctx context.Context, userID string, from time.Time, days []models.TrainingLoadDay) error {
+	return nil
+}
+
+// Mocks for training settings
+func (m *IntegrationUserMockDB) GetUserTrainingSettings(ctx context.Context, userID string) (*models.TrainingSettings, error) {
+	return nil, nil
+}
+
+// Mocks for zones
+func (m *IntegrationUserMockDB) ReplaceActivityZoneTimes(ctx context.Context, activityID string, zones []models.ActivityZoneTime) error {
+	return nil
+}
+func (m *IntegrationUserMockDB) GetActivityZoneTimes(ctx context.Context, activityIDs []string) ([]models.ActivityZoneTime, error) {
+	return nil, nil
+}
+
+// Mocks for stats
+func (m *IntegrationUserMockDB) GetActivityStats(ctx context.Context, userID string, period models.StatsPeriod, timezone string, from time.Time, to time.Time) ([]models.ActivityStats, error) {
+	return nil, nil
+}
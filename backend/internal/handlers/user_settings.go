@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+)
+
+// maxZoneBoundaries caps custom zones at ten
+const maxZoneBoundaries = 9
+
+// trainingSettingsColumns maps each setting's JSON name, which is also its users column, to its value
+func trainingSettingsColumns(settings models.TrainingSettings) map[string]interface{} {
+	return map[string]interface{}{
+		"max_hr_bpm":              settings.MaxHRBpm,
+		"resting_hr_bpm":          settings.RestingHRBpm,
+		"threshold_hr_bpm":        settings.ThresholdHRBpm,
+		"threshold_pace_s_per_km": settings.ThresholdPaceSPerKm,
+		"ftp_watt":                settings.FTPWatt,
+		"weight_kg":               settings.WeightKg,
+		"birth_year":              settings.BirthYear,
+		"sex":                     settings.Sex,
+		"hr_zones_bpm":            settings.HRZonesBpm,
+		"pace_zones_s_per_km":     settings.PaceZonesSPerKm,
+		"power_zones_watt":        settings.PowerZonesWatt,
+	}
+}
+
+// decodeSetting decodes a single setting value, where null unsets it
+func decodeSetting[T any](raw json.RawMessage) (*T, error) {
+	if string(raw) == "null" {
+		return nil, nil
+	}
+	var value T
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// decodeZoneBoundaries decodes a list of zone boundaries, where null or an empty list restores the default zones
+func decodeZoneBoundaries[T any](raw json.RawMessage) ([]T, error) {
+	var bounds []T
+	if err := json.Unmarshal(raw, &bounds); err != nil {
+		return nil, err
+	}
+	if len(bounds) == 0 {
+		return nil, nil
+	}
+	return bounds, nil
+}
+
+// applyTrainingSettings applies a partial settings update to the current settings. It returns the merged
+// settings and the users columns to update, or an error describing the first invalid setting.
+func applyTrainingSettings(current models.TrainingSettings, raw map[string]json.RawMessage, now time.Time) (models.TrainingSettings, map[string]interface{}, error) {
+	merged := current
+	for key, value := range raw {
+		var err error
+		switch key {
+		case "max_hr_bpm":
+			merged.MaxHRBpm, err = decodeSetting[int16](value)
+		case "resting_hr_bpm":
+			merged.RestingHRBpm, err = decodeSetting[int16](value)
+		case "threshold_hr_bpm":
+			merged.ThresholdHRBpm, err = decodeSetting[int16](value)
+		case "threshold_pace_s_per_km":
+			merged.ThresholdPaceSPerKm, err = decodeSetting[float64](value)
+		case "ftp_watt":
+			merged.FTPWatt, err = decodeSetting[int16](value)
+		case "weight_kg":
+			merged.WeightKg, err = decodeSetting[float64](value)
+		case "birth_year":
+			merged.BirthYear, err = decodeSetting[int16](value)
+		case "sex":
+			merged.Sex, err = decodeSetting[models.Sex](value)
+		case "hr_zones_bpm":
+			merged.HRZonesBpm, err = decodeZoneBoundaries[int16](value)
+		case "pace_zones_s_per_km":
+			merged.PaceZonesSPerKm, err = decodeZoneBoundaries[float64](value)
+		case "power_zones_watt":
+			merged.PowerZonesWatt, err = decodeZoneBoundaries[int16](value)
+		default:
+			return current, nil, fmt.Errorf("unknown setting: %s", key)
+		}
+		if err != nil {
+			return current, nil, fmt.Errorf("invalid value for %s", key)
+		}
+	}
+
+	if err := validateTrainingSettings(merged, now); err != nil {
+		return current, nil, err
+	}
+
+	columns := trainingSettingsColumns(merged)
+	updates := make(map[string]interface{}, len(raw))
+	for key := range raw {
+		updates[key] = columns[key]
+	}
+	return merged, updates, nil
+}
+
+// checkSettingRange checks that a set value lies within [min, max]
+func checkSettingRange[T int16 | float64](name string, value *T, min, max T) error {
+	if value != nil && (*value < min || *value > max) {
+		return fmt.Errorf("%s must be between %v and %v", name, min, max)
+	}
+	return nil
+}
+
+// checkZoneBoundaries checks that zone boundaries lie within [min, max] and strictly increase, or decrease for pace
+func checkZoneBoundaries[T int16 | float64](name string, bounds []T, min, max T, decreasing bool) error {
+	if len(bounds) > maxZoneBoundaries {
+		return fmt.Errorf("%s can have at most %d boundaries", name, maxZoneBoundaries)
+	}
+	for i, bound := range bounds {
+		if bound < min || bound > max {
+			return fmt.Errorf("%s boundaries must be between %v and %v", name, min, max)
+		}
+		if i == 0 {
+			continue
+		}
+		if decreasing && bound >= bounds[i-1] {
+			return fmt.Errorf("%s must be strictly decreasing", name)
+		}
+		if !decreasing && bound <= bounds[i-1] {
+			return fmt.Errorf("%s must be strictly increasing", name)
+		}
+	}
+	return nil
+}
+
+// validateTrainingSettings checks every setting's range and that resting, threshold and max heart rate are in
+// order, including values estimated from the birth year
+func validateTrainingSettings(settings models.TrainingSettings, now time.Time) error {
+	checks := []error{
+		checkSettingRange("max_hr_bpm", settings.MaxHRBpm, 100, 230),
+		checkSettingRange("resting_hr_bpm", settings.RestingHRBpm, 25, 120),
+		checkSettingRange("threshold_hr_bpm", settings.ThresholdHRBpm, 80, 230),
+		checkSettingRange("threshold_pace_s_per_km", settings.ThresholdPaceSPerKm, 120, 900),
+		checkSettingRange("ftp_watt", settings.FTPWatt, 50, 600),
+		checkSettingRange("weight_kg", settings.WeightKg, 25, 250),
+		checkSettingRange("birth_year", settings.BirthYear, 1900, int16(now.Year())),
+		checkZoneBoundaries("hr_zones_bpm", settings.HRZonesBpm, 40, 230, false),
+		checkZoneBoundaries("pace_zones_s_per_km", settings.PaceZonesSPerKm, 120, 1200, true),
+		checkZoneBoundaries("power_zones_watt", settings.PowerZonesWatt, 1, 2000, false),
+	}
+	for _, err := range checks {
+		if err != nil {
+			return err
+		}
+	}
+
+	if settings.Sex != nil && *settings.Sex != models.SexMale && *settings.Sex != models.SexFemale {
+		return fmt.Errorf("sex must be male or female")
+	}
+
+	thresholds := trainingThresholdsFor(&settings, now)
+	if thresholds.RestingHR >= thresholds.ThresholdHR || thresholds.ThresholdHR > thresholds.MaxHR {
+		return fmt.Errorf("heart rates must satisfy resting < threshold <= max (got %.0f, %.0f and %.0f bpm)",
+			thresholds.RestingHR, thresholds.ThresholdHR, thresholds.MaxHR)
+	}
+	return nil
+}
+
+// recomputeDerivedMetrics rescores the training stress and zone times of every activity of a user from its
+// stored original file against the user's current settings, then rebuilds the training load series. loc sets
+// the local day of each activity, like the timezone of an upload. Activities without a stored file keep their values.
+// It runs in the background after a settings change, so failures are only logged.
+func (h *Handler) recomputeDerivedMetrics(ctx context.Context, userID string, loc *time.Location) {
+	activities, err := h.database.GetActivitiesByUserID(ctx, userID, models.ActivityListQuery{})
+	if err != nil {
+		h.log.Error("Failed to get activities for recomputation", err)
+		return
+	}
+	settings := h.userTrainingSettings(ctx, userID)
+	thresholds := trainingThresholdsFor(settings, time.Now())
+
+	recomputed := 0
+	for i := range activities {
+		activity := &activities[i]
+		samples, metadata, fullStream, err := h.parseStoredActivityFile(ctx, activity)
+		if err != nil {
+			continue // logged while parsing
+		}
+		h.saveDerivedMetrics(ctx, activity, fullStream, samples, metadata.TimerPauses, settings, thresholds, loc)
+		recomputed++
+	}
+
+	if err := h.updateTrainingLoad(ctx, userID, time.Time{}); err != nil {
+		h.log.Error("Failed to update training load", err)
+	}
+	h.log.Info(fmt.Sprintf("Recomputed derived metrics for %d of %d activities of user: %s", recomputed, len(activities), userID))
+}
+
+// saveDerivedMetrics scores and stores the training stress and zone times of an activity against the user's
+// settings, replacing earlier results. The training load series is left to the caller.
+func (h *Handler) saveDerivedMetrics(ctx context.Context, activity *models.Activity, fullStream *FullResolutionStream, samples []Sample, pauses []TimerPause, settings *models.TrainingSettings, thresholds trainingThresholds, loc *time.Location) {
+	h.saveTrainingStress(ctx, activity, fullStream, samples, pauses, thresholds, loc, false)
+	h.saveZoneTimes(ctx, activity, fullStream, samples, pauses, settings)
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+)
+
+func TestApplyTrainingSettings(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	maxHR, pace := int16(195), 280.0
+	current := models.TrainingSettings{MaxHRBpm: &maxHR, ThresholdPaceSPerKm: &pace, PowerZonesWatt: []int16{150, 200, 250}}
+
+	tests := []struct {
+		name        string
+		body        string
+		expectedErr string
+		check       func(t *testing.T, merged models.TrainingSettings, updates map[string]interface{})
+	}{
+		{
+			name: "partial update keeps other settings",
+			body: `{"resting_hr_bpm":52,"sex":"female","hr_zones_bpm":[120,140,160,175]}`,
+			check: func(t *testing.T, merged models.TrainingSettings, updates map[string]interface{}) {
+				if len(updates) != 3 {
+					t.Errorf("Expected only the sent settings to be updated, got %v", updates)
+				}
+				if *merged.RestingHRBpm != 52 || *merged.Sex != models.SexFemale || len(merged.HRZonesBpm) != 4 {
+					t.Errorf("Unexpected merged settings: %+v", merged)
+				}
+				if *merged.MaxHRBpm != 195 || *merged.ThresholdPaceSPerKm != 280 {
+					t.Errorf("Existing settings changed: %+v", merged)
+				}
+			},
+		},
+		{
+			name: "null and empty zones unset",
+			body: `{"threshold_pace_s_per_km":null,"power_zones_watt":[]}`,
+			check: func(t *testing.T, merged models.TrainingSettings, updates map[string]interface{}) {
+				if merged.ThresholdPaceSPerKm != nil || merged.PowerZonesWatt != nil {
+					t.Errorf("Expected settings to be unset, got %+v", merged)
+				}
+				if v, ok := updates["threshold_pace_s_per_km"].(*float64); !ok || v != nil {
+					t.Errorf("Expected a NULL column update, got %#v", updates["threshold_pace_s_per_km"])
+				}
+			},
+		},
+		{name: "unknown setting", body: `{"vo2max":55}`, expectedErr: "unknown setting: vo2max"},
+		{name: "wrong type", body: `{"ftp_watt":"250"}`, expectedErr: "invalid value for ftp_watt"},
+		{name: "out of range", body: `{"weight_kg":400}`, expectedErr: "weight_kg must be between 25 and 250"},
+		{name: "birth year in the future", body: `{"birth_year":2030}`, expectedErr: "birth_year must be between"},
+		{name: "invalid sex", body: `{"sex":"other"}`, expectedErr: "sex must be male or female"},
+		{name: "hr zones not increasing", body: `{"hr_zones_bpm":[120,140,140]}`, expectedErr: "hr_zones_bpm must be strictly increasing"},
+		{name: "pace zones not decreasing", body: `{"pace_zones_s_per_km":[300,320]}`, expectedErr: "pace_zones_s_per_km must be strictly decreasing"},
+		{name: "too many zones", body: `{"hr_zones_bpm":[100,110,120,130,140,150,160,170,180,190]}`, expectedErr: "at most 9 boundaries"},
+		{name: "threshold above max", body: `{"threshold_hr_bpm":200}`, expectedErr: "resting < threshold <= max"},
+		{name: "threshold above estimated max", body: `{"max_hr_bpm":null,"birth_year":1930,"threshold_hr_bpm":150}`, expectedErr: "resting < threshold <= max"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(tt.body), &raw); err != nil {
+				t.Fatalf("Invalid test body: %v", err)
+			}
+
+			merged, updates, err := applyTrainingSettings(current, raw, now)
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyTrainingSettings() error = %v", err)
+			}
+			tt.check(t, merged, updates)
+		})
+	}
+}
+
+func TestTrainingThresholdsFor(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	if got := trainingThresholdsFor(nil, now); got != defaultTrainingThresholds {
+		t.Errorf("Expected defaults without settings, got %+v", got)
+	}
+
+	birthYear, pace := int16(1986), 240.0
+	sex := models.SexFemale
+	got := trainingThresholdsFor(&models.TrainingSettings{BirthYear: &birthYear, ThresholdPaceSPerKm: &pace, Sex: &sex}, now)
+	if math.Abs(got.MaxHR-180) > 1e-9 || math.Abs(got.ThresholdHR-162) > 1e-9 {
+		t.Errorf("Expected max/threshold HR of 180/162 estimated from age 40, got %f/%f", got.MaxHR, got.ThresholdHR)
+	}
+	if got.ThresholdSpeedMps != 1000.0/240 || got.TRIMPWeight != 1.67 || got.RestingHR != defaultTrainingThresholds.RestingHR {
+		t.Errorf("Unexpected thresholds: %+v", got)
+	}
+
+	maxHR, thresholdHR := int16(200), int16(178)
+	got = trainingThresholdsFor(&models.TrainingSettings{MaxHRBpm: &maxHR, ThresholdHRBpm: &thresholdHR, BirthYear: &birthYear}, now)
+	if got.MaxHR != 200 || got.ThresholdHR != 178 {
+		t.Errorf("Expected measured heart rates to win over estimates, got %+v", got)
+	}
+}
+
+func TestHandleUpdateUserSettings(t *testing.T) {
+	h, mockDB := newMatchingTestHandler()
+	mockDB.users["user-123"] = &models.UserRecord{ID: "user-123", Email: "user@example.com", Name: "Test User"}
+	ftp := int16(240)
+	mockDB.userSettings["user-123"] = &models.TrainingSettings{FTPWatt: &ftp}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedCols   []string
+	}{
+		{"settings update", `{"settings":{"max_hr_bpm":188,"threshold_pace_s_per_km":265}}`, http.StatusOK, []string{"max_hr_bpm", "threshold_pace_s_per_km"}},
+		{"name and settings", `{"name":"Runner","settings":{"sex":"male"}}`, http.StatusOK, []string{"name", "sex"}},
+		{"recompute only", `{"recompute":true,"timezone":"Europe/Berlin"}`, http.StatusOK, nil},
+		{"invalid setting", `{"settings":{"ftp_watt":5}}`, http.StatusBadRequest, nil},
+		{"invalid timezone", `{"recompute":true,"timezone":"Mars/Olympus"}`, http.StatusBadRequest, nil},
+		{"nothing to do", `{"settings":{}}`, http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB.userUpdates = nil
+			req := httptest.NewRequest(http.MethodPatch, "/user", strings.NewReader(tt.body))
+			req = withTestUser(req, "user@example.com", nil)
+			w := httptest.NewRecorder()
+			h.HandleUpdateUser()(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				if mockDB.userUpdates != nil {
+					t.Errorf("Expected no update, got %v", mockDB.userUpdates)
+				}
+				return
+			}
+
+			if len(mockDB.userUpdates) != len(tt.expectedCols) {
+				t.Errorf("Expected updates to %v, got %v", tt.expectedCols, mockDB.userUpdates)
+			}
+			for _, col := range tt.expectedCols {
+				if _, ok := mockDB.userUpdates[col]; !ok {
+					t.Errorf("Expected %s to be updated, got %v", col, mockDB.userUpdates)
+				}
+			}
+
+			var response UserResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Settings == nil || response.Settings.FTPWatt == nil || *response.Settings.FTPWatt != 240 {
+				t.Errorf("Expected the stored settings in the response, got %+v", response.Settings)
+			}
+			if response.RecomputeStarted != strings.Contains(tt.body, "recompute") {
+				t.Errorf("RecomputeStarted = %v", response.RecomputeStarted)
+			}
+		})
+	}
+}
+
+func TestRecomputeDerivedMetrics(t *testing.T) {
+	fileKey := "activities/user-123/550e8400-e29b-41d4-a716-446655440000.gpx"
+	h, activity := setupFullLODHandler(t, fileKey, testFullLODGPX)
+	mockDB := h.database.(*MockDatabase)
+
+	activity.ActivityType = models.ActivityTypeRun
+	activity.StartTime = time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	mockDB.activities[activity.ID.String()] = activity
+	withoutFile := createTestActivity("6ba7b810-9dad-11d1-80b4-00c04fd430c8", "user-123")
+	mockDB.activities[withoutFile.ID.String()] = withoutFile
+
+	h.recomputeDerivedMetrics(t.Context(), "user-123", time.UTC)
+	if len(mockDB.trainingStress) != 1 || mockDB.trainingStress[0].Method != models.TrainingStressMethodPace {
+		t.Fatalf("Expected one pace-based score, got %+v", mockDB.trainingStress)
+	}
+	defaultTSS := mockDB.trainingStress[0].TSS
+	if len(mockDB.trainingLoad) != 1 || mockDB.trainingLoad[0].TSS != defaultTSS {
+		t.Errorf("Expected the training load to be rebuilt, got %+v", mockDB.trainingLoad)
+	}
+
+	// A faster threshold pace makes the same run easier
+	pace := 240.0
+	mockDB.userSettings["user-123"] = &models.TrainingSettings{ThresholdPaceSPerKm: &pace}
+	h.recomputeDerivedMetrics(t.Context(), "user-123", time.UTC)
+	if len(mockDB.trainingStress) != 1 {
+		t.Fatalf("Expected the score to be replaced, got %+v", mockDB.trainingStress)
+	}
+	if tss := mockDB.trainingStress[0].TSS; tss >= defaultTSS {
+		t.Errorf("Expected a lower score with a faster threshold pace, got %f then %f", defaultTSS, tss)
+	}
+	if mockDB.trainingLoad[0].TSS != mockDB.trainingStress[0].TSS {
+		t.Errorf("Expected the training load to use the new score, got %+v", mockDB.trainingLoad)
+	}
+}
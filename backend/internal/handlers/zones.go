@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// zoneDefinition is one kind of zones as the upper bounds of every zone but the last, in the stream's unit
+type zoneDefinition struct {
+	Kind       models.ZoneKind
+	Bounds     []float64
+	Decreasing bool // pace zones get harder as s/km decrease
+}
+
+// Default zones as fractions of the user's thresholds, used when no custom boundaries are set
+var (
+	defaultHRZoneFractions    = []float64{0.60, 0.70, 0.80, 0.90}             // of max heart rate, 5 zones
+	defaultPaceZoneFractions  = []float64{1.29, 1.14, 1.06, 0.99}             // of threshold pace in s/km, 5 zones
+	defaultPowerZoneFractions = []float64{0.55, 0.75, 0.90, 1.05, 1.20, 1.50} // of FTP, 7 zones
+)
+
+// zoneKindOrder orders zone kinds in responses
+var zoneKindOrder = map[models.ZoneKind]int{
+	models.ZoneKindHR:    0,
+	models.ZoneKindPace:  1,
+	models.ZoneKindPower: 2,
+}
+
+// ZoneResult is the time spent in one zone
+type ZoneResult struct {
+	Zone     int      `json:"zone"`
+	MinValue *float64 `json:"min_value"` // null for the open end of the first or last zone
+	MaxValue *float64 `json:"max_value"`
+	Seconds  float64  `json:"seconds"`
+}
+
+// ActivityZonesResult is an activity's time in each zone of one kind
+type ActivityZonesResult struct {
+	Kind  string       `json:"kind"` // hr (bpm), pace (s/km) or power (watts)
+	Zones []ZoneResult `json:"zones"`
+}
+
+// WeeklyZoneDistribution sums a calendar week's time in each zone of one kind. Zones are also grouped
+// into the three intensities of the polarized model: zones 1-2 are low, zone 3 moderate and the rest high.
+type WeeklyZoneDistribution struct {
+	WeekStart   string    `json:"week_start"` // local Monday, YYYY-MM-DD
+	Kind        string    `json:"kind"`
+	ZoneSeconds []float64 `json:"zone_seconds"` // zone 1 first
+	LowS        float64   `json:"low_s"`
+	ModerateS   float64   `json:"moderate_s"`
+	HighS       float64   `json:"high_s"`
+	LowFraction float64   `json:"low_fraction"` // share of low intensity time, 0.8 for an 80/20 week
+}
+
+// scaleZones multiplies zone fractions by a threshold
+func scaleZones(fractions []float64, threshold float64) []float64 {
+	bounds := make([]float64, len(fractions))
+	for i, fraction := range fractions {
+		bounds[i] = fraction * threshold
+	}
+	return bounds
+}
+
+// zoneBoundsToFloat converts stored zone boundaries to the stream's float values
+func zoneBoundsToFloat[T int16 | float64](bounds []T) []float64 {
+	result := make([]float64, len(bounds))
+	for i, bound := range bounds {
+		result[i] = float64(bound)
+	}
+	return result
+}
+
+// zoneDefinitions returns the zones an activity is scored against: the user's custom boundaries where set and
+// defaults derived from their thresholds otherwise. Pace zones only apply to runs, and power zones need an FTP
+// or custom boundaries.
+func zoneDefinitions(settings *models.TrainingSettings, activityType models.ActivityType, now time.Time) []zoneDefinition {
+	thresholds := trainingThresholdsFor(settings, now)
+	if settings == nil {
+		settings = &models.TrainingSettings{}
+	}
+
+	hr := zoneDefinition{Kind: models.ZoneKindHR, Bounds: scaleZones(defaultHRZoneFractions, thresholds.MaxHR)}
+	if len(settings.HRZonesBpm) > 0 {
+		hr.Bounds = zoneBoundsToFloat(settings.HRZonesBpm)
+	}
+	definitions := []zoneDefinition{hr}
+
+	if activityType == models.ActivityTypeRun {
+		pace := zoneDefinition{Kind: models.ZoneKindPace, Bounds: scaleZones(defaultPaceZoneFractions, 1000/thresholds.ThresholdSpeedMps), Decreasing: true}
+		if len(settings.PaceZonesSPerKm) > 0 {
+			pace.Bounds = zoneBoundsToFloat(settings.PaceZonesSPerKm)
+		}
+		definitions = append(definitions, pace)
+	}
+
+	switch {
+	case len(settings.PowerZonesWatt) > 0:
+		definitions = append(definitions, zoneDefinition{Kind: models.ZoneKindPower, Bounds: zoneBoundsToFloat(settings.PowerZonesWatt)})
+	case settings.FTPWatt != nil:
+		definitions = append(definitions, zoneDefinition{Kind: models.ZoneKindPower, Bounds: scaleZones(defaultPowerZoneFractions, float64(*settings.FTPWatt))})
+	}
+	return definitions
+}
+
+// zoneValues returns the per-sample values a kind of zones is measured on, or nil when the stream is missing.
+// Pace is zero while standing still.
+func zoneValues(stream *FullResolutionStream, kind models.ZoneKind) []float64 {
+	switch kind {
+	case models.ZoneKindHR:
+		return stream.HeartRateBpm
+	case models.ZoneKindPower:
+		return stream.PowerWatt
+	case models.ZoneKindPace:
+		if stream.SpeedMps == nil {
+			return nil
+		}
+		pace := make([]float64, len(stream.SpeedMps))
+		for i, speed := range stream.SpeedMps {
+			if speed > 0 {
+				pace[i] = 1000 / speed
+			}
+		}
+		return pace
+	}
+	return nil
+}
+
+// zoneIndex returns the 0-based zone a value falls in. Boundaries belong to the harder zone.
+func zoneIndex(value float64, definition zoneDefinition) int {
+	for i, bound := range definition.Bounds {
+		if (!definition.Decreasing && value < bound) || (definition.Decreasing && value > bound) {
+			return i
+		}
+	}
+	return len(definition.Bounds)
+}
+
+// zoneRange returns the value range of a 0-based zone, nil at the open ends
+func zoneRange(definition zoneDefinition, zone int) (*float64, *float64) {
+	var lower, upper *float64
+	if zone > 0 {
+		lower = &definition.Bounds[zone-1]
+	}
+	if zone < len(definition.Bounds) {
+		upper = &definition.Bounds[zone]
+	}
+	if definition.Decreasing {
+		return upper, lower // faster paces are smaller values
+	}
+	return lower, upper
+}
+
+// calculateZoneTimes sums the moving time spent in each zone. movingS holds the cumulative moving seconds per
+// sample (see cumulativeMovingSeconds). Kinds without data leave no results; every zone of the others is
+// returned, including empty ones.
+func calculateZoneTimes(activityID uuid.UUID, stream *FullResolutionStream, movingS []float64, definitions []zoneDefinition) []models.ActivityZoneTime {
+	n := len(stream.TimeS)
+	if n < 2 || len(movingS) != n {
+		return nil
+	}
+
+	now := time.Now()
+	var result []models.ActivityZoneTime
+	for _, definition := range definitions {
+		values := zoneValues(stream, definition.Kind)
+		if len(values) != n {
+			continue
+		}
+
+		seconds := make([]float64, len(definition.Bounds)+1)
+		total := 0.0
+		for i := 1; i < n; i++ {
+			dt := movingS[i] - movingS[i-1]
+			// Zero power is coasting, but zero heart rate or pace means no reading
+			if dt <= 0 || values[i] < 0 || (values[i] == 0 && definition.Kind != models.ZoneKindPower) {
+				continue
+			}
+			seconds[zoneIndex(values[i], definition)] += dt
+			total += dt
+		}
+		if total == 0 {
+			continue
+		}
+
+		for zone, s := range seconds {
+			zoneTime := models.ActivityZoneTime{
+				ActivityID: activityID,
+				Kind:       definition.Kind,
+				Zone:       zone + 1,
+				Seconds:    s,
+				CreatedAt:  now,
+			}
+			zoneTime.MinValue, zoneTime.MaxValue = zoneRange(definition, zone)
+			result = append(result, zoneTime)
+		}
+	}
+	return result
+}
+
+// saveZoneTimes scores and stores an activity's time in each zone against the user's settings, replacing
+// earlier results
+func (h *Handler) saveZoneTimes(ctx context.Context, activity *models.Activity, fullStream *FullResolutionStream, samples []Sample, pauses []TimerPause, settings *models.TrainingSettings) {
+	definitions := zoneDefinitions(settings, activity.ActivityType, time.Now())
+	zones := calculateZoneTimes(activity.ID, fullStream, cumulativeMovingSeconds(samples, pauses), definitions)
+
+	if err := h.database.ReplaceActivityZoneTimes(ctx, activity.ID.String(), zones); err != nil {
+		h.log.Error("Failed to save zone times to database", err)
+		return
+	}
+	h.log.Debug(fmt.Sprintf("Successfully saved %d zone times for activity: %s", len(zones), activity.ID.String()))
+}
+
+// buildActivityZones groups an activity's stored zone times by kind
+func buildActivityZones(zones []models.ActivityZoneTime) []ActivityZonesResult {
+	var result []ActivityZonesResult
+	for _, zone := range zones {
+		if len(result) == 0 || result[len(result)-1].Kind != string(zone.Kind) {
+			result = append(result, ActivityZonesResult{Kind: string(zone.Kind)})
+		}
+		last := &result[len(result)-1]
+		last.Zones = append(last.Zones, ZoneResult{
+			Zone:     zone.Zone,
+			MinValue: zone.MinValue,
+			MaxValue: zone.MaxValue,
+			Seconds:  zone.Seconds,
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return zoneKindOrder[models.ZoneKind(result[i].Kind)] < zoneKindOrder[models.ZoneKind(result[j].Kind)]
+	})
+	return result
+}
+
+// buildWeeklyZoneDistributions sums the zone times of activities per calendar week (local Monday start in loc, like
+// weekly compliance) and zone kind. Activities scored against different zone counts add up by zone number.
+func buildWeeklyZoneDistributions(activities []models.Activity, zones []models.ActivityZoneTime, loc *time.Location) []WeeklyZoneDistribution {
+	weekOf := make(map[uuid.UUID]string, len(activities))
+	for _, activity := range activities {
+		weekOf[activity.ID] = weekStart(activity.StartTime, loc).Format("2006-01-02")
+	}
+
+	type weekKind struct {
+		week string
+		kind models.ZoneKind
+	}
+	weekly := make(map[weekKind]*WeeklyZoneDistribution)
+	for _, zone := range zones {
+		week, ok := weekOf[zone.ActivityID]
+		if !ok {
+			continue
+		}
+		key := weekKind{week, zone.Kind}
+		distribution := weekly[key]
+		if distribution == nil {
+			distribution = &WeeklyZoneDistribution{WeekStart: week, Kind: string(zone.Kind)}
+			weekly[key] = distribution
+		}
+
+		for len(distribution.ZoneSeconds) < zone.Zone {
+			distribution.ZoneSeconds = append(distribution.ZoneSeconds, 0)
+		}
+		distribution.ZoneSeconds[zone.Zone-1] += zone.Seconds
+
+		switch {
+		case zone.Zone <= 2:
+			distribution.LowS += zone.Seconds
+		case zone.Zone == 3:
+			distribution.ModerateS += zone.Seconds
+		default:
+			distribution.HighS += zone.Seconds
+		}
+	}
+
+	result := make([]WeeklyZoneDistribution, 0, len(weekly))
+	for _, distribution := range weekly {
+		if total := distribution.LowS + distribution.ModerateS + distribution.HighS; total > 0 {
+			distribution.LowFraction = distribution.LowS / total
+		}
+		result = append(result, *distribution)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].WeekStart != result[j].WeekStart {
+			return result[i].WeekStart < result[j].WeekStart
+		}
+		return zoneKindOrder[models.ZoneKind(result[i].Kind)] < zoneKindOrder[models.ZoneKind(result[j].Kind)]
+	})
+	return result
+}
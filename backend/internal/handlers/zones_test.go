@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestZoneDefinitions(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("defaults", func(t *testing.T) {
+		definitions := zoneDefinitions(nil, models.ActivityTypeRun, now)
+		if len(definitions) != 2 || definitions[0].Kind != models.ZoneKindHR || definitions[1].Kind != models.ZoneKindPace {
+			t.Fatalf("Expected heart rate and pace zones for a run without FTP, got %+v", definitions)
+		}
+		for i, want := range []float64{114, 133, 152, 171} { // of 190 bpm max
+			if math.Abs(definitions[0].Bounds[i]-want) > 1e-9 {
+				t.Errorf("HR bound %d = %f, want %f", i, definitions[0].Bounds[i], want)
+			}
+		}
+		if !definitions[1].Decreasing || math.Abs(definitions[1].Bounds[0]-387) > 1e-9 { // 129% of 5:00/km
+			t.Errorf("Unexpected pace zones: %+v", definitions[1])
+		}
+
+		if definitions := zoneDefinitions(nil, models.ActivityTypeRoadBike, now); len(definitions) != 1 {
+			t.Errorf("Expected only heart rate zones for a ride without FTP, got %+v", definitions)
+		}
+	})
+
+	t.Run("custom and FTP", func(t *testing.T) {
+		ftp := int16(250)
+		settings := &models.TrainingSettings{FTPWatt: &ftp, HRZonesBpm: []int16{130, 150, 170}}
+		definitions := zoneDefinitions(settings, models.ActivityTypeRoadBike, now)
+		if len(definitions) != 2 {
+			t.Fatalf("Expected heart rate and power zones, got %+v", definitions)
+		}
+		if len(definitions[0].Bounds) != 3 || definitions[0].Bounds[2] != 170 {
+			t.Errorf("Expected the custom heart rate zones, got %v", definitions[0].Bounds)
+		}
+		if power := definitions[1]; power.Kind != models.ZoneKindPower || len(power.Bounds) != 6 || math.Abs(power.Bounds[3]-262.5) > 1e-9 {
+			t.Errorf("Expected 7 power zones from FTP, got %+v", power)
+		}
+
+		settings.PowerZonesWatt = []int16{200, 300}
+		if power := zoneDefinitions(settings, models.ActivityTypeRoadBike, now)[1]; len(power.Bounds) != 2 {
+			t.Errorf("Expected custom power zones to win over FTP, got %v", power.Bounds)
+		}
+	})
+}
+
+func TestCalculateZoneTimes(t *testing.T) {
+	activityID := uuid.New()
+	// One sample every 10 s; the sample at the end of each interval sets its zone
+	stream := &FullResolutionStream{
+		TimeS:        []float64{0, 10, 20, 30, 40, 50},
+		SpeedMps:     []float64{0, 2.5, 4, 4, 0, 5},
+		HeartRateBpm: []float64{100, 120, 145, 0, 165, 180},
+		PowerWatt:    []float64{0, 0, 150, 250, 300, 400},
+	}
+	movingS := []float64{0, 10, 20, 30, 30, 40} // paused between samples 3 and 4
+
+	definitions := []zoneDefinition{
+		{Kind: models.ZoneKindHR, Bounds: []float64{130, 150, 170}},
+		{Kind: models.ZoneKindPace, Bounds: []float64{300, 240}, Decreasing: true},
+		{Kind: models.ZoneKindPower, Bounds: []float64{200}},
+	}
+	zones := calculateZoneTimes(activityID, stream, movingS, definitions)
+
+	seconds := make(map[models.ZoneKind][]float64)
+	for _, zone := range zones {
+		if zone.ActivityID != activityID || zone.Zone != len(seconds[zone.Kind])+1 {
+			t.Fatalf("Unexpected zone order: %+v", zones)
+		}
+		seconds[zone.Kind] = append(seconds[zone.Kind], zone.Seconds)
+	}
+
+	expected := map[models.ZoneKind][]float64{
+		models.ZoneKindHR:    {10, 10, 0, 10}, // zero heart rate has no zone
+		models.ZoneKindPace:  {10, 20, 10},    // 6:40/km, 4:10/km twice, then 3:20/km
+		models.ZoneKindPower: {20, 20},        // coasting counts as zone 1
+	}
+	for kind, want := range expected {
+		got := seconds[kind]
+		if len(got) != len(want) {
+			t.Errorf("%s zones = %v, want %v", kind, got, want)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s zones = %v, want %v", kind, got, want)
+				break
+			}
+		}
+	}
+
+	// Bounds are open at the ends, and pace zones run from slow to fast
+	first, second := zones[0], zones[1]
+	if first.MinValue != nil || *first.MaxValue != 130 || *second.MinValue != 130 || *second.MaxValue != 150 {
+		t.Errorf("Unexpected HR bounds: %+v %+v", first, second)
+	}
+	pace := zones[4:7]
+	if *pace[0].MinValue != 300 || pace[0].MaxValue != nil || *pace[1].MinValue != 240 || *pace[1].MaxValue != 300 || pace[2].MinValue != nil {
+		t.Errorf("Unexpected pace bounds: %+v", pace)
+	}
+
+	t.Run("missing streams", func(t *testing.T) {
+		stream := &FullResolutionStream{TimeS: []float64{0, 10}, SpeedMps: []float64{3, 3}}
+		zones := calculateZoneTimes(activityID, stream, []float64{0, 10}, definitions)
+		if len(zones) != 3 || zones[0].Kind != models.ZoneKindPace {
+			t.Errorf("Expected only pace zones, got %+v", zones)
+		}
+	})
+}
+
+func TestBuildWeeklyZoneDistributions(t *testing.T) {
+	monday := time.Date(2024, 6, 3, 7, 0, 0, 0, time.UTC)
+	activities := []models.Activity{
+		{ID: uuid.New(), StartTime: monday},
+		{ID: uuid.New(), StartTime: monday.AddDate(0, 0, 6)}, // Sunday, same week
+		{ID: uuid.New(), StartTime: monday.AddDate(0, 0, 7)},
+	}
+	zone := func(activity int, kind models.ZoneKind, zone int, seconds float64) models.ActivityZoneTime {
+		return models.ActivityZoneTime{ActivityID: activities[activity].ID, Kind: kind, Zone: zone, Seconds: seconds}
+	}
+	zones := []models.ActivityZoneTime{
+		zone(0, models.ZoneKindHR, 1, 1200), zone(0, models.ZoneKindHR, 2, 1800), zone(0, models.ZoneKindHR, 3, 300),
+		zone(0, models.ZoneKindHR, 4, 300), zone(0, models.ZoneKindHR, 5, 0),
+		zone(1, models.ZoneKindHR, 1, 600), zone(1, models.ZoneKindHR, 2, 0), zone(1, models.ZoneKindHR, 3, 0),
+		zone(1, models.ZoneKindPower, 7, 60),
+		zone(2, models.ZoneKindHR, 1, 100),
+		{ActivityID: uuid.New(), Kind: models.ZoneKindHR, Zone: 1, Seconds: 999}, // outside the range
+	}
+
+	weekly := buildWeeklyZoneDistributions(activities, zones, time.UTC)
+	if len(weekly) != 3 {
+		t.Fatalf("Expected 3 week/kind distributions, got %+v", weekly)
+	}
+
+	hr := weekly[0]
+	if hr.WeekStart != "2024-06-03" || hr.Kind != "hr" || len(hr.ZoneSeconds) != 5 || hr.ZoneSeconds[0] != 1800 {
+		t.Errorf("Unexpected first week heart rate distribution: %+v", hr)
+	}
+	if hr.LowS != 3600 || hr.ModerateS != 300 || hr.HighS != 300 || math.Abs(hr.LowFraction-3600.0/4200) > 1e-9 {
+		t.Errorf("Unexpected intensity split: %+v", hr)
+	}
+	if power := weekly[1]; power.Kind != "power" || len(power.ZoneSeconds) != 7 || power.HighS != 60 || power.LowFraction != 0 {
+		t.Errorf("Unexpected power distribution: %+v", power)
+	}
+	if weekly[2].WeekStart != "2024-06-10" || weekly[2].LowFraction != 1 {
+		t.Errorf("Unexpected second week: %+v", weekly[2])
+	}
+}
+
+func TestBuildWeeklyZoneDistributions_LocalWeek(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	// 8 pm on Sunday June 9 in Los Angeles, which is Monday June 10 in UTC
+	sundayEvening := time.Date(2024, 6, 10, 3, 0, 0, 0, time.UTC)
+	activities := []models.Activity{
+		{ID: uuid.New(), StartTime: sundayEvening},
+		{ID: uuid.New(), StartTime: sundayEvening.AddDate(0, 0, -2)},
+	}
+	zones := []models.ActivityZoneTime{
+		{ActivityID: activities[0].ID, Kind: models.ZoneKindHR, Zone: 1, Seconds: 600},
+		{ActivityID: activities[1].ID, Kind: models.ZoneKindHR, Zone: 4, Seconds: 300},
+	}
+
+	weekly := buildWeeklyZoneDistributions(activities, zones, losAngeles)
+	if len(weekly) != 1 || weekly[0].WeekStart != "2024-06-03" || weekly[0].LowS != 600 || weekly[0].HighS != 300 {
+		t.Errorf("Expected both activities in the local week of 2024-06-03, got %+v", weekly)
+	}
+	if weekly = buildWeeklyZoneDistributions(activities, zones, time.UTC); len(weekly) != 2 {
+		t.Errorf("Expected the Sunday evening activity in the next UTC week, got %+v", weekly)
+	}
+}
+
+func TestHandleGetActivity(t *testing.T) {
+	h, mockDB := newMatchingTestHandler()
+	mockDB.usersByEmail["other@example.com"] = &models.UserRecord{ID: "user-456", Email: "other@example.com"}
+	activity := createTestActivity(uuid.New().String(), "user-123")
+	mockDB.activities[activity.ID.String()] = activity
+	bound := 150.0
+	mockDB.zoneTimes = []models.ActivityZoneTime{
+		{ActivityID: activity.ID, Kind: models.ZoneKindPace, Zone: 1, Seconds: 400},
+		{ActivityID: activity.ID, Kind: models.ZoneKindHR, Zone: 1, MaxValue: &bound, Seconds: 300},
+		{ActivityID: activity.ID, Kind: models.ZoneKindHR, Zone: 2, MinValue: &bound, Seconds: 100},
+	}
+
+	tests := []struct {
+		name           string
+		activityID     string
+		email          string
+		expectedStatus int
+	}{
+		{"own activity", activity.ID.String(), "user@example.com", http.StatusOK},
+		{"invalid id", "not-a-uuid", "user@example.com", http.StatusBadRequest},
+		{"other user's activity", activity.ID.String(), "other@example.com", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/activities/"+tt.activityID, nil)
+			req = withTestUser(req, tt.email, map[string]string{"id": tt.activityID})
+			w := httptest.NewRecorder()
+			h.HandleGetActivity()(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response ActivityResult
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.ID != activity.ID.String() || len(response.Zones) != 2 {
+				t.Fatalf("Unexpected response: %+v", response)
+			}
+			hr := response.Zones[0]
+			if hr.Kind != "hr" || len(hr.Zones) != 2 || hr.Zones[1].Seconds != 100 || *hr.Zones[1].MinValue != 150 {
+				t.Errorf("Unexpected heart rate zones: %+v", hr)
+			}
+			if response.Zones[1].Kind != "pace" {
+				t.Errorf("Expected pace zones after heart rate zones, got %+v", response.Zones)
+			}
+		})
+	}
+}
@@ -27,7 +27,8 @@ type Activity struct {
 	// Time information
 	StartTime   time.Time  `json:"start_time" db:"start_time"`
 	EndTime     *time.Time `json:"end_time" db:"end_time"`
-	ElapsedTime int        `json:"elapsed_time" db:"elapsed_time"` // seconds
+	ElapsedTime int        `json:"elapsed_time" db:"elapsed_time"`   // seconds
+	MovingTime  *int       `json:"moving_time_s" db:"moving_time_s"` // seconds, excluding pauses (nullable for older activities)
 
 	// Distance and performance metrics
 	DistanceM      float64  `json:"distance_m" db:"distance_m"`
@@ -37,11 +38,18 @@ type Activity struct {
 	MinHeightM     *float64 `json:"min_height_m" db:"min_height_m"`
 	AvgSpeedMps    *float64 `json:"avg_speed_mps" db:"avg_speed_mps"`
 	MaxSpeedMps    *float64 `json:"max_speed_mps" db:"max_speed_mps"`
+	AvgGAPMps      *float64 `json:"avg_gap_mps" db:"avg_gap_mps"` // grade-adjusted pace as equivalent flat speed, running only
 
 	// Heart rate data (nullable if no HR sensor)
 	AvgHRBpm *int16 `json:"avg_hr_bpm" db:"avg_hr_bpm"`
 	MaxHRBpm *int16 `json:"max_hr_bpm" db:"max_hr_bpm"`
 
+	// Cadence and power data (nullable if no sensor)
+	AvgCadenceRpm *int16 `json:"avg_cadence_rpm" db:"avg_cadence_rpm"`
+	MaxCadenceRpm *int16 `json:"max_cadence_rpm" db:"max_cadence_rpm"`
+	AvgPowerWatt  *int16 `json:"avg_power_watt" db:"avg_power_watt"`
+	MaxPowerWatt  *int16 `json:"max_power_watt" db:"max_power_watt"`
+
 	// User-provided effort rating (nullable)
 	PerceivedEffort *int16 `json:"perceived_effort" db:"perceived_effort"`
 
@@ -49,7 +57,8 @@ type Activity struct {
 	ProcessingVer int `json:"processing_ver" db:"processing_ver"`
 
 	// Route data
-	Polyline *string `json:"polyline" db:"polyline"`
+	Polyline    *string `json:"polyline" db:"polyline"`
+	RawPolyline *string `json:"-" db:"raw_polyline"` // original GPS track when polyline was snapped to the map, nil otherwise
 
 	// Bounding box coordinates
 	BBoxMinLat *float64 `json:"bbox_min_lat" db:"bbox_min_lat"`
@@ -71,6 +80,108 @@ type Activity struct {
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// ActivitySortField is the field a user's activity list is ordered by
+type ActivitySortField string
+
+const (
+	ActivitySortByDate     ActivitySortField = "date"     // start_time
+	ActivitySortByDistance ActivitySortField = "distance" // distance_m
+	ActivitySortByDuration ActivitySortField = "duration" // elapsed_time
+)
+
+// ActivityListQuery filters, orders and pages a user's activity list. Nil or zero filters are not applied.
+type ActivityListQuery struct {
+	ActivityType *ActivityType
+	StartFrom    *time.Time // inclusive
+	StartTo      *time.Time // inclusive
+	MinDistanceM *float64
+	MaxDistanceM *float64
+	TitleSearch  string // case-insensitive substring of the title
+
+	SortBy    ActivitySortField // defaults to date
+	Ascending bool
+	Limit     int             // 0 returns every matching activity
+	After     *ActivityCursor // keyset position of the last activity on the previous page
+}
+
+// ActivityCursor is an activity's position in a sorted activity list.
+// ID breaks ties between activities with the same sort value.
+type ActivityCursor struct {
+	StartTime   time.Time
+	DistanceM   float64
+	ElapsedTime int
+	ID          uuid.UUID
+}
+
+// SplitUnit is the distance an activity is split by
+type SplitUnit string
+
+const (
+	SplitUnitKm   SplitUnit = "km"
+	SplitUnitMile SplitUnit = "mi"
+)
+
+// ActivitySplit is one kilometer or mile of an activity. The last split of each unit may be shorter.
+type ActivitySplit struct {
+	ActivityID     uuid.UUID `json:"activity_id" db:"activity_id"`
+	Unit           SplitUnit `json:"unit" db:"unit"`
+	SplitIndex     int       `json:"split_index" db:"split_index"` // 1-based
+	DistanceM      float64   `json:"distance_m" db:"distance_m"`
+	ElapsedS       float64   `json:"elapsed_s" db:"elapsed_s"`
+	MovingS        float64   `json:"moving_s" db:"moving_s"`
+	ElevationGainM float64   `json:"elevation_gain_m" db:"elevation_gain_m"`
+	ElevationLossM float64   `json:"elevation_loss_m" db:"elevation_loss_m"`
+	AvgHRBpm       *int16    `json:"avg_hr_bpm" db:"avg_hr_bpm"` // nil without heart rate data
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// ActivityLap is a lap recorded by the device. It covers the full-resolution points from StartIndex
+// up to the next lap's StartIndex.
+type ActivityLap struct {
+	ActivityID  uuid.UUID `json:"activity_id" db:"activity_id"`
+	LapIndex    int       `json:"lap_index" db:"lap_index"`     // 1-based
+	StartIndex  int       `json:"start_index" db:"start_index"` // index into the full-resolution streams
+	StartTime   time.Time `json:"start_time" db:"start_time"`
+	ElapsedS    float64   `json:"elapsed_s" db:"elapsed_s"`
+	DistanceM   float64   `json:"distance_m" db:"distance_m"`
+	AvgSpeedMps *float64  `json:"avg_speed_mps" db:"avg_speed_mps"`
+	AvgHRBpm    *int16    `json:"avg_hr_bpm" db:"avg_hr_bpm"`
+	MaxHRBpm    *int16    `json:"max_hr_bpm" db:"max_hr_bpm"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// BestEffortKind says which side of a best effort is fixed
+type BestEffortKind string
+
+const (
+	BestEffortKindDistance BestEffortKind = "distance" // fastest time over a fixed distance
+	BestEffortKindDuration BestEffortKind = "duration" // longest distance in a fixed time
+)
+
+// BestEffort is an activity's best performance over one standard distance or duration, e.g. its fastest 5k
+type BestEffort struct {
+	ActivityID uuid.UUID      `json:"activity_id" db:"activity_id"`
+	Effort     string         `json:"effort" db:"effort"` // e.g. "5k", "half_marathon", "20min"
+	Kind       BestEffortKind `json:"kind" db:"kind"`
+	DistanceM  float64        `json:"distance_m" db:"distance_m"`
+	ElapsedS   float64        `json:"elapsed_s" db:"elapsed_s"`
+	StartIndex int            `json:"start_index" db:"start_index"` // index into the full-resolution streams
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+}
+
+// PersonalRecord is a user's best effort of all their activities of one type
+type PersonalRecord struct {
+	UserID       string         `json:"user_id" db:"user_id"`
+	ActivityType ActivityType   `json:"activity_type" db:"activity_type"`
+	Effort       string         `json:"effort" db:"effort"`
+	Kind         BestEffortKind `json:"kind" db:"kind"`
+	ActivityID   uuid.UUID      `json:"activity_id" db:"activity_id"`
+	DistanceM    float64        `json:"distance_m" db:"distance_m"`
+	ElapsedS     float64        `json:"elapsed_s" db:"elapsed_s"`
+	AchievedAt   time.Time      `json:"achieved_at" db:"achieved_at"` // start time of the activity
+	UpdatedAt    time.Time      `json:"updated_at" db:"updated_at"`
+}
+
 // Stream data types
 type StreamLOD string
 
@@ -84,7 +195,7 @@ type StreamIndexBy string
 
 const (
 	StreamIndexByDistance StreamIndexBy = "distance" // downsampled by distance
-	// In future: StreamIndexByTime StreamIndexBy = "time", maybe
+	StreamIndexByTime     StreamIndexBy = "time"     // downsampled by elapsed time
 )
 
 type ActivityStream struct {
@@ -100,6 +211,19 @@ type ActivityStream struct {
 	SpeedMpsBytes   []byte `json:"-" db:"speed_mps_bytes"`   // speed in meters per second
 	ElevationMBytes []byte `json:"-" db:"elevation_m_bytes"` // elevation in meters
 
+	// Sensor streams, nil when the source file had no data for the sensor
+	HeartRateBpmBytes []byte `json:"-" db:"heart_rate_bpm_bytes"` // heart rate in beats per minute
+	CadenceRpmBytes   []byte `json:"-" db:"cadence_rpm_bytes"`    // cadence in rpm (steps per minute for running)
+	PowerWattBytes    []byte `json:"-" db:"power_watt_bytes"`     // power in watts
+	TemperatureCBytes []byte `json:"-" db:"temperature_c_bytes"`  // temperature in degrees celsius
+
+	// Coordinates, nil when the track has no positions
+	LatBytes []byte `json:"-" db:"lat_bytes"` // latitude in degrees
+	LonBytes []byte `json:"-" db:"lon_bytes"` // longitude in degrees
+
+	// Smoothed grade derived from distance and elevation, nil for streams stored before grade was added
+	GradePctBytes []byte `json:"-" db:"grade_pct_bytes"` // grade in percent
+
 	// Compression metadata
 	Codec map[string]interface{} `json:"codec" db:"codec"` // JSON metadata about compression
 
@@ -112,8 +236,14 @@ type ActivityStream struct {
 type StreamType string
 
 const (
-	StreamTypeTime      StreamType = "time"
-	StreamTypeDistance  StreamType = "distance"
-	StreamTypeElevation StreamType = "elevation"
-	StreamTypeSpeed     StreamType = "speed"
+	StreamTypeTime        StreamType = "time"
+	StreamTypeDistance    StreamType = "distance"
+	StreamTypeElevation   StreamType = "elevation"
+	StreamTypeSpeed       StreamType = "speed"
+	StreamTypeHeartRate   StreamType = "heart_rate"
+	StreamTypeCadence     StreamType = "cadence"
+	StreamTypePower       StreamType = "power"
+	StreamTypeTemperature StreamType = "temperature"
+	StreamTypeLatLng      StreamType = "latlng" // latitude/longitude pairs
+	StreamTypeGrade       StreamType = "grade"  // smoothed grade in percent
 )
@@ -41,6 +41,7 @@ type PlannedActivity struct {
 	TargetPowerWatt       *int     `json:"targetPowerWatt" db:"target_power_watt"`
 
 	MatchedActivityID *uuid.UUID `json:"matchedActivityId" db:"matched_activity_id"`
+	RouteID           *uuid.UUID `json:"routeId" db:"route_id"`
 
 	UserTrainingPlanID *uuid.UUID `json:"userTrainingPlanId" db:"user_training_plan_id"`
 	PlanSequenceIndex  *int       `json:"planSequenceIndex" db:"plan_sequence_index"`
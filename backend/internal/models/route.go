@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RouteWaypoint is a point a route is built through
+type RouteWaypoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Route is a named path planned with Valhalla routing, which planned activities can reference
+type Route struct {
+	ID          uuid.UUID    `json:"id" db:"id"`
+	UserID      string       `json:"user_id" db:"user_id"`
+	Name        string       `json:"name" db:"name"`
+	Description *string      `json:"description" db:"description"`
+	Type        ActivityType `json:"type" db:"type"` // running routes follow footpaths, road_biking routes follow cycleable roads
+
+	Waypoints []RouteWaypoint `json:"waypoints" db:"waypoints"`
+	Polyline  string          `json:"polyline" db:"polyline"` // precision 6
+
+	DistanceM      float64  `json:"distance_m" db:"distance_m"`
+	ElevationGainM *float64 `json:"elevation_gain_m" db:"elevation_gain_m"`
+	ElevationLossM *float64 `json:"elevation_loss_m" db:"elevation_loss_m"`
+
+	// Elevation profile, one value per polyline point (DIBS compressed, nil without heights)
+	ProfileDistanceMBytes  []byte `json:"-" db:"profile_distance_m_bytes"`
+	ProfileElevationMBytes []byte `json:"-" db:"profile_elevation_m_bytes"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
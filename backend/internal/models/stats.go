@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// StatsPeriod is the calendar period activity statistics are aggregated over
+type StatsPeriod string
+
+const (
+	StatsPeriodWeek  StatsPeriod = "week" // Monday to Sunday
+	StatsPeriodMonth StatsPeriod = "month"
+	StatsPeriodYear  StatsPeriod = "year"
+)
+
+// ActivityStats are the summed activities of one type in one period
+type ActivityStats struct {
+	PeriodStart    time.Time    `json:"period_start" db:"period_start"` // local date the period starts on, midnight UTC
+	ActivityType   ActivityType `json:"type" db:"type"`
+	Count          int          `json:"count" db:"count"`
+	DistanceM      float64      `json:"distance_m" db:"distance_m"`
+	MovingTimeS    float64      `json:"moving_time_s" db:"moving_time_s"` // elapsed time for activities without moving time
+	ElevationGainM float64      `json:"elevation_gain_m" db:"elevation_gain_m"`
+	TSS            float64      `json:"tss" db:"tss"`
+}
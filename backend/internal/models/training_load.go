@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TrainingStressMethod is how an activity's training stress score was computed
+type TrainingStressMethod string
+
+const (
+	TrainingStressMethodHR   TrainingStressMethod = "hr"   // hrTSS from the heart rate stream
+	TrainingStressMethodPace TrainingStressMethod = "pace" // rTSS from running pace
+)
+
+// ActivityTrainingStress is the training stress score of one activity
+type ActivityTrainingStress struct {
+	ActivityID      uuid.UUID            `json:"activity_id" db:"activity_id"`
+	UserID          string               `json:"user_id" db:"user_id"`
+	Day             time.Time            `json:"day" db:"day"` // local calendar day of the activity start, midnight UTC
+	TSS             float64              `json:"tss" db:"tss"`
+	IntensityFactor float64              `json:"intensity_factor" db:"intensity_factor"`
+	Method          TrainingStressMethod `json:"method" db:"method"`
+	CreatedAt       time.Time            `json:"created_at" db:"created_at"`
+}
+
+// DailyTrainingStress is the summed training stress of a user's activities on one day
+type DailyTrainingStress struct {
+	Day time.Time `json:"day" db:"day"`
+	TSS float64   `json:"tss" db:"tss"`
+}
+
+// TrainingLoadDay is one day of a user's fitness/fatigue series.
+// CTL (fitness) and ATL (fatigue) are exponentially weighted averages of daily TSS;
+// TSB (form) is the previous day's CTL minus its ATL.
+type TrainingLoadDay struct {
+	UserID string    `json:"user_id" db:"user_id"`
+	Day    time.Time `json:"day" db:"day"`
+	TSS    float64   `json:"tss" db:"tss"`
+	CTL    float64   `json:"ctl" db:"ctl"`
+	ATL    float64   `json:"atl" db:"atl"`
+	TSB    float64   `json:"tsb" db:"tsb"`
+}
@@ -18,3 +18,30 @@ type UserRecord struct {
 	UpdatedAt    int64        `json:"updated_at"`
 	// we will add other fields as needed, e.g., profile picture URL, etc.
 }
+
+// Sex is used for sex-specific training formulas such as Banister TRIMP
+type Sex string
+
+const (
+	SexMale   Sex = "male"
+	SexFemale Sex = "female"
+)
+
+// TrainingSettings holds a user's personal thresholds, body metrics and zone boundaries.
+// They are stored on the user row but loaded separately, since the user record is read on every request.
+// Unset values are nil and are left out of JSON; population defaults are used in their place.
+type TrainingSettings struct {
+	MaxHRBpm            *int16   `json:"max_hr_bpm,omitempty"`
+	RestingHRBpm        *int16   `json:"resting_hr_bpm,omitempty"`
+	ThresholdHRBpm      *int16   `json:"threshold_hr_bpm,omitempty"` // lactate threshold heart rate
+	ThresholdPaceSPerKm *float64 `json:"threshold_pace_s_per_km,omitempty"`
+	FTPWatt             *int16   `json:"ftp_watt,omitempty"` // functional threshold power
+	WeightKg            *float64 `json:"weight_kg,omitempty"`
+	BirthYear           *int16   `json:"birth_year,omitempty"`
+	Sex                 *Sex     `json:"sex,omitempty"`
+
+	// Zone boundaries are the upper bounds of every zone but the last. Nil uses zones derived from the thresholds.
+	HRZonesBpm      []int16   `json:"hr_zones_bpm,omitempty"`
+	PaceZonesSPerKm []float64 `json:"pace_zones_s_per_km,omitempty"` // decreasing, zone 1 is the slowest
+	PowerZonesWatt  []int16   `json:"power_zones_watt,omitempty"`
+}
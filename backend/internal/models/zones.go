@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ZoneKind is the stream an activity's zones are measured on
+type ZoneKind string
+
+const (
+	ZoneKindHR    ZoneKind = "hr"    // heart rate in bpm
+	ZoneKindPace  ZoneKind = "pace"  // running pace in s/km
+	ZoneKindPower ZoneKind = "power" // power in watts
+)
+
+// ActivityZoneTime is the moving time an activity spent in one zone
+type ActivityZoneTime struct {
+	ActivityID uuid.UUID `json:"activity_id" db:"activity_id"`
+	Kind       ZoneKind  `json:"kind" db:"kind"`
+	Zone       int       `json:"zone" db:"zone"`           // 1-based, zone 1 is the easiest
+	MinValue   *float64  `json:"min_value" db:"min_value"` // nil for the open end of the first or last zone
+	MaxValue   *float64  `json:"max_value" db:"max_value"`
+	Seconds    float64   `json:"seconds" db:"seconds"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
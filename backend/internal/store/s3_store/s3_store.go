@@ -0,0 +1,554 @@
+package s3_store
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/logger"
+	"github.com/anish-chanda/cadent/backend/internal/store"
+)
+
+const (
+	defaultRegion   = "us-east-1"
+	signingAlgo     = "AWS4-HMAC-SHA256"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	emptyPayloadSum = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" // sha256 of ""
+)
+
+// S3Store is an ObjectStore backed by AWS S3 or any S3-compatible service (MinIO, R2, etc).
+// Requests are signed with AWS Signature Version 4.
+type S3Store struct {
+	bucket       string
+	region       string
+	endpoint     string // scheme://host[:port] of the S3 API
+	pathStyle    bool   // true: endpoint/bucket/key, false: bucket.endpoint/key
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	httpClient   *http.Client
+	log          logger.ServiceLogger
+
+	now func() time.Time // overridable for signing tests
+}
+
+func NewS3Store(log logger.ServiceLogger) *S3Store {
+	return &S3Store{
+		log: log,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+		now: time.Now,
+	}
+}
+
+// Connect parses the DSN, resolves credentials and makes sure the bucket is reachable.
+//
+// Supported DSN formats:
+//   - s3://bucket-name                                   (AWS, virtual-hosted style)
+//   - s3://endpoint[:port]/bucket-name                   (custom endpoint, path style)
+//   - s3://ACCESS_KEY:SECRET_KEY@endpoint/bucket-name    (inline credentials)
+//
+// Optional query parameters: region, path_style (true/false), secure (true/false).
+// Credentials not present in the DSN are read from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY
+// and AWS_SESSION_TOKEN; the region falls back to AWS_REGION / AWS_DEFAULT_REGION.
+func (s *S3Store) Connect(dsn string) error {
+	config, err := store.ParseStorageDSN(dsn)
+	if err != nil {
+		return fmt.Errorf("failed to parse storage DSN: %w", err)
+	}
+
+	if config.Type != "s3" {
+		return fmt.Errorf("invalid storage type for s3 store: %s", config.Type)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return fmt.Errorf("failed to parse storage DSN: %w", err)
+	}
+	query := u.Query()
+
+	s.bucket = config.Path
+	if strings.Contains(s.bucket, "/") {
+		return fmt.Errorf("s3 bucket name cannot contain '/': %s", s.bucket)
+	}
+
+	// Region: DSN, then environment, then the S3 default
+	s.region = firstNonEmpty(query.Get("region"), os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), defaultRegion)
+
+	// Credentials: DSN userinfo takes priority over the environment
+	if u.User != nil {
+		s.accessKey = u.User.Username()
+		s.secretKey, _ = u.User.Password()
+	}
+	if s.accessKey == "" {
+		s.accessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		s.secretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		s.sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if s.accessKey == "" || s.secretKey == "" {
+		return fmt.Errorf("s3 credentials not found in DSN or environment")
+	}
+
+	secure, err := parseBoolParam(query, "secure", true)
+	if err != nil {
+		return err
+	}
+	scheme := "https"
+	if !secure {
+		scheme = "http"
+	}
+
+	if config.Host == "" {
+		// AWS S3: virtual-hosted style is the default there
+		s.endpoint = fmt.Sprintf("%s://s3.%s.amazonaws.com", scheme, s.region)
+		s.pathStyle, err = parseBoolParam(query, "path_style", false)
+	} else {
+		// Custom endpoints (MinIO and friends) generally only support path style
+		s.endpoint = fmt.Sprintf("%s://%s", scheme, config.Host)
+		s.pathStyle, err = parseBoolParam(query, "path_style", true)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.log.Debug(fmt.Sprintf("Initializing s3 store for bucket %s at %s (path style: %t)", s.bucket, s.endpoint, s.pathStyle))
+
+	if err := s.ensureBucket(context.Background()); err != nil {
+		s.log.Error(fmt.Sprintf("Failed to access bucket: %s", s.bucket), err)
+		return err
+	}
+
+	s.log.Info(fmt.Sprintf("S3 store initialized for bucket: %s", s.bucket))
+	return nil
+}
+
+// PutObject uploads an object to the configured bucket
+func (s *S3Store) PutObject(ctx context.Context, key string, data io.Reader, size int64) error {
+	s.log.Debug(fmt.Sprintf("Uploading object with key: %s", key))
+
+	cleanKey := sanitizeKey(key)
+	if cleanKey == "" {
+		return fmt.Errorf("object key cannot be empty")
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, cleanKey, data, size)
+	if err != nil {
+		return fmt.Errorf("failed to create put request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Failed to upload object with key: %s", key), err)
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := responseError(resp)
+		s.log.Error(fmt.Sprintf("Failed to upload object with key: %s", key), err)
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+
+	s.log.Info(fmt.Sprintf("Successfully uploaded object with key: %s", key))
+	return nil
+}
+
+// GetObject downloads an object from the configured bucket. The caller must close the reader.
+func (s *S3Store) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.log.Debug(fmt.Sprintf("Downloading object with key: %s", key))
+
+	cleanKey := sanitizeKey(key)
+	if cleanKey == "" {
+		return nil, fmt.Errorf("object not found: %s", key)
+	}
+
+	req, err := s.newRequest(ctx, http.MethodGet, cleanKey, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create get request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Failed to download object with key: %s", key), err)
+		return nil, fmt.Errorf("failed to download object: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		s.log.Debug(fmt.Sprintf("Successfully opened object with key: %s", key))
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		s.log.Debug(fmt.Sprintf("Object not found with key: %s", key))
+		return nil, fmt.Errorf("object not found: %s", key)
+	default:
+		defer resp.Body.Close()
+		err := responseError(resp)
+		s.log.Error(fmt.Sprintf("Failed to download object with key: %s", key), err)
+		return nil, fmt.Errorf("failed to download object: %w", err)
+	}
+}
+
+// DeleteObject deletes an object from the configured bucket.
+// S3 deletes are idempotent, so the object is checked first to keep the same
+// "object not found" semantics as the local store.
+func (s *S3Store) DeleteObject(ctx context.Context, key string) error {
+	s.log.Debug(fmt.Sprintf("Deleting object with key: %s", key))
+
+	cleanKey := sanitizeKey(key)
+	if cleanKey == "" {
+		return fmt.Errorf("object not found: %s", key)
+	}
+
+	exists, err := s.objectExists(ctx, cleanKey)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Failed to check object with key: %s", key), err)
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	if !exists {
+		s.log.Debug(fmt.Sprintf("Object not found for deletion with key: %s", key))
+		return fmt.Errorf("object not found: %s", key)
+	}
+
+	req, err := s.newRequest(ctx, http.MethodDelete, cleanKey, nil, 0)
+	if err != nil {
+		return fmt.Errorf("failed to create delete request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("Failed to delete object with key: %s", key), err)
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		err := responseError(resp)
+		s.log.Error(fmt.Sprintf("Failed to delete object with key: %s", key), err)
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+
+	s.log.Info(fmt.Sprintf("Successfully deleted object with key: %s", key))
+	return nil
+}
+
+// Close releases idle HTTP connections held by the store
+func (s *S3Store) Close() error {
+	s.log.Debug("Closing s3 store")
+	s.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// ensureBucket checks the bucket exists and creates it when missing
+func (s *S3Store) ensureBucket(ctx context.Context) error {
+	req, err := s.newRequest(ctx, http.MethodHead, "", nil, 0)
+	if err != nil {
+		return fmt.Errorf("failed to create bucket request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach s3 endpoint: %w", err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		s.log.Info(fmt.Sprintf("Bucket %s does not exist, creating it", s.bucket))
+		return s.createBucket(ctx)
+	default:
+		return fmt.Errorf("failed to access bucket %s: status %d", s.bucket, resp.StatusCode)
+	}
+}
+
+type createBucketConfiguration struct {
+	XMLName            xml.Name `xml:"CreateBucketConfiguration"`
+	Xmlns              string   `xml:"xmlns,attr"`
+	LocationConstraint string   `xml:"LocationConstraint"`
+}
+
+func (s *S3Store) createBucket(ctx context.Context) error {
+	var body []byte
+	// us-east-1 is the only region that rejects an explicit location constraint
+	if s.region != defaultRegion {
+		encoded, err := xml.Marshal(createBucketConfiguration{
+			Xmlns:              "http://s3.amazonaws.com/doc/2006-03-01/",
+			LocationConstraint: s.region,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal bucket configuration: %w", err)
+		}
+		body = encoded
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, "", bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return fmt.Errorf("failed to create bucket request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to create bucket: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to create bucket %s: %w", s.bucket, responseError(resp))
+	}
+	return nil
+}
+
+func (s *S3Store) objectExists(ctx context.Context, key string) (bool, error) {
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil, 0)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+}
+
+// objectURL builds the request URL for a key (an empty key addresses the bucket itself)
+func (s *S3Store) objectURL(key string) (*url.URL, error) {
+	u, err := url.Parse(s.endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.pathStyle {
+		u.Path = "/" + s.bucket
+		if key != "" {
+			u.Path += "/" + key
+		}
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+	u.RawPath = encodePath(u.Path)
+	return u, nil
+}
+
+// newRequest builds a signed request. Request bodies are sent as UNSIGNED-PAYLOAD so
+// uploads can be streamed without buffering them to compute a checksum first.
+func (s *S3Store) newRequest(ctx context.Context, method, key string, body io.Reader, size int64) (*http.Request, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	payloadHash := emptyPayloadSum
+	if body != nil {
+		req.ContentLength = size
+		payloadHash = unsignedPayload
+	}
+
+	s.signRequest(req, payloadHash)
+	return req, nil
+}
+
+// signRequest adds AWS Signature Version 4 headers to the request
+func (s *S3Store) signRequest(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	shortDate := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	// Canonical headers must be lowercase and sorted
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-date":           amzDate,
+		"x-amz-content-sha256": payloadHash,
+	}
+	if s.sessionToken != "" {
+		headers["x-amz-security-token"] = s.sessionToken
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", shortDate, s.region)
+	stringToSign := strings.Join([]string{
+		signingAlgo,
+		amzDate,
+		scope,
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.secretKey), shortDate)
+	signingKey = hmacSHA256(signingKey, s.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signingAlgo, s.accessKey, scope, signedHeaders, signature))
+}
+
+// s3Error is the XML error body returned by S3-compatible APIs
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	var parsed s3Error
+	if err := xml.Unmarshal(body, &parsed); err == nil && parsed.Code != "" {
+		return fmt.Errorf("s3 returned status %d: %s: %s", resp.StatusCode, parsed.Code, parsed.Message)
+	}
+	return fmt.Errorf("s3 returned status %d", resp.StatusCode)
+}
+
+// sanitizeKey normalises keys so they map to the same objects the local store would write
+func sanitizeKey(key string) string {
+	clean := strings.ReplaceAll(key, "\\", "/")
+	clean = strings.ReplaceAll(clean, "\x00", "")
+
+	parts := strings.Split(clean, "/")
+	var stack []string
+	for _, part := range parts {
+		if part == "" || part == "." {
+			continue
+		} else if part == ".." {
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		} else {
+			stack = append(stack, part)
+		}
+	}
+
+	return strings.Join(stack, "/")
+}
+
+// encodePath URI-encodes every path segment as required by SigV4 (RFC 3986 unreserved set)
+func encodePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func canonicalQuery(values url.Values) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		vals := append([]string(nil), values[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			pairs = append(pairs, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func parseBoolParam(query url.Values, name string, defaultValue bool) (bool, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s value in storage DSN: %s", name, raw)
+	}
+	return value, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
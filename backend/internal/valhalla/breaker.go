@@ -0,0 +1,71 @@
+package valhalla
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling Valhalla while the circuit breaker is open
+var ErrCircuitOpen = errors.New("valhalla circuit breaker is open")
+
+// outcome is how a request counts towards the circuit breaker
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota // Valhalla answered, including rejections of bad requests
+	outcomeFailure                // Valhalla was unreachable, timed out or failed
+	outcomeIgnored                // the caller gave up, which says nothing about Valhalla
+)
+
+// circuitBreaker stops calls to Valhalla after threshold consecutive failures. While open it rejects requests
+// for cooldown, then lets a single trial request through: success closes the circuit, failure opens it again.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int // 0 disables the breaker
+	cooldown  time.Duration
+	now       func() time.Time
+
+	failures int
+	open     bool
+	openedAt time.Time
+	trial    bool // a half-open trial request is in flight
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow reports whether a request may be sent
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 || !b.open {
+		return true
+	}
+	if b.now().Sub(b.openedAt) < b.cooldown || b.trial {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// record updates the breaker with the outcome of an allowed request
+func (b *circuitBreaker) record(result outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch result {
+	case outcomeSuccess:
+		b.failures = 0
+		b.open = false
+	case outcomeFailure:
+		b.failures++
+		if b.trial || (b.threshold > 0 && b.failures >= b.threshold) {
+			b.open = true
+			b.openedAt = b.now()
+		}
+	}
+	b.trial = false
+}
@@ -0,0 +1,105 @@
+package valhalla
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// heightCache is a small LRU cache of height responses keyed by a hash of the request, so re-processing the
+// same track, such as a re-upload or a retried import, doesn't call Valhalla again
+type heightCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front = most recently used
+	entries  map[string]*list.Element
+}
+
+type heightCacheEntry struct {
+	key      string
+	response *HeightResponse
+}
+
+// newHeightCache creates a cache holding at most capacity responses, nil when capacity is zero or less
+func newHeightCache(capacity int) *heightCache {
+	if capacity <= 0 {
+		return nil
+	}
+	return &heightCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// heightCacheKey hashes everything that affects a height response
+func heightCacheKey(req HeightRequest) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%t|%d|%s", req.Range, req.HeightPrecision, req.EncodedPolyline)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns a copy of the cached response for key, marking it as recently used
+func (c *heightCache) Get(key string) (*HeightResponse, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return copyHeightResponse(elem.Value.(*heightCacheEntry).response), true
+}
+
+// Put stores a copy of a response for key, evicting the least recently used entry when full
+func (c *heightCache) Put(key string, response *HeightResponse) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*heightCacheEntry).response = copyHeightResponse(response)
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&heightCacheEntry{key: key, response: copyHeightResponse(response)})
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*heightCacheEntry).key)
+	}
+}
+
+// Len returns the number of cached responses
+func (c *heightCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// copyHeightResponse copies a response so callers can't modify cached heights
+func copyHeightResponse(resp *HeightResponse) *HeightResponse {
+	if resp.Height == nil {
+		return &HeightResponse{EncodedPolyline: resp.EncodedPolyline}
+	}
+	heights := make([]*float64, len(resp.Height))
+	for i, h := range resp.Height {
+		if h != nil {
+			v := *h
+			heights[i] = &v
+		}
+	}
+	return &HeightResponse{EncodedPolyline: resp.EncodedPolyline, Height: heights}
+}
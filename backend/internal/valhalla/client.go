@@ -4,24 +4,80 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/geo"
+)
+
+// Default client options
+const (
+	DefaultTimeout           = 30 * time.Second
+	DefaultMaxRetries        = 2
+	DefaultRetryBackoff      = 500 * time.Millisecond
+	DefaultBreakerThreshold  = 5
+	DefaultBreakerCooldown   = 30 * time.Second
+	DefaultHeightChunkPoints = 5000
+	DefaultHeightCacheSize   = 256
+
+	maxRetryBackoff = 10 * time.Second
 )
 
+// Options configures how a Client copes with a slow or failing Valhalla
+type Options struct {
+	Timeout           time.Duration // per HTTP attempt
+	MaxRetries        int           // retries after the first attempt for network errors, 429 and 5xx responses
+	RetryBackoff      time.Duration // wait before the first retry, doubled for every further retry
+	BreakerThreshold  int           // consecutive failed requests that open the circuit breaker, 0 disables it
+	BreakerCooldown   time.Duration // how long an open circuit rejects requests before trying Valhalla again
+	HeightChunkPoints int           // longer polylines are split into several /height requests, 0 disables chunking
+	HeightCacheSize   int           // height responses cached by request hash, 0 disables caching
+}
+
+// DefaultOptions returns the options used by NewClient
+func DefaultOptions() Options {
+	return Options{
+		Timeout:           DefaultTimeout,
+		MaxRetries:        DefaultMaxRetries,
+		RetryBackoff:      DefaultRetryBackoff,
+		BreakerThreshold:  DefaultBreakerThreshold,
+		BreakerCooldown:   DefaultBreakerCooldown,
+		HeightChunkPoints: DefaultHeightChunkPoints,
+		HeightCacheSize:   DefaultHeightCacheSize,
+	}
+}
+
 // Client represents a Valhalla API client
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	opts       Options
+	breaker    *circuitBreaker
+	heights    *heightCache // nil when caching is disabled
+
+	// sleep waits between retries, returning early with the context's error
+	sleep func(ctx context.Context, d time.Duration) error
 }
 
 func NewClient(baseURL string) *Client {
+	return NewClientWithOptions(baseURL, DefaultOptions())
+}
+
+// NewClientWithOptions creates a client with custom retry, circuit breaker, chunking and caching options
+func NewClientWithOptions(baseURL string, opts Options) *Client {
 	return &Client{
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: opts.Timeout,
 		},
+		opts:    opts,
+		breaker: newCircuitBreaker(opts.BreakerThreshold, opts.BreakerCooldown),
+		heights: newHeightCache(opts.HeightCacheSize),
+		sleep:   sleepContext,
 	}
 }
 
@@ -37,46 +93,165 @@ type HeightResponse struct {
 	Height          []*float64 `json:"height"` // Array of nullable floats for decimal precision
 }
 
+// GetHeight looks up the height of every point of a polyline. Responses are cached by a hash of the request,
+// and polylines longer than Options.HeightChunkPoints are sent in chunks whose heights are joined in order.
 func (c *Client) GetHeight(ctx context.Context, req HeightRequest) (*HeightResponse, error) {
 	if req.EncodedPolyline == "" {
 		return nil, fmt.Errorf("encoded_polyline cannot be empty")
 	}
 
+	key := heightCacheKey(req)
+	if cached, ok := c.heights.Get(key); ok {
+		return cached, nil
+	}
+
+	var result *HeightResponse
+	chunks, points := c.heightChunks(req)
+	if chunks == nil {
+		var err error
+		if result, err = c.getHeight(ctx, req); err != nil {
+			return nil, err
+		}
+	} else {
+		result = &HeightResponse{EncodedPolyline: req.EncodedPolyline, Height: make([]*float64, 0, points)}
+		for _, chunk := range chunks {
+			resp, err := c.getHeight(ctx, HeightRequest{EncodedPolyline: chunk.polyline, HeightPrecision: req.HeightPrecision})
+			if err != nil {
+				return nil, err
+			}
+			if len(resp.Height) != chunk.points {
+				return nil, fmt.Errorf("height API returned %d heights for %d points", len(resp.Height), chunk.points)
+			}
+			result.Height = append(result.Height, resp.Height...)
+		}
+	}
+
+	c.heights.Put(key, result)
+	return result, nil
+}
+
+// getHeight sends a single /height request
+func (c *Client) getHeight(ctx context.Context, req HeightRequest) (*HeightResponse, error) {
 	requestBody := map[string]interface{}{
 		"range":            req.Range,
 		"encoded_polyline": req.EncodedPolyline,
 		"height_precision": req.HeightPrecision,
 	}
 
+	var result HeightResponse
+	if err := c.post(ctx, "height", requestBody, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// heightChunk is part of a polyline sent in its own /height request
+type heightChunk struct {
+	polyline string
+	points   int
+}
+
+// heightChunks splits a request's polyline into chunks of at most Options.HeightChunkPoints points, returning nil
+// when it fits in one request. Range requests are never split since their distances run along the whole polyline.
+func (c *Client) heightChunks(req HeightRequest) ([]heightChunk, int) {
+	if req.Range || c.opts.HeightChunkPoints <= 0 {
+		return nil, 0
+	}
+	points, err := geo.Decode6(req.EncodedPolyline)
+	if err != nil || len(points) <= c.opts.HeightChunkPoints {
+		return nil, 0
+	}
+
+	var chunks []heightChunk
+	for start := 0; start < len(points); start += c.opts.HeightChunkPoints {
+		end := min(start+c.opts.HeightChunkPoints, len(points))
+		chunks = append(chunks, heightChunk{polyline: geo.Encode6(points[start:end]), points: end - start})
+	}
+	return chunks, len(points)
+}
+
+// post sends a JSON request to a Valhalla endpoint such as "height" and decodes the JSON response into result.
+// Network errors, 429 and 5xx responses are retried with exponential backoff, and every request is rejected
+// with ErrCircuitOpen while the circuit breaker is open.
+func (c *Client) post(ctx context.Context, endpoint string, requestBody interface{}, result interface{}) error {
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal height request: %w", err)
+		return fmt.Errorf("failed to marshal %s request: %w", endpoint, err)
+	}
+
+	if !c.breaker.allow() {
+		return fmt.Errorf("%s API unavailable: %w", endpoint, ErrCircuitOpen)
 	}
 
-	url := fmt.Sprintf("%s/height", c.baseURL)
+	backoff := c.opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		var failed bool
+		retry, failed, err = c.send(ctx, endpoint, jsonData, result)
+
+		if ctx.Err() != nil {
+			c.breaker.record(outcomeIgnored)
+			return err
+		}
+		if !retry || attempt >= c.opts.MaxRetries {
+			if failed {
+				c.breaker.record(outcomeFailure)
+			} else {
+				c.breaker.record(outcomeSuccess)
+			}
+			return err
+		}
+
+		if sleepErr := c.sleep(ctx, backoff); sleepErr != nil {
+			c.breaker.record(outcomeIgnored)
+			return err
+		}
+		backoff = min(2*backoff, maxRetryBackoff)
+	}
+}
+
+// send makes a single attempt of a request. It reports whether a failed attempt is worth retrying and whether
+// it counts as a Valhalla failure for the circuit breaker; requests Valhalla rejected as invalid count as neither.
+func (c *Client) send(ctx context.Context, endpoint string, jsonData []byte, result interface{}) (retry bool, failed bool, err error) {
+	url := fmt.Sprintf("%s/%s", c.baseURL, endpoint)
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create height request: %w", err)
+		return false, false, fmt.Errorf("failed to create %s request: %w", endpoint, err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("height API request failed: %w", err)
+		// Timeouts aren't retried so a hanging Valhalla costs a single timeout
+		var netErr net.Error
+		timeout := errors.As(err, &netErr) && netErr.Timeout()
+		return !timeout, true, fmt.Errorf("%s API request failed: %w", endpoint, err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read height response: %w", err)
+		return true, true, fmt.Errorf("failed to read %s response: %w", endpoint, err)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("height API returned status %d: %s", resp.StatusCode, string(body))
+		serviceFailure := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return serviceFailure, serviceFailure, fmt.Errorf("%s API returned status %d: %s", endpoint, resp.StatusCode, string(body))
 	}
 
-	var result HeightResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse height response: %w", err)
+	if err := json.Unmarshal(body, result); err != nil {
+		return false, true, fmt.Errorf("failed to parse %s response: %w", endpoint, err)
+	}
+	return false, false, nil
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	return &result, nil
 }
@@ -0,0 +1,327 @@
+package valhalla
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anish-chanda/cadent/backend/internal/geo"
+)
+
+// fakeValhalla is a /height server that answers with scripted status codes, then 200 with one height per point
+type fakeValhalla struct {
+	mu       sync.Mutex
+	statuses []int // consumed one per request; 200 once exhausted
+	requests []HeightRequest
+}
+
+func newFakeValhalla(t *testing.T, statuses ...int) (*fakeValhalla, *httptest.Server) {
+	t.Helper()
+	fake := &fakeValhalla{statuses: statuses}
+	server := httptest.NewServer(http.HandlerFunc(fake.serveHeight))
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeValhalla) serveHeight(w http.ResponseWriter, r *http.Request) {
+	var req HeightRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	status := http.StatusOK
+	if len(f.statuses) > 0 {
+		status, f.statuses = f.statuses[0], f.statuses[1:]
+	}
+	f.mu.Unlock()
+
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	points, err := geo.Decode6(req.EncodedPolyline)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := HeightResponse{EncodedPolyline: req.EncodedPolyline}
+	for _, p := range points {
+		h := p.Lat * 100
+		resp.Height = append(resp.Height, &h)
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (f *fakeValhalla) calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests)
+}
+
+// testClient creates a client whose retry waits are recorded instead of slept
+func testClient(baseURL string, opts Options) (*Client, *[]time.Duration) {
+	client := NewClientWithOptions(baseURL, opts)
+	var sleeps []time.Duration
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return ctx.Err()
+	}
+	return client, &sleeps
+}
+
+// testPolyline encodes n points north along a meridian
+func testPolyline(n int) string {
+	points := make([]geo.Point, n)
+	for i := range points {
+		points[i] = geo.Point{Lat: 45 + float64(i)*0.001, Lon: 7}
+	}
+	return geo.Encode6(points)
+}
+
+func TestDefaultOptions(t *testing.T) {
+	client := NewClient("https://api.example.com")
+	if client.opts != DefaultOptions() {
+		t.Errorf("NewClient() options = %+v, want %+v", client.opts, DefaultOptions())
+	}
+	if client.heights == nil || client.breaker.threshold != DefaultBreakerThreshold {
+		t.Error("Expected the height cache and circuit breaker to be enabled by default")
+	}
+
+	disabled := NewClientWithOptions("https://api.example.com", Options{Timeout: time.Second})
+	if disabled.heights != nil || disabled.httpClient.Timeout != time.Second {
+		t.Error("Expected a zero cache size to disable the height cache")
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	req := HeightRequest{EncodedPolyline: testPolyline(3), HeightPrecision: 2}
+	opts := Options{MaxRetries: 2, RetryBackoff: 100 * time.Millisecond}
+
+	t.Run("retries server errors then succeeds", func(t *testing.T) {
+		fake, server := newFakeValhalla(t, http.StatusBadGateway, http.StatusTooManyRequests)
+		client, sleeps := testClient(server.URL, opts)
+
+		resp, err := client.GetHeight(context.Background(), req)
+		if err != nil {
+			t.Fatalf("GetHeight() error = %v", err)
+		}
+		if len(resp.Height) != 3 || fake.calls() != 3 {
+			t.Errorf("Got %d heights after %d calls, want 3 heights after 3 calls", len(resp.Height), fake.calls())
+		}
+		if len(*sleeps) != 2 || (*sleeps)[0] != 100*time.Millisecond || (*sleeps)[1] != 200*time.Millisecond {
+			t.Errorf("Retry waits = %v, want [100ms 200ms]", *sleeps)
+		}
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		fake, server := newFakeValhalla(t, 500, 500, 500, 500)
+		client, _ := testClient(server.URL, opts)
+
+		_, err := client.GetHeight(context.Background(), req)
+		if err == nil || !strings.Contains(err.Error(), "height API returned status 500") {
+			t.Errorf("GetHeight() error = %v, want the last status 500 error", err)
+		}
+		if fake.calls() != 3 {
+			t.Errorf("Expected 3 attempts, got %d", fake.calls())
+		}
+	})
+
+	t.Run("does not retry rejected requests", func(t *testing.T) {
+		fake, server := newFakeValhalla(t, http.StatusBadRequest)
+		client, sleeps := testClient(server.URL, opts)
+
+		if _, err := client.GetHeight(context.Background(), req); err == nil {
+			t.Error("Expected an error for a 400 response")
+		}
+		if fake.calls() != 1 || len(*sleeps) != 0 {
+			t.Errorf("Expected a single attempt, got %d", fake.calls())
+		}
+	})
+
+	t.Run("stops when the context is cancelled during backoff", func(t *testing.T) {
+		fake, server := newFakeValhalla(t, 503, 503, 503)
+		client := NewClientWithOptions(server.URL, Options{MaxRetries: 5, RetryBackoff: time.Hour, BreakerThreshold: 1})
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		if _, err := client.GetHeight(ctx, req); err == nil {
+			t.Error("Expected an error when the context is cancelled")
+		}
+		if time.Since(start) > 5*time.Second || fake.calls() != 1 {
+			t.Errorf("Expected to give up during the first backoff, took %v and %d calls", time.Since(start), fake.calls())
+		}
+		if !client.breaker.allow() {
+			t.Error("Expected a cancelled request not to open the circuit breaker")
+		}
+	})
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	req := HeightRequest{EncodedPolyline: testPolyline(3), HeightPrecision: 2}
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	newBreakerClient := func(t *testing.T, statuses ...int) (*fakeValhalla, *Client) {
+		fake, server := newFakeValhalla(t, statuses...)
+		client, _ := testClient(server.URL, Options{BreakerThreshold: 2, BreakerCooldown: 30 * time.Second})
+		client.breaker.now = func() time.Time { return now }
+		return fake, client
+	}
+
+	t.Run("opens after consecutive failures and closes after a successful trial", func(t *testing.T) {
+		fake, client := newBreakerClient(t, 500, 500)
+		for i := 0; i < 2; i++ {
+			if _, err := client.GetHeight(context.Background(), req); err == nil {
+				t.Fatal("Expected a server error")
+			}
+		}
+
+		_, err := client.GetHeight(context.Background(), req)
+		if !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("GetHeight() error = %v, want ErrCircuitOpen", err)
+		}
+		if fake.calls() != 2 {
+			t.Errorf("Expected the open circuit to skip Valhalla, got %d calls", fake.calls())
+		}
+
+		now = now.Add(31 * time.Second)
+		if _, err := client.GetHeight(context.Background(), req); err != nil {
+			t.Fatalf("Expected the trial request after the cooldown to succeed, got %v", err)
+		}
+		if _, err := client.GetHeight(context.Background(), HeightRequest{EncodedPolyline: testPolyline(4)}); err != nil {
+			t.Errorf("Expected the circuit to close after a successful trial, got %v", err)
+		}
+	})
+
+	t.Run("reopens when the trial fails", func(t *testing.T) {
+		fake, client := newBreakerClient(t, 500, 500, 500)
+		for i := 0; i < 2; i++ {
+			_, _ = client.GetHeight(context.Background(), req)
+		}
+
+		now = now.Add(31 * time.Second)
+		if _, err := client.GetHeight(context.Background(), req); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Expected the trial request to reach Valhalla and fail, got %v", err)
+		}
+		if _, err := client.GetHeight(context.Background(), req); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected the circuit to reopen after a failed trial, got %v", err)
+		}
+		if fake.calls() != 3 {
+			t.Errorf("Expected 3 calls, got %d", fake.calls())
+		}
+	})
+
+	t.Run("rejected requests don't count as failures", func(t *testing.T) {
+		_, client := newBreakerClient(t, 400, 400, 400)
+		for i := 0; i < 3; i++ {
+			if _, err := client.GetHeight(context.Background(), req); errors.Is(err, ErrCircuitOpen) {
+				t.Fatal("Expected 4xx responses to leave the circuit closed")
+			}
+		}
+	})
+}
+
+func TestClient_GetHeight_Chunking(t *testing.T) {
+	fake, server := newFakeValhalla(t)
+	client, _ := testClient(server.URL, Options{HeightChunkPoints: 3})
+	polyline := testPolyline(7)
+
+	resp, err := client.GetHeight(context.Background(), HeightRequest{EncodedPolyline: polyline, HeightPrecision: 2})
+	if err != nil {
+		t.Fatalf("GetHeight() error = %v", err)
+	}
+	if fake.calls() != 3 {
+		t.Errorf("Expected 7 points to be sent in 3 chunks, got %d requests", fake.calls())
+	}
+	for i, r := range fake.requests {
+		points, _ := geo.Decode6(r.EncodedPolyline)
+		if want := min(3, 7-3*i); len(points) != want || r.HeightPrecision != 2 {
+			t.Errorf("Chunk %d has %d points and precision %d, want %d points and precision 2", i, len(points), r.HeightPrecision, want)
+		}
+	}
+
+	if resp.EncodedPolyline != polyline || len(resp.Height) != 7 {
+		t.Fatalf("Expected 7 heights for the original polyline, got %d", len(resp.Height))
+	}
+	for i, h := range resp.Height {
+		if want := (45 + float64(i)*0.001) * 100; h == nil || *h < want-1e-6 || *h > want+1e-6 {
+			t.Errorf("Height %d = %v, want %.1f in the original point order", i, h, want)
+		}
+	}
+
+	t.Run("range requests are not split", func(t *testing.T) {
+		fake, server := newFakeValhalla(t)
+		client, _ := testClient(server.URL, Options{HeightChunkPoints: 3})
+		if _, err := client.GetHeight(context.Background(), HeightRequest{Range: true, EncodedPolyline: polyline}); err != nil {
+			t.Fatalf("GetHeight() error = %v", err)
+		}
+		if fake.calls() != 1 {
+			t.Errorf("Expected a single request, got %d", fake.calls())
+		}
+	})
+
+	t.Run("a failed chunk fails the request", func(t *testing.T) {
+		_, server := newFakeValhalla(t, 200, 400)
+		client, _ := testClient(server.URL, Options{HeightChunkPoints: 3, HeightCacheSize: 4})
+		if _, err := client.GetHeight(context.Background(), HeightRequest{EncodedPolyline: polyline}); err == nil {
+			t.Error("Expected an error when a chunk fails")
+		}
+		if client.heights.Len() != 0 {
+			t.Error("Expected failed requests not to be cached")
+		}
+	})
+}
+
+func TestClient_GetHeight_Cache(t *testing.T) {
+	fake, server := newFakeValhalla(t)
+	client, _ := testClient(server.URL, Options{HeightCacheSize: 2})
+	req := HeightRequest{EncodedPolyline: testPolyline(3), HeightPrecision: 2}
+
+	first, err := client.GetHeight(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetHeight() error = %v", err)
+	}
+	*first.Height[0] = -1
+
+	second, err := client.GetHeight(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetHeight() error = %v", err)
+	}
+	if fake.calls() != 1 {
+		t.Errorf("Expected the repeated request to be served from the cache, got %d calls", fake.calls())
+	}
+	if *second.Height[0] != 4500 {
+		t.Errorf("Cached height = %v, want 4500 unaffected by changes to an earlier response", *second.Height[0])
+	}
+
+	// A different precision is a different request
+	if _, err := client.GetHeight(context.Background(), HeightRequest{EncodedPolyline: req.EncodedPolyline, HeightPrecision: 0}); err != nil {
+		t.Fatalf("GetHeight() error = %v", err)
+	}
+	if fake.calls() != 2 {
+		t.Errorf("Expected a cache miss for a different precision, got %d calls", fake.calls())
+	}
+
+	// A third request evicts the least recently used one
+	if _, err := client.GetHeight(context.Background(), HeightRequest{EncodedPolyline: testPolyline(5), HeightPrecision: 2}); err != nil {
+		t.Fatalf("GetHeight() error = %v", err)
+	}
+	if client.heights.Len() != 2 {
+		t.Errorf("Expected 2 cached responses, got %d", client.heights.Len())
+	}
+	if _, err := client.GetHeight(context.Background(), req); err != nil {
+		t.Fatalf("GetHeight() error = %v", err)
+	}
+	if fake.calls() != 4 {
+		t.Errorf("Expected the evicted request to call Valhalla again, got %d calls", fake.calls())
+	}
+}
@@ -0,0 +1,65 @@
+package valhalla
+
+import (
+	"context"
+	"fmt"
+)
+
+// Route API structures
+type Location struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+type RouteRequest struct {
+	Locations []Location `json:"locations"` // start, any via points and end, in order
+	Costing   string     `json:"costing"`
+}
+
+type RouteSummary struct {
+	Length float64 `json:"length"` // kilometers
+	Time   float64 `json:"time"`   // seconds
+}
+
+type RouteLeg struct {
+	Shape   string       `json:"shape"` // path between two consecutive locations, precision 6
+	Summary RouteSummary `json:"summary"`
+}
+
+type Trip struct {
+	Legs    []RouteLeg   `json:"legs"`
+	Summary RouteSummary `json:"summary"`
+}
+
+type RouteResponse struct {
+	Trip Trip `json:"trip"`
+}
+
+// Route computes a route through req.Locations with Valhalla's /route API. The trip has one leg per pair of
+// consecutive locations, each with its own shape; lengths are in kilometers.
+func (c *Client) Route(ctx context.Context, req RouteRequest) (*RouteResponse, error) {
+	if len(req.Locations) < 2 {
+		return nil, fmt.Errorf("at least 2 locations are required, got %d", len(req.Locations))
+	}
+	if req.Costing == "" {
+		return nil, fmt.Errorf("costing cannot be empty")
+	}
+
+	requestBody := map[string]interface{}{
+		"locations": req.Locations,
+		"costing":   req.Costing,
+		"units":     "kilometers",
+		"directions_options": map[string]interface{}{
+			"directions_type": "none",
+		},
+	}
+
+	var result RouteResponse
+	if err := c.post(ctx, "route", requestBody, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Trip.Legs) == 0 {
+		return nil, fmt.Errorf("route API returned no legs")
+	}
+	return &result, nil
+}
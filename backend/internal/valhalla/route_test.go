@@ -0,0 +1,92 @@
+package valhalla
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_Route_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/route" {
+			t.Errorf("Expected POST /route, got %s %s", r.Method, r.URL.Path)
+		}
+
+		var req struct {
+			Locations []Location `json:"locations"`
+			Costing   string     `json:"costing"`
+			Units     string     `json:"units"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+			return
+		}
+		if len(req.Locations) != 3 || req.Locations[1].Lat != 37.78 || req.Costing != CostingBicycle {
+			t.Errorf("Unexpected request %+v", req)
+		}
+		if req.Units != "kilometers" {
+			t.Errorf("units = %s, want kilometers", req.Units)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"trip": {
+				"status": 0,
+				"legs": [
+					{"shape": "abc", "summary": {"length": 1.25, "time": 300}},
+					{"shape": "def", "summary": {"length": 0.75, "time": 180}}
+				],
+				"summary": {"length": 2.0, "time": 480}
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	resp, err := NewClient(server.URL).Route(context.Background(), RouteRequest{
+		Locations: []Location{{Lat: 37.77, Lon: -122.42}, {Lat: 37.78, Lon: -122.41}, {Lat: 37.79, Lon: -122.40}},
+		Costing:   CostingBicycle,
+	})
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+
+	if len(resp.Trip.Legs) != 2 || resp.Trip.Legs[1].Shape != "def" {
+		t.Fatalf("Unexpected legs %+v", resp.Trip.Legs)
+	}
+	if resp.Trip.Summary.Length != 2.0 || resp.Trip.Summary.Time != 480 {
+		t.Errorf("Summary = %+v, want 2 km in 480 s", resp.Trip.Summary)
+	}
+}
+
+func TestClient_Route_Errors(t *testing.T) {
+	client := NewClient("http://localhost")
+	if _, err := client.Route(context.Background(), RouteRequest{Locations: []Location{{Lat: 1, Lon: 1}}, Costing: CostingPedestrian}); err == nil {
+		t.Error("Expected error for a single location")
+	}
+	if _, err := client.Route(context.Background(), RouteRequest{Locations: []Location{{Lat: 1, Lon: 1}, {Lat: 2, Lon: 2}}}); err == nil {
+		t.Error("Expected error for empty costing")
+	}
+
+	locations := []Location{{Lat: 1, Lon: 1}, {Lat: 2, Lon: 2}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error_code":442,"error":"No path could be found for input"}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL).Route(context.Background(), RouteRequest{Locations: locations, Costing: CostingPedestrian})
+	if err == nil || !strings.Contains(err.Error(), "route API returned status 400") {
+		t.Errorf("Expected status error, got %v", err)
+	}
+
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"trip": {"legs": []}}`))
+	}))
+	defer empty.Close()
+
+	if _, err := NewClient(empty.URL).Route(context.Background(), RouteRequest{Locations: locations, Costing: CostingPedestrian}); err == nil {
+		t.Error("Expected error for a trip without legs")
+	}
+}
@@ -0,0 +1,64 @@
+package valhalla
+
+import (
+	"context"
+	"fmt"
+)
+
+// Costing models used to match tracks to the network and to plan routes
+const (
+	CostingPedestrian = "pedestrian"
+	CostingBicycle    = "bicycle"
+)
+
+// Match types of points in a trace_attributes response
+const (
+	MatchTypeMatched      = "matched"      // snapped onto an edge
+	MatchTypeInterpolated = "interpolated" // placed along the path between matched points
+	MatchTypeUnmatched    = "unmatched"    // no edge found nearby, position unchanged
+)
+
+// Trace attributes API structures
+type TraceRequest struct {
+	EncodedPolyline string `json:"encoded_polyline"` // GPS track, precision 6
+	Costing         string `json:"costing"`
+}
+
+type MatchedPoint struct {
+	Lat                    float64 `json:"lat"`
+	Lon                    float64 `json:"lon"`
+	Type                   string  `json:"type"`
+	DistanceFromTracePoint float64 `json:"distance_from_trace_point"` // meters between the GPS point and its match
+}
+
+type TraceResponse struct {
+	MatchedPoints []MatchedPoint `json:"matched_points"` // one per input point, in order
+	Shape         string         `json:"shape"`          // matched path along the network, precision 6
+}
+
+// TraceAttributes map-matches a GPS track to the road and path network with Valhalla's /trace_attributes API.
+// Every point is snapped onto the matched path (shape_match map_snap) and returned in MatchedPoints.
+func (c *Client) TraceAttributes(ctx context.Context, req TraceRequest) (*TraceResponse, error) {
+	if req.EncodedPolyline == "" {
+		return nil, fmt.Errorf("encoded_polyline cannot be empty")
+	}
+	if req.Costing == "" {
+		return nil, fmt.Errorf("costing cannot be empty")
+	}
+
+	requestBody := map[string]interface{}{
+		"encoded_polyline": req.EncodedPolyline,
+		"costing":          req.Costing,
+		"shape_match":      "map_snap",
+		"filters": map[string]interface{}{
+			"attributes": []string{"matched.point", "matched.type", "matched.distance_from_trace_point", "shape"},
+			"action":     "include",
+		},
+	}
+
+	var result TraceResponse
+	if err := c.post(ctx, "trace_attributes", requestBody, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
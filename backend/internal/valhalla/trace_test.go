@@ -0,0 +1,77 @@
+package valhalla
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_TraceAttributes_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/trace_attributes" {
+			t.Errorf("Expected POST /trace_attributes, got %s %s", r.Method, r.URL.Path)
+		}
+
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+			return
+		}
+		if req["encoded_polyline"] != "abc" || req["costing"] != CostingPedestrian {
+			t.Errorf("Unexpected request %v", req)
+		}
+		if req["shape_match"] != "map_snap" {
+			t.Errorf("shape_match = %v, want map_snap", req["shape_match"])
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"shape": "xyz",
+			"matched_points": [
+				{"lat": 37.7749, "lon": -122.4194, "type": "matched", "edge_index": 0, "distance_from_trace_point": 12.5},
+				{"lat": 37.7759, "lon": -122.4190, "type": "unmatched"}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	resp, err := client.TraceAttributes(context.Background(), TraceRequest{EncodedPolyline: "abc", Costing: CostingPedestrian})
+	if err != nil {
+		t.Fatalf("TraceAttributes() error = %v", err)
+	}
+
+	if resp.Shape != "xyz" || len(resp.MatchedPoints) != 2 {
+		t.Fatalf("Unexpected response %+v", resp)
+	}
+	first := resp.MatchedPoints[0]
+	if first.Lat != 37.7749 || first.Lon != -122.4194 || first.Type != MatchTypeMatched || first.DistanceFromTracePoint != 12.5 {
+		t.Errorf("MatchedPoints[0] = %+v", first)
+	}
+	if resp.MatchedPoints[1].Type != MatchTypeUnmatched {
+		t.Errorf("MatchedPoints[1].Type = %s, want unmatched", resp.MatchedPoints[1].Type)
+	}
+}
+
+func TestClient_TraceAttributes_Errors(t *testing.T) {
+	client := NewClient("http://localhost")
+	if _, err := client.TraceAttributes(context.Background(), TraceRequest{Costing: CostingBicycle}); err == nil {
+		t.Error("Expected error for empty polyline")
+	}
+	if _, err := client.TraceAttributes(context.Background(), TraceRequest{EncodedPolyline: "abc"}); err == nil {
+		t.Error("Expected error for empty costing")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error_code":442,"error":"No suitable edges near location"}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL).TraceAttributes(context.Background(), TraceRequest{EncodedPolyline: "abc", Costing: CostingBicycle})
+	if err == nil || !strings.Contains(err.Error(), "trace_attributes API returned status 400") {
+		t.Errorf("Expected status error, got %v", err)
+	}
+}
@@ -13,10 +13,13 @@ import (
 
 	"github.com/anish-chanda/cadent/backend/internal/db"
 	"github.com/anish-chanda/cadent/backend/internal/db/postgres"
+	"github.com/anish-chanda/cadent/backend/internal/elevation"
+	"github.com/anish-chanda/cadent/backend/internal/elevation/dem_provider"
 	"github.com/anish-chanda/cadent/backend/internal/handlers"
 	"github.com/anish-chanda/cadent/backend/internal/logger"
 	"github.com/anish-chanda/cadent/backend/internal/store"
 	"github.com/anish-chanda/cadent/backend/internal/store/local_store"
+	"github.com/anish-chanda/cadent/backend/internal/store/s3_store"
 	"github.com/anish-chanda/cadent/backend/internal/valhalla"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -59,8 +62,7 @@ func main() {
 	case "local":
 		objectStore = local_store.NewLocalStore(*log)
 	case "s3":
-		log.Error("S3 storage not yet implemented", nil)
-		return
+		objectStore = s3_store.NewS3Store(*log)
 	default:
 		log.Error(fmt.Sprintf("Unsupported storage type: %s", storageConfig.Type), nil)
 		return
@@ -73,7 +75,33 @@ func main() {
 
 	// Initialize Valhalla client
 	log.Info("Initializing Valhalla client")
-	valhallaClient := valhalla.NewClient(cfg.ValhallaURL)
+	valhallaClient := valhalla.NewClientWithOptions(cfg.ValhallaURL, valhalla.Options{
+		Timeout:           valhalla.DefaultTimeout,
+		MaxRetries:        cfg.ValhallaMaxRetries,
+		RetryBackoff:      time.Duration(cfg.ValhallaRetryBackoffMs) * time.Millisecond,
+		BreakerThreshold:  cfg.ValhallaBreakerThreshold,
+		BreakerCooldown:   time.Duration(cfg.ValhallaBreakerCooldownSeconds) * time.Second,
+		HeightChunkPoints: cfg.ValhallaHeightChunkPoints,
+		HeightCacheSize:   cfg.ValhallaHeightCacheSize,
+	})
+
+	// Initialize elevation provider based on config
+	var elevationProvider elevation.Provider
+	switch cfg.ElevationProvider {
+	case elevation.ProviderValhalla:
+		elevationProvider = valhallaClient
+	case elevation.ProviderDEM:
+		log.Info(fmt.Sprintf("Initializing DEM elevation provider at: %s", cfg.ElevationDEMPath))
+		demProvider, err := dem_provider.NewProvider(cfg.ElevationDEMPath, *log)
+		if err != nil {
+			log.Error("Failed to initialize DEM elevation provider", err)
+			return
+		}
+		elevationProvider = demProvider
+	default:
+		log.Error(fmt.Sprintf("Unsupported elevation provider: %s", cfg.ElevationProvider), nil)
+		return
+	}
 
 	log.Info("Initializing database")
 	database := postgres.NewPostgresDB(*log)
@@ -115,6 +143,14 @@ func main() {
 	// Create auth service with providers
 	authService := authpkg.NewService(authOptions)
 	apiHandler := handlers.NewHandler(database, valhallaClient, objectStore, log)
+	apiHandler.EnableFullStreamCache(cfg.FullStreamCacheSize)
+	decimation, err := handlers.ParseDecimationStrategy(cfg.StreamDecimation)
+	if err != nil {
+		log.Error("Invalid stream decimation strategy", err)
+		return
+	}
+	apiHandler.SetStreamDecimation(decimation)
+	apiHandler.SetElevationProvider(elevationProvider)
 
 	authService.AddDirectProvider("local", provider.CredCheckerFunc(func(user, password string) (ok bool, err error) {
 		return apiHandler.HandleLogin(user, password)
@@ -167,15 +203,40 @@ func main() {
 			r.Get("/training-plans/{id}/workouts", apiHandler.HandleGetTrainingPlanWorkouts())
 			r.Post("/training-plans/{id}/import/dry-run", apiHandler.HandleImportTrainingPlanDryRun())
 			r.Post("/training-plans/{id}/import", apiHandler.HandleImportTrainingPlan())
+			r.Get("/training-plans/enrollments/{id}/progress", apiHandler.HandleGetTrainingPlanProgress())
 
 			// Activity endpoints
 			r.Post("/activities", apiHandler.HandleCreateActivity())
 			r.Get("/activities", apiHandler.HandleGetActivities())
+			r.Get("/activities/{id}", apiHandler.HandleGetActivity())
+			r.Patch("/activities/{id}", apiHandler.HandleUpdateActivity())
+			r.Delete("/activities/{id}", apiHandler.HandleDeleteActivity())
 			r.Get("/activities/{id}/streams", apiHandler.HandleGetActivityStreams())
+			r.Get("/activities/{id}/splits", apiHandler.HandleGetActivitySplits())
+			r.Get("/activities/{id}/laps", apiHandler.HandleGetActivityLaps())
 			r.Post("/activities/plan", apiHandler.HandleCreatePlannedActivity())
 			r.Delete("/activities/plan", apiHandler.HandleDeletePlannedActivity())
 			r.Patch("/activities/plan", apiHandler.HandleUpdatePlannedActivity())
+			r.Put("/activities/plan/{id}/match", apiHandler.HandleLinkPlannedActivity())
+			r.Delete("/activities/plan/{id}/match", apiHandler.HandleUnlinkPlannedActivity())
 			r.Post("/activities/upload", apiHandler.HandleActivityUpload())
+			r.Post("/activities/import", apiHandler.HandleBulkImport())
+			r.Get("/activities/import/{id}", apiHandler.HandleGetImportJob())
+
+			// Routes
+			r.Post("/routes", apiHandler.HandleCreateRoute())
+			r.Get("/routes", apiHandler.HandleGetRoutes())
+			r.Get("/routes/{id}", apiHandler.HandleGetRoute())
+			r.Delete("/routes/{id}", apiHandler.HandleDeleteRoute())
+
+			// Personal records
+			r.Get("/records", apiHandler.HandleGetRecords())
+
+			// Training load
+			r.Get("/training-load", apiHandler.HandleGetTrainingLoad())
+
+			// Summary statistics
+			r.Get("/stats", apiHandler.HandleGetStats())
 
 			// Calendar endpoints
 			r.Get("/calendar", apiHandler.HandleGetActivityCalendar())
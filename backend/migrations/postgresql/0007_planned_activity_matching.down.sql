@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS planned_activities_user_id_start_time_idx;
+DROP INDEX IF EXISTS planned_activities_matched_activity_id_unique;
@@ -0,0 +1,8 @@
+-- A completed activity can satisfy at most one planned activity
+CREATE UNIQUE INDEX planned_activities_matched_activity_id_unique
+    ON planned_activities (matched_activity_id)
+    WHERE matched_activity_id IS NOT NULL;
+
+-- Candidate lookup when matching a new activity against the user's plan for that day
+CREATE INDEX planned_activities_user_id_start_time_idx
+    ON planned_activities (user_id, start_time);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS activities_user_id_start_time_idx;
@@ -0,0 +1,3 @@
+-- Duplicate detection during bulk imports looks activities up by start time
+CREATE INDEX activities_user_id_start_time_idx
+    ON activities (user_id, start_time);
@@ -0,0 +1,11 @@
+ALTER TABLE activity_streams
+    DROP COLUMN IF EXISTS temperature_c_bytes,
+    DROP COLUMN IF EXISTS power_watt_bytes,
+    DROP COLUMN IF EXISTS cadence_rpm_bytes,
+    DROP COLUMN IF EXISTS heart_rate_bpm_bytes;
+
+ALTER TABLE activities
+    DROP COLUMN IF EXISTS max_power_watt,
+    DROP COLUMN IF EXISTS avg_power_watt,
+    DROP COLUMN IF EXISTS max_cadence_rpm,
+    DROP COLUMN IF EXISTS avg_cadence_rpm;
@@ -0,0 +1,13 @@
+-- Summary metrics for cadence and power sensors (avg/max HR already exist)
+ALTER TABLE activities
+    ADD COLUMN avg_cadence_rpm smallint,
+    ADD COLUMN max_cadence_rpm smallint,
+    ADD COLUMN avg_power_watt smallint,
+    ADD COLUMN max_power_watt smallint;
+
+-- Sensor streams, NULL when the source file had no data for the sensor
+ALTER TABLE activity_streams
+    ADD COLUMN heart_rate_bpm_bytes bytea, -- heart rate in beats per minute, compressed
+    ADD COLUMN cadence_rpm_bytes bytea, -- cadence in revolutions (or steps) per minute, compressed
+    ADD COLUMN power_watt_bytes bytea, -- power in watts, compressed
+    ADD COLUMN temperature_c_bytes bytea; -- temperature in degrees celsius, compressed
@@ -0,0 +1,2 @@
+ALTER TABLE activities
+    DROP COLUMN IF EXISTS moving_time_s;
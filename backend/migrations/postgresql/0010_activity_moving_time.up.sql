@@ -0,0 +1,4 @@
+-- Time spent moving in seconds, excluding timer pauses or detected stops.
+-- NULL for activities created before moving time detection.
+ALTER TABLE activities
+    ADD COLUMN moving_time_s integer;
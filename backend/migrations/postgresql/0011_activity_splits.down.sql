@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS activity_splits;
+DROP TYPE IF EXISTS split_unit;
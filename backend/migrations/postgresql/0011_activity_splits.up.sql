@@ -0,0 +1,19 @@
+CREATE TYPE split_unit AS ENUM ('km', 'mi');
+
+-- per-kilometer and per-mile splits, the last split of each unit may be partial
+CREATE TABLE activity_splits (
+    activity_id uuid REFERENCES activities(id) ON DELETE CASCADE,
+    unit split_unit NOT NULL,
+    split_index integer NOT NULL CHECK (split_index > 0), -- 1-based
+
+    distance_m double precision NOT NULL CHECK (distance_m > 0),
+    elapsed_s double precision NOT NULL CHECK (elapsed_s >= 0),
+    moving_s double precision NOT NULL CHECK (moving_s >= 0),
+    elevation_gain_m double precision NOT NULL DEFAULT 0,
+    elevation_loss_m double precision NOT NULL DEFAULT 0,
+    avg_hr_bpm smallint, -- NULL when the activity has no heart rate data
+
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (activity_id, unit, split_index)
+);
@@ -0,0 +1,17 @@
+-- laps recorded by the device (FIT lap messages, GPX track segments)
+CREATE TABLE activity_laps (
+    activity_id uuid REFERENCES activities(id) ON DELETE CASCADE,
+    lap_index integer NOT NULL CHECK (lap_index > 0), -- 1-based
+
+    start_index integer NOT NULL CHECK (start_index >= 0), -- index of the first point in the full resolution streams
+    start_time timestamptz NOT NULL,
+    elapsed_s double precision NOT NULL CHECK (elapsed_s >= 0),
+    distance_m double precision NOT NULL CHECK (distance_m >= 0),
+    avg_speed_mps double precision, -- NULL when the lap has no duration
+    avg_hr_bpm smallint, -- NULL when the lap has no heart rate data
+    max_hr_bpm smallint,
+
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (activity_id, lap_index)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS personal_records;
+DROP TABLE IF EXISTS activity_best_efforts;
+DROP TYPE IF EXISTS best_effort_kind;
@@ -0,0 +1,33 @@
+CREATE TYPE best_effort_kind AS ENUM ('distance', 'duration');
+
+-- each activity's best performance over the standard distances and durations of its type
+CREATE TABLE activity_best_efforts (
+    activity_id uuid REFERENCES activities(id) ON DELETE CASCADE,
+    effort text NOT NULL, -- e.g. '5k', 'half_marathon', '20min'
+    kind best_effort_kind NOT NULL,
+
+    distance_m double precision NOT NULL CHECK (distance_m > 0),
+    elapsed_s double precision NOT NULL CHECK (elapsed_s > 0),
+    start_index integer NOT NULL CHECK (start_index >= 0), -- index of the first point in the full resolution streams
+
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (activity_id, effort)
+);
+
+-- the best of each user's best efforts per activity type, refilled from activity_best_efforts when the record activity goes away
+CREATE TABLE personal_records (
+    user_id text NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    activity_type activity_type NOT NULL,
+    effort text NOT NULL,
+    kind best_effort_kind NOT NULL,
+
+    activity_id uuid NOT NULL REFERENCES activities(id) ON DELETE CASCADE,
+    distance_m double precision NOT NULL,
+    elapsed_s double precision NOT NULL,
+    achieved_at timestamptz NOT NULL,
+
+    updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (user_id, activity_type, effort)
+);
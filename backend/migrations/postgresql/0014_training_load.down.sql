@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS training_load;
+DROP TABLE IF EXISTS activity_training_stress;
+DROP TYPE IF EXISTS training_stress_method;
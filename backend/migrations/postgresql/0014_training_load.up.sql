@@ -0,0 +1,30 @@
+CREATE TYPE training_stress_method AS ENUM ('hr', 'pace');
+
+-- training stress score of each activity, hrTSS when it has heart rate data and rTSS otherwise
+CREATE TABLE activity_training_stress (
+    activity_id uuid PRIMARY KEY REFERENCES activities(id) ON DELETE CASCADE,
+    user_id text NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day date NOT NULL, -- local calendar day of the activity start
+
+    tss double precision NOT NULL CHECK (tss >= 0),
+    intensity_factor double precision NOT NULL CHECK (intensity_factor >= 0),
+    method training_stress_method NOT NULL,
+
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX activity_training_stress_user_id_day_idx
+    ON activity_training_stress (user_id, day);
+
+-- daily fitness (CTL), fatigue (ATL) and form (TSB), one row per day without gaps from a user's first stressed day
+CREATE TABLE training_load (
+    user_id text NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day date NOT NULL,
+
+    tss double precision NOT NULL DEFAULT 0,
+    ctl double precision NOT NULL,
+    atl double precision NOT NULL,
+    tsb double precision NOT NULL,
+
+    PRIMARY KEY (user_id, day)
+);
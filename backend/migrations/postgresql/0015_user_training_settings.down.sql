@@ -0,0 +1,14 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS max_hr_bpm,
+    DROP COLUMN IF EXISTS resting_hr_bpm,
+    DROP COLUMN IF EXISTS threshold_hr_bpm,
+    DROP COLUMN IF EXISTS threshold_pace_s_per_km,
+    DROP COLUMN IF EXISTS ftp_watt,
+    DROP COLUMN IF EXISTS weight_kg,
+    DROP COLUMN IF EXISTS birth_year,
+    DROP COLUMN IF EXISTS sex,
+    DROP COLUMN IF EXISTS hr_zones_bpm,
+    DROP COLUMN IF EXISTS pace_zones_s_per_km,
+    DROP COLUMN IF EXISTS power_zones_watt;
+
+DROP TYPE IF EXISTS user_sex;
@@ -0,0 +1,18 @@
+CREATE TYPE user_sex AS ENUM ('male', 'female');
+
+-- Personal thresholds, body metrics and zone boundaries used for training stress and zones.
+-- NULL means unset; population defaults are used instead.
+ALTER TABLE users
+    ADD COLUMN max_hr_bpm smallint,
+    ADD COLUMN resting_hr_bpm smallint,
+    ADD COLUMN threshold_hr_bpm smallint, -- lactate threshold heart rate
+    ADD COLUMN threshold_pace_s_per_km double precision,
+    ADD COLUMN ftp_watt smallint, -- functional threshold power
+    ADD COLUMN weight_kg double precision,
+    ADD COLUMN birth_year smallint,
+    ADD COLUMN sex user_sex,
+
+    -- upper bounds of every zone but the last; NULL uses zones derived from the thresholds
+    ADD COLUMN hr_zones_bpm smallint[],
+    ADD COLUMN pace_zones_s_per_km double precision[], -- decreasing, zone 1 is the slowest
+    ADD COLUMN power_zones_watt smallint[];
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS activity_zone_times;
+DROP TYPE IF EXISTS zone_kind;
@@ -0,0 +1,17 @@
+CREATE TYPE zone_kind AS ENUM ('hr', 'pace', 'power');
+
+-- time an activity spent in each heart rate, pace and power zone, scored against the user's zones at the time
+CREATE TABLE activity_zone_times (
+    activity_id uuid REFERENCES activities(id) ON DELETE CASCADE,
+    kind zone_kind NOT NULL,
+    zone smallint NOT NULL CHECK (zone > 0), -- 1-based, zone 1 is the easiest
+
+    -- zone bounds in bpm, s/km or watts; NULL for the open end of the first and last zone
+    min_value double precision,
+    max_value double precision,
+    seconds double precision NOT NULL CHECK (seconds >= 0), -- moving time
+
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (activity_id, kind, zone)
+);
@@ -0,0 +1,8 @@
+DELETE FROM activity_streams WHERE index_by = 'time';
+
+-- enum values can't be dropped, so the type is recreated without 'time'
+ALTER TYPE stream_index_by RENAME TO stream_index_by_old;
+CREATE TYPE stream_index_by AS ENUM ('distance');
+ALTER TABLE activity_streams
+    ALTER COLUMN index_by TYPE stream_index_by USING index_by::text::stream_index_by;
+DROP TYPE stream_index_by_old;
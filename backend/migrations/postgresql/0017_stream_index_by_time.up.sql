@@ -0,0 +1,2 @@
+-- medium LOD streams are also stored downsampled evenly in elapsed time, so charts against time keep stops
+ALTER TYPE stream_index_by ADD VALUE IF NOT EXISTS 'time';
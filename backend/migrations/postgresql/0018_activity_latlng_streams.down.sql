@@ -0,0 +1,3 @@
+ALTER TABLE activity_streams
+    DROP COLUMN IF EXISTS lon_bytes,
+    DROP COLUMN IF EXISTS lat_bytes;
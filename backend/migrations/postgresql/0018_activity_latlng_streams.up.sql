@@ -0,0 +1,4 @@
+-- Coordinates aligned index-for-index with the other streams, NULL when the track has no positions
+ALTER TABLE activity_streams
+    ADD COLUMN lat_bytes bytea, -- latitude in degrees, compressed
+    ADD COLUMN lon_bytes bytea; -- longitude in degrees, compressed
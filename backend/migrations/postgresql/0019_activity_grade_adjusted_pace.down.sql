@@ -0,0 +1,5 @@
+ALTER TABLE activity_streams
+    DROP COLUMN IF EXISTS grade_pct_bytes;
+
+ALTER TABLE activities
+    DROP COLUMN IF EXISTS avg_gap_mps;
@@ -0,0 +1,8 @@
+-- Grade-adjusted pace of running activities as the equivalent flat speed in meters per second.
+-- NULL for other activity types and activities created before grade adjustment.
+ALTER TABLE activities
+    ADD COLUMN avg_gap_mps numeric(10, 3);
+
+-- Smoothed grade in percent, aligned index-for-index with the other streams
+ALTER TABLE activity_streams
+    ADD COLUMN grade_pct_bytes bytea; -- grade in percent, compressed
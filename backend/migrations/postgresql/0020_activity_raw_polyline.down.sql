@@ -0,0 +1,2 @@
+ALTER TABLE activities
+    DROP COLUMN IF EXISTS raw_polyline;
@@ -0,0 +1,5 @@
+-- Original GPS track of activities whose positions were snapped to the road and path network with
+-- Valhalla map matching, encoded like polyline with one point per sample. polyline then holds the snapped
+-- track; NULL for activities that were not snapped.
+ALTER TABLE activities
+    ADD COLUMN raw_polyline text;
@@ -0,0 +1,4 @@
+ALTER TABLE planned_activities
+    DROP COLUMN IF EXISTS route_id;
+
+DROP TABLE IF EXISTS routes CASCADE;
@@ -0,0 +1,38 @@
+-- Routes planned ahead of an activity with Valhalla routing
+CREATE TABLE routes (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id text NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    name text NOT NULL,
+    description text,
+
+    -- Picks the Valhalla costing: pedestrian for runs, bicycle for rides
+    type activity_type NOT NULL,
+
+    -- Points the route was built through, as a JSON array of {"lat", "lon"}
+    waypoints jsonb NOT NULL,
+
+    -- Routed path, encoded polyline with precision 6
+    polyline text NOT NULL,
+
+    distance_m numeric(12, 2) NOT NULL,
+    elevation_gain_m numeric(10, 2),
+    elevation_loss_m numeric(10, 2),
+
+    -- Elevation profile with one value per polyline point, DIBS compressed; NULL when no heights were available
+    profile_distance_m_bytes bytea,
+    profile_elevation_m_bytes bytea,
+
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT routes_distance_m_nonnegative
+        CHECK (distance_m >= 0)
+);
+
+CREATE INDEX routes_user_id_created_at_idx
+    ON routes (user_id, created_at DESC);
+
+-- The route a planned activity is meant to follow
+ALTER TABLE planned_activities
+    ADD COLUMN route_id uuid REFERENCES routes(id) ON DELETE SET NULL;